@@ -0,0 +1,49 @@
+package main
+
+import (
+	"cloth-mini-app/internal/app"
+	"cloth-mini-app/internal/config"
+	"cloth-mini-app/internal/facade"
+	sl "cloth-mini-app/internal/logger"
+	auditRepo "cloth-mini-app/internal/repository/audit"
+	brandRepo "cloth-mini-app/internal/repository/brand"
+	"cloth-mini-app/internal/service/brand"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"log"
+	"log/slog"
+	"os"
+)
+
+// Transliterate slugs of cyrillic brands that migration filled with brand-<id> placeholders.
+// Run once after migration 20250420110000, before brand pages are published
+func main() {
+	log.Println("config initializing...")
+	config := config.MustLoad()
+
+	log.Println("logger initializing...")
+	logger := sl.NewLogger(config.Env)
+
+	storage, err := postgresql.NewPostgreSQL(config.DB)
+	if err != nil {
+		logger.Error("failed to init postgresql storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	blobStorage, err := app.NewBlobStorage(config)
+	if err != nil {
+		logger.Error("failed to init blob storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	auditFacade := facade.NewAuditFacade(storage, logger, auditRepo.NewAuditRepository(logger, storage))
+	brandService := brand.NewBrandService(logger, brandRepo.NewBrandRepository(logger, storage), blobStorage, auditFacade)
+
+	updated, err := brandService.BackfillSlugs(context.Background())
+	if err != nil {
+		logger.Error("failed brand slug backfill", slog.Int("updated", updated), sl.Err(err))
+		os.Exit(1)
+	}
+
+	logger.Info("brand slug backfill finished", slog.Int("updated", updated))
+}
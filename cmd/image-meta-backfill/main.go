@@ -0,0 +1,55 @@
+package main
+
+import (
+	"cloth-mini-app/internal/app"
+	"cloth-mini-app/internal/config"
+	"cloth-mini-app/internal/facade"
+	sl "cloth-mini-app/internal/logger"
+	auditRepo "cloth-mini-app/internal/repository/audit"
+	imageRepo "cloth-mini-app/internal/repository/image"
+	revisionRepo "cloth-mini-app/internal/repository/revision"
+	"cloth-mini-app/internal/service/image"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"log"
+	"log/slog"
+	"os"
+)
+
+// Calculate blurhash, dominant color and dimensions for images that were stored before metadata existed
+func main() {
+	log.Println("config initializing...")
+	config := config.MustLoad()
+
+	log.Println("logger initializing...")
+	logger := sl.NewLogger(config.Env)
+
+	storage, err := postgresql.NewPostgreSQL(config.DB)
+	if err != nil {
+		logger.Error("failed to init postgresql storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	blobStorage, err := app.NewBlobStorage(config)
+	if err != nil {
+		logger.Error("failed to init blob storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	archiveNameRule, err := image.NewArchiveNameRule(config.Image.ArchiveNamePattern)
+	if err != nil {
+		logger.Error("failed to compile image archive name rule", sl.Err(err))
+		os.Exit(1)
+	}
+
+	auditFacade := facade.NewAuditFacade(storage, logger, auditRepo.NewAuditRepository(logger, storage))
+	imageService := image.NewImageService(logger, blobStorage, imageRepo.NewImageRepository(logger, storage), revisionRepo.NewRevisionRepository(logger, storage), auditFacade, archiveNameRule, config.Image.MetaMaxPixels)
+
+	updated, err := imageService.BackfillMeta(context.Background())
+	if err != nil {
+		logger.Error("failed image metadata backfill", slog.Int("updated", updated), sl.Err(err))
+		os.Exit(1)
+	}
+
+	logger.Info("image metadata backfill finished", slog.Int("updated", updated))
+}
@@ -4,28 +4,59 @@ import (
 	"cloth-mini-app/internal/background"
 	congig "cloth-mini-app/internal/config"
 	"cloth-mini-app/internal/delivery/rest"
+	fdomain "cloth-mini-app/internal/domain/feed"
 	"cloth-mini-app/internal/facade"
 	"cloth-mini-app/internal/kafka"
+	checker "cloth-mini-app/internal/linkcheck"
 	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/ratelimit"
+	analyticsRepo "cloth-mini-app/internal/repository/analytics"
+	apiKeyRepo "cloth-mini-app/internal/repository/apikey"
+	auditRepo "cloth-mini-app/internal/repository/audit"
 	brandRepo "cloth-mini-app/internal/repository/brand"
 	categoryRepo "cloth-mini-app/internal/repository/category"
+	clickRepo "cloth-mini-app/internal/repository/click"
+	exportJobRepo "cloth-mini-app/internal/repository/exportjob"
+	feedRepo "cloth-mini-app/internal/repository/feed"
 	imageRepo "cloth-mini-app/internal/repository/image"
+	importJobRepo "cloth-mini-app/internal/repository/importjob"
 	itemRepo "cloth-mini-app/internal/repository/item"
 	itemImageRepo "cloth-mini-app/internal/repository/item_image"
+	linkCheckRepo "cloth-mini-app/internal/repository/linkcheck"
 	lockRepo "cloth-mini-app/internal/repository/lock"
 	outboxRepo "cloth-mini-app/internal/repository/outbox"
+	priceRepo "cloth-mini-app/internal/repository/price"
+	revisionRepo "cloth-mini-app/internal/repository/revision"
+	scrapeRepo "cloth-mini-app/internal/repository/scrape"
+	userRepo "cloth-mini-app/internal/repository/user"
+	"cloth-mini-app/internal/scraper"
+	"cloth-mini-app/internal/service/analytics"
+	"cloth-mini-app/internal/service/apikey"
+	"cloth-mini-app/internal/service/audit"
+	"cloth-mini-app/internal/service/auth"
 	"cloth-mini-app/internal/service/brand"
 	"cloth-mini-app/internal/service/category"
+	"cloth-mini-app/internal/service/click"
+	"cloth-mini-app/internal/service/export"
+	"cloth-mini-app/internal/service/feed"
 	"cloth-mini-app/internal/service/image"
+	"cloth-mini-app/internal/service/importer"
 	"cloth-mini-app/internal/service/item"
+	"cloth-mini-app/internal/service/linkcheck"
 	"cloth-mini-app/internal/service/lock"
-	"cloth-mini-app/internal/storage/minio"
+	"cloth-mini-app/internal/service/scrape"
+	"cloth-mini-app/internal/service/sitemap"
 	"cloth-mini-app/internal/storage/postgresql"
-	"fmt"
+	"context"
+	"errors"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 // Running application
@@ -35,18 +66,23 @@ func Run(config *congig.Config, logger *slog.Logger) {
 	// time.Sleep(time.Second * 15)
 	storage, err := postgresql.NewPostgreSQL(config.DB)
 	if err != nil {
-		logger.Error("failed to init postgresql storage", sl.Err(err))
-		fmt.Println(config)
+		// config has secrets, so only connection settings are logged
+		logger.Error("failed to init postgresql storage",
+			slog.String("host", config.DB.Host),
+			slog.Int("port", config.DB.Port),
+			slog.String("db", config.DB.DBname),
+			slog.String("user", config.DB.User),
+			sl.Err(err),
+		)
 		os.Exit(0)
 	}
 	_ = storage
 
-	minioClient, err := minio.NewMinioClient(config.Minio)
+	blobStorage, err := NewBlobStorage(config)
 	if err != nil {
-		logger.Error("failed to init minio storage", sl.Err(err))
+		logger.Error("failed to init blob storage", slog.String("backend", config.Storage.Backend), sl.Err(err))
 		os.Exit(1)
 	}
-	_ = minioClient
 
 	kafkaProducer := kafka.NewProducer(config.Kafka)
 
@@ -58,26 +94,117 @@ func Run(config *congig.Config, logger *slog.Logger) {
 	itemImageRepo := itemImageRepo.NewItemImageRepository(logger, storage)
 	lockRepo := lockRepo.NewLockRepository(storage)
 	outboxRepo := outboxRepo.NewOutboxRepository(logger, storage)
+	userRepo := userRepo.NewUserRepository(logger, storage)
+	apiKeyRepo := apiKeyRepo.NewAPIKeyRepository(logger, storage)
+	auditRepo := auditRepo.NewAuditRepository(logger, storage)
+	revisionRepo := revisionRepo.NewRevisionRepository(logger, storage)
+	importJobRepo := importJobRepo.NewImportJobRepository(logger, storage)
+	exportJobRepo := exportJobRepo.NewExportJobRepository(logger, storage)
+	feedRepo := feedRepo.NewFeedRepository(logger, storage)
+	clickRepo := clickRepo.NewClickRepository(logger, storage)
+	linkCheckRepo := linkCheckRepo.NewLinkCheckRepository(logger, storage)
+	priceRepo := priceRepo.NewPriceHistoryRepository(logger, storage)
+	scrapeRepo := scrapeRepo.NewScrapeRepository(logger, storage)
+	analyticsRepo := analyticsRepo.NewAnalyticsRepository(logger, storage)
 
 	// facade
-	outboxFacade := facade.NewOutboxFacade(storage, logger, outboxRepo, itemImageRepo, brandRepo)
+	outboxFacade := facade.NewOutboxFacade(storage, logger, outboxRepo, itemImageRepo, brandRepo, itemRepo, revisionRepo)
+	auditFacade := facade.NewAuditFacade(storage, logger, auditRepo)
 
 	// prepare services
 	lockService := lock.NewLockService(lockRepo)
-	itemService := item.NewItemService(logger, itemRepo, imageRepo, itemImageRepo, outboxFacade)
-	categoryService := category.NewCategoryService(logger, categoryRepo)
-	brandService := brand.NewBrandService(logger, brandRepo)
-	imageService := image.NewImageService(logger, minioClient, imageRepo)
+	itemService := item.NewItemService(logger, itemRepo, imageRepo, itemImageRepo, brandRepo, categoryRepo, revisionRepo, priceRepo, outboxFacade, auditFacade)
+	categoryService := category.NewCategoryService(logger, categoryRepo, auditFacade)
+	brandService := brand.NewBrandService(logger, brandRepo, blobStorage, auditFacade)
+	archiveNameRule, err := image.NewArchiveNameRule(config.Image.ArchiveNamePattern)
+	if err != nil {
+		logger.Error("failed to compile image archive name rule", sl.Err(err))
+		os.Exit(1)
+	}
+	imageService := image.NewImageService(logger, blobStorage, imageRepo, revisionRepo, auditFacade, archiveNameRule, config.Image.MetaMaxPixels)
+	if config.Auth.JWTSecret == "" {
+		logger.Error("JWT_SECRET isn't set")
+		os.Exit(1)
+	}
+	authService := auth.NewAuthService(logger, userRepo, config.Auth.JWTSecret, config.Auth.AccessTTL, config.Auth.RefreshTTL)
+	if err := authService.EnsureAdmin(context.Background(), config.Auth.AdminLogin, config.Auth.AdminPassword); err != nil {
+		logger.Error("failed to create admin user", sl.Err(err))
+		os.Exit(1)
+	}
+	apiKeyService := apikey.NewAPIKeyService(logger, apiKeyRepo)
+	auditService := audit.NewAuditService(logger, auditRepo)
+	importService := importer.NewImportService(logger, importJobRepo, itemService, brandService, categoryRepo, config.Import.BatchSize, config.Import.MaxRows)
+	exportService := export.NewExportService(logger, itemRepo, imageRepo, exportJobRepo, blobStorage, config.PublicURL, config.Export.BatchSize, config.Export.Retention)
+	feedService := feed.NewFeedService(logger, feedRepo, itemRepo, imageRepo, categoryRepo, outboxRepo, blobStorage, fdomain.Shop{
+		Name:     config.Feed.ShopName,
+		Company:  config.Feed.Company,
+		URL:      config.PublicURL,
+		Currency: config.Feed.Currency,
+	}, config.Feed.BatchSize)
+	linkChecker := checker.NewChecker(checker.Config{
+		Concurrency:  config.LinkCheck.Concurrency,
+		PerHost:      config.LinkCheck.PerHost,
+		Timeout:      config.LinkCheck.Timeout,
+		UserAgent:    config.LinkCheck.UserAgent,
+		AllowPrivate: config.LinkCheck.AllowPrivate,
+	})
+	linkCheckService := linkcheck.NewLinkCheckService(logger, linkCheckRepo, linkChecker, config.LinkCheck.Recheck, config.LinkCheck.BatchSize, config.LinkCheck.BrokenAfter)
+	priceScraper := scraper.NewScraper(scraper.Config{
+		Timeout:      config.Scrape.Timeout,
+		UserAgent:    config.Scrape.UserAgent,
+		AllowPrivate: config.Scrape.AllowPrivate,
+	})
+	scrapeService := scrape.NewScrapeService(logger, scrapeRepo, priceScraper, itemService, config.Scrape.Rescrape, config.Scrape.BatchSize, config.Scrape.AutoApply, config.Scrape.MaxChange)
+	clickService := click.NewClickService(logger, clickRepo, itemRepo)
+	analyticsService := analytics.NewAnalyticsService(logger, analyticsRepo, config.Analytics.BufferSize, config.Analytics.Retention)
+	sitemapService := sitemap.NewSitemapService(logger, itemRepo, brandRepo, categoryRepo, outboxRepo, auditRepo, config.PublicURL, config.Sitemap.PageSize, config.Sitemap.FeedSize)
 
 	// backgrounds tasks
-	backgroundTask := background.NewBackgroundTask(
-		logger, minioClient, imageRepo, lockService, outboxRepo, kafkaProducer,
-	)
-	_ = backgroundTask
+	backgroundTask := &background.BackgroundTask{
+		TempImage:   background.NewImageBackground(logger, blobStorage, imageRepo, lockService),
+		Event:       background.NewEventBackground(logger, outboxRepo, lockService, kafkaProducer),
+		Trash:       background.NewTrashBackground(logger, blobStorage, itemRepo, config.Trash.Retention, config.Trash.PurgeInterval),
+		Publication: background.NewPublicationBackground(logger, outboxFacade, lockService, config.Publication.Interval),
+		Import:      background.NewImportBackground(logger, importService, config.Import.Interval),
+		Export:      background.NewExportBackground(logger, exportService, config.Export.Interval),
+		Feed:        background.NewFeedBackground(logger, feedService, lockService, config.Feed.Interval),
+		LinkCheck:   background.NewLinkCheckBackground(logger, linkCheckService, lockService, config.LinkCheck.Interval),
+		Scrape:      background.NewScrapeBackground(logger, scrapeService, lockService, config.Scrape.Interval),
+		Analytics:   background.NewAnalyticsBackground(logger, analyticsService, lockService, config.Analytics.FlushInterval, config.Analytics.RollupInterval),
+	}
 	backgroundTask.TempImage.StartDeleteTempImage()
 	backgroundTask.Event.StartSendEvent()
+	backgroundTask.Trash.StartPurgeItems()
+	backgroundTask.Publication.StartPublishItems()
+	backgroundTask.Import.StartProcessJobs()
+	backgroundTask.Export.StartProcessJobs()
+	backgroundTask.Feed.StartRegenerateFeeds()
+	backgroundTask.LinkCheck.StartCheckLinks()
+	backgroundTask.Scrape.StartScrapePrices()
+	backgroundTask.Analytics.StartFlushEvents()
+	backgroundTask.Analytics.StartRollup()
+
+	limitConfig, err := NewLimitConfig(config.Limits)
+	if err != nil {
+		logger.Error("failed to parse limits", sl.Err(err))
+		os.Exit(1)
+	}
+	limitMiddleware := rest.NewLimitMiddleware(limitConfig)
 
 	e := echo.New()
+	e.HTTPErrorHandler = rest.NewHTTPErrorHandler(logger)
+	e.Server.ReadHeaderTimeout = config.Limits.ReadHeaderTimeout
+	e.Server.ReadTimeout = config.Limits.ReadTimeout
+	e.Server.IdleTimeout = config.Limits.IdleTimeout
+	if config.Limits.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+	e.Use(middleware.RequestID())
+	e.Use(limitMiddleware.RateLimit())
+	e.Use(limitMiddleware.BodyLimit())
+	e.Use(limitMiddleware.Timeout())
 	e.Static("/admin/static", "public")
 
 	e.GET("/ping", func(c echo.Context) error {
@@ -86,11 +213,46 @@ func Run(config *congig.Config, logger *slog.Logger) {
 	})
 
 	// prepare handlers
-	rest.NewItemHandler(e, itemService)
-	rest.NewAdminHandler(e)
-	rest.NewCategoryHandler(e, categoryService)
-	rest.NewBrandHandler(e, brandService)
-	rest.NewImageHandler(e, imageService)
+	authMiddleware := rest.NewAuthMiddleware(authService, apiKeyService, ratelimit.Limit{
+		Rate:  config.Limits.AnonymousRate,
+		Burst: config.Limits.AnonymousBurst,
+	})
 
-	logger.Info("echo", sl.Err(e.Start(config.Host+":"+config.Port)))
+	rest.NewAuthHandler(e, authService, authMiddleware)
+	rest.NewAPIKeyHandler(e, apiKeyService, authMiddleware)
+	rest.NewItemHandler(e, itemService, analyticsService, authMiddleware)
+	rest.NewAdminHandler(e, imageService, authMiddleware)
+	rest.NewCategoryHandler(e, categoryService, authMiddleware)
+	rest.NewBrandHandler(e, brandService, authMiddleware)
+	rest.NewImageHandler(e, imageService, authMiddleware)
+	rest.NewAuditHandler(e, auditService, authMiddleware)
+	rest.NewImportHandler(e, importService, authMiddleware)
+	rest.NewExportHandler(e, exportService, authMiddleware)
+	rest.NewFeedHandler(e, feedService)
+	rest.NewSitemapHandler(e, sitemapService)
+	rest.NewClickHandler(e, clickService, authMiddleware)
+	rest.NewLinkCheckHandler(e, linkCheckService, authMiddleware)
+	rest.NewScrapeHandler(e, scrapeService, authMiddleware)
+	rest.NewAnalyticsHandler(e, analyticsService, authMiddleware)
+
+	go func() {
+		if err := e.Start(config.Host + ":" + config.Port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("echo", sl.Err(err))
+			os.Exit(1)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+	logger.Info("stopping app...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Limits.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(ctx); err != nil {
+		logger.Error("failed to stop echo", sl.Err(err))
+	}
+	// events collected by handled requests are kept in memory until flush
+	backgroundTask.Analytics.FlushEvents(ctx)
 }
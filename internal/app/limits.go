@@ -0,0 +1,64 @@
+package app
+
+import (
+	congig "cloth-mini-app/internal/config"
+	"cloth-mini-app/internal/delivery/rest"
+	"cloth-mini-app/internal/ratelimit"
+	"fmt"
+
+	"github.com/labstack/gommon/bytes"
+)
+
+var (
+	// routes uploading images
+	uploadRoutes = []string{"/image/create", "/image/temp", "/image/upload-url", "/brand/logo/", "/admin/image/archive", "/import/upload"}
+	// routes uploading archives
+	archiveRoutes = []string{"/admin/image/archive"}
+	// routes processing or streaming archives and exports, they can take longer than request timeout
+	longRoutes = []string{"/admin/image/archive", "/image/archive", "/item/:id/images.zip", "/export/items", "/export/jobs/:id/download", "/feed/:name"}
+)
+
+// Create limits of rest routes from config
+func NewLimitConfig(config congig.Limits) (rest.LimitConfig, error) {
+	bodyLimit, err := bytes.Parse(config.BodyLimit)
+	if err != nil {
+		return rest.LimitConfig{}, fmt.Errorf("parsing body limit: %w", err)
+	}
+	uploadBodyLimit, err := bytes.Parse(config.UploadBodyLimit)
+	if err != nil {
+		return rest.LimitConfig{}, fmt.Errorf("parsing upload body limit: %w", err)
+	}
+	archiveBodyLimit, err := bytes.Parse(config.ArchiveBodyLimit)
+	if err != nil {
+		return rest.LimitConfig{}, fmt.Errorf("parsing archive body limit: %w", err)
+	}
+
+	return rest.LimitConfig{
+		IP: ratelimit.Limit{Rate: config.IPRate, Burst: config.IPBurst},
+		Groups: []rest.RouteLimit{
+			{
+				Name:     "read",
+				Prefixes: []string{"/item/get"},
+				Limit:    ratelimit.Limit{Rate: config.ReadRate, Burst: config.ReadBurst},
+			},
+			{
+				Name:     "upload",
+				Prefixes: uploadRoutes,
+				Limit:    ratelimit.Limit{Rate: config.UploadRate, Burst: config.UploadBurst},
+			},
+			{
+				Name:     "auth",
+				Prefixes: []string{"/auth/login", "/auth/refresh"},
+				Limit:    ratelimit.Limit{Rate: config.AuthRate, Burst: config.AuthBurst},
+			},
+		},
+		BodyLimit: bodyLimit,
+		BodyLimits: []rest.RouteBodyLimit{
+			// archive route is also upload route, so it goes first
+			{Prefixes: archiveRoutes, Limit: archiveBodyLimit},
+			{Prefixes: uploadRoutes, Limit: uploadBodyLimit},
+		},
+		RequestTimeout: config.RequestTimeout,
+		NoTimeout:      longRoutes,
+	}, nil
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	congig "cloth-mini-app/internal/config"
+	"cloth-mini-app/internal/storage/blob"
+	"cloth-mini-app/internal/storage/local"
+	"cloth-mini-app/internal/storage/memory"
+	"cloth-mini-app/internal/storage/minio"
+	"fmt"
+)
+
+// Create blob storage with backend selected in config
+func NewBlobStorage(config *congig.Config) (blob.Storage, error) {
+	switch config.Storage.Backend {
+	case blob.BackendMinio:
+		return minio.NewMinioClient(config.Minio)
+	case blob.BackendLocal:
+		return local.NewLocalStorage(config.Storage.LocalPath)
+	case blob.BackendMemory:
+		return memory.NewMemoryStorage(), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage backend: %s", config.Storage.Backend)
+}
@@ -0,0 +1,90 @@
+package background
+
+import (
+	ldomain "cloth-mini-app/internal/domain/lock"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type AnalyticsBackground struct {
+	logger         *slog.Logger
+	service        AnalyticsService
+	lockSrv        LockService
+	flushInterval  time.Duration
+	rollupInterval time.Duration
+}
+
+func NewAnalyticsBackground(logger *slog.Logger, as AnalyticsService, ls LockService, flushInterval, rollupInterval time.Duration) *AnalyticsBackground {
+	return &AnalyticsBackground{
+		logger:         logger,
+		service:        as,
+		lockSrv:        ls,
+		flushInterval:  flushInterval,
+		rollupInterval: rollupInterval,
+	}
+}
+
+// Write collected view and search events. Every instance writes its own events, so there is no lock
+func (a *AnalyticsBackground) StartFlushEvents() {
+	const op = "background.analytics.StartFlushEvents"
+	a.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(a.flushInterval)
+
+		for range ticker.C {
+			if _, err := a.service.Flush(context.Background()); err != nil {
+				a.logger.Error(fmt.Sprintf("%s : failed flush events", op), sl.Err(err))
+			}
+		}
+	}()
+}
+
+// Write events left in buffer, called on stop of app after requests are finished
+func (a *AnalyticsBackground) FlushEvents(ctx context.Context) {
+	const op = "background.analytics.FlushEvents"
+
+	flushed, err := a.service.Flush(ctx)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : failed flush events", op), sl.Err(err))
+
+		return
+	}
+	a.logger.Info(fmt.Sprintf("%s: events flushed", op), slog.Int("flushed", flushed))
+}
+
+// Aggregate events into daily stats. Instances run task one by one, so days aren't counted twice
+func (a *AnalyticsBackground) StartRollup() {
+	const op = "background.analytics.StartRollup"
+	a.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(a.rollupInterval)
+
+		for range ticker.C {
+			a.rollup(context.Background())
+		}
+	}()
+}
+
+func (a *AnalyticsBackground) rollup(ctx context.Context) {
+	const op = "background.analytics.rollup"
+
+	if err := a.lockSrv.AdvisoryLock(ctx, ldomain.AnalyticsLockId); err != nil {
+		a.logger.Error(fmt.Sprintf("%s : failed get advisory lock", op), sl.Err(err))
+
+		return
+	}
+	defer func() {
+		if err := a.lockSrv.AdvisoryUnlock(ctx, ldomain.AnalyticsLockId); err != nil {
+			a.logger.Error(fmt.Sprintf("%s : failed advisory unlock", op), sl.Err(err))
+		}
+	}()
+
+	if err := a.service.Rollup(ctx); err != nil {
+		a.logger.Error(fmt.Sprintf("%s : failed rollup events", op), sl.Err(err))
+	}
+}
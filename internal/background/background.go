@@ -4,14 +4,27 @@ import (
 	edomain "cloth-mini-app/internal/domain/event"
 	idomain "cloth-mini-app/internal/domain/image"
 	ldomain "cloth-mini-app/internal/domain/lock"
-	"cloth-mini-app/internal/storage/minio"
 	"context"
-	"log/slog"
+	"time"
 )
 
+// Background tasks of app. Tasks are built by app with their own dependencies and intervals
 type BackgroundTask struct {
-	TempImage *ImageBackground
-	Event     *EventBackground
+	TempImage   *ImageBackground
+	Event       *EventBackground
+	Trash       *TrashBackground
+	Publication *PublicationBackground
+	Import      *ImportBackground
+	Export      *ExportBackground
+	Feed        *FeedBackground
+	LinkCheck   *LinkCheckBackground
+	Scrape      *ScrapeBackground
+	Analytics   *AnalyticsBackground
+}
+
+type BlobStorage interface {
+	// Delete file from storage
+	Delete(ctx context.Context, objectId string) error
 }
 
 type ImageRepository interface {
@@ -19,6 +32,50 @@ type ImageRepository interface {
 	DeleteTempImage(ctx context.Context, deleteFn func([]idomain.TempImage) ([]idomain.TempImage, error)) error
 }
 
+type ItemRepository interface {
+	// Permanently delete items deleted before provided time, purgeFn deletes objects of their images
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit uint64, purgeFn func(objectIds []string) error) (int, error)
+}
+
+type PublicationFacade interface {
+	// Publish scheduled and archive expired items with events, returns number of published and archived items
+	PublishScheduledItems(ctx context.Context, now time.Time) (int, int, error)
+}
+
+type ImportService interface {
+	// Process stage of next import job, returns false if there are no jobs
+	ProcessNextJob(ctx context.Context) (bool, error)
+}
+
+type ExportService interface {
+	// Export items of next waiting job, returns false if there are no jobs
+	ProcessNextJob(ctx context.Context) (bool, error)
+	// Delete files of old jobs, returns number of expired jobs
+	ExpireFiles(ctx context.Context) (int, error)
+}
+
+type FeedService interface {
+	// Regenerate feeds if items changed, returns false if feeds are up to date
+	Regenerate(ctx context.Context) (bool, error)
+}
+
+type LinkCheckService interface {
+	// Check next batch of outer links, returns number of checked links
+	CheckLinks(ctx context.Context) (int, error)
+}
+
+type ScrapeService interface {
+	// Scrape next batch of item pages, returns number of scraped pages
+	ScrapePrices(ctx context.Context) (int, error)
+}
+
+type AnalyticsService interface {
+	// Write collected events, returns number of written events
+	Flush(ctx context.Context) (int, error)
+	// Aggregate events into daily stats
+	Rollup(ctx context.Context) error
+}
+
 type LockService interface {
 	AdvisoryLock(ctx context.Context, id ldomain.AdvisoryLockId) error
 	AdvisoryUnlock(ctx context.Context, id ldomain.AdvisoryLockId) error
@@ -32,17 +89,3 @@ type OutboxRepository interface {
 type Producer interface {
 	WriteMesage(ctx context.Context, eventType string, payload []byte) error
 }
-
-func NewBackgroundTask(
-	logger *slog.Logger,
-	mc *minio.MinioClient,
-	imr ImageRepository,
-	lcrv LockService,
-	outboxr OutboxRepository,
-	producer Producer,
-) *BackgroundTask {
-	return &BackgroundTask{
-		TempImage: NewImageBackground(logger, mc, imr, lcrv),
-		Event:     NewEventBackground(logger, outboxr, lcrv, producer),
-	}
-}
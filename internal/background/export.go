@@ -0,0 +1,67 @@
+package background
+
+import (
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type ExportBackground struct {
+	logger   *slog.Logger
+	service  ExportService
+	interval time.Duration
+}
+
+func NewExportBackground(logger *slog.Logger, es ExportService, interval time.Duration) *ExportBackground {
+	return &ExportBackground{
+		logger:   logger,
+		service:  es,
+		interval: interval,
+	}
+}
+
+// Export items to blob storage and delete files of old jobs. Jobs are reserved by instance
+// processing them, so instances handle different jobs at once
+func (e *ExportBackground) StartProcessJobs() {
+	const op = "background.export.StartProcessJobs"
+	e.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(e.interval)
+
+		for range ticker.C {
+			e.processJobs(context.Background())
+			e.expireFiles(context.Background())
+		}
+	}()
+}
+
+func (e *ExportBackground) processJobs(ctx context.Context) {
+	const op = "background.export.processJobs"
+
+	for {
+		processed, err := e.service.ProcessNextJob(ctx)
+		if err != nil {
+			e.logger.Error(fmt.Sprintf("%s : failed process export job", op), sl.Err(err))
+
+			return
+		}
+		if !processed {
+			return
+		}
+	}
+}
+
+func (e *ExportBackground) expireFiles(ctx context.Context) {
+	const op = "background.export.expireFiles"
+
+	expired, err := e.service.ExpireFiles(ctx)
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s : failed expire export files", op), sl.Err(err))
+	}
+	if expired > 0 {
+		e.logger.Info(fmt.Sprintf("%s: export files expired", op), slog.Int("jobs", expired))
+	}
+}
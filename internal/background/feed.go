@@ -0,0 +1,60 @@
+package background
+
+import (
+	ldomain "cloth-mini-app/internal/domain/lock"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type FeedBackground struct {
+	logger   *slog.Logger
+	service  FeedService
+	lockSrv  LockService
+	interval time.Duration
+}
+
+func NewFeedBackground(logger *slog.Logger, fs FeedService, ls LockService, interval time.Duration) *FeedBackground {
+	return &FeedBackground{
+		logger:   logger,
+		service:  fs,
+		lockSrv:  ls,
+		interval: interval,
+	}
+}
+
+// Regenerate product feeds when items change. Instances run task one by one,
+// so feed files aren't replaced at once
+func (f *FeedBackground) StartRegenerateFeeds() {
+	const op = "background.feed.StartRegenerateFeeds"
+	f.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(f.interval)
+
+		for range ticker.C {
+			f.regenerateFeeds(context.Background())
+		}
+	}()
+}
+
+func (f *FeedBackground) regenerateFeeds(ctx context.Context) {
+	const op = "background.feed.regenerateFeeds"
+
+	if err := f.lockSrv.AdvisoryLock(ctx, ldomain.FeedLockId); err != nil {
+		f.logger.Error(fmt.Sprintf("%s : failed get advisory lock", op), sl.Err(err))
+
+		return
+	}
+	defer func() {
+		if err := f.lockSrv.AdvisoryUnlock(ctx, ldomain.FeedLockId); err != nil {
+			f.logger.Error(fmt.Sprintf("%s : failed advisory unlock", op), sl.Err(err))
+		}
+	}()
+
+	if _, err := f.service.Regenerate(ctx); err != nil {
+		f.logger.Error(fmt.Sprintf("%s : failed regenerate feeds", op), sl.Err(err))
+	}
+}
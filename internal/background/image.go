@@ -4,7 +4,6 @@ import (
 	idomain "cloth-mini-app/internal/domain/image"
 	ldomain "cloth-mini-app/internal/domain/lock"
 	sl "cloth-mini-app/internal/logger"
-	"cloth-mini-app/internal/storage/minio"
 	"context"
 	"errors"
 	"fmt"
@@ -23,15 +22,15 @@ var (
 
 type ImageBackground struct {
 	logger    *slog.Logger
-	minioCl   *minio.MinioClient
+	storage   BlobStorage
 	imageRepo ImageRepository
 	lockSrv   LockService
 }
 
-func NewImageBackground(logger *slog.Logger, mc *minio.MinioClient, imr ImageRepository, lsrv LockService) *ImageBackground {
+func NewImageBackground(logger *slog.Logger, bs BlobStorage, imr ImageRepository, lsrv LockService) *ImageBackground {
 	return &ImageBackground{
 		logger:    logger,
-		minioCl:   mc,
+		storage:   bs,
 		imageRepo: imr,
 		lockSrv:   lsrv,
 	}
@@ -64,7 +63,7 @@ func (i *ImageBackground) StartDeleteTempImage() {
 						curr := time.Now()
 						if curr.Sub(image.UploadedAt) > tempImageTTL {
 							deletingImage = append(deletingImage, image)
-							err := i.minioCl.Delete(ctx, image.ObjectId)
+							err := i.storage.Delete(ctx, image.ObjectId)
 							if err != nil {
 								i.logger.Error(fmt.Sprintf("%s: failed delete image from s3", op), sl.Err(err))
 
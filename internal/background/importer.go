@@ -0,0 +1,54 @@
+package background
+
+import (
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type ImportBackground struct {
+	logger   *slog.Logger
+	service  ImportService
+	interval time.Duration
+}
+
+func NewImportBackground(logger *slog.Logger, is ImportService, interval time.Duration) *ImportBackground {
+	return &ImportBackground{
+		logger:   logger,
+		service:  is,
+		interval: interval,
+	}
+}
+
+// Validate and import uploaded files. Jobs are reserved by instance processing them,
+// so instances handle different jobs at once
+func (i *ImportBackground) StartProcessJobs() {
+	const op = "background.importer.StartProcessJobs"
+	i.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(i.interval)
+
+		for range ticker.C {
+			i.processJobs(context.Background())
+		}
+	}()
+}
+
+func (i *ImportBackground) processJobs(ctx context.Context) {
+	const op = "background.importer.processJobs"
+
+	for {
+		processed, err := i.service.ProcessNextJob(ctx)
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : failed process import job", op), sl.Err(err))
+
+			return
+		}
+		if !processed {
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package background
+
+import (
+	ldomain "cloth-mini-app/internal/domain/lock"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type LinkCheckBackground struct {
+	logger   *slog.Logger
+	service  LinkCheckService
+	lockSrv  LockService
+	interval time.Duration
+}
+
+func NewLinkCheckBackground(logger *slog.Logger, lcs LinkCheckService, ls LockService, interval time.Duration) *LinkCheckBackground {
+	return &LinkCheckBackground{
+		logger:   logger,
+		service:  lcs,
+		lockSrv:  ls,
+		interval: interval,
+	}
+}
+
+// Check outer links of items batch by batch. Instances run task one by one,
+// so shops get requests only from one instance
+func (l *LinkCheckBackground) StartCheckLinks() {
+	const op = "background.linkcheck.StartCheckLinks"
+	l.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(l.interval)
+
+		for range ticker.C {
+			l.checkLinks(context.Background())
+		}
+	}()
+}
+
+func (l *LinkCheckBackground) checkLinks(ctx context.Context) {
+	const op = "background.linkcheck.checkLinks"
+
+	if err := l.lockSrv.AdvisoryLock(ctx, ldomain.LinkCheckLockId); err != nil {
+		l.logger.Error(fmt.Sprintf("%s : failed get advisory lock", op), sl.Err(err))
+
+		return
+	}
+	defer func() {
+		if err := l.lockSrv.AdvisoryUnlock(ctx, ldomain.LinkCheckLockId); err != nil {
+			l.logger.Error(fmt.Sprintf("%s : failed advisory unlock", op), sl.Err(err))
+		}
+	}()
+
+	if _, err := l.service.CheckLinks(ctx); err != nil {
+		l.logger.Error(fmt.Sprintf("%s : failed check links", op), sl.Err(err))
+	}
+}
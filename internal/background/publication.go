@@ -0,0 +1,67 @@
+package background
+
+import (
+	ldomain "cloth-mini-app/internal/domain/lock"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type PublicationBackground struct {
+	logger   *slog.Logger
+	facade   PublicationFacade
+	lockSrv  LockService
+	interval time.Duration
+}
+
+func NewPublicationBackground(logger *slog.Logger, pf PublicationFacade, ls LockService, interval time.Duration) *PublicationBackground {
+	return &PublicationBackground{
+		logger:   logger,
+		facade:   pf,
+		lockSrv:  ls,
+		interval: interval,
+	}
+}
+
+// Publish scheduled items and archive items which unpublish time has come.
+// Instances run task one by one, so events aren't duplicated
+func (p *PublicationBackground) StartPublishItems() {
+	const op = "background.publication.StartPublishItems"
+	p.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+
+		for range ticker.C {
+			p.publishItems(context.Background())
+		}
+	}()
+}
+
+func (p *PublicationBackground) publishItems(ctx context.Context) {
+	const op = "background.publication.publishItems"
+
+	if err := p.lockSrv.AdvisoryLock(ctx, ldomain.PublicationLockId); err != nil {
+		p.logger.Error(fmt.Sprintf("%s : failed get advisory lock", op), sl.Err(err))
+
+		return
+	}
+	defer func() {
+		if err := p.lockSrv.AdvisoryUnlock(ctx, ldomain.PublicationLockId); err != nil {
+			p.logger.Error(fmt.Sprintf("%s : failed advisory unlock", op), sl.Err(err))
+		}
+	}()
+
+	published, archived, err := p.facade.PublishScheduledItems(ctx, time.Now())
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("%s : failed publish items", op), sl.Err(err))
+
+		return
+	}
+
+	if published != 0 || archived != 0 {
+		p.logger.Info(fmt.Sprintf("%s: items status changed", op), slog.Int("published", published), slog.Int("archived", archived))
+	}
+}
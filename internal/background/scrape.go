@@ -0,0 +1,60 @@
+package background
+
+import (
+	ldomain "cloth-mini-app/internal/domain/lock"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type ScrapeBackground struct {
+	logger   *slog.Logger
+	service  ScrapeService
+	lockSrv  LockService
+	interval time.Duration
+}
+
+func NewScrapeBackground(logger *slog.Logger, ss ScrapeService, ls LockService, interval time.Duration) *ScrapeBackground {
+	return &ScrapeBackground{
+		logger:   logger,
+		service:  ss,
+		lockSrv:  ls,
+		interval: interval,
+	}
+}
+
+// Scrape prices from shop pages of items batch by batch. Instances run task one by one,
+// so item isn't scraped twice at once
+func (s *ScrapeBackground) StartScrapePrices() {
+	const op = "background.scrape.StartScrapePrices"
+	s.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+
+		for range ticker.C {
+			s.scrapePrices(context.Background())
+		}
+	}()
+}
+
+func (s *ScrapeBackground) scrapePrices(ctx context.Context) {
+	const op = "background.scrape.scrapePrices"
+
+	if err := s.lockSrv.AdvisoryLock(ctx, ldomain.ScrapeLockId); err != nil {
+		s.logger.Error(fmt.Sprintf("%s : failed get advisory lock", op), sl.Err(err))
+
+		return
+	}
+	defer func() {
+		if err := s.lockSrv.AdvisoryUnlock(ctx, ldomain.ScrapeLockId); err != nil {
+			s.logger.Error(fmt.Sprintf("%s : failed advisory unlock", op), sl.Err(err))
+		}
+	}()
+
+	if _, err := s.service.ScrapePrices(ctx); err != nil {
+		s.logger.Error(fmt.Sprintf("%s : failed scrape prices", op), sl.Err(err))
+	}
+}
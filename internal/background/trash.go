@@ -0,0 +1,83 @@
+package background
+
+import (
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// items purged in one transaction
+	purgeItemsBatch = 100
+)
+
+type TrashBackground struct {
+	logger   *slog.Logger
+	storage  BlobStorage
+	itemRepo ItemRepository
+	// time deleted item stays in trash
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashBackground(logger *slog.Logger, bs BlobStorage, itemr ItemRepository, retention, interval time.Duration) *TrashBackground {
+	return &TrashBackground{
+		logger:    logger,
+		storage:   bs,
+		itemRepo:  itemr,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Permanently delete items which are in trash longer than retention period with their images.
+// Items are locked while purging, so instances don't purge the same items
+func (t *TrashBackground) StartPurgeItems() {
+	const op = "background.trash.StartPurgeItems"
+	t.logger.Info(fmt.Sprintf("%s: task started...", op))
+
+	go func() {
+		ticker := time.NewTicker(t.interval)
+
+		for range ticker.C {
+			t.purgeItems(context.Background())
+		}
+	}()
+}
+
+func (t *TrashBackground) purgeItems(ctx context.Context) {
+	const op = "background.trash.purgeItems"
+
+	deletedBefore := time.Now().Add(-t.retention)
+
+	var total int
+	for {
+		purged, err := t.itemRepo.PurgeDeleted(ctx, deletedBefore, purgeItemsBatch, func(objectIds []string) error {
+			for _, objectId := range objectIds {
+				if err := t.storage.Delete(ctx, objectId); err != nil {
+					t.logger.Error(fmt.Sprintf("%s: failed delete image from storage", op), slog.String("object_id", objectId), sl.Err(err))
+
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.logger.Error(fmt.Sprintf("%s: failed purge items", op), sl.Err(err))
+
+			break
+		}
+
+		total += purged
+		if purged < purgeItemsBatch {
+			break
+		}
+	}
+
+	if total != 0 {
+		t.logger.Info(fmt.Sprintf("%s: items purged", op), slog.Int("count", total))
+	}
+}
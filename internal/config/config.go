@@ -3,17 +3,33 @@ package config
 import (
 	"log"
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Host  string `env:"HOST" env-required:"true"`
-	Port  string `env:"PORT" env-required:"true"`
-	Env   string `env:"ENV" env-required:"true"`
-	DB    DB
-	Minio Minio
-	Kafka Kafka
+	Host string `env:"HOST" env-required:"true"`
+	Port string `env:"PORT" env-required:"true"`
+	Env  string `env:"ENV" env-required:"true"`
+	// Base url of app in links given to clients, e.g. image links in exports
+	PublicURL   string `env:"PUBLIC_URL" env-default:"http://localhost:8081"`
+	DB          DB
+	Storage     Storage
+	Minio       Minio
+	Kafka       Kafka
+	Image       Image
+	Auth        Auth
+	Limits      Limits
+	Trash       Trash
+	Publication Publication
+	Import      Import
+	Export      Export
+	Feed        Feed
+	Sitemap     Sitemap
+	LinkCheck   LinkCheck
+	Scrape      Scrape
+	Analytics   Analytics
 }
 
 type DB struct {
@@ -24,11 +40,18 @@ type DB struct {
 	DBname   string `env:"DBNAME" env-required:"true"`
 }
 
+// Blob storage for images. Backend: minio, local (files in LocalPath directory), memory
+type Storage struct {
+	Backend   string `env:"STORAGE_BACKEND" env-default:"minio"`
+	LocalPath string `env:"STORAGE_LOCAL_PATH" env-default:"./storage"`
+}
+
+// Required only for minio storage backend
 type Minio struct {
-	Endpoint   string `env:"MINIO_ENDPOINT" env-required:"true"`
-	BucketName string `env:"MINIO_BUCKET_NAME" env-required:"true"`
-	User       string `env:"MINIO_ROOT_USER" env-required:"true"`
-	Password   string `env:"MINIO_ROOT_PASSWORD" env-required:"true"`
+	Endpoint   string `env:"MINIO_ENDPOINT"`
+	BucketName string `env:"MINIO_BUCKET_NAME"`
+	User       string `env:"MINIO_ROOT_USER"`
+	Password   string `env:"MINIO_ROOT_PASSWORD"`
 }
 
 type Kafka struct {
@@ -36,6 +59,153 @@ type Kafka struct {
 	KafkaTopic  string `env:"KAFKA_TOPIC" env-required:"true"`
 }
 
+type Image struct {
+	// Rule for mapping archive file names to items. Must contain named group item_id
+	ArchiveNamePattern string `env:"IMAGE_ARCHIVE_NAME_PATTERN" env-default:"^(?P<item_id>\\d+)_\\d+\\.(?i:jpe?g|png)$"`
+	// metadata isn't computed for images with more pixels (width * height), 0 is no limit
+	MetaMaxPixels int `env:"IMAGE_META_MAX_PIXELS" env-default:"16000000"`
+}
+
+type Auth struct {
+	// Secret for signing access tokens (HS256), required by app
+	JWTSecret  string        `env:"JWT_SECRET"`
+	AccessTTL  time.Duration `env:"JWT_ACCESS_TTL" env-default:"15m"`
+	RefreshTTL time.Duration `env:"JWT_REFRESH_TTL" env-default:"720h"`
+	// Admin created on start if there are no users
+	AdminLogin    string `env:"ADMIN_LOGIN"`
+	AdminPassword string `env:"ADMIN_PASSWORD"`
+}
+
+// Protection from abusive clients. Rate is requests per second per client ip,
+// burst is requests allowed at once. Zero rate disables limit
+type Limits struct {
+	// All routes
+	IPRate  float64 `env:"RATE_LIMIT_IP_RATE" env-default:"20"`
+	IPBurst int     `env:"RATE_LIMIT_IP_BURST" env-default:"40"`
+	// Catalog reading (/item/get)
+	ReadRate  float64 `env:"RATE_LIMIT_READ_RATE" env-default:"10"`
+	ReadBurst int     `env:"RATE_LIMIT_READ_BURST" env-default:"20"`
+	// Catalog reading without api key and user token, partners get more with api key
+	AnonymousRate  float64 `env:"RATE_LIMIT_ANONYMOUS_RATE" env-default:"5"`
+	AnonymousBurst int     `env:"RATE_LIMIT_ANONYMOUS_BURST" env-default:"10"`
+	// Image and archive uploads
+	UploadRate  float64 `env:"RATE_LIMIT_UPLOAD_RATE" env-default:"1"`
+	UploadBurst int     `env:"RATE_LIMIT_UPLOAD_BURST" env-default:"10"`
+	// Login and token refresh
+	AuthRate  float64 `env:"RATE_LIMIT_AUTH_RATE" env-default:"0.2"`
+	AuthBurst int     `env:"RATE_LIMIT_AUTH_BURST" env-default:"10"`
+
+	// Max request body, e.g. 512K, 1M
+	BodyLimit        string `env:"BODY_LIMIT" env-default:"1M"`
+	UploadBodyLimit  string `env:"UPLOAD_BODY_LIMIT" env-default:"11M"`
+	ArchiveBodyLimit string `env:"ARCHIVE_BODY_LIMIT" env-default:"512M"`
+
+	// Time for handling request, archive processing and downloads aren't limited
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" env-default:"30s"`
+	// Time for reading request from slow client
+	ReadHeaderTimeout time.Duration `env:"READ_HEADER_TIMEOUT" env-default:"5s"`
+	ReadTimeout       time.Duration `env:"READ_TIMEOUT" env-default:"5m"`
+	IdleTimeout       time.Duration `env:"IDLE_TIMEOUT" env-default:"2m"`
+	// Time for finishing requests and writing buffered events on stop
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"15s"`
+	// Take client ip from X-Forwarded-For, enable only behind proxy setting it
+	TrustProxy bool `env:"TRUST_PROXY" env-default:"false"`
+}
+
+// Deleted items can be restored during retention period, then they are purged with images
+type Trash struct {
+	Retention     time.Duration `env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// Scheduler publishing and archiving items by their publication time
+type Publication struct {
+	Interval time.Duration `env:"PUBLICATION_INTERVAL" env-default:"1m"`
+}
+
+// Items import from csv and xlsx files
+type Import struct {
+	Interval time.Duration `env:"IMPORT_INTERVAL" env-default:"10s"`
+	// rows created in one transaction
+	BatchSize int `env:"IMPORT_BATCH_SIZE" env-default:"100"`
+	// max rows in file after header
+	MaxRows int `env:"IMPORT_MAX_ROWS" env-default:"10000"`
+}
+
+// Catalog export to csv, ndjson and xlsx files
+type Export struct {
+	Interval time.Duration `env:"EXPORT_INTERVAL" env-default:"10s"`
+	// items fetched by one query
+	BatchSize int `env:"EXPORT_BATCH_SIZE" env-default:"500"`
+	// files of done jobs are deleted after this time
+	Retention time.Duration `env:"EXPORT_RETENTION" env-default:"168h"`
+}
+
+// Product feeds for Yandex.Market and Google Merchant, shop url in feeds is PublicURL
+type Feed struct {
+	Interval time.Duration `env:"FEED_INTERVAL" env-default:"1m"`
+	// items rendered or entries fetched by one query
+	BatchSize int    `env:"FEED_BATCH_SIZE" env-default:"500"`
+	ShopName  string `env:"FEED_SHOP_NAME" env-default:"Cloth"`
+	Company   string `env:"FEED_COMPANY" env-default:"Cloth"`
+	// ISO code of item prices currency
+	Currency string `env:"FEED_CURRENCY" env-default:"RUB"`
+}
+
+// Sitemap and new arrivals feeds of brands and categories
+type Sitemap struct {
+	// urls in one sitemap file, sitemap is split into indexed files above it
+	PageSize int `env:"SITEMAP_PAGE_SIZE" env-default:"50000"`
+	// items in new arrivals feed
+	FeedSize int `env:"SITEMAP_FEED_SIZE" env-default:"20"`
+}
+
+// Health check of outer links of items
+type LinkCheck struct {
+	Interval time.Duration `env:"LINKCHECK_INTERVAL" env-default:"1m"`
+	// link is checked again after this time
+	Recheck time.Duration `env:"LINKCHECK_RECHECK" env-default:"24h"`
+	// links checked by one run
+	BatchSize   int `env:"LINKCHECK_BATCH_SIZE" env-default:"100"`
+	Concurrency int `env:"LINKCHECK_CONCURRENCY" env-default:"10"`
+	// requests at once to one shop
+	PerHost int           `env:"LINKCHECK_PER_HOST" env-default:"2"`
+	Timeout time.Duration `env:"LINKCHECK_TIMEOUT" env-default:"10s"`
+	// failed checks in a row to mark link broken
+	BrokenAfter int    `env:"LINKCHECK_BROKEN_AFTER" env-default:"3"`
+	UserAgent   string `env:"LINKCHECK_USER_AGENT" env-default:"ClothLinkChecker/1.0"`
+	// check links to private addresses too, only for development and tests
+	AllowPrivate bool `env:"LINKCHECK_ALLOW_PRIVATE" env-default:"false"`
+}
+
+// Scraping of prices from shop pages of items
+type Scrape struct {
+	Interval time.Duration `env:"SCRAPE_INTERVAL" env-default:"5m"`
+	// item page is scraped again after this time
+	Rescrape time.Duration `env:"SCRAPE_RESCRAPE" env-default:"24h"`
+	// pages scraped by one run
+	BatchSize int           `env:"SCRAPE_BATCH_SIZE" env-default:"50"`
+	Timeout   time.Duration `env:"SCRAPE_TIMEOUT" env-default:"15s"`
+	UserAgent string        `env:"SCRAPE_USER_AGENT" env-default:"ClothPriceBot/1.0"`
+	// apply scraped prices of all shops without review, otherwise only shops with auto apply rule
+	AutoApply bool `env:"SCRAPE_AUTO_APPLY" env-default:"false"`
+	// price changed by larger share of sale price is proposed for review even if it can be applied, 0 is no limit
+	MaxChange float64 `env:"SCRAPE_MAX_CHANGE" env-default:"0.5"`
+	// scrape pages on private addresses too, only for development and tests
+	AllowPrivate bool `env:"SCRAPE_ALLOW_PRIVATE" env-default:"false"`
+}
+
+// Collecting of item views and searches
+type Analytics struct {
+	// events kept in memory until flush, events over it are dropped
+	BufferSize    int           `env:"ANALYTICS_BUFFER_SIZE" env-default:"10000"`
+	FlushInterval time.Duration `env:"ANALYTICS_FLUSH_INTERVAL" env-default:"5s"`
+	// events are aggregated into daily stats with this interval
+	RollupInterval time.Duration `env:"ANALYTICS_ROLLUP_INTERVAL" env-default:"5m"`
+	// raw events are deleted after this time, daily stats are kept
+	Retention time.Duration `env:"ANALYTICS_RETENTION" env-default:"2160h"`
+}
+
 var (
 	config *Config
 	once   sync.Once
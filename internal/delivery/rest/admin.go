@@ -1,7 +1,11 @@
 package rest
 
 import (
-	"crypto/subtle"
+	"archive/zip"
+	apperr "cloth-mini-app/internal/domain/apperror"
+	domain "cloth-mini-app/internal/domain/image"
+	"context"
+	"errors"
 	"html/template"
 	"io"
 	"net/http"
@@ -10,7 +14,15 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
+var errZipArchive = apperr.Invalid("invalid_archive", "incorrect zip archive")
+
+type AdminImageService interface {
+	// Attach images from zip archive to items
+	CreateFromArchive(ctx context.Context, archive *zip.Reader) []domain.ArchiveFileResult
+}
+
 type AdminHandler struct {
+	ImageService AdminImageService
 }
 
 // TemplateRenderer is a custom html/template renderer for Echo framework
@@ -29,19 +41,14 @@ func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c
 	return t.templates.ExecuteTemplate(w, name, data)
 }
 
-// Create admin handler object
-func NewAdminHandler(e *echo.Echo) {
-	handler := &AdminHandler{}
+// Create admin handler object. Pages are public, data is loaded by scripts with access token
+// and user is redirected to login page if token is missing or expired
+func NewAdminHandler(e *echo.Echo, imgSrv AdminImageService, auth *AuthMiddleware) {
+	handler := &AdminHandler{
+		ImageService: imgSrv,
+	}
 
 	g := e.Group("/admin")
-	g.Use(middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
-		// Be careful to use constant time comparison to prevent timing attacks
-		if subtle.ConstantTimeCompare([]byte(username), []byte("admin")) == 1 &&
-			subtle.ConstantTimeCompare([]byte(password), []byte("admin")) == 1 {
-			return true, nil
-		}
-		return false, nil
-	}))
 	g.Use(middleware.Logger())
 	// g.Use(middleware.Static("/public"))
 
@@ -49,9 +56,20 @@ func NewAdminHandler(e *echo.Echo) {
 		templates: template.Must(template.ParseGlob("public/html/admin/*.html")),
 	}
 
+	g.GET("/login", handler.AdminLoginPage)
 	g.GET("/", handler.AdminMainPage)
 	g.GET("/update/:id", handler.AdminUpdatePage)
 	g.GET("/create", handler.AdminCreatePage)
+	g.GET("/audit/view", handler.AdminAuditPage)
+	g.GET("/trash", handler.AdminTrashPage)
+	g.GET("/revisions/:id", handler.AdminRevisionsPage)
+	g.GET("/import", handler.AdminImportPage)
+	g.GET("/analytics", handler.AdminAnalyticsPage)
+	g.POST("/image/archive", handler.ImageArchive, auth.Editor())
+}
+
+func (a *AdminHandler) AdminLoginPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "login.html", nil)
 }
 
 func (a *AdminHandler) AdminMainPage(c echo.Context) error {
@@ -65,3 +83,95 @@ func (a *AdminHandler) AdminUpdatePage(c echo.Context) error {
 func (a *AdminHandler) AdminCreatePage(c echo.Context) error {
 	return c.Render(http.StatusOK, "create.html", nil)
 }
+
+func (a *AdminHandler) AdminAuditPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "audit.html", nil)
+}
+
+func (a *AdminHandler) AdminTrashPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "trash.html", nil)
+}
+
+func (a *AdminHandler) AdminRevisionsPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "revisions.html", nil)
+}
+
+func (a *AdminHandler) AdminImportPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "import.html", nil)
+}
+
+func (a *AdminHandler) AdminAnalyticsPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "analytics.html", nil)
+}
+
+type ArchiveFileResponse struct {
+	FileName string `json:"file_name"`
+	ItemId   int    `json:"item_id,omitempty"`
+	FileId   string `json:"file_id,omitempty"`
+	Status   string `json:"status"`
+	Code     string `json:"code,omitempty"`
+	Err      string `json:"error,omitempty"`
+}
+
+type ArchiveResponse struct {
+	Total    int                   `json:"total"`
+	Attached int                   `json:"attached"`
+	Failed   int                   `json:"failed"`
+	Files    []ArchiveFileResponse `json:"files"`
+}
+
+// POST /admin/image/archive Attach images from zip archive (form field "archive") to items.
+// Item is resolved from file name by configured rule (<item_id>_<n>.jpg by default)
+func (a *AdminHandler) ImageArchive(c echo.Context) error {
+	file, err := formFile(c, "archive")
+	if err != nil {
+		return err
+	}
+
+	archive, err := file.Open()
+	if err != nil {
+		return domain.ErrNoFile
+	}
+	defer archive.Close()
+
+	reader, err := zip.NewReader(archive, file.Size)
+	if err != nil {
+		return errZipArchive
+	}
+
+	results := a.ImageService.CreateFromArchive(c.Request().Context(), reader)
+
+	response := ArchiveResponse{
+		Total: len(results),
+		Files: make([]ArchiveFileResponse, 0, len(results)),
+	}
+	for _, result := range results {
+		fileResponse := ArchiveFileResponse{
+			FileName: result.FileName,
+			ItemId:   result.ItemId,
+			FileId:   result.FileId,
+			Status:   "attached",
+		}
+		if result.Err != nil {
+			fileResponse.Status = "failed"
+			fileResponse.Code, fileResponse.Err = archiveFileError(result.Err)
+			response.Failed++
+		} else {
+			response.Attached++
+		}
+
+		response.Files = append(response.Files, fileResponse)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Code and message of failed file. Internal errors are not exposed in report
+func archiveFileError(err error) (string, string) {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) && appErr.Kind != apperr.KindInternal {
+		return appErr.Code, appErr.Error()
+	}
+
+	return internalErrorCode, "failed store image"
+}
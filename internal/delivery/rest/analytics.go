@@ -0,0 +1,152 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/analytics"
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type AnalyticsService interface {
+	// Get most viewed items for period
+	GetPopular(ctx context.Context, filter domain.StatsFilter) ([]domain.ItemStats, error)
+	// Get items which views grow fastest
+	GetTrending(ctx context.Context, filter domain.StatsFilter) ([]domain.ItemStats, error)
+	// Get most frequent search queries for period
+	GetTopQueries(ctx context.Context, filter domain.StatsFilter) ([]domain.QueryStats, error)
+}
+
+type AnalyticsHandler struct {
+	Service AnalyticsService
+}
+
+func NewAnalyticsHandler(e *echo.Echo, srv AnalyticsService, auth *AuthMiddleware) {
+	handler := &AnalyticsHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/analytics")
+	g.Use(middleware.Logger())
+	g.GET("/items/popular", handler.Popular, auth.Editor())
+	g.GET("/items/trending", handler.Trending, auth.Editor())
+	g.GET("/searches", handler.Searches, auth.Editor())
+}
+
+type AnalyticsQueryParams struct {
+	// stats for last days including today (UTC), 7 days by default
+	Days  int    `query:"days" validate:"gte=0,lte=365"`
+	Limit uint64 `query:"limit" validate:"lte=1000"`
+}
+
+type SearchesQueryParams struct {
+	Days  int    `query:"days" validate:"gte=0,lte=365"`
+	Limit uint64 `query:"limit" validate:"lte=1000"`
+	// only queries which found nothing at least once
+	ZeroResults bool `query:"zero_results"`
+}
+
+type ItemViewStats struct {
+	ItemId    int     `json:"item_id"`
+	Name      string  `json:"name"`
+	Views     int     `json:"views"`
+	PrevViews int     `json:"prev_views"`
+	Velocity  float64 `json:"velocity"`
+}
+
+type SearchStats struct {
+	Query       string `json:"query"`
+	Searches    int    `json:"searches"`
+	ZeroResults int    `json:"zero_results"`
+	// query never found anything
+	NoResults bool `json:"no_results"`
+}
+
+// GET /analytics/items/popular Get most viewed items for last days
+func (a *AnalyticsHandler) Popular(c echo.Context) error {
+	var params AnalyticsQueryParams
+	if err := a.bindParams(c, &params); err != nil {
+		return err
+	}
+
+	stats, err := a.Service.GetPopular(c.Request().Context(), domain.StatsFilter{
+		Days:  params.Days,
+		Limit: params.Limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, convertItemViewStats(stats))
+}
+
+// GET /analytics/items/trending Get items which views grow fastest compared with previous period
+func (a *AnalyticsHandler) Trending(c echo.Context) error {
+	var params AnalyticsQueryParams
+	if err := a.bindParams(c, &params); err != nil {
+		return err
+	}
+
+	stats, err := a.Service.GetTrending(c.Request().Context(), domain.StatsFilter{
+		Days:  params.Days,
+		Limit: params.Limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, convertItemViewStats(stats))
+}
+
+// GET /analytics/searches Get most frequent search queries, queries without results are flagged
+func (a *AnalyticsHandler) Searches(c echo.Context) error {
+	var params SearchesQueryParams
+	if err := a.bindParams(c, &params); err != nil {
+		return err
+	}
+
+	stats, err := a.Service.GetTopQueries(c.Request().Context(), domain.StatsFilter{
+		Days:     params.Days,
+		Limit:    params.Limit,
+		ZeroOnly: params.ZeroResults,
+	})
+	if err != nil {
+		return err
+	}
+
+	response := make([]SearchStats, 0, len(stats))
+	for _, query := range stats {
+		response = append(response, SearchStats{
+			Query:       query.Query,
+			Searches:    query.Searches,
+			ZeroResults: query.ZeroResults,
+			NoResults:   query.NoResults(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *AnalyticsHandler) bindParams(c echo.Context, params any) error {
+	if err := bind(c, params); err != nil {
+		return err
+	}
+
+	return validateRequest(params)
+}
+
+func convertItemViewStats(stats []domain.ItemStats) []ItemViewStats {
+	response := make([]ItemViewStats, 0, len(stats))
+	for _, item := range stats {
+		response = append(response, ItemViewStats{
+			ItemId:    item.ItemId,
+			Name:      item.Name,
+			Views:     item.Views,
+			PrevViews: item.PrevViews,
+			Velocity:  item.Velocity,
+		})
+	}
+
+	return response
+}
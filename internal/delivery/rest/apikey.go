@@ -0,0 +1,283 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/apikey"
+	adomain "cloth-mini-app/internal/domain/audit"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const (
+	headerAPIKey = "X-API-Key"
+
+	// key of authenticated api key in echo context
+	apiKeyKey = "auth_api_key"
+)
+
+type APIKeyService interface {
+	GetAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	// Create key, key is returned only once
+	Create(ctx context.Context, key domain.APIKeyCreate) (domain.APIKeySecret, error)
+	// Replace key, previous key stops working
+	Rotate(ctx context.Context, keyId int) (domain.APIKeySecret, error)
+	Revoke(ctx context.Context, keyId int) error
+	// Get usage of key for last days
+	GetUsage(ctx context.Context, keyId int) ([]domain.Usage, error)
+	// Find active key
+	Authenticate(ctx context.Context, key string) (domain.APIKey, error)
+	// Count request against rate limit and daily quota, returns delay for rejected request
+	Consume(ctx context.Context, key domain.APIKey) (time.Duration, error)
+}
+
+// Identify partner by api key if it's provided. Requests without key are allowed for public pages,
+// they are limited by anonymous rate limit of client ip, so partner with revoked key or exceeded quota
+// doesn't get more by dropping the key. Users are authenticated before, see OptionalUser, and aren't limited
+func (a *AuthMiddleware) APIKey(scope domain.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		byKey := a.requireAPIKey([]domain.Scope{scope}, next)
+
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(headerAPIKey) != "" {
+				return byKey(c)
+			}
+			if isUser(c) {
+				return next(c)
+			}
+
+			if retryAfter := a.limiter.Reserve("anonymous:"+c.RealIP(), a.Anonymous); retryAfter > 0 {
+				setRetryAfter(c, retryAfter)
+
+				return errRateLimited
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Allow request only with active api key having one of scopes and within its limits
+func (a *AuthMiddleware) requireAPIKey(scopes []domain.Scope, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if a.APIKeys == nil {
+			return domain.ErrAPIKeyInvalid
+		}
+
+		ctx := c.Request().Context()
+
+		key, err := a.APIKeys.Authenticate(ctx, c.Request().Header.Get(headerAPIKey))
+		if err != nil {
+			return err
+		}
+
+		allowed := false
+		for _, scope := range scopes {
+			if key.HasScope(scope) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return domain.ErrScopeMissing
+		}
+
+		retryAfter, err := a.APIKeys.Consume(ctx, key)
+		if err != nil {
+			if retryAfter > 0 {
+				setRetryAfter(c, retryAfter)
+			}
+			return err
+		}
+
+		c.Set(apiKeyKey, key)
+		withActor(c, adomain.Actor{APIKeyId: key.ID})
+
+		return next(c)
+	}
+}
+
+type APIKeyHandler struct {
+	Service APIKeyService
+}
+
+func NewAPIKeyHandler(e *echo.Echo, srv APIKeyService, auth *AuthMiddleware) {
+	handler := &APIKeyHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/apikey", auth.Admin())
+	g.Use(middleware.Logger())
+
+	g.GET("/get", handler.APIKeys)
+	g.POST("/create", handler.Create)
+	g.POST("/rotate/:id", handler.Rotate)
+	g.DELETE("/revoke/:id", handler.Revoke)
+	g.GET("/:id/usage", handler.Usage)
+}
+
+type APIKey struct {
+	ID         int        `json:"api_key_id"`
+	Prefix     string     `json:"prefix"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	DailyQuota int        `json:"daily_quota"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKeyCreate struct {
+	Name   string   `json:"name" validate:"required"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=catalog:read items:write images:write"`
+	// requests per minute
+	RateLimit  int `json:"rate_limit" validate:"required,min=1"`
+	DailyQuota int `json:"daily_quota" validate:"required,min=1"`
+}
+
+type APIKeyId struct {
+	Id int `param:"id"`
+}
+
+// Key is shown only in this response
+type APIKeySecretResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyUsage struct {
+	Day      string `json:"day"`
+	Requests int    `json:"requests"`
+	Rejected int    `json:"rejected"`
+}
+
+// GET /apikey/get Get all api keys, keys themselves aren't returned
+func (a *APIKeyHandler) APIKeys(ctx echo.Context) error {
+	keys, err := a.Service.GetAPIKeys(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	keysResponse := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		keysResponse = append(keysResponse, convertAPIKeyFromDomain(key))
+	}
+
+	return ctx.JSON(http.StatusOK, keysResponse)
+}
+
+func convertAPIKeyFromDomain(key domain.APIKey) APIKey {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	return APIKey{
+		ID:         key.ID,
+		Prefix:     key.Prefix,
+		Name:       key.Name,
+		Scopes:     scopes,
+		RateLimit:  key.RateLimit,
+		DailyQuota: key.DailyQuota,
+		CreatedAt:  key.CreatedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// POST /apikey/create Create api key. Key is returned only once
+func (a *APIKeyHandler) Create(ctx echo.Context) error {
+	var key APIKeyCreate
+	err := bind(ctx, &key)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(key); err != nil {
+		return err
+	}
+
+	scopes := make([]domain.Scope, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, domain.Scope(scope))
+	}
+
+	secret, err := a.Service.Create(ctx.Request().Context(), domain.APIKeyCreate{
+		Name:       key.Name,
+		Scopes:     scopes,
+		RateLimit:  key.RateLimit,
+		DailyQuota: key.DailyQuota,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, APIKeySecretResponse{
+		APIKey: convertAPIKeyFromDomain(secret.APIKey),
+		Key:    secret.Key,
+	})
+}
+
+// POST /apikey/rotate/:id Replace key of api key, previous key stops working immediately
+func (a *APIKeyHandler) Rotate(ctx echo.Context) error {
+	var keyId APIKeyId
+	err := bind(ctx, &keyId)
+	if err != nil {
+		return err
+	}
+
+	secret, err := a.Service.Rotate(ctx.Request().Context(), keyId.Id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, APIKeySecretResponse{
+		APIKey: convertAPIKeyFromDomain(secret.APIKey),
+		Key:    secret.Key,
+	})
+}
+
+// DELETE /apikey/revoke/:id Revoke api key, revoked key can't be restored
+func (a *APIKeyHandler) Revoke(ctx echo.Context) error {
+	var keyId APIKeyId
+	err := bind(ctx, &keyId)
+	if err != nil {
+		return err
+	}
+
+	err = a.Service.Revoke(ctx.Request().Context(), keyId.Id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "revoke",
+	})
+}
+
+// GET /apikey/:id/usage Get requests of api key per day for last 30 days
+func (a *APIKeyHandler) Usage(ctx echo.Context) error {
+	var keyId APIKeyId
+	err := bind(ctx, &keyId)
+	if err != nil {
+		return err
+	}
+
+	usage, err := a.Service.GetUsage(ctx.Request().Context(), keyId.Id)
+	if err != nil {
+		return err
+	}
+
+	usageResponse := make([]APIKeyUsage, 0, len(usage))
+	for _, day := range usage {
+		usageResponse = append(usageResponse, APIKeyUsage{
+			Day:      day.Day.Format(time.DateOnly),
+			Requests: day.Requests,
+			Rejected: day.Rejected,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, usageResponse)
+}
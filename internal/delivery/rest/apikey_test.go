@@ -0,0 +1,104 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/apikey"
+	udomain "cloth-mini-app/internal/domain/user"
+	"cloth-mini-app/internal/ratelimit"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	revokedKey = "cm_revoked"
+	userToken  = "user-token"
+)
+
+// All keys are revoked
+type revokedKeys struct {
+	APIKeyService
+}
+
+func (revokedKeys) Authenticate(context.Context, string) (domain.APIKey, error) {
+	return domain.APIKey{}, domain.ErrAPIKeyInvalid
+}
+
+type stubTokens struct {
+	AuthService
+}
+
+func (stubTokens) ParseAccessToken(token string) (udomain.Claims, error) {
+	if token != userToken {
+		return udomain.Claims{}, udomain.ErrUnauthenticated
+	}
+
+	return udomain.Claims{UserId: 1, Role: udomain.RoleEditor}, nil
+}
+
+func newCatalog(anonymous ratelimit.Limit) *echo.Echo {
+	auth := NewAuthMiddleware(stubTokens{}, revokedKeys{}, anonymous)
+
+	e := echo.New()
+	e.HTTPErrorHandler = NewHTTPErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	e.GET("/item/get", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, auth.OptionalUser(), auth.APIKey(domain.ScopeCatalogRead))
+
+	return e
+}
+
+func TestAPIKeyRevokedKeyWithoutHeaderIsLimited(t *testing.T) {
+	e := newCatalog(ratelimit.Limit{Rate: 1 / time.Hour.Seconds(), Burst: 2})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{name: "revoked key", header: headerAPIKey, value: revokedKey, status: http.StatusUnauthorized},
+		{name: "first anonymous", status: http.StatusOK},
+		{name: "second anonymous", status: http.StatusOK},
+		{name: "anonymous over limit", status: http.StatusTooManyRequests},
+		{name: "revoked key over limit", header: headerAPIKey, value: revokedKey, status: http.StatusUnauthorized},
+		{name: "user isn't limited", header: echo.HeaderAuthorization, value: "Bearer " + userToken, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/item/get", nil)
+		if tt.header != "" {
+			request.Header.Set(tt.header, tt.value)
+		}
+		response := httptest.NewRecorder()
+
+		e.ServeHTTP(response, request)
+
+		if response.Code != tt.status {
+			t.Fatalf("%s: expected status %d, got %d", tt.name, tt.status, response.Code)
+		}
+		if tt.status == http.StatusTooManyRequests && response.Header().Get(echo.HeaderRetryAfter) == "" {
+			t.Errorf("%s: expected Retry-After header", tt.name)
+		}
+	}
+}
+
+func TestAPIKeyAnonymousLimitIsPerIP(t *testing.T) {
+	e := newCatalog(ratelimit.Limit{Rate: 1 / time.Hour.Seconds(), Burst: 1})
+
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+		request := httptest.NewRequest(http.MethodGet, "/item/get", nil)
+		request.RemoteAddr = ip + ":1234"
+		response := httptest.NewRecorder()
+
+		e.ServeHTTP(response, request)
+
+		if response.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", ip, response.Code)
+		}
+	}
+}
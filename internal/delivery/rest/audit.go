@@ -0,0 +1,148 @@
+package rest
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	domain "cloth-mini-app/internal/domain/audit"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type AuditService interface {
+	// Get audit entries by filter, latest first
+	GetEntries(ctx context.Context, filter domain.Filter) ([]domain.Entry, error)
+}
+
+type AuditHandler struct {
+	Service AuditService
+}
+
+func NewAuditHandler(e *echo.Echo, srv AuditService, auth *AuthMiddleware) {
+	handler := &AuditHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/admin/audit")
+	g.Use(middleware.Logger())
+
+	g.GET("", handler.Entries, auth.Admin())
+}
+
+type AuditQueryParams struct {
+	UserId     *int   `query:"user_id"`
+	APIKeyId   *int   `query:"api_key_id"`
+	Action     string `query:"action"`
+	EntityType string `query:"entity_type"`
+	EntityId   string `query:"entity_id"`
+	// RFC 3339 time, from is inclusive and to is exclusive
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Limit  uint64 `query:"limit"`
+	Offset uint64 `query:"offset"`
+}
+
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	UserId     *int            `json:"user_id"`
+	APIKeyId   *int            `json:"api_key_id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityId   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestId  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditResponse struct {
+	Count   int          `json:"count"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+// GET /admin/audit Get audit log of catalog changes by query params. Only for admin
+func (a *AuditHandler) Entries(ctx echo.Context) error {
+	var params AuditQueryParams
+	err := bind(ctx, &params)
+	if err != nil {
+		return err
+	}
+
+	var verr apperr.FieldErrors
+	from, ok := parseQueryTime(params.From)
+	if !ok {
+		verr.Add("from", "from must be RFC 3339 time")
+	}
+	to, ok := parseQueryTime(params.To)
+	if !ok {
+		verr.Add("to", "to must be RFC 3339 time")
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	entries, err := a.Service.GetEntries(ctx.Request().Context(), domain.Filter{
+		UserId:     params.UserId,
+		APIKeyId:   params.APIKeyId,
+		Action:     domain.Action(params.Action),
+		EntityType: domain.EntityType(params.EntityType),
+		EntityId:   params.EntityId,
+		From:       from,
+		To:         to,
+		Limit:      params.Limit,
+		Offset:     params.Offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	entriesResponse := make([]AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		entriesResponse = append(entriesResponse, convertAuditEntryFromDomain(entry))
+	}
+
+	return ctx.JSON(http.StatusOK, AuditResponse{
+		Count:   len(entriesResponse),
+		Entries: entriesResponse,
+	})
+}
+
+// Parse optional time of query, empty value is nil
+func parseQueryTime(value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, false
+	}
+
+	return &t, true
+}
+
+func convertAuditEntryFromDomain(entry domain.Entry) AuditEntry {
+	auditEntry := AuditEntry{
+		ID:         entry.ID,
+		Actor:      entry.ActorName,
+		Action:     string(entry.Action),
+		EntityType: string(entry.EntityType),
+		EntityId:   entry.EntityId,
+		Before:     entry.Before,
+		After:      entry.After,
+		RequestId:  entry.RequestId,
+		CreatedAt:  entry.CreatedAt,
+	}
+	if entry.Actor.UserId != 0 {
+		auditEntry.UserId = &entry.Actor.UserId
+	}
+	if entry.Actor.APIKeyId != 0 {
+		auditEntry.APIKeyId = &entry.Actor.APIKeyId
+	}
+
+	return auditEntry
+}
@@ -0,0 +1,344 @@
+package rest
+
+import (
+	akdomain "cloth-mini-app/internal/domain/apikey"
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/user"
+	"cloth-mini-app/internal/ratelimit"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// key of authenticated user claims in echo context
+const claimsKey = "auth_claims"
+
+type AuthService interface {
+	// Check credentials and issue tokens
+	Login(ctx context.Context, login, password string) (domain.TokenPair, error)
+	// Exchange refresh token for new pair
+	Refresh(ctx context.Context, refreshToken string) (domain.TokenPair, error)
+	// Revoke refresh token
+	Logout(ctx context.Context, refreshToken string) error
+	// Check access token and get user claims
+	ParseAccessToken(token string) (domain.Claims, error)
+	GetUser(ctx context.Context, userId int) (domain.User, error)
+	GetUsers(ctx context.Context) ([]domain.User, error)
+	// Create user and return its id
+	CreateUser(ctx context.Context, user domain.UserCreate) (int, error)
+}
+
+// Middlewares checking access token (Authorization: Bearer <token>) and role of user.
+// Partners can be authenticated by api key (X-API-Key header) with scopes instead
+type AuthMiddleware struct {
+	Service AuthService
+	APIKeys APIKeyService
+	// Rate limit of client ip for requests without api key and user, see APIKey
+	Anonymous ratelimit.Limit
+	limiter   *ratelimit.Keyed
+}
+
+func NewAuthMiddleware(srv AuthService, apiKeys APIKeyService, anonymous ratelimit.Limit) *AuthMiddleware {
+	return &AuthMiddleware{
+		Service:   srv,
+		APIKeys:   apiKeys,
+		Anonymous: anonymous,
+		limiter:   ratelimit.NewKeyed(),
+	}
+}
+
+// Allow request only for users with one of roles
+func (a *AuthMiddleware) RequireRole(roles ...domain.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" {
+				return domain.ErrUnauthenticated
+			}
+
+			claims, err := a.Service.ParseAccessToken(token)
+			if err != nil {
+				return err
+			}
+
+			if len(roles) != 0 && !slices.Contains(roles, claims.Role) {
+				return domain.ErrForbidden
+			}
+
+			c.Set(claimsKey, claims)
+			withActor(c, adomain.Actor{UserId: claims.UserId})
+
+			return next(c)
+		}
+	}
+}
+
+// Allow request for any authenticated user
+func (a *AuthMiddleware) Authenticated() echo.MiddlewareFunc {
+	return a.RequireRole()
+}
+
+// Authenticate user if request has access token, anonymous request is allowed.
+// Invalid token is rejected, so client can refresh it
+func (a *AuthMiddleware) OptionalUser() echo.MiddlewareFunc {
+	requireUser := a.RequireRole()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withUser := requireUser(next)
+
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+				return next(c)
+			}
+
+			return withUser(c)
+		}
+	}
+}
+
+// Check if request is made by authenticated user
+func isUser(c echo.Context) bool {
+	_, ok := c.Get(claimsKey).(domain.Claims)
+
+	return ok
+}
+
+// Check if request is made by user managing content
+func isEditor(c echo.Context) bool {
+	claims, ok := c.Get(claimsKey).(domain.Claims)
+
+	return ok && (claims.Role == domain.RoleAdmin || claims.Role == domain.RoleEditor)
+}
+
+// Allow request for users managing content or api keys with one of scopes
+func (a *AuthMiddleware) Editor(scopes ...akdomain.Scope) echo.MiddlewareFunc {
+	requireRole := a.RequireRole(domain.RoleAdmin, domain.RoleEditor)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		byRole := requireRole(next)
+		byKey := a.requireAPIKey(scopes, next)
+
+		return func(c echo.Context) error {
+			if len(scopes) != 0 && c.Request().Header.Get(headerAPIKey) != "" {
+				return byKey(c)
+			}
+
+			return byRole(c)
+		}
+	}
+}
+
+func (a *AuthMiddleware) Admin() echo.MiddlewareFunc {
+	return a.RequireRole(domain.RoleAdmin)
+}
+
+// Pass actor and request id to services through request context for audit log
+func withActor(c echo.Context, actor adomain.Actor) {
+	ctx := adomain.WithActor(c.Request().Context(), actor)
+	ctx = adomain.WithRequestId(ctx, c.Response().Header().Get(echo.HeaderXRequestID))
+
+	c.SetRequest(c.Request().WithContext(ctx))
+}
+
+type AuthHandler struct {
+	Service AuthService
+}
+
+func NewAuthHandler(e *echo.Echo, srv AuthService, auth *AuthMiddleware) {
+	handler := &AuthHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/auth")
+	g.Use(middleware.Logger())
+
+	g.POST("/login", handler.Login)
+	g.POST("/refresh", handler.Refresh)
+	g.POST("/logout", handler.Logout)
+	g.GET("/me", handler.Me, auth.Authenticated())
+
+	u := e.Group("/user", auth.Admin())
+	u.Use(middleware.Logger())
+
+	u.GET("/get", handler.Users)
+	u.POST("/create", handler.CreateUser)
+}
+
+type LoginRequest struct {
+	Login    string `json:"login" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type TokenResponse struct {
+	TokenType        string    `json:"token_type"`
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type User struct {
+	ID        int       `json:"user_id"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserCreate struct {
+	Login    string `json:"login" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=admin editor viewer"`
+}
+
+type CreateUserResponse struct {
+	ID int `json:"user_id"`
+}
+
+// POST /auth/login Get access and refresh tokens by login and password
+func (a *AuthHandler) Login(ctx echo.Context) error {
+	var login LoginRequest
+	err := bind(ctx, &login)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(login); err != nil {
+		return err
+	}
+
+	tokens, err := a.Service.Login(ctx.Request().Context(), login.Login, login.Password)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, convertTokensFromDomain(tokens))
+}
+
+// POST /auth/refresh Exchange refresh token for new tokens. Refresh token can be used only once
+func (a *AuthHandler) Refresh(ctx echo.Context) error {
+	var refresh RefreshRequest
+	err := bind(ctx, &refresh)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(refresh); err != nil {
+		return err
+	}
+
+	tokens, err := a.Service.Refresh(ctx.Request().Context(), refresh.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, convertTokensFromDomain(tokens))
+}
+
+// POST /auth/logout Revoke refresh token
+func (a *AuthHandler) Logout(ctx echo.Context) error {
+	var refresh RefreshRequest
+	err := bind(ctx, &refresh)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(refresh); err != nil {
+		return err
+	}
+
+	err = a.Service.Logout(ctx.Request().Context(), refresh.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "logout",
+	})
+}
+
+func convertTokensFromDomain(tokens domain.TokenPair) TokenResponse {
+	return TokenResponse{
+		TokenType:        "Bearer",
+		AccessToken:      tokens.AccessToken,
+		AccessExpiresAt:  tokens.AccessExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+// GET /auth/me Get authenticated user
+func (a *AuthHandler) Me(ctx echo.Context) error {
+	claims, ok := ctx.Get(claimsKey).(domain.Claims)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	user, err := a.Service.GetUser(ctx.Request().Context(), claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, convertUserFromDomain(user))
+}
+
+// GET /user/get Get all users. Only for admin
+func (a *AuthHandler) Users(ctx echo.Context) error {
+	users, err := a.Service.GetUsers(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	usersResponse := make([]User, 0, len(users))
+	for _, user := range users {
+		usersResponse = append(usersResponse, convertUserFromDomain(user))
+	}
+
+	return ctx.JSON(http.StatusOK, usersResponse)
+}
+
+func convertUserFromDomain(user domain.User) User {
+	return User{
+		ID:        user.ID,
+		Login:     user.Login,
+		Role:      user.Role.String(),
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// POST /user/create Create user with role. Only for admin
+func (a *AuthHandler) CreateUser(ctx echo.Context) error {
+	var user UserCreate
+	err := bind(ctx, &user)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(user); err != nil {
+		return err
+	}
+
+	role, _ := domain.ParseRole(user.Role)
+
+	userId, err := a.Service.CreateUser(ctx.Request().Context(), domain.UserCreate{
+		Login:    user.Login,
+		Password: user.Password,
+		Role:     role,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, CreateUserResponse{
+		ID: userId,
+	})
+}
@@ -11,13 +11,25 @@ import (
 
 type BrandService interface {
 	GetBrands(ctx context.Context) ([]domain.Brand, error)
+	// Get brand with amount of items
+	GetBrand(ctx context.Context, brandId int) (domain.Brand, error)
+	// Create brand and return its id
+	Create(ctx context.Context, brand domain.BrandCreate) (int, error)
+	// Update brand
+	Update(ctx context.Context, brand domain.BrandUpdate) error
+	// Delete brand without items
+	Delete(ctx context.Context, brandId int) error
+	// Store brand logo and return its id
+	UploadLogo(ctx context.Context, brandId int, file []byte) (string, error)
+	// Remove brand logo
+	DeleteLogo(ctx context.Context, brandId int) error
 }
 
 type BrandHandler struct {
 	Service BrandService
 }
 
-func NewBrandHandler(e *echo.Echo, srv BrandService) {
+func NewBrandHandler(e *echo.Echo, srv BrandService, auth *AuthMiddleware) {
 	handler := &BrandHandler{
 		Service: srv,
 	}
@@ -26,28 +38,212 @@ func NewBrandHandler(e *echo.Echo, srv BrandService) {
 	g.Use(middleware.Logger())
 
 	g.GET("/get", handler.Brands)
+	g.GET("/:id", handler.Brand)
+	g.POST("/create", handler.Create, auth.Editor())
+	g.POST("/update/:id", handler.Update, auth.Editor())
+	g.DELETE("/delete/:id", handler.Delete, auth.Editor())
+	g.POST("/logo/:id", handler.UploadLogo, auth.Editor())
+	g.DELETE("/logo/:id", handler.DeleteLogo, auth.Editor())
 }
 
 type Brand struct {
-	ID   int    `json:"brand_id"`
-	Name string `json:"brand_name"`
+	ID          int     `json:"brand_id"`
+	Name        string  `json:"brand_name"`
+	Slug        string  `json:"slug"`
+	Description string  `json:"description"`
+	Country     string  `json:"country"`
+	LogoId      *string `json:"logo_id"`
+}
+
+type BrandByIdResponse struct {
+	Brand
+	ItemsCount int `json:"items_count"`
+}
+
+type BrandCreate struct {
+	Name        string `json:"brand_name" validate:"required"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Country     string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+}
+
+type BrandUpdate struct {
+	ID          int     `param:"id"`
+	Name        *string `json:"brand_name" validate:"omitempty,min=1"`
+	Slug        *string `json:"slug"`
+	Description *string `json:"description"`
+	Country     *string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+}
+
+type BrandId struct {
+	Id int `param:"id"`
+}
+
+type CreateBrandResponse struct {
+	ID int `json:"brand_id"`
+}
+
+type BrandLogoResponse struct {
+	LogoId string `json:"logo_id"`
 }
 
 func (b *BrandHandler) Brands(ctx echo.Context) error {
 	brands, err := b.Service.GetBrands(ctx.Request().Context())
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "getting brands",
-		})
+		return err
 	}
 
 	brandsResponse := make([]Brand, 0, len(brands))
 	for _, brand := range brands {
-		brandsResponse = append(brandsResponse, Brand{
-			ID:   brand.ID,
-			Name: brand.Name,
-		})
+		brandsResponse = append(brandsResponse, convertBrandFromDomain(brand))
 	}
 
 	return ctx.JSON(http.StatusOK, brandsResponse)
 }
+
+func convertBrandFromDomain(brand domain.Brand) Brand {
+	return Brand{
+		ID:          brand.ID,
+		Name:        brand.Name,
+		Slug:        brand.Slug,
+		Description: brand.Description,
+		Country:     brand.Country,
+		LogoId:      brand.LogoId,
+	}
+}
+
+// GET /brand/:id Get brand with amount of items
+func (b *BrandHandler) Brand(ctx echo.Context) error {
+	var brandId BrandId
+	err := bind(ctx, &brandId)
+	if err != nil {
+		return err
+	}
+
+	brand, err := b.Service.GetBrand(ctx.Request().Context(), brandId.Id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, BrandByIdResponse{
+		Brand:      convertBrandFromDomain(brand),
+		ItemsCount: brand.ItemsCount,
+	})
+}
+
+// POST /brand/create Create brand, slug is made from name if not provided
+func (b *BrandHandler) Create(ctx echo.Context) error {
+	var brand BrandCreate
+	err := bind(ctx, &brand)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(brand); err != nil {
+		return err
+	}
+
+	brandId, err := b.Service.Create(ctx.Request().Context(), domain.BrandCreate{
+		Name:        brand.Name,
+		Slug:        brand.Slug,
+		Description: brand.Description,
+		Country:     brand.Country,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, CreateBrandResponse{
+		ID: brandId,
+	})
+}
+
+// POST /brand/update/:id Update provided brand fields
+func (b *BrandHandler) Update(ctx echo.Context) error {
+	var brand BrandUpdate
+	err := bind(ctx, &brand)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(brand); err != nil {
+		return err
+	}
+
+	err = b.Service.Update(ctx.Request().Context(), domain.BrandUpdate{
+		ID:          brand.ID,
+		Name:        brand.Name,
+		Slug:        brand.Slug,
+		Description: brand.Description,
+		Country:     brand.Country,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "update",
+	})
+}
+
+// DELETE /brand/delete/:id Delete brand. Brand with items can't be deleted
+func (b *BrandHandler) Delete(ctx echo.Context) error {
+	var brandId BrandId
+	err := bind(ctx, &brandId)
+	if err != nil {
+		return err
+	}
+
+	err = b.Service.Delete(ctx.Request().Context(), brandId.Id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "delete",
+	})
+}
+
+// POST /brand/logo/:id Upload brand logo (form field "image"). Logo is available by /image/get/:logo_id
+func (b *BrandHandler) UploadLogo(ctx echo.Context) error {
+	var brandId BrandId
+	err := bind(ctx, &brandId)
+	if err != nil {
+		return err
+	}
+
+	imageBytes, err := (&ImageHandler{}).file(ctx)
+	if err != nil {
+		return err
+	}
+
+	logoId, err := b.Service.UploadLogo(ctx.Request().Context(), brandId.Id, imageBytes)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, BrandLogoResponse{
+		LogoId: logoId,
+	})
+}
+
+// DELETE /brand/logo/:id Remove brand logo
+func (b *BrandHandler) DeleteLogo(ctx echo.Context) error {
+	var brandId BrandId
+	err := bind(ctx, &brandId)
+	if err != nil {
+		return err
+	}
+
+	err = b.Service.DeleteLogo(ctx.Request().Context(), brandId.Id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "delete",
+	})
+}
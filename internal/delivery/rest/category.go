@@ -11,13 +11,27 @@ import (
 
 type CategoryService interface {
 	GetCategories(ctx context.Context) ([]domain.Category, error)
+	// Get categories as tree
+	GetTree(ctx context.Context) ([]domain.CategoryNode, error)
+	// Get category with own and inherited attributes
+	GetCategory(ctx context.Context, categoryId int) (domain.Category, []domain.Attribute, error)
+	// Create category and return its id
+	Create(ctx context.Context, category domain.CategoryCreate) (int, error)
+	// Rename or move category
+	Update(ctx context.Context, category domain.CategoryUpdate) error
+	// Delete category without subcategories and items
+	Delete(ctx context.Context, categoryId int) error
+	// Add attribute definition to category
+	CreateAttribute(ctx context.Context, attr domain.Attribute) error
+	// Remove attribute definition from category
+	DeleteAttribute(ctx context.Context, categoryId int, code string) error
 }
 
 type CategoryHandler struct {
 	Service CategoryService
 }
 
-func NewCategoryHandler(e *echo.Echo, srv CategoryService) {
+func NewCategoryHandler(e *echo.Echo, srv CategoryService, auth *AuthMiddleware) {
 	handler := &CategoryHandler{
 		Service: srv,
 	}
@@ -26,30 +40,272 @@ func NewCategoryHandler(e *echo.Echo, srv CategoryService) {
 	g.Use(middleware.Logger())
 
 	g.GET("/get", handler.Categories)
+	g.GET("/tree", handler.Tree)
+	g.GET("/:id", handler.Category)
+	g.POST("/create", handler.Create, auth.Editor())
+	g.POST("/update/:id", handler.Update, auth.Editor())
+	g.DELETE("/delete/:id", handler.Delete, auth.Editor())
+	g.POST("/:id/attribute", handler.CreateAttribute, auth.Editor())
+	g.DELETE("/:id/attribute/:code", handler.DeleteAttribute, auth.Editor())
 }
 
 type Category struct {
 	CategoryId int    `json:"category_id"`
-	Type       int    `json:"type"`
+	ParentId   *int   `json:"parent_id"`
+	Type       string `json:"type"`
 	Name       string `json:"category_name"`
 }
 
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+type CategoryByIdResponse struct {
+	Category
+	Attributes []CategoryAttribute `json:"attributes"`
+}
+
+type CategoryAttribute struct {
+	CategoryId int      `json:"category_id"`
+	Code       string   `json:"code"`
+	Name       string   `json:"name"`
+	ValueType  string   `json:"value_type"`
+	Options    []string `json:"options,omitempty"`
+	Required   bool     `json:"required"`
+}
+
+type CategoryCreate struct {
+	ParentId *int   `json:"parent_id" validate:"omitempty,min=1"`
+	Type     string `json:"type" validate:"required_without=ParentId,omitempty,oneof=clothes shoes"`
+	Name     string `json:"category_name" validate:"required"`
+}
+
+type CategoryUpdate struct {
+	ID int `param:"id"`
+	// 0 moves category to root
+	ParentId *int    `json:"parent_id" validate:"omitempty,min=0"`
+	Name     *string `json:"category_name" validate:"omitempty,min=1"`
+}
+
+type CategoryId struct {
+	Id int `param:"id"`
+}
+
+type CreateCategoryResponse struct {
+	ID int `json:"category_id"`
+}
+
+type AttributeCreate struct {
+	CategoryId int      `param:"id"`
+	Code       string   `json:"code" validate:"required"`
+	Name       string   `json:"name" validate:"required"`
+	ValueType  string   `json:"value_type" validate:"required,oneof=string int bool enum"`
+	Options    []string `json:"options"`
+	Required   bool     `json:"required"`
+}
+
+type AttributeId struct {
+	CategoryId int    `param:"id"`
+	Code       string `param:"code"`
+}
+
 func (c *CategoryHandler) Categories(ctx echo.Context) error {
 	categories, err := c.Service.GetCategories(ctx.Request().Context())
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "getting categories",
-		})
+		return err
 	}
 
 	categoriesResponse := make([]Category, 0, len(categories))
 	for _, cat := range categories {
-		categoriesResponse = append(categoriesResponse, Category{
-			CategoryId: cat.CategoryId,
-			Type:       cat.Type,
-			Name:       cat.Name,
-		})
+		categoriesResponse = append(categoriesResponse, convertCategoryFromDomain(cat))
 	}
 
 	return ctx.JSON(http.StatusOK, categoriesResponse)
 }
+
+func convertCategoryFromDomain(category domain.Category) Category {
+	return Category{
+		CategoryId: category.CategoryId,
+		ParentId:   category.ParentId,
+		Type:       category.Type.String(),
+		Name:       category.Name,
+	}
+}
+
+// GET /category/tree Get categories as tree
+func (c *CategoryHandler) Tree(ctx echo.Context) error {
+	tree, err := c.Service.GetTree(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, convertTreeFromDomain(tree))
+}
+
+func convertTreeFromDomain(nodes []domain.CategoryNode) []CategoryNode {
+	tree := make([]CategoryNode, 0, len(nodes))
+	for _, node := range nodes {
+		tree = append(tree, CategoryNode{
+			Category: convertCategoryFromDomain(node.Category),
+			Children: convertTreeFromDomain(node.Children),
+		})
+	}
+
+	return tree
+}
+
+// GET /category/:id Get category with attributes, including inherited from parent categories
+func (c *CategoryHandler) Category(ctx echo.Context) error {
+	var categoryId CategoryId
+	err := bind(ctx, &categoryId)
+	if err != nil {
+		return err
+	}
+
+	category, attributes, err := c.Service.GetCategory(ctx.Request().Context(), categoryId.Id)
+	if err != nil {
+		return err
+	}
+
+	attributesResponse := make([]CategoryAttribute, 0, len(attributes))
+	for _, attr := range attributes {
+		attributesResponse = append(attributesResponse, CategoryAttribute{
+			CategoryId: attr.CategoryId,
+			Code:       attr.Code,
+			Name:       attr.Name,
+			ValueType:  string(attr.ValueType),
+			Options:    attr.Options,
+			Required:   attr.Required,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, CategoryByIdResponse{
+		Category:   convertCategoryFromDomain(category),
+		Attributes: attributesResponse,
+	})
+}
+
+// POST /category/create Create category. Type is required only for root category, subcategory gets type of parent
+func (c *CategoryHandler) Create(ctx echo.Context) error {
+	var category CategoryCreate
+	err := bind(ctx, &category)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(category); err != nil {
+		return err
+	}
+
+	// type of subcategory can be omitted
+	kind, _ := domain.ParseKind(category.Type)
+
+	categoryId, err := c.Service.Create(ctx.Request().Context(), domain.CategoryCreate{
+		ParentId: category.ParentId,
+		Type:     kind,
+		Name:     category.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, CreateCategoryResponse{
+		ID: categoryId,
+	})
+}
+
+// POST /category/update/:id Rename category or move it to another parent
+func (c *CategoryHandler) Update(ctx echo.Context) error {
+	var category CategoryUpdate
+	err := bind(ctx, &category)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(category); err != nil {
+		return err
+	}
+
+	err = c.Service.Update(ctx.Request().Context(), domain.CategoryUpdate{
+		ID:       category.ID,
+		ParentId: category.ParentId,
+		Name:     category.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "update",
+	})
+}
+
+// DELETE /category/delete/:id Delete category without subcategories and items
+func (c *CategoryHandler) Delete(ctx echo.Context) error {
+	var categoryId CategoryId
+	err := bind(ctx, &categoryId)
+	if err != nil {
+		return err
+	}
+
+	err = c.Service.Delete(ctx.Request().Context(), categoryId.Id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "delete",
+	})
+}
+
+// POST /category/:id/attribute Add attribute definition. Attribute is used by category and all its subcategories
+func (c *CategoryHandler) CreateAttribute(ctx echo.Context) error {
+	var attr AttributeCreate
+	err := bind(ctx, &attr)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(attr); err != nil {
+		return err
+	}
+
+	err = c.Service.CreateAttribute(ctx.Request().Context(), domain.Attribute{
+		CategoryId: attr.CategoryId,
+		Code:       attr.Code,
+		Name:       attr.Name,
+		ValueType:  domain.ValueType(attr.ValueType),
+		Options:    attr.Options,
+		Required:   attr.Required,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "create",
+	})
+}
+
+// DELETE /category/:id/attribute/:code Remove attribute definition
+func (c *CategoryHandler) DeleteAttribute(ctx echo.Context) error {
+	var attr AttributeId
+	err := bind(ctx, &attr)
+	if err != nil {
+		return err
+	}
+
+	err = c.Service.DeleteAttribute(ctx.Request().Context(), attr.CategoryId, attr.Code)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "delete",
+	})
+}
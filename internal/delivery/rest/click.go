@@ -0,0 +1,297 @@
+package rest
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	domain "cloth-mini-app/internal/domain/click"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type ClickService interface {
+	// Record click on outer link of item and get link to shop with affiliate params
+	Redirect(ctx context.Context, itemId int, visit domain.Visit) (string, error)
+	// Get clicks per item, most clicked first
+	GetItemStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error)
+	// Get clicks per brand, most clicked first
+	GetBrandStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error)
+	// Get clicks per day, latest first
+	GetDayStats(ctx context.Context, filter domain.StatsFilter) ([]domain.DayStats, error)
+	GetTemplates(ctx context.Context) ([]domain.Template, error)
+	// Create or replace affiliate template of shop domain
+	SaveTemplate(ctx context.Context, template domain.Template) error
+	DeleteTemplate(ctx context.Context, domainName string) error
+}
+
+type ClickHandler struct {
+	Service ClickService
+}
+
+func NewClickHandler(e *echo.Echo, srv ClickService, auth *AuthMiddleware) {
+	handler := &ClickHandler{
+		Service: srv,
+	}
+
+	// links to shops are followed by anyone
+	e.GET("/go/:item_id", handler.Redirect, middleware.Logger())
+
+	g := e.Group("/clicks")
+	g.Use(middleware.Logger())
+	g.GET("/items", handler.ItemStats, auth.Editor())
+	g.GET("/brands", handler.BrandStats, auth.Editor())
+	g.GET("/days", handler.DayStats, auth.Editor())
+
+	// templates define affiliate income, so only admin changes them
+	t := e.Group("/affiliate/templates", auth.Admin())
+	t.Use(middleware.Logger())
+	t.GET("", handler.Templates)
+	t.PUT("/:domain", handler.SaveTemplate)
+	t.DELETE("/:domain", handler.DeleteTemplate)
+}
+
+type RedirectParams struct {
+	ItemId   int     `param:"item_id"`
+	Source   *string `query:"utm_source"`
+	Medium   *string `query:"utm_medium"`
+	Campaign *string `query:"utm_campaign"`
+	Content  *string `query:"utm_content"`
+	Term     *string `query:"utm_term"`
+}
+
+type ClickStatsQueryParams struct {
+	ItemId  *int `query:"item_id"`
+	BrandId *int `query:"brand_id"`
+	// RFC 3339 time, from is inclusive and to is exclusive. Last 30 days if both aren't set
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Limit  uint64 `query:"limit" validate:"lte=1000"`
+	Offset uint64 `query:"offset"`
+}
+
+type ClickStats struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Clicks   int    `json:"clicks"`
+	Visitors int    `json:"visitors"`
+}
+
+type ClickDayStats struct {
+	Day      string `json:"day"`
+	Clicks   int    `json:"clicks"`
+	Visitors int    `json:"visitors"`
+}
+
+type AffiliateTemplateDomain struct {
+	Domain string `param:"domain"`
+}
+
+type AffiliateTemplateSave struct {
+	Domain string `param:"domain"`
+	// query string with placeholders {item_id}, {brand_id}, {click_id}, {source}, {medium}, {campaign}, {content}, {term}
+	Params string `json:"params" validate:"required"`
+}
+
+type AffiliateTemplate struct {
+	Domain    string    `json:"domain"`
+	Params    string    `json:"params"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GET /go/:item_id Record click on outer link of published item and redirect to shop.
+// UTM params of request are saved with click and passed to affiliate template of shop domain
+func (cl *ClickHandler) Redirect(c echo.Context) error {
+	var params RedirectParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	link, err := cl.Service.Redirect(c.Request().Context(), params.ItemId, domain.Visit{
+		Referrer:  c.Request().Referer(),
+		UserAgent: c.Request().UserAgent(),
+		Campaign: domain.Campaign{
+			Source:   params.Source,
+			Medium:   params.Medium,
+			Campaign: params.Campaign,
+			Content:  params.Content,
+			Term:     params.Term,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// every click is counted, so redirect isn't cached
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	return c.Redirect(http.StatusFound, link)
+}
+
+// GET /clicks/items Get clicks per item, most clicked first
+func (cl *ClickHandler) ItemStats(c echo.Context) error {
+	filter, err := cl.statsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	stats, err := cl.Service.GetItemStats(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, convertClickStats(stats))
+}
+
+// GET /clicks/brands Get clicks per brand of items, most clicked first
+func (cl *ClickHandler) BrandStats(c echo.Context) error {
+	filter, err := cl.statsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	stats, err := cl.Service.GetBrandStats(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, convertClickStats(stats))
+}
+
+// GET /clicks/days Get clicks per day (UTC), latest first
+func (cl *ClickHandler) DayStats(c echo.Context) error {
+	filter, err := cl.statsFilter(c)
+	if err != nil {
+		return err
+	}
+
+	stats, err := cl.Service.GetDayStats(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	response := make([]ClickDayStats, 0, len(stats))
+	for _, day := range stats {
+		response = append(response, ClickDayStats{
+			Day:      day.Day.Format(time.DateOnly),
+			Clicks:   day.Clicks,
+			Visitors: day.Visitors,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (cl *ClickHandler) statsFilter(c echo.Context) (domain.StatsFilter, error) {
+	var params ClickStatsQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return domain.StatsFilter{}, err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return domain.StatsFilter{}, err
+	}
+
+	var verr apperr.FieldErrors
+	from, ok := parseQueryTime(params.From)
+	if !ok {
+		verr.Add("from", "from must be RFC 3339 time")
+	}
+	to, ok := parseQueryTime(params.To)
+	if !ok {
+		verr.Add("to", "to must be RFC 3339 time")
+	}
+	if err := verr.Err(); err != nil {
+		return domain.StatsFilter{}, err
+	}
+
+	return domain.StatsFilter{
+		From:    from,
+		To:      to,
+		ItemId:  params.ItemId,
+		BrandId: params.BrandId,
+		Limit:   params.Limit,
+		Offset:  params.Offset,
+	}, nil
+}
+
+func convertClickStats(stats []domain.Stats) []ClickStats {
+	response := make([]ClickStats, 0, len(stats))
+	for _, row := range stats {
+		response = append(response, ClickStats{
+			ID:       row.ID,
+			Name:     row.Name,
+			Clicks:   row.Clicks,
+			Visitors: row.Visitors,
+		})
+	}
+
+	return response
+}
+
+// GET /affiliate/templates Get affiliate templates of shop domains. Only for admin
+func (cl *ClickHandler) Templates(c echo.Context) error {
+	templates, err := cl.Service.GetTemplates(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	response := make([]AffiliateTemplate, 0, len(templates))
+	for _, template := range templates {
+		response = append(response, AffiliateTemplate{
+			Domain:    template.Domain,
+			Params:    template.Params,
+			UpdatedAt: template.UpdatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// PUT /affiliate/templates/:domain Create or replace affiliate template of shop domain, it's applied to subdomains too
+func (cl *ClickHandler) SaveTemplate(c echo.Context) error {
+	var template AffiliateTemplateSave
+	err := bind(c, &template)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(template); err != nil {
+		return err
+	}
+
+	err = cl.Service.SaveTemplate(c.Request().Context(), domain.Template{
+		Domain: template.Domain,
+		Params: template.Params,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "save",
+	})
+}
+
+// DELETE /affiliate/templates/:domain Delete affiliate template, links to domain are redirected without params
+func (cl *ClickHandler) DeleteTemplate(c echo.Context) error {
+	var template AffiliateTemplateDomain
+	err := bind(c, &template)
+	if err != nil {
+		return err
+	}
+
+	err = cl.Service.DeleteTemplate(c.Request().Context(), template.Domain)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "delete",
+	})
+}
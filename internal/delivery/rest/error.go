@@ -0,0 +1,117 @@
+package rest
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	sl "cloth-mini-app/internal/logger"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const internalErrorCode = "internal_error"
+
+var statusByKind = map[apperr.Kind]int{
+	apperr.KindInternal:             http.StatusInternalServerError,
+	apperr.KindInvalid:              http.StatusBadRequest,
+	apperr.KindValidation:           http.StatusUnprocessableEntity,
+	apperr.KindNotFound:             http.StatusNotFound,
+	apperr.KindConflict:             http.StatusConflict,
+	apperr.KindLimit:                http.StatusConflict,
+	apperr.KindUnsupported:          http.StatusNotImplemented,
+	apperr.KindUnauthorized:         http.StatusUnauthorized,
+	apperr.KindForbidden:            http.StatusForbidden,
+	apperr.KindTooManyRequests:      http.StatusTooManyRequests,
+	apperr.KindUnavailable:          http.StatusServiceUnavailable,
+	apperr.KindPreconditionRequired: http.StatusPreconditionRequired,
+}
+
+// Echo error handler. Handlers return errors as is, here they are mapped to status and ErrorResponse.
+// Unknown errors are logged and reported as internal without details
+func NewHTTPErrorHandler(logger *slog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		requestId := c.Response().Header().Get(echo.HeaderXRequestID)
+
+		// response is partially sent (e.g. streamed archive), status can't be changed
+		if c.Response().Committed {
+			logger.Error("failed writing response", slog.String("request_id", requestId), sl.Err(err))
+
+			return
+		}
+
+		status, response := errorResponse(err)
+		response.RequestId = requestId
+		if response.Code == internalErrorCode {
+			logger.Error("request failed",
+				slog.String("method", c.Request().Method),
+				slog.String("uri", c.Request().RequestURI),
+				slog.String("request_id", requestId),
+				sl.Err(err),
+			)
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(status)
+		} else {
+			err = c.JSON(status, response)
+		}
+		if err != nil {
+			logger.Error("failed writing error response", slog.String("request_id", requestId), sl.Err(err))
+		}
+	}
+}
+
+func errorResponse(err error) (int, ErrorResponse) {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) && appErr.Kind != apperr.KindInternal {
+		status := statusByKind[appErr.Kind]
+
+		response := ErrorResponse{
+			// wrapped error contains details, e.g. max images count
+			Err:       err.Error(),
+			Code:      appErr.Code,
+			Retryable: retryable(status),
+		}
+		for _, field := range appErr.Fields {
+			response.Fields = append(response.Fields, FieldError{
+				Field:   field.Field,
+				Message: field.Message,
+			})
+		}
+
+		return status, response
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code < http.StatusInternalServerError {
+		message := http.StatusText(httpErr.Code)
+		if text, ok := httpErr.Message.(string); ok {
+			message = text
+		}
+
+		return httpErr.Code, ErrorResponse{
+			Err:       message,
+			Code:      statusCode(httpErr.Code),
+			Retryable: retryable(httpErr.Code),
+		}
+	}
+
+	return http.StatusInternalServerError, ErrorResponse{
+		Err:       "internal server error",
+		Code:      internalErrorCode,
+		Retryable: true,
+	}
+}
+
+// Failure doesn't depend on request, so it can be repeated later
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		(status >= http.StatusInternalServerError && status != http.StatusNotImplemented)
+}
+
+// Code for status, e.g. method_not_allowed
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
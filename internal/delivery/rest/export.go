@@ -0,0 +1,264 @@
+package rest
+
+import (
+	akdomain "cloth-mini-app/internal/domain/apikey"
+	domain "cloth-mini-app/internal/domain/export"
+	idomain "cloth-mini-app/internal/domain/item"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type ExportService interface {
+	// Write items matching filter to w in format and return number of exported items
+	Write(ctx context.Context, w io.Writer, format domain.Format, filter idomain.ItemInputData) (int, error)
+	// Create export job processed in background
+	CreateJob(ctx context.Context, format domain.Format, filter idomain.ItemInputData) (int, error)
+	GetJob(ctx context.Context, id int) (domain.Job, error)
+	// Get export jobs, latest first
+	GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error)
+	// Get link for downloading file of done job
+	DownloadURL(ctx context.Context, job domain.Job) (string, error)
+	// Open file of done job for reading
+	Open(ctx context.Context, id int) (io.ReadCloser, domain.Job, error)
+}
+
+type ExportHandler struct {
+	Service ExportService
+}
+
+func NewExportHandler(e *echo.Echo, srv ExportService, auth *AuthMiddleware) {
+	handler := &ExportHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/export")
+	g.Use(middleware.Logger())
+	// editors export items of any status, partners with api keys only published
+	g.GET("/items", handler.Items, auth.Editor(akdomain.ScopeCatalogRead))
+	g.POST("/jobs", handler.CreateJob, auth.Editor(akdomain.ScopeCatalogRead))
+	g.GET("/jobs", handler.Jobs, auth.Editor(akdomain.ScopeCatalogRead))
+	g.GET("/jobs/:id", handler.Job, auth.Editor(akdomain.ScopeCatalogRead))
+	g.GET("/jobs/:id/download", handler.Download, auth.Editor(akdomain.ScopeCatalogRead))
+}
+
+// Filters of GET /item/get without pagination. Bound from query of GET and json body of POST
+type ExportParams struct {
+	Format     string  `query:"format" json:"format" validate:"required,oneof=csv ndjson xlsx"`
+	ID         *uint   `query:"id" json:"id"`
+	BrandId    *uint   `query:"brand_id" json:"brand_id"`
+	Name       *string `query:"name" json:"name"`
+	Sex        *string `query:"sex" json:"sex" validate:"omitempty,oneof=male female unisex"`
+	CategoryId *uint   `query:"category_id" json:"category_id"`
+	MinPrice   *uint   `query:"min_price" json:"min_price"`
+	MaxPrice   *uint   `query:"max_price" json:"max_price"`
+	Discount   *uint   `query:"discount" json:"discount"`
+	// applied only for editors, others export only published items
+	Status *string `query:"status" json:"status" validate:"omitempty,oneof=draft scheduled published archived"`
+}
+
+type ExportJobId struct {
+	ID int `param:"id"`
+}
+
+type ExportJobsQueryParams struct {
+	Offset uint64 `query:"offset"`
+	Limit  uint64 `query:"limit" validate:"lte=100"`
+}
+
+type CreateExportJobResponse struct {
+	JobId int `json:"job_id"`
+}
+
+type ExportJobResponse struct {
+	ID       int    `json:"job_id"`
+	Format   string `json:"format"`
+	Status   string `json:"status"`
+	Items    int    `json:"items"`
+	Size     int64  `json:"size"`
+	Error    string `json:"error"`
+	UserId   *int   `json:"user_id"`
+	APIKeyId *int   `json:"api_key_id"`
+	// set for done job, signed link expires in an hour
+	DownloadURL string    `json:"download_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ExportJobsResponse struct {
+	Count int                 `json:"count"`
+	Jobs  []ExportJobResponse `json:"jobs"`
+}
+
+// GET /export/items Stream items matching filters of GET /item/get in format csv, ndjson or xlsx
+func (e *ExportHandler) Items(c echo.Context) error {
+	params, err := e.params(c)
+	if err != nil {
+		return err
+	}
+
+	format := domain.Format(params.Format)
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, format.ContentType())
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, format.FileName()))
+
+	_, err = e.Service.Write(c.Request().Context(), response, format, e.filter(c, params))
+	if err != nil && !response.Committed {
+		// nothing is sent, so error is written as json
+		response.Header().Del(echo.HeaderContentType)
+		response.Header().Del(echo.HeaderContentDisposition)
+	}
+
+	return err
+}
+
+// POST /export/jobs Create export job with filters in body, file is stored in blob storage
+func (e *ExportHandler) CreateJob(c echo.Context) error {
+	params, err := e.params(c)
+	if err != nil {
+		return err
+	}
+
+	jobId, err := e.Service.CreateJob(c.Request().Context(), domain.Format(params.Format), e.filter(c, params))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, CreateExportJobResponse{
+		JobId: jobId,
+	})
+}
+
+// GET /export/jobs Get export jobs, latest first
+func (e *ExportHandler) Jobs(c echo.Context) error {
+	var params ExportJobsQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	jobs, err := e.Service.GetJobs(c.Request().Context(), params.Limit, params.Offset)
+	if err != nil {
+		return err
+	}
+
+	response := make([]ExportJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		converted, err := e.convertJob(c.Request().Context(), job)
+		if err != nil {
+			return err
+		}
+		response = append(response, converted)
+	}
+
+	return c.JSON(http.StatusOK, ExportJobsResponse{
+		Count: len(response),
+		Jobs:  response,
+	})
+}
+
+// GET /export/jobs/:id Get status of export job with download link when file is ready
+func (e *ExportHandler) Job(c echo.Context) error {
+	var params ExportJobId
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	job, err := e.Service.GetJob(c.Request().Context(), params.ID)
+	if err != nil {
+		return err
+	}
+
+	response, err := e.convertJob(c.Request().Context(), job)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GET /export/jobs/:id/download Download file of done job through app, used if storage can't sign links
+func (e *ExportHandler) Download(c echo.Context) error {
+	var params ExportJobId
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	file, job, err := e.Service.Open(c.Request().Context(), params.ID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, job.Format.FileName()))
+
+	return c.Stream(http.StatusOK, job.Format.ContentType(), file)
+}
+
+func (e *ExportHandler) params(c echo.Context) (ExportParams, error) {
+	var params ExportParams
+	err := bind(c, &params)
+	if err != nil {
+		return ExportParams{}, err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return ExportParams{}, err
+	}
+
+	return params, nil
+}
+
+func (e *ExportHandler) filter(c echo.Context, params ExportParams) idomain.ItemInputData {
+	return idomain.ItemInputData{
+		ID:         params.ID,
+		BrandId:    params.BrandId,
+		Name:       params.Name,
+		Sex:        parseSex(params.Sex),
+		CategoryId: params.CategoryId,
+		MinPrice:   params.MinPrice,
+		MaxPrice:   params.MaxPrice,
+		Discount:   params.Discount,
+		Statuses:   visibleStatuses(c, params.Status),
+	}
+}
+
+func (e *ExportHandler) convertJob(ctx context.Context, job domain.Job) (ExportJobResponse, error) {
+	response := ExportJobResponse{
+		ID:        job.ID,
+		Format:    string(job.Format),
+		Status:    string(job.Status),
+		Items:     job.Items,
+		Size:      job.Size,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+	if job.Actor.UserId != 0 {
+		response.UserId = &job.Actor.UserId
+	}
+	if job.Actor.APIKeyId != 0 {
+		response.APIKeyId = &job.Actor.APIKeyId
+	}
+
+	if job.Status == domain.StatusDone {
+		url, err := e.Service.DownloadURL(ctx, job)
+		if err != nil {
+			return ExportJobResponse{}, err
+		}
+		response.DownloadURL = url
+	}
+
+	return response, nil
+}
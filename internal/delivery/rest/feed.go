@@ -0,0 +1,69 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/feed"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const headerIfNoneMatch = "If-None-Match"
+
+type FeedService interface {
+	// Get generated feed by file name
+	GetFeed(ctx context.Context, name string) (domain.Feed, error)
+	// Open file of generated feed for reading, reader must be closed
+	Open(ctx context.Context, feed domain.Feed) (io.ReadCloser, error)
+}
+
+type FeedHandler struct {
+	Service FeedService
+}
+
+func NewFeedHandler(e *echo.Echo, srv FeedService) {
+	handler := &FeedHandler{
+		Service: srv,
+	}
+
+	// feeds are fetched by marketplaces, so they are public
+	e.GET("/feed/:name", handler.Feed, middleware.Logger())
+}
+
+type FeedName struct {
+	Name string `param:"name"`
+}
+
+// GET /feed/:name Get product feed yandex.yml, google.xml or google.tsv. Not modified if If-None-Match has its ETag
+func (f *FeedHandler) Feed(c echo.Context) error {
+	var params FeedName
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	feed, err := f.Service.GetFeed(c.Request().Context(), params.Name)
+	if err != nil {
+		return err
+	}
+
+	etag := strconv.Quote(feed.ETag)
+	c.Response().Header().Set(headerETag, etag)
+	c.Response().Header().Set(echo.HeaderLastModified, feed.GeneratedAt.UTC().Format(http.TimeFormat))
+	if c.Request().Header.Get(headerIfNoneMatch) == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	file, err := f.Service.Open(c.Request().Context(), feed)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(feed.Size, 10))
+
+	return c.Stream(http.StatusOK, domain.ContentType(feed.Name), file)
+}
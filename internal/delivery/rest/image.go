@@ -1,23 +1,26 @@
 package rest
 
 import (
+	akdomain "cloth-mini-app/internal/domain/apikey"
+	apperr "cloth-mini-app/internal/domain/apperror"
+	domain "cloth-mini-app/internal/domain/image"
 	"cloth-mini-app/internal/dto"
 	"context"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/gabriel-vasile/mimetype"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
 var (
-	errGetFile   = fmt.Errorf("failed get file")
-	errOpenFile  = fmt.Errorf("failed open file")
-	errReadFile  = fmt.Errorf("failed read file")
-	errImageType = fmt.Errorf("incorrect image format. allowed image formats: .jpg/.png")
+	errParseForm    = apperr.Invalid("invalid_form", "form can't be parsed")
+	errItemIdParam  = apperr.FieldInvalid("invalid_item_id", "itemId", "itemId is incorrect or not provided")
+	errImageIdParam = apperr.FieldInvalid("invalid_image_id", "image_id", "image_id not provided")
 )
 
 type ImageService interface {
@@ -29,13 +32,23 @@ type ImageService interface {
 	Delete(ctx context.Context, imageId string) error
 	// Create temp image
 	CreateTempImage(ctx context.Context, file []byte, uuid string) (string, error)
+	// Register temp image and get presigned url for direct upload
+	CreateUploadURL(ctx context.Context, contentType string, size int64) (domain.UploadURL, error)
+	// Validate image uploaded by presigned url
+	ConfirmUpload(ctx context.Context, imageId string) error
+	// Get archive entries for all images of item, publishedOnly hides unpublished items
+	ItemArchiveEntries(ctx context.Context, itemId int, publishedOnly bool) ([]domain.ArchiveEntry, error)
+	// Get archive entries for provided images of items, publishedOnly hides unpublished items
+	ImagesArchiveEntries(ctx context.Context, imageIds []string, publishedOnly bool) ([]domain.ArchiveEntry, error)
+	// Write zip archive with entries
+	WriteArchive(ctx context.Context, w io.Writer, entries []domain.ArchiveEntry) error
 }
 
 type ImageHandler struct {
 	Service ImageService
 }
 
-func NewImageHandler(e *echo.Echo, srv ImageService) {
+func NewImageHandler(e *echo.Echo, srv ImageService, auth *AuthMiddleware) {
 	handler := &ImageHandler{
 		Service: srv,
 	}
@@ -43,10 +56,16 @@ func NewImageHandler(e *echo.Echo, srv ImageService) {
 	g := e.Group("/image")
 	g.Use(middleware.Logger())
 
-	g.POST("/create", handler.CreateItemImage)
-	g.POST("/temp", handler.CreateTempImage)
+	g.POST("/create", handler.CreateItemImage, auth.Editor(akdomain.ScopeImagesWrite))
+	g.POST("/temp", handler.CreateTempImage, auth.Editor(akdomain.ScopeImagesWrite))
+	g.POST("/upload-url", handler.CreateUploadURL, auth.Editor(akdomain.ScopeImagesWrite))
+	g.POST("/upload-url/:image_id/confirm", handler.ConfirmUpload, auth.Editor(akdomain.ScopeImagesWrite))
 	g.GET("/get/:image_id", handler.Image)
-	g.DELETE("/delete", handler.Delete)
+	g.DELETE("/delete", handler.Delete, auth.Editor(akdomain.ScopeImagesWrite))
+	// editors download images of any item, others only of published
+	g.GET("/archive", handler.Archive, auth.OptionalUser())
+
+	e.GET("/item/:id/images.zip", handler.ItemArchive, middleware.Logger(), auth.OptionalUser())
 }
 
 type CreateImageResponse struct {
@@ -59,26 +78,22 @@ func (i *ImageHandler) CreateItemImage(c echo.Context) error {
 	request := c.Request()
 	err := request.ParseForm()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "parse query params"})
+		return errParseForm
 	}
 
 	itemId, err := strconv.Atoi(request.Form.Get("itemId"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "itemId is incorrect or not provided"})
+		return errItemIdParam
 	}
 
 	imageBytes, err := i.file(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "failed get file",
-		})
+		return err
 	}
 
 	fileId, err := i.Service.CreateItemImage(c.Request().Context(), itemId, imageBytes)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "failet store image. Maybe reached max image per item",
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, CreateImageResponse{
@@ -93,16 +108,14 @@ type ImageId struct {
 // Return image by image_id in query param
 func (i *ImageHandler) Image(c echo.Context) error {
 	var imageId ImageId
-	err := c.Bind(&imageId)
+	err := bind(c, &imageId)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "binding params"})
+		return err
 	}
 
 	file, err := i.Service.GetImage(c.Request().Context(), imageId.Id)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "getting image from storage",
-		})
+		return err
 	}
 
 	response := c.Response()
@@ -119,17 +132,17 @@ func (i *ImageHandler) Delete(c echo.Context) error {
 	request := c.Request()
 	err := request.ParseForm()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "parse query params"})
+		return errParseForm
 	}
 
 	imageId := request.Form.Get("image_id")
 	if imageId == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "image_id not provided"})
+		return errImageIdParam
 	}
 
 	err = i.Service.Delete(c.Request().Context(), imageId)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "failed deleting image"})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, SuccessResponse{
@@ -145,16 +158,12 @@ type UUID struct {
 func (i *ImageHandler) CreateTempImage(c echo.Context) error {
 	imageBytes, err := i.file(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: err.Error(),
-		})
+		return err
 	}
 
 	fileId, err := i.Service.CreateTempImage(c.Request().Context(), imageBytes, c.FormValue("uuid"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "failet store image. Maybe reached max image per item",
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, CreateImageResponse{
@@ -162,28 +171,152 @@ func (i *ImageHandler) CreateTempImage(c echo.Context) error {
 	})
 }
 
+type UploadURLRequest struct {
+	ContentType string `json:"content_type" validate:"required"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+}
+
+type UploadURLResponse struct {
+	FileId    string            `json:"file_id"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// POST /image/upload-url Register temp image and return presigned url.
+// Client uploads file directly to storage with PUT request and provided headers,
+// then confirms upload with POST /image/upload-url/:image_id/confirm
+func (i *ImageHandler) CreateUploadURL(c echo.Context) error {
+	var uploadRequest UploadURLRequest
+	err := bind(c, &uploadRequest)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(uploadRequest); err != nil {
+		return err
+	}
+
+	upload, err := i.Service.CreateUploadURL(c.Request().Context(), uploadRequest.ContentType, uploadRequest.Size)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UploadURLResponse{
+		FileId:    upload.ObjectId,
+		URL:       upload.URL,
+		Method:    http.MethodPut,
+		Headers:   upload.Headers,
+		ExpiresAt: upload.ExpiresAt,
+	})
+}
+
+// POST /image/upload-url/:image_id/confirm Validate uploaded file.
+// Confirmed file id can be passed to /item/create as temp image
+func (i *ImageHandler) ConfirmUpload(c echo.Context) error {
+	var imageId ImageId
+	err := bind(c, &imageId)
+	if err != nil {
+		return err
+	}
+
+	err = i.Service.ConfirmUpload(c.Request().Context(), imageId.Id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, CreateImageResponse{
+		FileId: imageId.Id,
+	})
+}
+
+// GET /item/:id/images.zip Download zip archive with all images of item. Item must be published unless user is editor
+func (i *ImageHandler) ItemArchive(c echo.Context) error {
+	var itemId ItemId
+	err := bind(c, &itemId)
+	if err != nil {
+		return err
+	}
+
+	entries, err := i.Service.ItemArchiveEntries(c.Request().Context(), itemId.Id, !isEditor(c))
+	if err != nil {
+		return err
+	}
+
+	return i.writeArchive(c, fmt.Sprintf("item_%d_images.zip", itemId.Id), entries)
+}
+
+type ImageIds struct {
+	Ids []string `query:"image_id"`
+}
+
+// GET /image/archive?image_id=...&image_id=... Download zip archive with provided images of published items, any items for editors
+func (i *ImageHandler) Archive(c echo.Context) error {
+	var imageIds ImageIds
+	err := bind(c, &imageIds)
+	if err != nil {
+		return err
+	}
+
+	entries, err := i.Service.ImagesArchiveEntries(c.Request().Context(), imageIds.Ids, !isEditor(c))
+	if err != nil {
+		return err
+	}
+
+	return i.writeArchive(c, "images.zip", entries)
+}
+
+// stream archive to response
+func (i *ImageHandler) writeArchive(c echo.Context, fileName string, entries []domain.ArchiveEntry) error {
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "application/zip")
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	err := i.Service.WriteArchive(c.Request().Context(), response, entries)
+	if err != nil && !response.Committed {
+		// nothing is sent, so error is written as json
+		response.Header().Del(echo.HeaderContentType)
+		response.Header().Del(echo.HeaderContentDisposition)
+	}
+
+	return err
+}
+
 // read image file
 func (i *ImageHandler) file(c echo.Context) ([]byte, error) {
-	file, err := c.FormFile("image")
+	file, err := formFile(c, "image")
 	if err != nil {
-		return nil, errGetFile
+		return nil, err
 	}
 
 	image, err := file.Open()
 	if err != nil {
-		return nil, errOpenFile
+		return nil, domain.ErrNoFile
 	}
 	defer image.Close()
 
 	imageBytes, err := io.ReadAll(image)
 	if err != nil {
-		return nil, errReadFile
+		return nil, domain.ErrNoFile
 	}
 
-	mtype := mimetype.Detect(imageBytes)
-	if !(mtype.Is("image/jpeg") || mtype.Is("image/png")) {
-		return nil, errImageType
+	if err = domain.CheckImageType(imageBytes); err != nil {
+		return nil, err
 	}
 
 	return imageBytes, nil
 }
+
+// Get file from multipart form. Body exceeding limit is reported as is, not as missing file
+func formFile(c echo.Context, name string) (*multipart.FileHeader, error) {
+	file, err := c.FormFile(name)
+	if err != nil {
+		if isBodyTooLarge(err) {
+			return nil, errBodyTooLarge
+		}
+		return nil, domain.ErrNoFile
+	}
+
+	return file, nil
+}
@@ -0,0 +1,245 @@
+package rest
+
+import (
+	akdomain "cloth-mini-app/internal/domain/apikey"
+	apperr "cloth-mini-app/internal/domain/apperror"
+	imdomain "cloth-mini-app/internal/domain/image"
+	domain "cloth-mini-app/internal/domain/importjob"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type ImportService interface {
+	// Parse file and create import job, rows are processed in background
+	CreateJob(ctx context.Context, upload domain.Upload) (int, error)
+	GetJob(ctx context.Context, id int) (domain.Job, error)
+	// Get import jobs, latest first
+	GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error)
+	// Import valid rows of checked dry run
+	Commit(ctx context.Context, id int) error
+}
+
+type ImportHandler struct {
+	Service ImportService
+}
+
+func NewImportHandler(e *echo.Echo, srv ImportService, auth *AuthMiddleware) {
+	handler := &ImportHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/import")
+	g.Use(middleware.Logger())
+
+	g.POST("/upload", handler.Upload, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/jobs", handler.Jobs, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/jobs/:id", handler.Job, auth.Editor(akdomain.ScopeItemsWrite))
+	g.POST("/jobs/:id/commit", handler.Commit, auth.Editor(akdomain.ScopeItemsWrite))
+}
+
+type ImportJobId struct {
+	ID int `param:"id"`
+}
+
+type ImportJobsQueryParams struct {
+	Offset uint64 `query:"offset"`
+	Limit  uint64 `query:"limit" validate:"lte=100"`
+}
+
+type CreateImportJobResponse struct {
+	JobId int `json:"job_id"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ImportJobResponse struct {
+	ID           int              `json:"job_id"`
+	FileName     string           `json:"file_name"`
+	Status       string           `json:"status"`
+	DryRun       bool             `json:"dry_run"`
+	CreateBrands bool             `json:"create_brands"`
+	Total        int              `json:"total"`
+	Processed    int              `json:"processed"`
+	Valid        int              `json:"valid"`
+	Created      int              `json:"created"`
+	Errors       []ImportRowError `json:"errors"`
+	NewBrands    []string         `json:"new_brands"`
+	Error        string           `json:"error"`
+	UserId       *int             `json:"user_id"`
+	APIKeyId     *int             `json:"api_key_id"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+type ImportJobsResponse struct {
+	Count int                 `json:"count"`
+	Jobs  []ImportJobResponse `json:"jobs"`
+}
+
+// POST /import/upload Upload csv or xlsx file with items.
+// Form fields: file, mapping (json object item field => column name), dry_run (true by default), create_brands
+func (i *ImportHandler) Upload(c echo.Context) error {
+	fileHeader, err := formFile(c, "file")
+	if err != nil {
+		return err
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return imdomain.ErrNoFile
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return imdomain.ErrNoFile
+	}
+
+	upload := domain.Upload{
+		FileName: fileHeader.Filename,
+		File:     content,
+		DryRun:   true,
+	}
+
+	if mapping := c.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &upload.Mapping); err != nil {
+			return domain.ErrMapping
+		}
+	}
+
+	var verr apperr.FieldErrors
+	for field, value := range map[string]*bool{"dry_run": &upload.DryRun, "create_brands": &upload.CreateBrands} {
+		if raw := c.FormValue(field); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				verr.Add(field, "must be boolean")
+				continue
+			}
+			*value = parsed
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	jobId, err := i.Service.CreateJob(c.Request().Context(), upload)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, CreateImportJobResponse{
+		JobId: jobId,
+	})
+}
+
+// GET /import/jobs Get import jobs without row errors, latest first
+func (i *ImportHandler) Jobs(c echo.Context) error {
+	var params ImportJobsQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	jobs, err := i.Service.GetJobs(c.Request().Context(), params.Limit, params.Offset)
+	if err != nil {
+		return err
+	}
+
+	response := make([]ImportJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		job.Errors = nil
+		response = append(response, convertImportJobFromDomain(job))
+	}
+
+	return c.JSON(http.StatusOK, ImportJobsResponse{
+		Count: len(response),
+		Jobs:  response,
+	})
+}
+
+// GET /import/jobs/:id Get progress of import job and report of invalid rows
+func (i *ImportHandler) Job(c echo.Context) error {
+	var params ImportJobId
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	job, err := i.Service.GetJob(c.Request().Context(), params.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, convertImportJobFromDomain(job))
+}
+
+// POST /import/jobs/:id/commit Import valid rows of checked dry run
+func (i *ImportHandler) Commit(c echo.Context) error {
+	var params ImportJobId
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	err = i.Service.Commit(c.Request().Context(), params.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "commit",
+	})
+}
+
+func convertImportJobFromDomain(job domain.Job) ImportJobResponse {
+	response := ImportJobResponse{
+		ID:           job.ID,
+		FileName:     job.FileName,
+		Status:       string(job.Status),
+		DryRun:       job.DryRun,
+		CreateBrands: job.CreateBrands,
+		Total:        job.Total,
+		Processed:    job.Processed,
+		Valid:        job.Valid,
+		Created:      job.Created,
+		Errors:       make([]ImportRowError, 0, len(job.Errors)),
+		NewBrands:    job.NewBrands,
+		Error:        job.Error,
+		CreatedAt:    job.CreatedAt,
+		UpdatedAt:    job.UpdatedAt,
+	}
+	for _, rowErr := range job.Errors {
+		response.Errors = append(response.Errors, ImportRowError{
+			Row:     rowErr.Row,
+			Field:   rowErr.Field,
+			Message: rowErr.Message,
+		})
+	}
+	if response.NewBrands == nil {
+		response.NewBrands = []string{}
+	}
+	if job.Actor.UserId != 0 {
+		response.UserId = &job.Actor.UserId
+	}
+	if job.Actor.APIKeyId != 0 {
+		response.APIKeyId = &job.Actor.APIKeyId
+	}
+
+	return response
+}
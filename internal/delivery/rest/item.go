@@ -1,18 +1,26 @@
 package rest
 
 import (
+	akdomain "cloth-mini-app/internal/domain/apikey"
+	apperr "cloth-mini-app/internal/domain/apperror"
+	imdomain "cloth-mini-app/internal/domain/image"
 	domain "cloth-mini-app/internal/domain/item"
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
 	"net/http"
+	"strconv"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+var errIfMatch = apperr.Invalid("invalid_if_match", "If-Match must contain ETag of item")
+
 type ItemService interface {
 	// Fetching items
 	GetItems(ctx context.Context, params domain.ItemInputData) ([]domain.ItemAPI, error)
@@ -22,51 +30,94 @@ type ItemService interface {
 	Update(ctx context.Context, item domain.ItemUpdate) error
 	// Create item
 	Create(ctx context.Context, item domain.ItemCreate) error
-	// Delete item
+	// Move item to trash
 	Delete(ctx context.Context, id int) error
+	// Get items in trash, recently deleted first
+	GetDeletedItems(ctx context.Context, limit, offset uint64) ([]domain.ItemAPI, error)
+	// Restore item from trash
+	Restore(ctx context.Context, id int) error
+	// Change publication status of item
+	ChangeStatus(ctx context.Context, status domain.ItemStatusUpdate) error
+	// Get saved versions of item, latest first
+	GetRevisions(ctx context.Context, itemId int, limit, offset uint64) ([]domain.Revision, error)
+	GetRevision(ctx context.Context, itemId, version int) (domain.Revision, error)
+	// Get fields changed between two versions of item
+	DiffRevisions(ctx context.Context, itemId, from, to int) ([]domain.FieldChange, error)
+	// Bring item back to version
+	RollbackItem(ctx context.Context, itemId, version int) error
+	// Get changes of item price, latest first
+	GetPriceHistory(ctx context.Context, itemId int, limit, offset uint64) ([]domain.PriceChange, error)
+}
+
+type AnalyticsRecorder interface {
+	// Record view of item page
+	RecordView(itemId int)
+	// Record catalog search with number of found items
+	RecordSearch(query string, results int)
 }
 
 type ItemHandler struct {
-	Service ItemService
+	Service   ItemService
+	Analytics AnalyticsRecorder
 }
 
 // Create item handler object
-func NewItemHandler(e *echo.Echo, srv ItemService) {
+func NewItemHandler(e *echo.Echo, srv ItemService, analytics AnalyticsRecorder, auth *AuthMiddleware) {
 	handler := &ItemHandler{
-		Service: srv,
+		Service:   srv,
+		Analytics: analytics,
 	}
 
 	g := e.Group("/item")
 	g.Use(middleware.Logger())
-	g.GET("/get", handler.Items)
-	g.GET("/get/:id", handler.ItemById)
-	g.POST("/update/:id", handler.Update)
-	g.POST("/create", handler.Create)
-	g.DELETE("/delete/:id", handler.Delete)
+	// editors see items of any status, others only published
+	g.GET("/get", handler.Items, auth.OptionalUser(), auth.APIKey(akdomain.ScopeCatalogRead))
+	g.GET("/get/:id", handler.ItemById, auth.OptionalUser(), auth.APIKey(akdomain.ScopeCatalogRead))
+	g.POST("/update/:id", handler.Update, auth.Editor(akdomain.ScopeItemsWrite))
+	g.POST("/create", handler.Create, auth.Editor(akdomain.ScopeItemsWrite))
+	g.DELETE("/delete/:id", handler.Delete, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/trash", handler.Trash, auth.Editor(akdomain.ScopeItemsWrite))
+	g.POST("/restore/:id", handler.Restore, auth.Editor(akdomain.ScopeItemsWrite))
+	g.POST("/status/:id", handler.ChangeStatus, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/:id/revisions", handler.Revisions, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/:id/revisions/diff", handler.RevisionsDiff, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/:id/revisions/:version", handler.Revision, auth.Editor(akdomain.ScopeItemsWrite))
+	g.POST("/:id/revisions/:version/rollback", handler.Rollback, auth.Editor(akdomain.ScopeItemsWrite))
+	g.GET("/:id/prices", handler.PriceHistory, auth.Editor(akdomain.ScopeItemsWrite))
 }
 
 // GET /item/get Fetch items by query params
 func (i *ItemHandler) Items(c echo.Context) error {
 	var itemInput ItemQueryParams
-	err := c.Bind(&itemInput)
+	err := bind(c, &itemInput)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "binding params"})
+		return err
+	}
+
+	if err := validateRequest(itemInput); err != nil {
+		return err
 	}
 
 	items, err := i.Service.GetItems(c.Request().Context(), domain.ItemInputData{
 		ID:         itemInput.ID,
 		BrandId:    itemInput.BrandId,
 		Name:       itemInput.Name,
-		Sex:        itemInput.Sex,
+		Sex:        parseSex(itemInput.Sex),
 		CategoryId: itemInput.CategoryId,
 		MinPrice:   itemInput.MinPrice,
 		MaxPrice:   itemInput.MaxPrice,
 		Discount:   itemInput.Discount,
 		Offset:     itemInput.Offset,
 		Limit:      itemInput.Limit,
+		Statuses:   visibleStatuses(c, itemInput.Status),
 	})
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "getting items"})
+		return err
+	}
+
+	// only first page of visitor search is counted, editors don't affect stats
+	if itemInput.Name != nil && (itemInput.Offset == nil || *itemInput.Offset == 0) && !isEditor(c) {
+		i.Analytics.RecordSearch(*itemInput.Name, len(items))
 	}
 
 	return c.JSON(http.StatusOK, ItemsResponse{
@@ -84,40 +135,84 @@ func (i *ItemHandler) convertItemAPIFromDomain(domainItems []domain.ItemAPI) []I
 			BrandName:    item.BrandName,
 			Name:         item.Name,
 			Description:  item.Description,
-			Sex:          item.Sex,
+			Sex:          item.Sex.String(),
 			CategoryId:   item.CategoryId,
-			CategoryType: item.CategoryType,
+			CategoryType: item.CategoryType.String(),
 			CategoryName: item.CategoryName,
 			Price:        item.Price,
 			Discount:     item.Discount,
 			OuterLink:    item.OuterLink,
 			CreatedAt:    item.CreatedAt,
 			UpdatedAt:    item.UpdatedAt,
+			Version:      item.Version,
+			Status:       item.Status.String(),
+			PublishAt:    item.PublishAt,
+			UnpublishAt:  item.UnpublishAt,
+			Images:       convertImagesFromDomain(item.Images),
+			Attributes:   item.Attributes,
 		})
 	}
 
 	return items
 }
 
+func convertImagesFromDomain(domainImages []imdomain.Image) []Image {
+	images := make([]Image, 0, len(domainImages))
+	for _, domainImage := range domainImages {
+		image := Image{
+			ImageId: domainImage.ObjectId,
+		}
+		if domainImage.Meta != nil {
+			image.BlurHash = domainImage.Meta.BlurHash
+			image.DominantColor = domainImage.Meta.DominantColor
+			image.Width = domainImage.Meta.Width
+			image.Height = domainImage.Meta.Height
+		}
+		images = append(images, image)
+	}
+
+	return images
+}
+
 // POST /item/update/:id Update item with provided id (required) and updating params
 func (i *ItemHandler) Update(c echo.Context) error {
 	var item ItemUpdate
-	err := c.Bind(&item)
+	err := bind(c, &item)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "binding params"})
+		return err
+	}
+
+	if err := validateRequest(item); err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+	if version == 0 && item.Version != nil {
+		version = *item.Version
 	}
 
-	i.Service.Update(c.Request().Context(), domain.ItemUpdate{
+	err = i.Service.Update(c.Request().Context(), domain.ItemUpdate{
 		ID:          item.ID,
 		BrandId:     item.BrandId,
 		Name:        item.Name,
 		Description: item.Description,
-		Sex:         item.Sex,
+		Sex:         parseSex(item.Sex),
 		CategoryId:  item.CategoryId,
 		Price:       item.Price,
 		Discount:    item.Discount,
 		OuterLink:   item.OuterLink,
+		Attributes:  item.Attributes,
+		Version:     version,
 	})
+	if errors.Is(err, domain.ErrVersionConflict) {
+		return i.versionConflict(c, item.ID, err)
+	}
+	if err != nil {
+		return err
+	}
 
 	return c.JSON(http.StatusOK, SuccessResponse{
 		Status:    true,
@@ -125,69 +220,172 @@ func (i *ItemHandler) Update(c echo.Context) error {
 	})
 }
 
+// Respond with current item, so client can merge its changes. Conditional request
+// with If-Match fails with 412, request with version field - with 409
+func (i *ItemHandler) versionConflict(c echo.Context, id int, conflict error) error {
+	item, err := i.Service.GetItemById(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusConflict
+	if c.Request().Header.Get(headerIfMatch) != "" {
+		status = http.StatusPreconditionFailed
+	}
+
+	c.Response().Header().Set(headerETag, itemETag(item.Version))
+
+	return c.JSON(status, VersionConflictResponse{
+		ErrorResponse: ErrorResponse{
+			Err:       conflict.Error(),
+			Code:      domain.ErrVersionConflict.Code,
+			RequestId: c.Response().Header().Get(echo.HeaderXRequestID),
+		},
+		Current: convertItemByIdFromDomain(item),
+	})
+}
+
+// Strong ETag of item version
+func itemETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Get item version from If-Match header, 0 if header isn't set
+func ifMatchVersion(c echo.Context) (int, error) {
+	ifMatch := c.Request().Header.Get(headerIfMatch)
+	if ifMatch == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, errIfMatch
+	}
+	parsed, err := strconv.Atoi(version)
+	if err != nil || parsed <= 0 {
+		return 0, errIfMatch
+	}
+
+	return parsed, nil
+}
+
+// Convert validated sex name, nil if sex isn't provided
+func parseSex(name *string) *domain.Sex {
+	if name == nil {
+		return nil
+	}
+
+	sex, _ := domain.ParseSex(*name)
+
+	return &sex
+}
+
+// Public catalog shows only published items, editors can filter items by any status
+func visibleStatuses(c echo.Context, status *string) []domain.Status {
+	if !isEditor(c) {
+		return []domain.Status{domain.StatusPublished}
+	}
+
+	if status == nil {
+		return nil
+	}
+
+	parsed, _ := domain.ParseStatus(*status)
+
+	return []domain.Status{parsed}
+}
+
 type ItemId struct {
 	Id int `param:"id"`
 }
 
 func (i *ItemHandler) ItemById(c echo.Context) error {
 	var itemId ItemId
-	err := c.Bind(&itemId)
+	err := bind(c, &itemId)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "binding params"})
+		return err
 	}
 
 	item, err := i.Service.GetItemById(c.Request().Context(), itemId.Id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "no records with provided id"})
-		}
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "failed getting item"})
+		return err
+	}
+	if item.Status != domain.StatusPublished && !isEditor(c) {
+		return domain.ErrItemNotFound
+	}
+	if !isEditor(c) {
+		i.Analytics.RecordView(int(item.ID))
 	}
 
-	return c.JSON(http.StatusOK, ItemByIdResponse{
+	// version is sent back in If-Match on update
+	c.Response().Header().Set(headerETag, itemETag(item.Version))
+
+	return c.JSON(http.StatusOK, convertItemByIdFromDomain(item))
+}
+
+func convertItemByIdFromDomain(item domain.ItemAPI) ItemByIdResponse {
+	return ItemByIdResponse{
 		ID:           item.ID,
 		BrandId:      item.BrandId,
 		BrandName:    item.BrandName,
 		Name:         item.Name,
 		Description:  item.Description,
-		Sex:          item.Sex,
+		Sex:          item.Sex.String(),
 		CategoryId:   item.CategoryId,
-		CategoryType: item.CategoryType,
+		CategoryType: item.CategoryType.String(),
 		CategoryName: item.CategoryName,
 		Price:        item.Price,
 		Discount:     item.Discount,
 		OuterLink:    item.OuterLink,
 		CreatedAt:    item.CreatedAt,
 		UpdatedAt:    item.UpdatedAt,
+		Version:      item.Version,
+		Status:       item.Status.String(),
+		PublishAt:    item.PublishAt,
+		UnpublishAt:  item.UnpublishAt,
 		ImageId:      item.ImageId,
-	})
+		Images:       convertImagesFromDomain(item.Images),
+		Attributes:   item.Attributes,
+	}
 }
 
 func (i *ItemHandler) Create(c echo.Context) error {
 	var item ItemCreate
-	err := c.Bind(&item)
+	err := bind(c, &item)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "binding params"})
+		return err
 	}
 
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	if err := validate.Struct(item); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: fmt.Sprintf("validation params : %s", err)})
+	if err := validateRequest(item); err != nil {
+		return err
+	}
+
+	sex, _ := domain.ParseSex(item.Sex)
+
+	var status domain.Status
+	if item.Status != nil {
+		status, _ = domain.ParseStatus(*item.Status)
 	}
 
 	err = i.Service.Create(c.Request().Context(), domain.ItemCreate{
 		BrandId:     item.BrandId,
 		Name:        item.Name,
 		Description: item.Description,
-		Sex:         item.Sex,
+		Sex:         sex,
 		CategoryId:  item.CategoryId,
 		Price:       item.Price,
 		Discount:    item.Discount,
 		OuterLink:   item.OuterLink,
 		Images:      item.Images,
+		Attributes:  item.Attributes,
+		Publication: domain.Publication{
+			Status:      status,
+			PublishAt:   item.PublishAt,
+			UnpublishAt: item.UnpublishAt,
+		},
 	})
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "failed creating item"})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, SuccessResponse{
@@ -198,16 +396,14 @@ func (i *ItemHandler) Create(c echo.Context) error {
 
 func (i *ItemHandler) Delete(c echo.Context) error {
 	var itemId ItemId
-	err := c.Bind(&itemId)
+	err := bind(c, &itemId)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Err: "binding params"})
+		return err
 	}
 
 	err = i.Service.Delete(c.Request().Context(), itemId.Id)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Err: "failed deleting item",
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, SuccessResponse{
@@ -215,3 +411,85 @@ func (i *ItemHandler) Delete(c echo.Context) error {
 		Operation: "delete",
 	})
 }
+
+// GET /item/trash Get deleted items. They can be restored until they are purged
+func (i *ItemHandler) Trash(c echo.Context) error {
+	var params TrashQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	items, err := i.Service.GetDeletedItems(c.Request().Context(), params.Limit, params.Offset)
+	if err != nil {
+		return err
+	}
+
+	trashItems := make([]TrashItemResponse, 0, len(items))
+	for idx, item := range i.convertItemAPIFromDomain(items) {
+		trashItems = append(trashItems, TrashItemResponse{
+			ItemResponse: item,
+			DeletedAt:    items[idx].DeletedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, TrashResponse{
+		Count: len(trashItems),
+		Items: trashItems,
+	})
+}
+
+// POST /item/restore/:id Restore item from trash
+func (i *ItemHandler) Restore(c echo.Context) error {
+	var itemId ItemId
+	err := bind(c, &itemId)
+	if err != nil {
+		return err
+	}
+
+	err = i.Service.Restore(c.Request().Context(), itemId.Id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "restore",
+	})
+}
+
+// POST /item/status/:id Change publication status of item
+func (i *ItemHandler) ChangeStatus(c echo.Context) error {
+	var status ItemStatusUpdate
+	err := bind(c, &status)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(status); err != nil {
+		return err
+	}
+
+	parsed, _ := domain.ParseStatus(status.Status)
+
+	err = i.Service.ChangeStatus(c.Request().Context(), domain.ItemStatusUpdate{
+		ID: status.ID,
+		Publication: domain.Publication{
+			Status:      parsed,
+			PublishAt:   status.PublishAt,
+			UnpublishAt: status.UnpublishAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "status",
+	})
+}
@@ -1,16 +1,25 @@
 package rest
 
+import "time"
+
 type ItemQueryParams struct {
 	ID         *uint   `query:"id"`
 	BrandId    *uint   `query:"brand_id"`
 	Name       *string `query:"name"`
-	Sex        *int    `query:"sex"`
+	Sex        *string `query:"sex" validate:"omitempty,oneof=male female unisex"`
 	CategoryId *uint   `query:"category_id"`
 	MinPrice   *uint   `query:"min_price"`
 	MaxPrice   *uint   `query:"max_price"`
 	Discount   *uint   `query:"discount"`
 	Offset     *uint   `query:"offset"`
 	Limit      *uint   `query:"limit"`
+	// applied only for editors, others see only published items
+	Status *string `query:"status" validate:"omitempty,oneof=draft scheduled published archived"`
+}
+
+type TrashQueryParams struct {
+	Offset uint64 `query:"offset"`
+	Limit  uint64 `query:"limit" validate:"lte=100"`
 }
 
 type ItemUpdate struct {
@@ -18,21 +27,37 @@ type ItemUpdate struct {
 	BrandId     *int    `json:"brand_id"`
 	Name        *string `json:"name"`
 	Description *string `json:"description"`
-	Sex         *int    `json:"sex"`
+	Sex         *string `json:"sex" validate:"omitempty,oneof=male female unisex"`
 	CategoryId  *int    `json:"category_id"`
 	Price       *uint   `json:"price"`
 	Discount    *uint   `json:"discount"`
-	OuterLink   *string `json:"outerlink"`
+	OuterLink   *string `json:"outerlink" validate:"omitempty,http_url"`
+	// values of category attributes, replaces stored attributes
+	Attributes map[string]any `json:"attributes"`
+	// version of item from GET /item/get/:id, If-Match header takes precedence
+	Version *int `json:"version"`
 }
 
 type ItemCreate struct {
-	BrandId     int      `json:"brand_id" validate:"required"`
-	Name        string   `json:"name" validate:"required"`
-	Description string   `json:"description" validate:"required"`
-	Sex         int      `json:"sex" validate:"required"`
-	CategoryId  int      `json:"category_id" validate:"required"`
-	Price       uint     `json:"price" validate:"required"`
-	Discount    uint     `json:"discount"`
-	OuterLink   string   `json:"outer_link" validate:"required"`
-	Images      []string `json:"temp_images" validate:"max=4"`
+	BrandId     int            `json:"brand_id" validate:"required"`
+	Name        string         `json:"name" validate:"required"`
+	Description string         `json:"description" validate:"required"`
+	Sex         string         `json:"sex" validate:"required,oneof=male female unisex"`
+	CategoryId  int            `json:"category_id" validate:"required"`
+	Price       uint           `json:"price" validate:"required"`
+	Discount    uint           `json:"discount"`
+	OuterLink   string         `json:"outer_link" validate:"required,http_url"`
+	Images      []string       `json:"temp_images" validate:"max=4"`
+	Attributes  map[string]any `json:"attributes"`
+	// draft if status isn't set
+	Status      *string    `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+type ItemStatusUpdate struct {
+	ID          int        `param:"id"`
+	Status      string     `json:"status" validate:"required,oneof=draft scheduled published archived"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
 }
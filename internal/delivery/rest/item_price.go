@@ -0,0 +1,78 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/item"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PriceHistoryQueryParams struct {
+	ID     int    `param:"id"`
+	Offset uint64 `query:"offset"`
+	Limit  uint64 `query:"limit" validate:"lte=100"`
+}
+
+type PriceChangeResponse struct {
+	Price        int       `json:"price"`
+	Discount     int       `json:"discount"`
+	PrevPrice    int       `json:"prev_price"`
+	PrevDiscount int       `json:"prev_discount"`
+	Source       string    `json:"source"`
+	UserId       *int      `json:"user_id"`
+	APIKeyId     *int      `json:"api_key_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PriceHistoryResponse struct {
+	Count   int                   `json:"count"`
+	Changes []PriceChangeResponse `json:"changes"`
+}
+
+// GET /item/:id/prices Get changes of item price and discount, latest first
+func (i *ItemHandler) PriceHistory(c echo.Context) error {
+	var params PriceHistoryQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	changes, err := i.Service.GetPriceHistory(c.Request().Context(), params.ID, params.Limit, params.Offset)
+	if err != nil {
+		return err
+	}
+
+	response := make([]PriceChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		response = append(response, convertPriceChangeFromDomain(change))
+	}
+
+	return c.JSON(http.StatusOK, PriceHistoryResponse{
+		Count:   len(response),
+		Changes: response,
+	})
+}
+
+func convertPriceChangeFromDomain(change domain.PriceChange) PriceChangeResponse {
+	response := PriceChangeResponse{
+		Price:        change.Price,
+		Discount:     change.Discount,
+		PrevPrice:    change.PrevPrice,
+		PrevDiscount: change.PrevDiscount,
+		Source:       string(change.Source),
+		CreatedAt:    change.CreatedAt,
+	}
+	if change.Actor.UserId != 0 {
+		response.UserId = &change.Actor.UserId
+	}
+	if change.Actor.APIKeyId != 0 {
+		response.APIKeyId = &change.Actor.APIKeyId
+	}
+
+	return response
+}
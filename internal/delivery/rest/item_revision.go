@@ -0,0 +1,191 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/item"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RevisionsQueryParams struct {
+	ID     int    `param:"id"`
+	Offset uint64 `query:"offset"`
+	Limit  uint64 `query:"limit" validate:"lte=100"`
+}
+
+type RevisionParams struct {
+	ID      int `param:"id"`
+	Version int `param:"version"`
+}
+
+type RevisionDiffParams struct {
+	ID   int `param:"id"`
+	From int `query:"from" validate:"required,gt=0"`
+	To   int `query:"to" validate:"required,gt=0"`
+}
+
+type ItemSnapshot struct {
+	BrandId     int            `json:"brand_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Sex         string         `json:"sex"`
+	CategoryId  int            `json:"category_id"`
+	Price       uint           `json:"price"`
+	Discount    uint           `json:"discount"`
+	OuterLink   string         `json:"outer_link"`
+	Attributes  map[string]any `json:"attributes"`
+	Images      []string       `json:"images"`
+}
+
+type RevisionResponse struct {
+	Version      int          `json:"version"`
+	RestoredFrom *int         `json:"restored_from"`
+	UserId       *int         `json:"user_id"`
+	APIKeyId     *int         `json:"api_key_id"`
+	Actor        string       `json:"actor"`
+	CreatedAt    time.Time    `json:"created_at"`
+	Snapshot     ItemSnapshot `json:"snapshot"`
+}
+
+type RevisionsResponse struct {
+	Count     int                `json:"count"`
+	Revisions []RevisionResponse `json:"revisions"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type RevisionDiffResponse struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// GET /item/:id/revisions Get saved versions of item, latest first
+func (i *ItemHandler) Revisions(c echo.Context) error {
+	var params RevisionsQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	revisions, err := i.Service.GetRevisions(c.Request().Context(), params.ID, params.Limit, params.Offset)
+	if err != nil {
+		return err
+	}
+
+	response := make([]RevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		response = append(response, convertRevisionFromDomain(revision))
+	}
+
+	return c.JSON(http.StatusOK, RevisionsResponse{
+		Count:     len(response),
+		Revisions: response,
+	})
+}
+
+// GET /item/:id/revisions/:version Get item as it was saved in version
+func (i *ItemHandler) Revision(c echo.Context) error {
+	var params RevisionParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	revision, err := i.Service.GetRevision(c.Request().Context(), params.ID, params.Version)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, convertRevisionFromDomain(revision))
+}
+
+// GET /item/:id/revisions/diff?from=&to= Get fields changed between two versions of item
+func (i *ItemHandler) RevisionsDiff(c echo.Context) error {
+	var params RevisionDiffParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	changes, err := i.Service.DiffRevisions(c.Request().Context(), params.ID, params.From, params.To)
+	if err != nil {
+		return err
+	}
+
+	response := make([]FieldChange, 0, len(changes))
+	for _, change := range changes {
+		response = append(response, FieldChange{
+			Field: change.Field,
+			From:  change.From,
+			To:    change.To,
+		})
+	}
+
+	return c.JSON(http.StatusOK, RevisionDiffResponse{
+		From:    params.From,
+		To:      params.To,
+		Changes: response,
+	})
+}
+
+// POST /item/:id/revisions/:version/rollback Bring item back to version. Rollback is saved as new version
+func (i *ItemHandler) Rollback(c echo.Context) error {
+	var params RevisionParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	err = i.Service.RollbackItem(c.Request().Context(), params.ID, params.Version)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "rollback",
+	})
+}
+
+func convertRevisionFromDomain(revision domain.Revision) RevisionResponse {
+	response := RevisionResponse{
+		Version:      revision.Version,
+		RestoredFrom: revision.RestoredFrom,
+		Actor:        revision.ActorName,
+		CreatedAt:    revision.CreatedAt,
+		Snapshot: ItemSnapshot{
+			BrandId:     revision.Snapshot.BrandId,
+			Name:        revision.Snapshot.Name,
+			Description: revision.Snapshot.Description,
+			Sex:         revision.Snapshot.Sex.String(),
+			CategoryId:  revision.Snapshot.CategoryId,
+			Price:       revision.Snapshot.Price,
+			Discount:    revision.Snapshot.Discount,
+			OuterLink:   revision.Snapshot.OuterLink,
+			Attributes:  revision.Snapshot.Attributes,
+			Images:      revision.Snapshot.Images,
+		},
+	}
+	if revision.Actor.UserId != 0 {
+		response.UserId = &revision.Actor.UserId
+	}
+	if revision.Actor.APIKeyId != 0 {
+		response.APIKeyId = &revision.Actor.APIKeyId
+	}
+
+	return response
+}
@@ -0,0 +1,171 @@
+package rest
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"cloth-mini-app/internal/ratelimit"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errRateLimited    = apperr.TooManyRequests("rate_limited", "too many requests, retry later")
+	errRequestTimeout = apperr.Unavailable("request_timeout", "request took too long")
+	errBodyTooLarge   = echo.ErrStatusRequestEntityTooLarge
+)
+
+// Limit of group of routes, e.g. image uploads
+type RouteLimit struct {
+	// used in bucket key, so groups are limited separately
+	Name string
+	// prefixes of route paths, e.g. /image/create
+	Prefixes []string
+	Limit    ratelimit.Limit
+}
+
+// Body limit of routes accepting files
+type RouteBodyLimit struct {
+	Prefixes []string
+	// bytes
+	Limit int64
+}
+
+type LimitConfig struct {
+	// Rate limit of client ip for all routes
+	IP ratelimit.Limit
+	// Rate limits of client ip for groups of routes, first matching group is applied
+	Groups []RouteLimit
+	// Default max request body in bytes
+	BodyLimit int64
+	// Body limits of routes accepting files, first matching route is applied
+	BodyLimits []RouteBodyLimit
+	// Time for handling request, 0 disables timeout
+	RequestTimeout time.Duration
+	// Prefixes of long running routes without timeout, e.g. archive processing
+	NoTimeout []string
+}
+
+// Middlewares protecting service from abusive clients: rate limits, body size limits and timeouts.
+// Routes are matched by path, so handlers don't need to know about limits
+type LimitMiddleware struct {
+	config  LimitConfig
+	limiter *ratelimit.Keyed
+}
+
+func NewLimitMiddleware(config LimitConfig) *LimitMiddleware {
+	return &LimitMiddleware{
+		config:  config,
+		limiter: ratelimit.NewKeyed(),
+	}
+}
+
+// Rate limit client ip for all routes and for group of route
+func (l *LimitMiddleware) RateLimit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ip := c.RealIP()
+
+			retryAfter := l.limiter.Reserve("ip:"+ip, l.config.IP)
+			if group, ok := l.group(c.Path()); ok && retryAfter == 0 {
+				retryAfter = l.limiter.Reserve(group.Name+":"+ip, group.Limit)
+			}
+
+			if retryAfter > 0 {
+				setRetryAfter(c, retryAfter)
+
+				return errRateLimited
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func (l *LimitMiddleware) group(path string) (RouteLimit, bool) {
+	for _, group := range l.config.Groups {
+		if hasPrefix(path, group.Prefixes) {
+			return group, true
+		}
+	}
+
+	return RouteLimit{}, false
+}
+
+// Limit request body size. Request with larger Content-Length is rejected before reading,
+// body without length fails on reading limit
+func (l *LimitMiddleware) BodyLimit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			limit := l.config.BodyLimit
+			for _, route := range l.config.BodyLimits {
+				if hasPrefix(c.Path(), route.Prefixes) {
+					limit = route.Limit
+					break
+				}
+			}
+			if limit <= 0 {
+				return next(c)
+			}
+
+			request := c.Request()
+			if request.ContentLength > limit {
+				return errBodyTooLarge
+			}
+			request.Body = http.MaxBytesReader(c.Response(), request.Body, limit)
+
+			return next(c)
+		}
+	}
+}
+
+// Cancel context of request handling after timeout
+func (l *LimitMiddleware) Timeout() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if l.config.RequestTimeout <= 0 || hasPrefix(c.Path(), l.config.NoTimeout) {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), l.config.RequestTimeout)
+			defer cancel()
+
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			if err != nil && errors.Is(err, context.DeadlineExceeded) {
+				return errRequestTimeout
+			}
+
+			return err
+		}
+	}
+}
+
+func hasPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Body exceeded limit while it was read by handler
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+
+	return errors.As(err, &maxBytesErr)
+}
+
+// Retry-After is set in whole seconds, rounded up so client doesn't retry too early
+func setRetryAfter(c echo.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/linkcheck"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type LinkCheckService interface {
+	// Get numbers of checked links and last checks, broken first
+	GetReport(ctx context.Context, filter domain.ReportFilter) (domain.Summary, []domain.Check, error)
+}
+
+type LinkCheckHandler struct {
+	Service LinkCheckService
+}
+
+func NewLinkCheckHandler(e *echo.Echo, srv LinkCheckService, auth *AuthMiddleware) {
+	handler := &LinkCheckHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/links")
+	g.Use(middleware.Logger())
+	g.GET("/report", handler.Report, auth.Editor())
+}
+
+type LinkReportQueryParams struct {
+	// only broken or only working links
+	Broken *bool  `query:"broken"`
+	Limit  uint64 `query:"limit" validate:"lte=1000"`
+	Offset uint64 `query:"offset"`
+}
+
+type LinkSummary struct {
+	Items   int `json:"items"`
+	Checked int `json:"checked"`
+	Failing int `json:"failing"`
+	Broken  int `json:"broken"`
+}
+
+type LinkCheck struct {
+	ItemId      int       `json:"item_id"`
+	ItemName    string    `json:"item_name"`
+	Brand       string    `json:"brand"`
+	URL         string    `json:"url"`
+	StatusCode  *int      `json:"status_code"`
+	RedirectURL string    `json:"redirect_url,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Failures    int       `json:"failures"`
+	Broken      bool      `json:"broken"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+type LinkReportResponse struct {
+	Summary LinkSummary `json:"summary"`
+	Count   int         `json:"count"`
+	Links   []LinkCheck `json:"links"`
+}
+
+// GET /links/report Get health of outer links of items, broken and failing links first
+func (l *LinkCheckHandler) Report(c echo.Context) error {
+	var params LinkReportQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	summary, checks, err := l.Service.GetReport(c.Request().Context(), domain.ReportFilter{
+		Broken: params.Broken,
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	links := make([]LinkCheck, 0, len(checks))
+	for _, check := range checks {
+		link := LinkCheck{
+			ItemId:      check.ItemId,
+			ItemName:    check.ItemName,
+			Brand:       check.BrandName,
+			URL:         check.URL,
+			RedirectURL: check.RedirectURL,
+			Error:       check.Error,
+			Failures:    check.Failures,
+			Broken:      check.Broken,
+			CheckedAt:   check.CheckedAt,
+		}
+		if check.StatusCode != 0 {
+			link.StatusCode = &check.StatusCode
+		}
+		links = append(links, link)
+	}
+
+	return c.JSON(http.StatusOK, LinkReportResponse{
+		Summary: LinkSummary{
+			Items:   summary.Items,
+			Checked: summary.Checked,
+			Failing: summary.Failing,
+			Broken:  summary.Broken,
+		},
+		Count: len(links),
+		Links: links,
+	})
+}
@@ -2,8 +2,18 @@ package rest
 
 import "time"
 
+// Error with machine-readable code. Request with retryable error can be repeated as is
 type ErrorResponse struct {
-	Err string `json:"error"`
+	Err       string       `json:"error"`
+	Code      string       `json:"code"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestId string       `json:"request_id,omitempty"`
+	Retryable bool         `json:"retryable"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type SuccessResponse struct {
@@ -12,20 +22,35 @@ type SuccessResponse struct {
 }
 
 type ItemResponse struct {
-	ID           uint       `json:"id"`
-	BrandId      uint       `json:"brand_id"`
-	BrandName    string     `json:"brand_name"`
-	Name         string     `json:"name"`
-	Description  string     `json:"description"`
-	Sex          int        `json:"sex"`
-	CategoryId   int        `json:"category_id"`
-	CategoryType int        `json:"category_type"`
-	CategoryName string     `json:"category_name"`
-	Price        int        `json:"price"`
-	Discount     *int       `json:"discount"`
-	OuterLink    string     `json:"outer_link"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at"`
+	ID           uint           `json:"id"`
+	BrandId      uint           `json:"brand_id"`
+	BrandName    string         `json:"brand_name"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Sex          string         `json:"sex"`
+	CategoryId   int            `json:"category_id"`
+	CategoryType string         `json:"category_type"`
+	CategoryName string         `json:"category_name"`
+	Price        int            `json:"price"`
+	Discount     *int           `json:"discount"`
+	OuterLink    string         `json:"outer_link"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    *time.Time     `json:"updated_at"`
+	Version      int            `json:"version"`
+	Status       string         `json:"status"`
+	PublishAt    *time.Time     `json:"publish_at"`
+	UnpublishAt  *time.Time     `json:"unpublish_at"`
+	Images       []Image        `json:"images"`
+	Attributes   map[string]any `json:"attributes"`
+}
+
+// Image with placeholder metadata. Metadata is omitted if it isn't calculated yet
+type Image struct {
+	ImageId       string `json:"image_id"`
+	BlurHash      string `json:"blurhash,omitempty"`
+	DominantColor string `json:"dominant_color,omitempty"`
+	Width         int    `json:"width,omitempty"`
+	Height        int    `json:"height,omitempty"`
 }
 
 type ItemsResponse struct {
@@ -33,20 +58,42 @@ type ItemsResponse struct {
 	Items []ItemResponse `json:"items"`
 }
 
+type TrashItemResponse struct {
+	ItemResponse
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type TrashResponse struct {
+	Count int                 `json:"count"`
+	Items []TrashItemResponse `json:"items"`
+}
+
 type ItemByIdResponse struct {
-	ID           uint       `json:"id"`
-	BrandId      uint       `json:"brand_id"`
-	BrandName    string     `json:"brand_name"`
-	Name         string     `json:"name"`
-	Description  string     `json:"description"`
-	Sex          int        `json:"sex"`
-	CategoryId   int        `json:"category_id"`
-	CategoryType int        `json:"category_type"`
-	CategoryName string     `json:"category_name"`
-	Price        int        `json:"price"`
-	Discount     *int       `json:"discount"`
-	OuterLink    string     `json:"outer_link"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at"`
-	ImageId      []string   `json:"image_id"`
+	ID           uint           `json:"id"`
+	BrandId      uint           `json:"brand_id"`
+	BrandName    string         `json:"brand_name"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Sex          string         `json:"sex"`
+	CategoryId   int            `json:"category_id"`
+	CategoryType string         `json:"category_type"`
+	CategoryName string         `json:"category_name"`
+	Price        int            `json:"price"`
+	Discount     *int           `json:"discount"`
+	OuterLink    string         `json:"outer_link"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    *time.Time     `json:"updated_at"`
+	Version      int            `json:"version"`
+	Status       string         `json:"status"`
+	PublishAt    *time.Time     `json:"publish_at"`
+	UnpublishAt  *time.Time     `json:"unpublish_at"`
+	ImageId      []string       `json:"image_id"`
+	Images       []Image        `json:"images"`
+	Attributes   map[string]any `json:"attributes"`
+}
+
+// Update is rejected because item was changed, client gets current state of item
+type VersionConflictResponse struct {
+	ErrorResponse
+	Current ItemByIdResponse `json:"current"`
 }
@@ -0,0 +1,254 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/scrape"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type ScrapeService interface {
+	// Get proposals of scraped prices, latest first
+	GetProposals(ctx context.Context, filter domain.ProposalFilter) ([]domain.Proposal, error)
+	// Apply proposed price to item
+	ApplyProposal(ctx context.Context, id int64) error
+	RejectProposal(ctx context.Context, id int64) error
+	GetRules(ctx context.Context) ([]domain.Rule, error)
+	// Create or replace scrape rule of shop domain
+	SaveRule(ctx context.Context, rule domain.Rule) error
+	DeleteRule(ctx context.Context, domainName string) error
+}
+
+type ScrapeHandler struct {
+	Service ScrapeService
+}
+
+func NewScrapeHandler(e *echo.Echo, srv ScrapeService, auth *AuthMiddleware) {
+	handler := &ScrapeHandler{
+		Service: srv,
+	}
+
+	g := e.Group("/prices/proposals", auth.Editor())
+	g.Use(middleware.Logger())
+	g.GET("", handler.Proposals)
+	g.POST("/:id/apply", handler.ApplyProposal)
+	g.POST("/:id/reject", handler.RejectProposal)
+
+	// auto apply rules change prices without review, so only admin changes them
+	r := e.Group("/prices/rules", auth.Admin())
+	r.Use(middleware.Logger())
+	r.GET("", handler.Rules)
+	r.PUT("/:domain", handler.SaveRule)
+	r.DELETE("/:domain", handler.DeleteRule)
+}
+
+type ProposalsQueryParams struct {
+	// pending, applied or rejected, all if empty
+	Status string `query:"status"`
+	Limit  uint64 `query:"limit" validate:"lte=1000"`
+	Offset uint64 `query:"offset"`
+}
+
+type ProposalIdParam struct {
+	ID int64 `param:"id"`
+}
+
+type PriceProposal struct {
+	ID           int64      `json:"id"`
+	ItemId       int        `json:"item_id"`
+	ItemName     string     `json:"item_name"`
+	URL          string     `json:"url"`
+	Price        uint       `json:"price"`
+	Discount     uint       `json:"discount"`
+	Available    *bool      `json:"available"`
+	ItemPrice    int        `json:"item_price"`
+	ItemDiscount int        `json:"item_discount"`
+	Version      int        `json:"version"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+}
+
+type ProposalsResponse struct {
+	Count     int             `json:"count"`
+	Proposals []PriceProposal `json:"proposals"`
+}
+
+type ScrapeRuleDomain struct {
+	Domain string `param:"domain"`
+}
+
+type ScrapeRuleSave struct {
+	Domain string `param:"domain"`
+	// CSS selectors used if page has no JSON-LD Product
+	PriceSelector        string `json:"price_selector"`
+	OldPriceSelector     string `json:"old_price_selector"`
+	AvailabilitySelector string `json:"availability_selector"`
+	InStockText          string `json:"in_stock_text"`
+	AutoApply            bool   `json:"auto_apply"`
+}
+
+type ScrapeRule struct {
+	Domain               string    `json:"domain"`
+	PriceSelector        string    `json:"price_selector"`
+	OldPriceSelector     string    `json:"old_price_selector"`
+	AvailabilitySelector string    `json:"availability_selector"`
+	InStockText          string    `json:"in_stock_text"`
+	AutoApply            bool      `json:"auto_apply"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// GET /prices/proposals Get prices found on shop pages which differ from item prices, latest first
+func (s *ScrapeHandler) Proposals(c echo.Context) error {
+	var params ProposalsQueryParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	proposals, err := s.Service.GetProposals(c.Request().Context(), domain.ProposalFilter{
+		Status: domain.ProposalStatus(params.Status),
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	response := make([]PriceProposal, 0, len(proposals))
+	for _, proposal := range proposals {
+		response = append(response, PriceProposal{
+			ID:           proposal.ID,
+			ItemId:       proposal.ItemId,
+			ItemName:     proposal.ItemName,
+			URL:          proposal.URL,
+			Price:        proposal.Price,
+			Discount:     proposal.Discount,
+			Available:    proposal.Available,
+			ItemPrice:    proposal.ItemPrice,
+			ItemDiscount: proposal.ItemDiscount,
+			Version:      proposal.Version,
+			Status:       string(proposal.Status),
+			CreatedAt:    proposal.CreatedAt,
+			ResolvedAt:   proposal.ResolvedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, ProposalsResponse{
+		Count:     len(response),
+		Proposals: response,
+	})
+}
+
+// POST /prices/proposals/:id/apply Set item price and discount to proposed ones
+func (s *ScrapeHandler) ApplyProposal(c echo.Context) error {
+	var params ProposalIdParam
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	err = s.Service.ApplyProposal(c.Request().Context(), params.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "apply",
+	})
+}
+
+// POST /prices/proposals/:id/reject Keep item price, proposal isn't shown as pending anymore
+func (s *ScrapeHandler) RejectProposal(c echo.Context) error {
+	var params ProposalIdParam
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	err = s.Service.RejectProposal(c.Request().Context(), params.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "reject",
+	})
+}
+
+// GET /prices/rules Get scrape rules of shop domains. Only for admin
+func (s *ScrapeHandler) Rules(c echo.Context) error {
+	rules, err := s.Service.GetRules(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	response := make([]ScrapeRule, 0, len(rules))
+	for _, rule := range rules {
+		response = append(response, ScrapeRule{
+			Domain:               rule.Domain,
+			PriceSelector:        rule.PriceSelector,
+			OldPriceSelector:     rule.OldPriceSelector,
+			AvailabilitySelector: rule.AvailabilitySelector,
+			InStockText:          rule.InStockText,
+			AutoApply:            rule.AutoApply,
+			UpdatedAt:            rule.UpdatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// PUT /prices/rules/:domain Create or replace scrape rule of shop domain, it's applied to subdomains too
+func (s *ScrapeHandler) SaveRule(c echo.Context) error {
+	var rule ScrapeRuleSave
+	err := bind(c, &rule)
+	if err != nil {
+		return err
+	}
+
+	err = s.Service.SaveRule(c.Request().Context(), domain.Rule{
+		Domain:               rule.Domain,
+		PriceSelector:        rule.PriceSelector,
+		OldPriceSelector:     rule.OldPriceSelector,
+		AvailabilitySelector: rule.AvailabilitySelector,
+		InStockText:          rule.InStockText,
+		AutoApply:            rule.AutoApply,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "save",
+	})
+}
+
+// DELETE /prices/rules/:domain Delete scrape rule, pages of domain are scraped only by JSON-LD
+func (s *ScrapeHandler) DeleteRule(c echo.Context) error {
+	var rule ScrapeRuleDomain
+	err := bind(c, &rule)
+	if err != nil {
+		return err
+	}
+
+	err = s.Service.DeleteRule(c.Request().Context(), rule.Domain)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Status:    true,
+		Operation: "delete",
+	})
+}
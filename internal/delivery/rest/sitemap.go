@@ -0,0 +1,107 @@
+package rest
+
+import (
+	domain "cloth-mini-app/internal/domain/sitemap"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type SitemapService interface {
+	// Get sitemap file, page 0 is sitemap.xml
+	Sitemap(ctx context.Context, page int) ([]byte, error)
+	// Get feed of newest published items of brand or category
+	Feed(ctx context.Context, source domain.Source, id int, format domain.Format) ([]byte, error)
+}
+
+type SitemapHandler struct {
+	Service SitemapService
+}
+
+func NewSitemapHandler(e *echo.Echo, srv SitemapService) {
+	handler := &SitemapHandler{
+		Service: srv,
+	}
+
+	// sitemap and feeds are read by search engines and feed readers, so they are public
+	e.GET("/sitemap.xml", handler.Sitemap, middleware.Logger())
+	e.GET("/sitemap-:page", handler.SitemapPage, middleware.Logger())
+	e.GET("/feed/brand/:id/:format", handler.BrandFeed, middleware.Logger())
+	e.GET("/feed/category/:id/:format", handler.CategoryFeed, middleware.Logger())
+}
+
+type SitemapPage struct {
+	// number of page with .xml suffix
+	Page string `param:"page"`
+}
+
+type NewArrivalsParams struct {
+	ID     int    `param:"id"`
+	Format string `param:"format" validate:"oneof=atom rss"`
+}
+
+// GET /sitemap.xml Get sitemap of items, brands and categories. It's index of pages if urls don't fit in one file
+func (s *SitemapHandler) Sitemap(c echo.Context) error {
+	content, err := s.Service.Sitemap(c.Request().Context(), 0)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, content)
+}
+
+// GET /sitemap-:page.xml Get page of sitemap listed in sitemap.xml index
+func (s *SitemapHandler) SitemapPage(c echo.Context) error {
+	var params SitemapPage
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	number, ok := strings.CutSuffix(params.Page, ".xml")
+	page, err := strconv.Atoi(number)
+	if !ok || err != nil || page < 1 {
+		return domain.ErrPageNotFound
+	}
+
+	content, err := s.Service.Sitemap(c.Request().Context(), page)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, content)
+}
+
+// GET /feed/brand/:id/:format Get atom or rss feed of newest items of brand
+func (s *SitemapHandler) BrandFeed(c echo.Context) error {
+	return s.feed(c, domain.SourceBrand)
+}
+
+// GET /feed/category/:id/:format Get atom or rss feed of newest items of category with subcategories
+func (s *SitemapHandler) CategoryFeed(c echo.Context) error {
+	return s.feed(c, domain.SourceCategory)
+}
+
+func (s *SitemapHandler) feed(c echo.Context, source domain.Source) error {
+	var params NewArrivalsParams
+	err := bind(c, &params)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequest(params); err != nil {
+		return err
+	}
+
+	format := domain.Format(params.Format)
+	content, err := s.Service.Feed(c.Request().Context(), source, params.ID, format)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, format.ContentType(), content)
+}
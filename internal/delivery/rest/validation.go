@@ -0,0 +1,94 @@
+package rest
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// Validator reporting invalid fields by json (or query) names
+func newValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "param"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+
+		return field.Name
+	})
+
+	return validate
+}
+
+// Bind request. Value of wrong type is reported as invalid field
+func bind(c echo.Context, i any) error {
+	err := c.Bind(i)
+	if err == nil {
+		return nil
+	}
+
+	if isBodyTooLarge(err) {
+		return errBodyTooLarge
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return apperr.Validation(apperr.FieldError{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be %s", typeErr.Type),
+		})
+	}
+
+	var bindErr *echo.BindingError
+	if errors.As(err, &bindErr) {
+		return apperr.Validation(apperr.FieldError{
+			Field:   bindErr.Field,
+			Message: "has invalid value",
+		})
+	}
+
+	return apperr.Invalid("invalid_request", "request can't be parsed")
+}
+
+// Validate bound request, all invalid fields are reported by json (or query) names
+func validateRequest(i any) error {
+	err := newValidator().Struct(i)
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	var verr apperr.FieldErrors
+	for _, fieldErr := range fieldErrs {
+		verr.Add(fieldErr.Field(), validationMessage(fieldErr))
+	}
+
+	return verr.Err()
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "http_url":
+		return "must be http or https url"
+	}
+
+	return fmt.Sprintf("failed %s validation", fieldErr.Tag())
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Longer search queries are cut, so junk queries don't bloat stats
+const maxQueryLength = 100
+
+// Item page shown to visitor
+type View struct {
+	ItemId    int
+	CreatedAt time.Time
+}
+
+// Catalog search by visitor with number of found items
+type Search struct {
+	Query     string
+	Results   int
+	CreatedAt time.Time
+}
+
+// Views of item for period. For trending items PrevViews are views of the same period before,
+// velocity is change of views per day between periods
+type ItemStats struct {
+	ItemId    int
+	Name      string
+	Views     int
+	PrevViews int
+	Velocity  float64
+}
+
+type QueryStats struct {
+	Query    string
+	Searches int
+	// searches which found nothing
+	ZeroResults int
+}
+
+// Query never finds anything, catalog may lack items visitors look for
+func (q QueryStats) NoResults() bool {
+	return q.Searches > 0 && q.ZeroResults == q.Searches
+}
+
+// Stats for last Days days including today (UTC)
+type StatsFilter struct {
+	Days  int
+	Limit uint64
+	// only queries which found nothing at least once
+	ZeroOnly bool
+}
+
+// First day of period, period of 1 day is today (UTC)
+func (f StatsFilter) From(now time.Time) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	return today.AddDate(0, 0, 1-f.Days)
+}
+
+// Lowercase query with single spaces, empty if there is nothing to count
+func NormalizeQuery(query string) string {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		query = string([]rune(query)[:maxQueryLength])
+	}
+
+	return strings.TrimSpace(query)
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"slices"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = apperr.NotFound("api_key_not_found", "api key not found")
+	ErrAPIKeyInvalid  = apperr.Unauthorized("invalid_api_key", "api key is invalid or revoked")
+	ErrAPIKeyRevoked  = apperr.Conflict("api_key_revoked", "api key is revoked")
+	ErrScope          = apperr.FieldInvalid("invalid_scope", "scopes", "scope must be one of: catalog:read, items:write, images:write")
+	ErrScopeMissing   = apperr.Forbidden("insufficient_scope", "api key doesn't have scope required for operation")
+	ErrRateLimited    = apperr.TooManyRequests("rate_limited", "api key rate limit exceeded")
+	ErrQuotaExceeded  = apperr.TooManyRequests("quota_exceeded", "api key daily quota exceeded")
+)
+
+// Prefix of generated keys, so leaked keys can be found by secret scanners
+const KeyPrefix = "cma_"
+
+// Operation allowed for api key
+type Scope string
+
+const (
+	ScopeCatalogRead Scope = "catalog:read"
+	ScopeItemsWrite  Scope = "items:write"
+	ScopeImagesWrite Scope = "images:write"
+)
+
+func (s Scope) Valid() bool {
+	return s == ScopeCatalogRead || s == ScopeItemsWrite || s == ScopeImagesWrite
+}
+
+// API key model table api_key. Key itself isn't stored, only its hash
+type APIKey struct {
+	ID int
+	// first characters of key, shown to identify key
+	Prefix string
+	Name   string
+	Scopes []Scope
+	// requests per minute
+	RateLimit  int
+	DailyQuota int
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+func (k APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type APIKeyCreate struct {
+	Name       string
+	Scopes     []Scope
+	RateLimit  int
+	DailyQuota int
+}
+
+// Created or rotated key. Key is shown only once
+type APIKeySecret struct {
+	APIKey
+	Key string
+}
+
+// Requests of api key per day, rejected requests are counted separately
+type Usage struct {
+	Day      time.Time
+	Requests int
+	Rejected int
+}
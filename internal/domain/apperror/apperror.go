@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind of error, defines response status and whether request can be retried
+type Kind int
+
+const (
+	KindInternal             Kind = iota // unexpected failure, request can be retried
+	KindInvalid                          // malformed request
+	KindValidation                       // request fields don't pass validation
+	KindNotFound                         // requested resource doesn't exist
+	KindConflict                         // state of resource doesn't allow operation
+	KindLimit                            // limit of resource is reached
+	KindUnsupported                      // operation isn't supported by current configuration
+	KindUnauthorized                     // client isn't authenticated
+	KindForbidden                        // client isn't allowed to perform operation
+	KindTooManyRequests                  // client exceeded rate limit or quota, request can be retried later
+	KindUnavailable                      // request can't be handled now (e.g. timed out), it can be retried
+	KindPreconditionRequired             // conditional request is required, e.g. version of updated resource
+)
+
+// Invalid value of request field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error returned by services and mapped to response by delivery layer.
+// Errors are declared as package variables, so they can be checked with errors.Is
+type Error struct {
+	Kind    Kind
+	Code    string // machine-readable code, e.g. item_not_found
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(fields, "; "))
+}
+
+func Invalid(code, message string) *Error {
+	return &Error{Kind: KindInvalid, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Limit(code, message string) *Error {
+	return &Error{Kind: KindLimit, Code: code, Message: message}
+}
+
+func Unsupported(code, message string) *Error {
+	return &Error{Kind: KindUnsupported, Code: code, Message: message}
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func TooManyRequests(code, message string) *Error {
+	return &Error{Kind: KindTooManyRequests, Code: code, Message: message}
+}
+
+func Unavailable(code, message string) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message}
+}
+
+func PreconditionRequired(code, message string) *Error {
+	return &Error{Kind: KindPreconditionRequired, Code: code, Message: message}
+}
+
+// Validation error of single field
+func FieldInvalid(code, field, message string) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Code:    code,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// Validation error of request fields
+func Validation(fields ...FieldError) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Code:    "validation_failed",
+		Message: "validation failed",
+		Fields:  fields,
+	}
+}
+
+// Collects invalid fields, so client gets all of them at once
+type FieldErrors []FieldError
+
+func (f *FieldErrors) Add(field, message string) {
+	*f = append(*f, FieldError{Field: field, Message: message})
+}
+
+// Get validation error or nil if there are no invalid fields
+func (f FieldErrors) Err() error {
+	if len(f) == 0 {
+		return nil
+	}
+
+	return Validation(f...)
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+var (
+	ErrAction     = apperr.FieldInvalid("invalid_action", "action", "action must be one of: create, update, delete, restore")
+	ErrEntityType = apperr.FieldInvalid("invalid_entity_type", "entity_type", "entity_type must be one of: item, image, brand, category, attribute")
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// restoring deleted entity from trash
+	ActionRestore Action = "restore"
+)
+
+func (a Action) Valid() bool {
+	return a == ActionCreate || a == ActionUpdate || a == ActionDelete || a == ActionRestore
+}
+
+type EntityType string
+
+const (
+	EntityItem      EntityType = "item"
+	EntityImage     EntityType = "image"
+	EntityBrand     EntityType = "brand"
+	EntityCategory  EntityType = "category"
+	EntityAttribute EntityType = "attribute"
+)
+
+func (e EntityType) Valid() bool {
+	switch e {
+	case EntityItem, EntityImage, EntityBrand, EntityCategory, EntityAttribute:
+		return true
+	}
+
+	return false
+}
+
+// Who made change: user or partner by api key. Zero id means not set
+type Actor struct {
+	UserId   int
+	APIKeyId int
+}
+
+// Change made by service. Before and After are marshaled to json, nil for created and deleted entity
+type Change struct {
+	Action     Action
+	EntityType EntityType
+	EntityId   string
+	Before     any
+	After      any
+}
+
+// Audit entry model table audit_log
+type Entry struct {
+	ID    int64
+	Actor Actor
+	// user login or api key name
+	ActorName  string
+	Action     Action
+	EntityType EntityType
+	EntityId   string
+	Before     json.RawMessage
+	After      json.RawMessage
+	RequestId  string
+	CreatedAt  time.Time
+}
+
+// Filter of audit entries, empty fields aren't applied
+type Filter struct {
+	UserId     *int
+	APIKeyId   *int
+	Action     Action
+	EntityType EntityType
+	EntityId   string
+	From       *time.Time
+	To         *time.Time
+	Limit      uint64
+	Offset     uint64
+}
+
+type actorKey struct{}
+
+type requestIdKey struct{}
+
+// Store actor of request, so services can record who made change
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+
+	return actor, ok
+}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+
+	return requestId
+}
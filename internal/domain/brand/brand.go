@@ -1,6 +1,83 @@
 package domain
 
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"strings"
+)
+
+var (
+	ErrBrandNotFound = apperr.NotFound("brand_not_found", "brand not found")
+	ErrBrandExists   = apperr.Conflict("brand_exists", "brand with the same name or slug already exists")
+	ErrBrandHasItems = apperr.Conflict("brand_has_items", "brand has items, delete or move them first")
+	ErrBrandSlug     = apperr.FieldInvalid("invalid_slug", "slug", "slug must contain only latin letters, digits and hyphens")
+)
+
 type Brand struct {
-	ID   int
-	Name string
+	ID          int
+	Name        string
+	Slug        string
+	Description string
+	Country     string
+	LogoId      *string
+	ItemsCount  int
+}
+
+type BrandCreate struct {
+	Name        string
+	Slug        string
+	Description string
+	Country     string
+}
+
+type BrandUpdate struct {
+	ID          int
+	Name        *string
+	Slug        *string
+	Description *string
+	Country     *string
+}
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// Make url slug from brand name: lower case latin letters and digits separated by hyphens.
+// Cyrillic is transliterated
+func Slugify(name string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		var part string
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			part = string(r)
+		default:
+			latin, ok := cyrillicToLatin[r]
+			if !ok {
+				hyphen = slug.Len() > 0
+				continue
+			}
+			part = latin
+		}
+
+		if part == "" {
+			continue
+		}
+		if hyphen {
+			slug.WriteByte('-')
+			hyphen = false
+		}
+		slug.WriteString(part)
+	}
+
+	return slug.String()
+}
+
+// Check that slug is already in canonical form
+func ValidSlug(slug string) bool {
+	return slug != "" && Slugify(slug) == slug
 }
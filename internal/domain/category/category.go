@@ -1,8 +1,227 @@
 package domain
 
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrCategoryNotFound  = apperr.NotFound("category_not_found", "category not found")
+	ErrCategoryNotEmpty  = apperr.Conflict("category_not_empty", "category has subcategories or items")
+	ErrCategoryCycle     = apperr.Conflict("category_cycle", "category can't be moved into its own subtree")
+	ErrCategoryKind      = apperr.Conflict("category_type_mismatch", "category type must be the same as parent category type")
+	ErrKindName          = apperr.FieldInvalid("invalid_category_type", "type", "category type must be one of: clothes, shoes")
+	ErrAttributeExists   = apperr.Conflict("attribute_exists", "attribute with the same code already exists in category, its parents or subcategories")
+	ErrAttributeConflict = apperr.Conflict("attribute_code_conflict", "category or its subcategories have attribute with the same code as new parent or its parents")
+	ErrAttributeNotFound = apperr.NotFound("attribute_not_found", "attribute not found")
+	ErrAttributeSchema   = apperr.Invalid("invalid_attribute_schema", "invalid attribute definition")
+	ErrAttributeValue    = apperr.Invalid("invalid_attribute_value", "invalid item attribute")
+)
+
+// Kind of category, all categories in subtree have the same kind.
+// Stored as int, in API represented by name
+type Kind int
+
+const (
+	KindClothes Kind = 1
+	KindShoes   Kind = 2
+)
+
+var kindNames = map[Kind]string{
+	KindClothes: "clothes",
+	KindShoes:   "shoes",
+}
+
+func (k Kind) Valid() bool {
+	_, ok := kindNames[k]
+	return ok
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+func ParseKind(name string) (Kind, error) {
+	for kind, kindName := range kindNames {
+		if kindName == name {
+			return kind, nil
+		}
+	}
+
+	return 0, ErrKindName
+}
+
 // Category model table category
 type Category struct {
 	CategoryId int
-	Type       int
+	ParentId   *int
+	Type       Kind
 	Name       string
 }
+
+// Category with nested subcategories
+type CategoryNode struct {
+	Category
+	Children []CategoryNode
+}
+
+type CategoryCreate struct {
+	ParentId *int
+	Type     Kind
+	Name     string
+}
+
+type CategoryUpdate struct {
+	ID       int
+	ParentId *int
+	Name     *string
+}
+
+// Type of attribute value
+type ValueType string
+
+const (
+	ValueString ValueType = "string"
+	ValueInt    ValueType = "int"
+	ValueBool   ValueType = "bool"
+	ValueEnum   ValueType = "enum"
+)
+
+var attributeCodeRule = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Attribute definition model table category_attribute.
+// Attribute defined in category is used by all its subcategories
+type Attribute struct {
+	CategoryId int
+	Code       string
+	Name       string
+	ValueType  ValueType
+	Options    []string
+	Required   bool
+}
+
+// Check attribute definition
+func (a Attribute) Validate() error {
+	if !attributeCodeRule.MatchString(a.Code) {
+		return fmt.Errorf("%w: code must contain only lowercase latin letters, digits and underscores", ErrAttributeSchema)
+	}
+	if a.Name == "" {
+		return fmt.Errorf("%w: empty name", ErrAttributeSchema)
+	}
+
+	switch a.ValueType {
+	case ValueString, ValueInt, ValueBool:
+		if len(a.Options) != 0 {
+			return fmt.Errorf("%w: options are allowed only for enum", ErrAttributeSchema)
+		}
+	case ValueEnum:
+		if len(a.Options) == 0 {
+			return fmt.Errorf("%w: enum without options", ErrAttributeSchema)
+		}
+	default:
+		return fmt.Errorf("%w: unknown value type %q", ErrAttributeSchema, a.ValueType)
+	}
+
+	return nil
+}
+
+// Invalid value of item attribute
+type AttributeError struct {
+	Code    string
+	Message string
+}
+
+func (a *AttributeError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrAttributeValue, a.Code, a.Message)
+}
+
+func (a *AttributeError) Unwrap() error {
+	return ErrAttributeValue
+}
+
+// Check item attributes (code => value decoded from json) by category attributes.
+// Unknown attributes are rejected, so typos don't silently disappear from filters
+func ValidateAttributes(schema []Attribute, values map[string]any) []*AttributeError {
+	var errs []*AttributeError
+
+	known := make(map[string]Attribute, len(schema))
+	for _, attr := range schema {
+		known[attr.Code] = attr
+
+		if _, ok := values[attr.Code]; !ok && attr.Required {
+			errs = append(errs, &AttributeError{Code: attr.Code, Message: "is required"})
+		}
+	}
+
+	for code, value := range values {
+		attr, ok := known[code]
+		if !ok {
+			errs = append(errs, &AttributeError{Code: code, Message: "isn't defined for category"})
+			continue
+		}
+
+		if !validValue(attr, value) {
+			message := fmt.Sprintf("must be %s", attr.ValueType)
+			if attr.ValueType == ValueEnum {
+				message = fmt.Sprintf("must be one of: %s", strings.Join(attr.Options, ", "))
+			}
+			errs = append(errs, &AttributeError{Code: code, Message: message})
+		}
+	}
+
+	slices.SortFunc(errs, func(a, b *AttributeError) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+
+	return errs
+}
+
+func validValue(attr Attribute, value any) bool {
+	switch attr.ValueType {
+	case ValueString:
+		_, ok := value.(string)
+		return ok
+	case ValueInt:
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case ValueBool:
+		_, ok := value.(bool)
+		return ok
+	case ValueEnum:
+		option, ok := value.(string)
+		return ok && slices.Contains(attr.Options, option)
+	}
+
+	return false
+}
+
+// Build tree from flat list of categories. Children are kept in list order
+func BuildTree(categories []Category) []CategoryNode {
+	children := make(map[int][]Category)
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentId == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentId] = append(children[*category.ParentId], category)
+	}
+
+	return buildNodes(roots, children)
+}
+
+func buildNodes(categories []Category, children map[int][]Category) []CategoryNode {
+	nodes := make([]CategoryNode, 0, len(categories))
+	for _, category := range categories {
+		nodes = append(nodes, CategoryNode{
+			Category: category,
+			Children: buildNodes(children[category.CategoryId], children),
+		})
+	}
+
+	return nodes
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTemplateNotFound = apperr.NotFound("affiliate_template_not_found", "affiliate template not found")
+	ErrDomain           = apperr.FieldInvalid("invalid_domain", "domain", "domain must be host name of shop, e.g. shop.com")
+	ErrTemplate         = apperr.FieldInvalid("invalid_template", "params",
+		"params must be query string with placeholders: {item_id}, {brand_id}, {click_id}, {source}, {medium}, {campaign}, {content}, {term}")
+	ErrOuterLink = apperr.Conflict("invalid_outer_link", "outer link of item isn't http url")
+)
+
+// Placeholders of affiliate template replaced by values of click
+const (
+	PlaceholderItemId   = "{item_id}"
+	PlaceholderBrandId  = "{brand_id}"
+	PlaceholderClickId  = "{click_id}"
+	PlaceholderSource   = "{source}"
+	PlaceholderMedium   = "{medium}"
+	PlaceholderCampaign = "{campaign}"
+	PlaceholderContent  = "{content}"
+	PlaceholderTerm     = "{term}"
+)
+
+var (
+	placeholderRe = regexp.MustCompile(`\{[^{}]*\}`)
+	domainRe      = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}$`)
+	placeholders  = map[string]bool{
+		PlaceholderItemId: true, PlaceholderBrandId: true, PlaceholderClickId: true, PlaceholderSource: true,
+		PlaceholderMedium: true, PlaceholderCampaign: true, PlaceholderContent: true, PlaceholderTerm: true,
+	}
+)
+
+// UTM parameters of link to /go/:item_id, all are optional
+type Campaign struct {
+	Source   *string
+	Medium   *string
+	Campaign *string
+	Content  *string
+	Term     *string
+}
+
+// Request to redirect to shop
+type Visit struct {
+	Referrer  string
+	UserAgent string
+	Campaign
+}
+
+// Click on outer link of item. User agent isn't stored, only its hash
+type Click struct {
+	ItemId   int
+	Referrer string
+	UAHash   string
+	Campaign
+	CreatedAt time.Time
+}
+
+// Parameters added to outer links of shop domain and its subdomains
+type Template struct {
+	Domain string
+	// query string with placeholders, e.g. utm_source=cloth&aff_sub={click_id}
+	Params    string
+	UpdatedAt time.Time
+}
+
+// Lowercase domain without www. prefix
+func NormalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+}
+
+func ValidDomain(domain string) bool {
+	return domainRe.MatchString(domain)
+}
+
+// Check that params are query string with known placeholders only
+func ValidParams(params string) bool {
+	query, err := url.ParseQuery(params)
+	if err != nil || len(query) == 0 {
+		return false
+	}
+
+	for _, placeholder := range placeholderRe.FindAllString(params, -1) {
+		if !placeholders[placeholder] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Set params of template in link, placeholders are replaced by values, missing values by empty strings.
+// Params of link with the same names are replaced
+func (t Template) Apply(link *url.URL, values map[string]string) {
+	pairs := make([]string, 0, len(placeholders)*2)
+	for placeholder := range placeholders {
+		pairs = append(pairs, placeholder, values[placeholder])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	params, _ := url.ParseQuery(t.Params)
+	query := link.Query()
+	for name, vals := range params {
+		query.Del(name)
+		for _, val := range vals {
+			query.Add(name, replacer.Replace(val))
+		}
+	}
+
+	link.RawQuery = query.Encode()
+}
+
+type StatsFilter struct {
+	// clicks since From and before To
+	From    *time.Time
+	To      *time.Time
+	ItemId  *int
+	BrandId *int
+	Limit   uint64
+	Offset  uint64
+}
+
+// Clicks of item or brand. Visitors are distinct user agents
+type Stats struct {
+	ID       int
+	Name     string
+	Clicks   int
+	Visitors int
+}
+
+type DayStats struct {
+	Day      time.Time
+	Clicks   int
+	Visitors int
+}
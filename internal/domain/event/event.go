@@ -3,7 +3,11 @@ package domain
 import "time"
 
 const (
-	EventCreateItem = "create_item"
+	EventCreateItem  = "create_item"
+	EventPublishItem = "publish_item"
+	EventArchiveItem = "archive_item"
+	// item is rolled back to one of its revisions
+	EventRollbackItem = "rollback_item"
 )
 
 type Event struct {
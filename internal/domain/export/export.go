@@ -0,0 +1,99 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	adomain "cloth-mini-app/internal/domain/audit"
+	idomain "cloth-mini-app/internal/domain/item"
+	"time"
+)
+
+var (
+	ErrJobNotFound = apperr.NotFound("export_job_not_found", "export job not found")
+	ErrFormat      = apperr.FieldInvalid("invalid_export_format", "format", "format must be one of: csv, ndjson, xlsx")
+	// file is downloaded only from finished job
+	ErrJobState = apperr.Conflict("export_job_state", "export file isn't ready")
+	// files are kept for retention period, export is created again after it
+	ErrFileExpired = apperr.NotFound("export_file_expired", "export file is expired")
+)
+
+// Format of exported file
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+var contentTypes = map[Format]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func (f Format) Valid() bool {
+	_, ok := contentTypes[f]
+	return ok
+}
+
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Name of exported file, e.g. items.csv
+func (f Format) FileName() string {
+	return "items." + string(f)
+}
+
+// Stage of export job
+type Status string
+
+const (
+	// waiting for background task
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	// file is stored in blob storage
+	StatusDone   Status = "done"
+	StatusFailed Status = "failed"
+	// file of done job is deleted after retention period
+	StatusExpired Status = "expired"
+)
+
+// Export made in background, file is stored in blob storage
+type Job struct {
+	ID     int
+	Format Format
+	// filters of GET /item/get, limit and offset are ignored
+	Filter idomain.ItemInputData
+	Status Status
+	// id of file in blob storage, set for done job
+	ObjectId string
+	// exported items
+	Items int
+	// file size in bytes
+	Size  int64
+	Error string
+	// who requested export
+	Actor     adomain.Actor
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// File isn't ready or isn't stored any more
+func (j Job) FileError() error {
+	switch j.Status {
+	case StatusDone:
+		return nil
+	case StatusExpired:
+		return ErrFileExpired
+	default:
+		return ErrJobState
+	}
+}
+
+// Result of finished export
+type Result struct {
+	ObjectId string
+	Items    int
+	Size     int64
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"time"
+)
+
+var (
+	ErrFeedNotFound = apperr.NotFound("feed_not_found", "feed not found, it's generated in background after start")
+)
+
+// Product feeds for traffic partners, name is the file name in url
+const (
+	FeedYandex    = "yandex.yml"
+	FeedGoogleXML = "google.xml"
+	FeedGoogleTSV = "google.tsv"
+)
+
+var contentTypes = map[string]string{
+	FeedYandex:    "application/xml; charset=utf-8",
+	FeedGoogleXML: "application/xml; charset=utf-8",
+	FeedGoogleTSV: "text/tab-separated-values; charset=utf-8",
+}
+
+// Names of all feeds
+func Feeds() []string {
+	return []string{FeedYandex, FeedGoogleXML, FeedGoogleTSV}
+}
+
+func ContentType(name string) string {
+	return contentTypes[name]
+}
+
+func ValidFeed(name string) bool {
+	_, ok := contentTypes[name]
+	return ok
+}
+
+// Shop described in feeds
+type Shop struct {
+	Name    string
+	Company string
+	// site of shop, base of image links
+	URL string
+	// ISO code of prices currency
+	Currency string
+}
+
+// Generated feed file stored in blob storage
+type Feed struct {
+	Name     string
+	ObjectId string
+	// hash of content
+	ETag string
+	Size int64
+	// last outbox event when feed was generated
+	EventId     int
+	GeneratedAt time.Time
+}
+
+// Rendered parts of feeds for one published item. Entry is rendered again when its source changes
+type Entry struct {
+	ItemId int
+	// hash of data entry is rendered from
+	Source    string
+	YML       string
+	GoogleXML string
+	GoogleTSV string
+}
+
+// Published item without entry or with entry rendered from old data
+type StaleItem struct {
+	ItemId int
+	Source string
+}
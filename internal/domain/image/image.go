@@ -1,6 +1,34 @@
 package domain
 
-import "time"
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const (
+	// content type of image which type isn't detected
+	ImageContentType = "image/jpeg"
+)
+
+var (
+	ErrImageType = apperr.Invalid("invalid_image_type", "incorrect image format. allowed image formats: .jpg/.png")
+	ErrImageSize = apperr.Invalid("image_too_large", "image size exceeds the limit")
+	// decoded image would take too much memory
+	ErrImageDimensions = apperr.Invalid("image_dimensions_too_large", "image width and height exceed the limit")
+	ErrNoFile          = apperr.Invalid("file_required", "file isn't provided or can't be read")
+	ErrImageNotFound   = apperr.NotFound("image_not_found", "image not found")
+	ErrUploadNotFound  = apperr.NotFound("upload_not_found", "no pending upload with provided id")
+	ErrNotUploaded     = apperr.Conflict("image_not_uploaded", "image is not uploaded to storage")
+	ErrNotConfirmed    = apperr.FieldInvalid("temp_image_not_confirmed", "temp_images", "temp image upload is not confirmed")
+	ErrMaxImages       = apperr.Limit("max_images_reached", "reached max images per item")
+	ErrItemNotFound    = apperr.NotFound("item_not_found", "item not found")
+	ErrFileName        = apperr.Invalid("invalid_file_name", "file name doesn't match naming rule")
+	ErrNoImages        = apperr.NotFound("no_images", "no images to archive")
+	ErrImageLimit      = apperr.Invalid("too_many_images", "too many images requested")
+	ErrDirectUpload    = apperr.Unsupported("direct_upload_unsupported", "direct upload is not supported by storage")
+)
 
 // image model table image
 type Image struct {
@@ -8,10 +36,74 @@ type Image struct {
 	ItemId     int
 	ObjectId   string
 	UploadedAt time.Time
+	Meta       *ImageMeta // nil if metadata isn't calculated yet
+}
+
+// Images of items. Editors get images of any item, others only of published items
+type ImageFilter struct {
+	ItemId    *int
+	ObjectIds []string
+	// only images of published items which aren't in trash
+	PublishedOnly bool
+}
+
+// Data for rendering placeholder while image is loading
+type ImageMeta struct {
+	BlurHash      string `json:"blurhash"`
+	DominantColor string `json:"dominant_color"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
 }
 
 type TempImage struct {
 	ID         uint
 	ObjectId   string
 	UploadedAt time.Time
+	Confirmed  bool
+}
+
+// Presigned url for direct upload to storage
+type UploadURL struct {
+	ObjectId  string
+	URL       string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// Result of attaching one file from archive
+type ArchiveFileResult struct {
+	FileName string
+	ItemId   int
+	FileId   string
+	Err      error
+}
+
+// File in downloaded archive. Extension is added to name by content type
+type ArchiveEntry struct {
+	ObjectId string
+	Name     string
+}
+
+// Check that content type is allowed for images
+func IsAllowedImageType(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png"
+}
+
+// Detect content type of image by its content. Image of other type is stored as jpeg, same as before detection
+func DetectImageContentType(file []byte) string {
+	contentType := mimetype.Detect(file).String()
+	if !IsAllowedImageType(contentType) {
+		return ImageContentType
+	}
+
+	return contentType
+}
+
+// Detect file type by content and check that it is allowed image
+func CheckImageType(file []byte) error {
+	if !IsAllowedImageType(mimetype.Detect(file).String()) {
+		return ErrImageType
+	}
+
+	return nil
 }
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	adomain "cloth-mini-app/internal/domain/audit"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrJobNotFound = apperr.NotFound("import_job_not_found", "import job not found")
+	ErrFileFormat  = apperr.FieldInvalid("invalid_import_file", "file", "file must be csv or xlsx table with header row")
+	ErrEncoding    = apperr.FieldInvalid("invalid_import_encoding", "file", "csv file must be in UTF-8")
+	ErrEmptyFile   = apperr.FieldInvalid("empty_import_file", "file", "file has no rows after header")
+	ErrTooManyRows = apperr.Limit("import_rows_limit", "file has too many rows")
+	ErrMapping     = apperr.FieldInvalid("invalid_import_mapping", "mapping", "mapping must be json object: item field => column name")
+	// only validated dry run can be committed
+	ErrJobState = apperr.Conflict("import_job_state", "import job isn't waiting for commit")
+)
+
+// Item fields which can be imported. Brand and category are resolved by name
+const (
+	FieldBrand       = "brand"
+	FieldCategory    = "category"
+	FieldName        = "name"
+	FieldDescription = "description"
+	FieldSex         = "sex"
+	FieldPrice       = "price"
+	FieldDiscount    = "discount"
+	FieldOuterLink   = "outer_link"
+	FieldStatus      = "status"
+	// prefix of item attribute, e.g. attributes.color
+	AttributePrefix = "attributes."
+)
+
+var (
+	requiredFields = []string{FieldBrand, FieldCategory, FieldName, FieldDescription, FieldSex, FieldPrice, FieldOuterLink}
+	optionalFields = []string{FieldDiscount, FieldStatus}
+)
+
+func knownField(field string) bool {
+	if code, ok := strings.CutPrefix(field, AttributePrefix); ok {
+		return code != ""
+	}
+
+	return slices.Contains(requiredFields, field) || slices.Contains(optionalFields, field)
+}
+
+// Get column index of every imported field. Columns named as fields are imported by default,
+// mapping (field => column name) sets columns with other names. Column names are case insensitive
+func ResolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for idx, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := positions[name]; !ok && name != "" {
+			positions[name] = idx
+		}
+	}
+
+	var verr apperr.FieldErrors
+	columns := make(map[string]int)
+	for name, idx := range positions {
+		if knownField(name) {
+			columns[name] = idx
+		}
+	}
+	for field, column := range mapping {
+		if !knownField(field) {
+			verr.Add("mapping."+field, "unknown item field")
+			continue
+		}
+
+		idx, ok := positions[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			verr.Add("mapping."+field, fmt.Sprintf("column %q not found in file", column))
+			continue
+		}
+		columns[field] = idx
+	}
+
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok && !hasKey(mapping, field) {
+			verr.Add("mapping."+field, "column of required field isn't mapped")
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// Stage of import job
+type Status string
+
+const (
+	// rows are checked in background
+	StatusValidating Status = "validating"
+	// dry run is checked, job waits for commit
+	StatusValidated Status = "validated"
+	// valid rows are created in background
+	StatusImporting Status = "importing"
+	StatusDone      Status = "done"
+	// job is stopped by unexpected error
+	StatusFailed Status = "failed"
+)
+
+// Invalid value in row of file. Row is number of row in file, header is row 1
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Import job model table import_jobs
+type Job struct {
+	ID       int
+	FileName string
+	Status   Status
+	// rows are only checked, items are created when job is committed
+	DryRun bool
+	// brands missing in catalog are created on import, otherwise rows with them are invalid
+	CreateBrands bool
+	// imported field => column index
+	Columns map[string]int
+	// rows after header, loaded only for processing
+	Rows  [][]string
+	Total int
+	// rows handled by current stage
+	Processed int
+	Valid     int
+	Created   int
+	Errors    []RowError
+	// brand names missing in catalog
+	NewBrands []string
+	// reason of failed job
+	Error     string
+	Actor     adomain.Actor
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Uploaded file with import settings
+type Upload struct {
+	FileName string
+	File     []byte
+	// item field => column name, columns named as fields are imported without mapping
+	Mapping      map[string]string
+	DryRun       bool
+	CreateBrands bool
+}
+
+// Number of row in file by index of row after header
+func RowNumber(idx int) int {
+	return idx + 2
+}
+
+// Result of processed batch of rows, added to job counters
+type Progress struct {
+	Processed int
+	Valid     int
+	Created   int
+	Errors    []RowError
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
@@ -1,6 +1,162 @@
 package domain
 
-import "time"
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	cdomain "cloth-mini-app/internal/domain/category"
+	imdomain "cloth-mini-app/internal/domain/image"
+	"net/url"
+	"slices"
+	"time"
+)
+
+var (
+	ErrItemNotFound = apperr.NotFound("item_not_found", "item not found")
+	ErrSex          = apperr.FieldInvalid("invalid_sex", "sex", "sex must be one of: male, female, unisex")
+	ErrStatus       = apperr.FieldInvalid("invalid_status", "status", "status must be one of: draft, scheduled, published, archived")
+	// status can't be changed to provided one, e.g. draft can't be archived
+	ErrStatusTransition = apperr.Conflict("invalid_status_transition", "item status can't be changed to provided status")
+	// item was changed after client had fetched it
+	ErrVersionConflict = apperr.Conflict("version_conflict", "item was changed by another request, fetch it again")
+	ErrVersionRequired = apperr.PreconditionRequired("version_required", "item version must be provided in If-Match header or version field")
+	ErrOuterLink       = apperr.FieldInvalid("invalid_outer_link", "outer_link", "outer_link must be http or https url")
+)
+
+// Outer link is absolute http(s) url of shop page, app requests it when checking links and scraping prices
+func ValidOuterLink(link string) bool {
+	parsed, err := url.Parse(link)
+
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Hostname() != ""
+}
+
+// Target audience of item. Stored as int, in API represented by name
+type Sex int
+
+const (
+	SexMale   Sex = 1
+	SexFemale Sex = 2
+	SexUnisex Sex = 3
+)
+
+var sexNames = map[Sex]string{
+	SexMale:   "male",
+	SexFemale: "female",
+	SexUnisex: "unisex",
+}
+
+func (s Sex) Valid() bool {
+	_, ok := sexNames[s]
+	return ok
+}
+
+func (s Sex) String() string {
+	return sexNames[s]
+}
+
+func ParseSex(name string) (Sex, error) {
+	for sex, sexName := range sexNames {
+		if sexName == name {
+			return sex, nil
+		}
+	}
+
+	return 0, ErrSex
+}
+
+// Publication status of item. Only published items are shown in public catalog.
+// Stored as int, in API represented by name
+type Status int
+
+const (
+	StatusDraft Status = 1
+	// published by scheduler at publish_at
+	StatusScheduled Status = 2
+	// archived by scheduler at unpublish_at if it's set
+	StatusPublished Status = 3
+	StatusArchived  Status = 4
+)
+
+var statusNames = map[Status]string{
+	StatusDraft:     "draft",
+	StatusScheduled: "scheduled",
+	StatusPublished: "published",
+	StatusArchived:  "archived",
+}
+
+// Allowed changes of status. Status can be set again to change publication time
+var statusTransitions = map[Status][]Status{
+	StatusDraft:     {StatusDraft, StatusScheduled, StatusPublished},
+	StatusScheduled: {StatusScheduled, StatusDraft, StatusPublished},
+	StatusPublished: {StatusPublished, StatusArchived},
+	StatusArchived:  {StatusArchived, StatusDraft},
+}
+
+func (s Status) Valid() bool {
+	_, ok := statusNames[s]
+	return ok
+}
+
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+func (s Status) CanChangeTo(status Status) bool {
+	return slices.Contains(statusTransitions[s], status)
+}
+
+func ParseStatus(name string) (Status, error) {
+	for status, statusName := range statusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+
+	return 0, ErrStatus
+}
+
+// Status with publication time. Published item gets publish_at of the moment it's published
+type Publication struct {
+	Status      Status
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+}
+
+// Check publication time for status, field errors are added to verr
+func (p Publication) Validate(now time.Time, verr *apperr.FieldErrors) {
+	switch p.Status {
+	case StatusScheduled:
+		if p.PublishAt == nil {
+			verr.Add("publish_at", "publish_at is required for scheduled item")
+		} else if !p.PublishAt.After(now) {
+			verr.Add("publish_at", "publish_at of scheduled item must be in the future")
+		}
+	case StatusPublished:
+		if p.PublishAt != nil && p.PublishAt.After(now) {
+			verr.Add("publish_at", "publish_at of published item can't be in the future, schedule item instead")
+		}
+	}
+
+	if p.UnpublishAt != nil && p.Status != StatusArchived {
+		if p.PublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+			verr.Add("unpublish_at", "unpublish_at must be after publish_at")
+		} else if !p.UnpublishAt.After(now) {
+			verr.Add("unpublish_at", "unpublish_at must be in the future")
+		}
+	}
+}
+
+// Set publish time of published item if it isn't provided. Archived item is unpublished now
+func (p Publication) WithDefaults(now time.Time) Publication {
+	switch p.Status {
+	case StatusPublished:
+		if p.PublishAt == nil {
+			p.PublishAt = &now
+		}
+	case StatusArchived:
+		p.UnpublishAt = &now
+	}
+
+	return p
+}
 
 type ItemAPI struct {
 	ID           uint
@@ -8,16 +164,39 @@ type ItemAPI struct {
 	BrandName    string
 	Name         string
 	Description  string
-	Sex          int
+	Sex          Sex
 	CategoryId   int
-	CategoryType int
+	CategoryType cdomain.Kind
 	CategoryName string
 	Price        int
 	Discount     *int
 	OuterLink    string
 	CreatedAt    time.Time
 	UpdatedAt    *time.Time
-	ImageId      []string
+	// incremented on every change of item
+	Version int
+	Publication
+	// set only for items in trash
+	DeletedAt  *time.Time
+	ImageId    []string
+	Images     []imdomain.Image
+	Attributes map[string]any
+}
+
+// Price with discount percent. Discount out of 1-99 range isn't applied
+func (i ItemAPI) SalePrice() int {
+	if i.Discount == nil || *i.Discount <= 0 || *i.Discount >= 100 {
+		return i.Price
+	}
+
+	return i.Price * (100 - *i.Discount) / 100
+}
+
+// Times of item for sitemap
+type ItemTimes struct {
+	ID        uint
+	CreatedAt time.Time
+	UpdatedAt *time.Time
 }
 
 type ItemUpdate struct {
@@ -25,34 +204,49 @@ type ItemUpdate struct {
 	BrandId     *int
 	Name        *string
 	Description *string
-	Sex         *int
+	Sex         *Sex
 	CategoryId  *int
 	Price       *uint
 	Discount    *uint
 	OuterLink   *string
+	// nil if attributes aren't changed
+	Attributes map[string]any
+	// version of item the change is based on
+	Version int
 }
 
 type ItemCreate struct {
 	BrandId     int
 	Name        string
 	Description string
-	Sex         int
+	Sex         Sex
 	CategoryId  int
 	Price       uint
 	Discount    uint
 	OuterLink   string
 	Images      []string
+	Attributes  map[string]any
+	// draft if status isn't set
+	Publication
+}
+
+// Change of item status
+type ItemStatusUpdate struct {
+	ID int
+	Publication
 }
 
 type ItemInputData struct {
 	ID         *uint
 	BrandId    *uint
 	Name       *string
-	Sex        *int
+	Sex        *Sex
 	CategoryId *uint
 	MinPrice   *uint
 	MaxPrice   *uint
 	Discount   *uint
 	Offset     *uint
 	Limit      *uint
+	// items of any status if empty
+	Statuses []Status
 }
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	"context"
+	"time"
+)
+
+// Who changed price of item
+type PriceSource string
+
+const (
+	PriceSourceEditor   PriceSource = "editor"
+	PriceSourceScraper  PriceSource = "scraper"
+	PriceSourceRollback PriceSource = "rollback"
+)
+
+// Change of item price or discount, stored in price history
+type PriceChange struct {
+	ID           int64
+	ItemId       int
+	Price        int
+	Discount     int
+	PrevPrice    int
+	PrevDiscount int
+	Source       PriceSource
+	Actor        adomain.Actor
+	CreatedAt    time.Time
+}
+
+type priceSourceKey struct{}
+
+// Store source of price change, editor is source if it isn't set
+func WithPriceSource(ctx context.Context, source PriceSource) context.Context {
+	return context.WithValue(ctx, priceSourceKey{}, source)
+}
+
+func PriceSourceFromContext(ctx context.Context) PriceSource {
+	source, ok := ctx.Value(priceSourceKey{}).(PriceSource)
+	if !ok {
+		return PriceSourceEditor
+	}
+
+	return source
+}
+
+// Price change between two states of item, false if price and discount are the same
+func NewPriceChange(before, after ItemAPI, source PriceSource) (PriceChange, bool) {
+	change := PriceChange{
+		ItemId:       int(after.ID),
+		Price:        after.Price,
+		Discount:     discount(after.Discount),
+		PrevPrice:    before.Price,
+		PrevDiscount: discount(before.Discount),
+		Source:       source,
+	}
+
+	return change, change.Price != change.PrevPrice || change.Discount != change.PrevDiscount
+}
+
+func discount(d *int) int {
+	if d == nil {
+		return 0
+	}
+
+	return *d
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	adomain "cloth-mini-app/internal/domain/audit"
+	imdomain "cloth-mini-app/internal/domain/image"
+	"reflect"
+	"time"
+)
+
+var ErrRevisionNotFound = apperr.NotFound("revision_not_found", "item revision not found")
+
+// Saved state of item. Revision is stored on every change of item fields or images
+type Revision struct {
+	ID       int64
+	ItemId   int
+	Version  int
+	Snapshot Snapshot
+	// version item was rolled back to, nil for regular change
+	RestoredFrom *int
+	Actor        adomain.Actor
+	// user login or api key name
+	ActorName string
+	CreatedAt time.Time
+}
+
+// Item fields and image set stored in revision
+type Snapshot struct {
+	BrandId     int            `json:"brand_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Sex         Sex            `json:"sex"`
+	CategoryId  int            `json:"category_id"`
+	Price       uint           `json:"price"`
+	Discount    uint           `json:"discount"`
+	OuterLink   string         `json:"outer_link"`
+	Attributes  map[string]any `json:"attributes"`
+	// object ids sorted, so equal sets are equal slices
+	Images []string `json:"images"`
+	// metadata of images by object id, images without metadata and revisions before it are missed
+	ImageMeta map[string]imdomain.ImageMeta `json:"image_meta,omitempty"`
+}
+
+// Update that brings item fields back to snapshot. Images are restored separately
+func (s Snapshot) ItemUpdate(itemId, version int) ItemUpdate {
+	attributes := s.Attributes
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+
+	return ItemUpdate{
+		ID:          itemId,
+		BrandId:     &s.BrandId,
+		Name:        &s.Name,
+		Description: &s.Description,
+		Sex:         &s.Sex,
+		CategoryId:  &s.CategoryId,
+		Price:       &s.Price,
+		Discount:    &s.Discount,
+		OuterLink:   &s.OuterLink,
+		Attributes:  attributes,
+		Version:     version,
+	}
+}
+
+// Changed field between two revisions
+type FieldChange struct {
+	Field string
+	From  any
+	To    any
+}
+
+// Get fields changed from s to other snapshot
+func (s Snapshot) Diff(to Snapshot) []FieldChange {
+	changes := make([]FieldChange, 0)
+	add := func(field string, from, to any) {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	add("brand_id", s.BrandId, to.BrandId)
+	add("name", s.Name, to.Name)
+	add("description", s.Description, to.Description)
+	add("sex", s.Sex.String(), to.Sex.String())
+	add("category_id", s.CategoryId, to.CategoryId)
+	add("price", s.Price, to.Price)
+	add("discount", s.Discount, to.Discount)
+	add("outer_link", s.OuterLink, to.OuterLink)
+	add("attributes", nonNilMap(s.Attributes), nonNilMap(to.Attributes))
+	add("images", nonNilSlice(s.Images), nonNilSlice(to.Images))
+
+	return changes
+}
+
+func nonNilMap(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+
+	return m
+}
+
+func nonNilSlice(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
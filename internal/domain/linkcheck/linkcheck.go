@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// Outer link of item to check
+type Link struct {
+	ItemId int
+	URL    string
+}
+
+// Result of checking link. StatusCode is 0 if request failed
+type Result struct {
+	ItemId     int
+	URL        string
+	StatusCode int
+	// final url if link redirects
+	RedirectURL string
+	Error       string
+	CheckedAt   time.Time
+}
+
+// Link is healthy if shop page is returned
+func (r Result) OK() bool {
+	return r.Error == "" && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Last check of item link with number of failed checks in a row
+type Check struct {
+	Result
+	ItemName  string
+	BrandName string
+	Failures  int
+	// link failed checks many times in a row
+	Broken bool
+}
+
+// Numbers of checked links of items
+type Summary struct {
+	Items   int
+	Checked int
+	// failed last check, but aren't broken yet
+	Failing int
+	Broken  int
+}
+
+type ReportFilter struct {
+	// only broken or only working links, all if nil
+	Broken *bool
+	Limit  uint64
+	Offset uint64
+}
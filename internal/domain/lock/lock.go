@@ -5,4 +5,9 @@ type AdvisoryLockId int
 const (
 	TempImageAdvisoryLockId AdvisoryLockId = 10
 	OutboxAdvisoryLockId    AdvisoryLockId = 20
+	PublicationLockId       AdvisoryLockId = 30
+	FeedLockId              AdvisoryLockId = 40
+	LinkCheckLockId         AdvisoryLockId = 50
+	ScrapeLockId            AdvisoryLockId = 60
+	AnalyticsLockId         AdvisoryLockId = 70
 )
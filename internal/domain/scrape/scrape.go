@@ -0,0 +1,141 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrRuleNotFound     = apperr.NotFound("scrape_rule_not_found", "scrape rule not found")
+	ErrProposalNotFound = apperr.NotFound("price_proposal_not_found", "price proposal not found")
+	ErrProposalResolved = apperr.Conflict("price_proposal_resolved", "price proposal is already applied or rejected")
+	// item was changed after price had been scraped, proposal is replaced by next scrape
+	ErrProposalOutdated = apperr.Conflict("price_proposal_outdated", "item was changed after price had been found, wait for next scrape")
+	ErrDomain           = apperr.FieldInvalid("invalid_domain", "domain", "domain must be host name of shop, e.g. shop.com")
+	ErrSelector         = apperr.FieldInvalid("invalid_selector", "price_selector",
+		"selectors must be simple CSS selectors: tag, #id, .class, [attr], [attr=value] and descendants")
+	ErrStatus = apperr.FieldInvalid("invalid_status", "status", "status must be one of: pending, applied, rejected")
+
+	// page doesn't contain price in known format
+	ErrNoOffer = errors.New("price not found on page")
+)
+
+// Names of extractors which found offer
+const (
+	ExtractorJSONLD    = "jsonld"
+	ExtractorSelectors = "selectors"
+)
+
+// Price found on shop page. Price is full price, discount is percent of sale price off it
+type Offer struct {
+	Price    uint
+	Discount uint
+	// nil if page doesn't show availability
+	Available *bool
+	// name of extractor which found offer
+	Extractor string
+}
+
+// Build offer from sale price and price before discount, oldPrice is 0 if there is no discount
+func NewOffer(price, oldPrice uint) Offer {
+	if oldPrice <= price || oldPrice == 0 {
+		return Offer{Price: price}
+	}
+
+	return Offer{
+		Price:    oldPrice,
+		Discount: uint((float64(oldPrice-price)/float64(oldPrice))*100 + 0.5),
+	}
+}
+
+// CSS selectors of shop pages without JSON-LD Product, applied to domain and its subdomains
+type Rule struct {
+	Domain        string
+	PriceSelector string
+	// price before discount
+	OldPriceSelector     string
+	AvailabilitySelector string
+	// text of availability element when item is in stock, e.g. "В наличии"
+	InStockText string
+	// scraped prices are applied without review
+	AutoApply bool
+	UpdatedAt time.Time
+}
+
+// Item which price is scraped
+type Target struct {
+	ItemId   int
+	URL      string
+	Price    int
+	Discount int
+	Version  int
+}
+
+// Relative change of sale price of target by offer, e.g. 0.5 when new price is half as high or 1.5 times higher
+func (t Target) PriceChange(offer Offer) float64 {
+	old := salePrice(uint(t.Price), uint(t.Discount))
+	if old == 0 {
+		return math.Inf(1)
+	}
+
+	return math.Abs(salePrice(offer.Price, offer.Discount)-old) / old
+}
+
+func salePrice(price, discount uint) float64 {
+	if discount > 100 {
+		discount = 100
+	}
+
+	return float64(price) * float64(100-discount) / 100
+}
+
+// Last scrape of item page
+type Result struct {
+	ItemId int
+	URL    string
+	// nil if price isn't found
+	Offer     *Offer
+	Error     string
+	ScrapedAt time.Time
+}
+
+// Review status of scraped price
+type ProposalStatus string
+
+const (
+	StatusPending  ProposalStatus = "pending"
+	StatusApplied  ProposalStatus = "applied"
+	StatusRejected ProposalStatus = "rejected"
+)
+
+func (s ProposalStatus) Valid() bool {
+	return s == StatusPending || s == StatusApplied || s == StatusRejected
+}
+
+// Scraped price which differs from item price
+type Proposal struct {
+	ID        int64
+	ItemId    int
+	ItemName  string
+	URL       string
+	Price     uint
+	Discount  uint
+	Available *bool
+	// current price of item
+	ItemPrice    int
+	ItemDiscount int
+	// version of item the proposal is based on
+	Version    int
+	Status     ProposalStatus
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+}
+
+// Filter of proposals, all statuses if status is empty
+type ProposalFilter struct {
+	Status ProposalStatus
+	Limit  uint64
+	Offset uint64
+}
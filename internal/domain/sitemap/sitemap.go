@@ -0,0 +1,49 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"time"
+)
+
+var (
+	ErrPageNotFound = apperr.NotFound("sitemap_page_not_found", "sitemap page not found")
+	ErrFormat       = apperr.FieldInvalid("invalid_feed_format", "format", "format must be one of: atom, rss")
+)
+
+// Max urls in one sitemap file by sitemaps.org protocol, sitemap is split into indexed files above it
+const MaxURLs = 50000
+
+// Format of new arrivals feed
+type Format string
+
+const (
+	FormatAtom Format = "atom"
+	FormatRSS  Format = "rss"
+)
+
+func (f Format) Valid() bool {
+	return f == FormatAtom || f == FormatRSS
+}
+
+func (f Format) ContentType() string {
+	if f == FormatAtom {
+		return "application/atom+xml; charset=utf-8"
+	}
+
+	return "application/rss+xml; charset=utf-8"
+}
+
+// Page of site in sitemap
+type URL struct {
+	Loc string
+	// nil if time of last change is unknown
+	LastMod *time.Time
+}
+
+// Source of new arrivals feed, items of brand or category with its subcategories
+type Source string
+
+const (
+	SourceBrand    Source = "brand"
+	SourceCategory Source = "category"
+)
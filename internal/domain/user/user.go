@@ -0,0 +1,85 @@
+package domain
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	"time"
+)
+
+var (
+	ErrUserNotFound       = apperr.NotFound("user_not_found", "user not found")
+	ErrUserExists         = apperr.Conflict("user_exists", "user with the same login already exists")
+	ErrRole               = apperr.FieldInvalid("invalid_role", "role", "role must be one of: admin, editor, viewer")
+	ErrPassword           = apperr.FieldInvalid("weak_password", "password", "password must contain at least 8 characters")
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid login or password")
+	ErrUnauthenticated    = apperr.Unauthorized("unauthenticated", "access token isn't provided")
+	ErrTokenInvalid       = apperr.Unauthorized("invalid_token", "token is invalid or expired")
+	ErrForbidden          = apperr.Forbidden("forbidden", "operation isn't allowed for user role")
+)
+
+const PasswordMinLength = 8
+
+// Role of user. Stored as int, in API and tokens represented by name
+type Role int
+
+const (
+	// manages users and content
+	RoleAdmin Role = 1
+	// manages content (items, images, brands, categories)
+	RoleEditor Role = 2
+	// read-only access to admin UI
+	RoleViewer Role = 3
+)
+
+var roleNames = map[Role]string{
+	RoleAdmin:  "admin",
+	RoleEditor: "editor",
+	RoleViewer: "viewer",
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleNames[r]
+	return ok
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role, nil
+		}
+	}
+
+	return 0, ErrRole
+}
+
+// User model table users
+type User struct {
+	ID           int
+	Login        string
+	PasswordHash string
+	Role         Role
+	CreatedAt    time.Time
+}
+
+type UserCreate struct {
+	Login    string
+	Password string
+	Role     Role
+}
+
+// Authenticated user, taken from access token
+type Claims struct {
+	UserId int
+	Role   Role
+}
+
+// Access token is short-lived and isn't stored, refresh token is stored as hash and used once
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
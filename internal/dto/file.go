@@ -5,3 +5,9 @@ type FileDTO struct {
 	ContentType string
 	Buffer      []byte
 }
+
+type FileInfo struct {
+	ID          string
+	ContentType string
+	Size        int64
+}
@@ -0,0 +1,79 @@
+package facade
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry adomain.Entry) error
+}
+
+type AuditFacade struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	auditRepo AuditRepository
+}
+
+func NewAuditFacade(db *postgresql.Storage, logger *slog.Logger, auditr AuditRepository) *AuditFacade {
+	return &AuditFacade{
+		db:        db.DB,
+		logger:    logger,
+		auditRepo: auditr,
+	}
+}
+
+// Run change and record its audit entry in one transaction, so change isn't stored without entry.
+// Actor and request id are taken from context
+func (a *AuditFacade) Record(ctx context.Context, change func(ctx context.Context) (adomain.Change, error)) error {
+	return postgresql.WrapTx(ctx, a.db, func(ctx context.Context) error {
+		changed, err := change(ctx)
+		if err != nil {
+			return err
+		}
+
+		entry, err := a.entry(ctx, changed)
+		if err != nil {
+			return err
+		}
+
+		return a.auditRepo.Create(ctx, entry)
+	})
+}
+
+func (a *AuditFacade) entry(ctx context.Context, change adomain.Change) (adomain.Entry, error) {
+	actor, _ := adomain.ActorFromContext(ctx)
+
+	entry := adomain.Entry{
+		Actor:      actor,
+		Action:     change.Action,
+		EntityType: change.EntityType,
+		EntityId:   change.EntityId,
+		RequestId:  adomain.RequestIdFromContext(ctx),
+	}
+
+	var err error
+	if change.Before != nil {
+		entry.Before, err = json.Marshal(change.Before)
+		if err != nil {
+			a.logger.Error("failed marshal audit entry", sl.Err(err))
+
+			return entry, err
+		}
+	}
+	if change.After != nil {
+		entry.After, err = json.Marshal(change.After)
+		if err != nil {
+			a.logger.Error("failed marshal audit entry", sl.Err(err))
+
+			return entry, err
+		}
+	}
+
+	return entry, nil
+}
@@ -3,13 +3,15 @@ package facade
 import (
 	bdomain "cloth-mini-app/internal/domain/brand"
 	edomain "cloth-mini-app/internal/domain/event"
+	imdomain "cloth-mini-app/internal/domain/image"
 	idomain "cloth-mini-app/internal/domain/item"
+	sl "cloth-mini-app/internal/logger"
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"log/slog"
+	"time"
 )
 
 type OutboxRepository interface {
@@ -18,6 +20,22 @@ type OutboxRepository interface {
 
 type ItemImageRepository interface {
 	Create(ctx context.Context, item idomain.ItemCreate) (uint, error)
+	// Replace images of item with provided set
+	SetImages(ctx context.Context, itemId int, imageIds []string, meta map[string]imdomain.ImageMeta) error
+}
+
+type ItemRepository interface {
+	Update(ctx context.Context, data idomain.ItemUpdate) error
+	SetStatus(ctx context.Context, status idomain.ItemStatusUpdate) error
+	// Publish scheduled items which publish time has come and return their ids
+	PublishScheduled(ctx context.Context, now time.Time) ([]int, error)
+	// Archive published items which unpublish time has come and return their ids
+	ArchiveExpired(ctx context.Context, now time.Time) ([]int, error)
+}
+
+type RevisionRepository interface {
+	// Store current state of item as revision of its current version
+	Create(ctx context.Context, itemId int, restoredFrom *int) error
 }
 
 type BrandRepositury interface {
@@ -30,15 +48,19 @@ type OutboxFacade struct {
 	outboxRepo    OutboxRepository
 	itemImageRepo ItemImageRepository
 	brandRepo     BrandRepositury
+	itemRepo      ItemRepository
+	revisionRepo  RevisionRepository
 }
 
-func NewOutboxFacade(db *postgresql.Storage, logger *slog.Logger, outboxr OutboxRepository, itimr ItemImageRepository, br BrandRepositury) *OutboxFacade {
+func NewOutboxFacade(db *postgresql.Storage, logger *slog.Logger, outboxr OutboxRepository, itimr ItemImageRepository, br BrandRepositury, ir ItemRepository, rr RevisionRepository) *OutboxFacade {
 	return &OutboxFacade{
 		db:            db.DB,
 		logger:        logger,
 		outboxRepo:    outboxr,
 		itemImageRepo: itimr,
 		brandRepo:     br,
+		itemRepo:      ir,
+		revisionRepo:  rr,
 	}
 }
 
@@ -49,14 +71,16 @@ type createItemEventPayload struct {
 	Price     uint   `json:"price"`
 }
 
-func (o *OutboxFacade) CreateItemWithNotification(ctx context.Context, item idomain.ItemCreate) error {
+// Create item with creation event in one transaction and return item id
+func (o *OutboxFacade) CreateItemWithNotification(ctx context.Context, item idomain.ItemCreate) (uint, error) {
 	brand, err := o.brandRepo.GetBrand(ctx, item.BrandId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	var itemId uint
 	err = postgresql.WrapTx(ctx, o.db, func(ctx context.Context) error {
-		itemId, err := o.itemImageRepo.Create(ctx, item)
+		itemId, err = o.itemImageRepo.Create(ctx, item)
 		if err != nil {
 			return err
 		}
@@ -82,8 +106,156 @@ func (o *OutboxFacade) CreateItemWithNotification(ctx context.Context, item idom
 		return nil
 	})
 	if err != nil {
-		fmt.Println(err)
-		return err
+		o.logger.Error("failed create item with notification", sl.Err(err))
+
+		return 0, err
+	}
+
+	return itemId, nil
+}
+
+type rollbackItemEventPayload struct {
+	ItemId       int `json:"item_id"`
+	RestoredFrom int `json:"restored_from"`
+}
+
+// Bring item fields and images back to revision with event in one transaction.
+// version is current version of item, so concurrent change isn't overwritten
+func (o *OutboxFacade) RollbackItemWithNotification(ctx context.Context, revision idomain.Revision, version int) error {
+	return postgresql.WrapTx(ctx, o.db, func(ctx context.Context) error {
+		err := o.itemRepo.Update(ctx, revision.Snapshot.ItemUpdate(revision.ItemId, version))
+		if err != nil {
+			return err
+		}
+
+		err = o.itemImageRepo.SetImages(ctx, revision.ItemId, revision.Snapshot.Images, revision.Snapshot.ImageMeta)
+		if err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(rollbackItemEventPayload{
+			ItemId:       revision.ItemId,
+			RestoredFrom: revision.Version,
+		})
+		if err != nil {
+			return err
+		}
+
+		return o.outboxRepo.CreateEvent(ctx, edomain.Event{
+			EventType: edomain.EventRollbackItem,
+			Payload:   payload,
+		})
+	})
+}
+
+type itemStatusEventPayload struct {
+	ItemId int       `json:"item_id"`
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// Change item status with revision and event in one transaction. Event is created if item is published or archived
+func (o *OutboxFacade) SetItemStatusWithNotification(ctx context.Context, status idomain.ItemStatusUpdate, prev idomain.Status) error {
+	return postgresql.WrapTx(ctx, o.db, func(ctx context.Context) error {
+		err := o.itemRepo.SetStatus(ctx, status)
+		if err != nil {
+			return err
+		}
+
+		err = o.revisionRepo.Create(ctx, status.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		if status.Status == prev {
+			return nil
+		}
+
+		return o.createStatusEvents(ctx, []int{status.ID}, status.Status, time.Now())
+	})
+}
+
+// Publish scheduled and archive expired items with revisions and events in one transaction.
+// Returns number of published and archived items
+func (o *OutboxFacade) PublishScheduledItems(ctx context.Context, now time.Time) (int, int, error) {
+	var published, archived []int
+	err := postgresql.WrapTx(ctx, o.db, func(ctx context.Context) error {
+		var err error
+		published, err = o.itemRepo.PublishScheduled(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		err = o.createRevisions(ctx, published)
+		if err != nil {
+			return err
+		}
+
+		err = o.createStatusEvents(ctx, published, idomain.StatusPublished, now)
+		if err != nil {
+			return err
+		}
+
+		archived, err = o.itemRepo.ArchiveExpired(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		err = o.createRevisions(ctx, archived)
+		if err != nil {
+			return err
+		}
+
+		return o.createStatusEvents(ctx, archived, idomain.StatusArchived, now)
+	})
+	if err != nil {
+		o.logger.Error("failed publish scheduled items", sl.Err(err))
+
+		return 0, 0, err
+	}
+
+	return len(published), len(archived), nil
+}
+
+// Status change is a new version of item, so it gets its revision
+func (o *OutboxFacade) createRevisions(ctx context.Context, itemIds []int) error {
+	for _, itemId := range itemIds {
+		if err := o.revisionRepo.Create(ctx, itemId, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *OutboxFacade) createStatusEvents(ctx context.Context, itemIds []int, status idomain.Status, at time.Time) error {
+	var eventType string
+	switch status {
+	case idomain.StatusPublished:
+		eventType = edomain.EventPublishItem
+	case idomain.StatusArchived:
+		eventType = edomain.EventArchiveItem
+	default:
+		return nil
+	}
+
+	for _, itemId := range itemIds {
+		payload, err := json.Marshal(itemStatusEventPayload{
+			ItemId: itemId,
+			Status: status.String(),
+			At:     at,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = o.outboxRepo.CreateEvent(ctx, edomain.Event{
+			EventType: eventType,
+			Payload:   payload,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,146 @@
+// Package linkcheck checks outer links of items with limited concurrency per host,
+// so shops don't get many requests at once
+package linkcheck
+
+import (
+	domain "cloth-mini-app/internal/domain/linkcheck"
+	"cloth-mini-app/internal/safehttp"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Body of GET response is read only partly, connection is reused anyway
+const bodyLimit = 64 << 10
+
+type Config struct {
+	// requests at once to all hosts
+	Concurrency int
+	// requests at once to one host
+	PerHost int
+	// timeout of one request with redirects
+	Timeout   time.Duration
+	UserAgent string
+	// links to private addresses are checked too, only for development and tests
+	AllowPrivate bool
+}
+
+type Checker struct {
+	client *http.Client
+	config Config
+	now    func() time.Time
+}
+
+func NewChecker(config Config) *Checker {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.PerHost <= 0 {
+		config.PerHost = 1
+	}
+
+	return &Checker{
+		// links are provided by editors and partners, so internal services aren't reachable by them
+		client: safehttp.NewClient(config.Timeout, config.AllowPrivate),
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Check links and return results in the same order
+func (c *Checker) Check(ctx context.Context, links []domain.Link) []domain.Result {
+	results := make([]domain.Result, len(links))
+
+	workers := make(chan struct{}, c.config.Concurrency)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		hosts = make(map[string]chan struct{})
+	)
+	hostSlot := func(host string) chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+
+		slot, ok := hosts[host]
+		if !ok {
+			slot = make(chan struct{}, c.config.PerHost)
+			hosts[host] = slot
+		}
+
+		return slot
+	}
+
+	for idx, link := range links {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			host := ""
+			if parsed, err := url.Parse(link.URL); err == nil {
+				host = strings.ToLower(parsed.Hostname())
+			}
+			slot := hostSlot(host)
+
+			// host slot is taken first, so workers aren't held by requests waiting for busy host
+			slot <- struct{}{}
+			workers <- struct{}{}
+			results[idx] = c.checkLink(ctx, link)
+			<-workers
+			<-slot
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// HEAD request is sent first, some shops don't support it, so failed HEAD is repeated with GET
+func (c *Checker) checkLink(ctx context.Context, link domain.Link) domain.Result {
+	result := c.request(ctx, http.MethodHead, link)
+	if !result.OK() && ctx.Err() == nil {
+		result = c.request(ctx, http.MethodGet, link)
+	}
+
+	return result
+}
+
+func (c *Checker) request(ctx context.Context, method string, link domain.Link) domain.Result {
+	result := domain.Result{
+		ItemId:    link.ItemId,
+		URL:       link.URL,
+		CheckedAt: c.now(),
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, link.URL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if c.config.UserAgent != "" {
+		request.Header.Set("User-Agent", c.config.UserAgent)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		result.Error = err.Error()
+		return result
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, bodyLimit))
+
+	result.StatusCode = response.StatusCode
+	if final := response.Request.URL.String(); final != link.URL {
+		result.RedirectURL = final
+	}
+
+	return result
+}
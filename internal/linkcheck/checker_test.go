@@ -0,0 +1,128 @@
+package linkcheck
+
+import (
+	domain "cloth-mini-app/internal/domain/linkcheck"
+	"cloth-mini-app/internal/safehttp"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newStub(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "linkcheck-test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("product page"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCheck(t *testing.T) {
+	server := newStub(t)
+	checker := NewChecker(Config{Concurrency: 2, PerHost: 2, Timeout: time.Second, UserAgent: "linkcheck-test", AllowPrivate: true})
+
+	results := checker.Check(context.Background(), []domain.Link{
+		{ItemId: 1, URL: server.URL + "/ok"},
+		{ItemId: 2, URL: server.URL + "/moved"},
+		{ItemId: 3, URL: server.URL + "/get-only"},
+		{ItemId: 4, URL: server.URL + "/missing"},
+		{ItemId: 5, URL: "http:/no-host"},
+	})
+
+	tests := []struct {
+		status   int
+		redirect string
+		ok       bool
+	}{
+		{status: http.StatusOK, ok: true},
+		{status: http.StatusOK, redirect: server.URL + "/ok", ok: true},
+		{status: http.StatusOK, ok: true},
+		{status: http.StatusNotFound},
+		{},
+	}
+	for idx, tt := range tests {
+		result := results[idx]
+		if result.ItemId != idx+1 {
+			t.Fatalf("result %d: expected item %d, got %d", idx, idx+1, result.ItemId)
+		}
+		if result.StatusCode != tt.status || result.RedirectURL != tt.redirect || result.OK() != tt.ok {
+			t.Fatalf("result %d: expected status %d, redirect %q, ok %t, got %+v", idx, tt.status, tt.redirect, tt.ok, result)
+		}
+	}
+	if results[4].Error == "" {
+		t.Fatalf("expected error of link without host")
+	}
+}
+
+func TestCheckLimitsRequestsPerHost(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		active    int
+		maxActive int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	checker := NewChecker(Config{Concurrency: 10, PerHost: 2, Timeout: time.Second, AllowPrivate: true})
+
+	links := make([]domain.Link, 8)
+	for idx := range links {
+		links[idx] = domain.Link{ItemId: idx, URL: server.URL}
+	}
+	for _, result := range checker.Check(context.Background(), links) {
+		if !result.OK() {
+			t.Fatalf("expected ok result, got %+v", result)
+		}
+	}
+
+	if maxActive != 2 {
+		t.Fatalf("expected 2 requests to host at once, got %d", maxActive)
+	}
+}
+
+func TestCheckRefusesPrivateAddresses(t *testing.T) {
+	server := newStub(t)
+	checker := NewChecker(Config{Concurrency: 1, PerHost: 1, Timeout: time.Second, UserAgent: "linkcheck-test"})
+
+	results := checker.Check(context.Background(), []domain.Link{
+		{ItemId: 1, URL: server.URL + "/ok"},
+		{ItemId: 2, URL: "http://169.254.169.254/latest/meta-data/"},
+	})
+	for _, result := range results {
+		if result.OK() || result.StatusCode != 0 || !strings.Contains(result.Error, safehttp.ErrForbiddenAddress.Error()) {
+			t.Fatalf("expected refused request, got %+v", result)
+		}
+	}
+}
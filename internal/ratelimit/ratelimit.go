@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// buckets unused longer than this (and refilled by then) are removed
+const idleTTL = 10 * time.Minute
+
+// Token bucket limit: Rate tokens per second are added to bucket holding up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Limit of n requests per minute, all of them can be sent at once
+func PerMinute(n int) Limit {
+	return Limit{
+		Rate:  float64(n) / time.Minute.Seconds(),
+		Burst: n,
+	}
+}
+
+// Limit with zero rate or burst doesn't limit anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	limit    Limit
+	lastSeen time.Time
+}
+
+// Token buckets by key, e.g. client ip or api key id. Limits are kept in memory of instance
+type Keyed struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	// replaced in tests
+	now func() time.Time
+}
+
+func NewKeyed() *Keyed {
+	return &Keyed{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take token from bucket of key. If there is no token, nothing is taken and
+// delay after which token will be available is returned
+func (k *Keyed) Reserve(key string, limit Limit) time.Duration {
+	if !limit.Enabled() {
+		return 0
+	}
+
+	now := k.now()
+
+	k.mu.Lock()
+	k.sweep(now)
+	b, ok := k.buckets[key]
+	// limit could be changed, e.g. of api key
+	if !ok || b.limit != limit {
+		b = &bucket{
+			limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst),
+			limit:   limit,
+		}
+		k.buckets[key] = b
+	}
+	b.lastSeen = now
+	k.mu.Unlock()
+
+	reservation := b.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// rejected request doesn't spend token
+		reservation.CancelAt(now)
+	}
+
+	return delay
+}
+
+// Remove bucket of key, e.g. of revoked api key
+func (k *Keyed) Delete(key string) {
+	k.mu.Lock()
+	delete(k.buckets, key)
+	k.mu.Unlock()
+}
+
+// Remove idle buckets, so map doesn't grow with every client seen. Called under lock
+func (k *Keyed) sweep(now time.Time) {
+	if now.Sub(k.lastSweep) < time.Minute {
+		return
+	}
+	k.lastSweep = now
+
+	for key, b := range k.buckets {
+		refill := time.Duration(float64(b.limit.Burst) / b.limit.Rate * float64(time.Second))
+		if now.Sub(b.lastSeen) > max(idleTTL, refill) {
+			delete(k.buckets, key)
+		}
+	}
+}
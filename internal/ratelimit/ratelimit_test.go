@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestKeyed() (*Keyed, *clock) {
+	c := &clock{now: time.Date(2025, 5, 18, 12, 0, 0, 0, time.UTC)}
+	k := NewKeyed()
+	k.now = c.Now
+
+	return k, c
+}
+
+func TestReserveBurstAndRefill(t *testing.T) {
+	k, c := newTestKeyed()
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i := range 2 {
+		if delay := k.Reserve("client", limit); delay != 0 {
+			t.Fatalf("request %d: expected to be allowed, got delay %s", i, delay)
+		}
+	}
+
+	delay := k.Reserve("client", limit)
+	if delay != time.Second {
+		t.Fatalf("expected delay 1s after burst, got %s", delay)
+	}
+
+	// rejected request doesn't spend token, so it's available after delay
+	c.now = c.now.Add(delay)
+	if delay := k.Reserve("client", limit); delay != 0 {
+		t.Fatalf("expected to be allowed after refill, got delay %s", delay)
+	}
+}
+
+func TestReserveKeysAreIndependent(t *testing.T) {
+	k, _ := newTestKeyed()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if delay := k.Reserve("first", limit); delay != 0 {
+		t.Fatalf("expected first key to be allowed, got delay %s", delay)
+	}
+	if delay := k.Reserve("second", limit); delay != 0 {
+		t.Fatalf("expected second key to be allowed, got delay %s", delay)
+	}
+	if delay := k.Reserve("first", limit); delay == 0 {
+		t.Fatal("expected first key to be limited")
+	}
+}
+
+func TestReserveLimitChange(t *testing.T) {
+	k, _ := newTestKeyed()
+
+	k.Reserve("key", PerMinute(1))
+	if delay := k.Reserve("key", PerMinute(1)); delay == 0 {
+		t.Fatal("expected key to be limited")
+	}
+
+	// raised limit is applied immediately
+	if delay := k.Reserve("key", PerMinute(10)); delay != 0 {
+		t.Fatalf("expected to be allowed with new limit, got delay %s", delay)
+	}
+}
+
+func TestReserveDisabled(t *testing.T) {
+	k, _ := newTestKeyed()
+
+	for range 100 {
+		if delay := k.Reserve("client", Limit{}); delay != 0 {
+			t.Fatalf("expected disabled limit to allow request, got delay %s", delay)
+		}
+	}
+}
+
+func TestSweepIdleBuckets(t *testing.T) {
+	k, c := newTestKeyed()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	k.Reserve("idle", limit)
+	c.now = c.now.Add(idleTTL + time.Minute)
+	k.Reserve("active", limit)
+
+	if _, ok := k.buckets["idle"]; ok {
+		t.Fatal("expected idle bucket to be removed")
+	}
+	if _, ok := k.buckets["active"]; !ok {
+		t.Fatal("expected active bucket to be kept")
+	}
+}
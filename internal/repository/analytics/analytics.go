@@ -0,0 +1,256 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/analytics"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Daily aggregates are rebuilt from the day before the last aggregated day. The day being filled is recounted
+// on every rollup, and events of previous day flushed after midnight are counted by the next rollup
+const (
+	rollupViews = `INSERT INTO item_view_days (day, item_id, views)
+		SELECT (v.created_at AT TIME ZONE 'UTC')::date AS day, v.item_id, count(*)
+		FROM item_views v
+		JOIN items i ON i.id = v.item_id
+		WHERE v.created_at >= COALESCE((SELECT max(day) - 1 FROM item_view_days)::timestamp AT TIME ZONE 'UTC', '-infinity')
+		GROUP BY day, v.item_id
+		ON CONFLICT (day, item_id) DO UPDATE SET views = EXCLUDED.views`
+	rollupSearches = `INSERT INTO search_query_days (day, query, searches, zero_results)
+		SELECT (created_at AT TIME ZONE 'UTC')::date AS day, query, count(*), count(*) FILTER (WHERE results = 0)
+		FROM search_queries
+		WHERE created_at >= COALESCE((SELECT max(day) - 1 FROM search_query_days)::timestamp AT TIME ZONE 'UTC', '-infinity')
+		GROUP BY day, query
+		ON CONFLICT (day, query) DO UPDATE SET searches = EXCLUDED.searches, zero_results = EXCLUDED.zero_results`
+)
+
+type AnalyticsRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAnalyticsRepository(logger *slog.Logger, db *postgresql.Storage) *AnalyticsRepository {
+	return &AnalyticsRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Append batch of events
+func (a *AnalyticsRepository) SaveEvents(ctx context.Context, views []domain.View, searches []domain.Search) error {
+	const op = "repository.analytics.SaveEvents"
+
+	var queries []squirrel.InsertBuilder
+	if len(views) > 0 {
+		q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Insert("item_views").
+			Columns("item_id", "created_at")
+		for _, view := range views {
+			q = q.Values(view.ItemId, view.CreatedAt)
+		}
+		queries = append(queries, q)
+	}
+	if len(searches) > 0 {
+		q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Insert("search_queries").
+			Columns("query", "results", "created_at")
+		for _, search := range searches {
+			q = q.Values(search.Query, search.Results, search.CreatedAt)
+		}
+		queries = append(queries, q)
+	}
+
+	return postgresql.WrapTx(ctx, a.db, func(ctx context.Context) error {
+		for _, q := range queries {
+			sql, args, err := q.ToSql()
+			if err != nil {
+				a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+				return err
+			}
+
+			_, err = postgresql.Conn(ctx, a.db).ExecContext(ctx, sql, args...)
+			if err != nil {
+				a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Aggregate events into daily stats
+func (a *AnalyticsRepository) Rollup(ctx context.Context) error {
+	const op = "repository.analytics.Rollup"
+
+	return postgresql.WrapTx(ctx, a.db, func(ctx context.Context) error {
+		for _, sql := range []string{rollupViews, rollupSearches} {
+			_, err := postgresql.Conn(ctx, a.db).ExecContext(ctx, sql)
+			if err != nil {
+				a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Delete events created before provided time, daily stats are kept
+func (a *AnalyticsRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "repository.analytics.DeleteEventsBefore"
+
+	var deleted int64
+	for _, table := range []string{"item_views", "search_queries"} {
+		sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Delete(table).
+			Where("created_at < ?", before).
+			ToSql()
+		if err != nil {
+			a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return 0, err
+		}
+
+		res, err := postgresql.Conn(ctx, a.db).ExecContext(ctx, sql, args...)
+		if err != nil {
+			a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return 0, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			a.logger.Error(op, sl.Err(err))
+
+			return 0, err
+		}
+		deleted += affected
+	}
+
+	return deleted, nil
+}
+
+// Get items with most views since provided day
+func (a *AnalyticsRepository) GetPopular(ctx context.Context, from time.Time, limit uint64) ([]domain.ItemStats, error) {
+	const op = "repository.analytics.GetPopular"
+
+	q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("d.item_id", "i.name", "sum(d.views)", "0", "0").
+		From("item_view_days d").
+		Join("items i ON i.id = d.item_id").
+		Where("d.day >= ?::date AND i.deleted_at IS NULL", from).
+		GroupBy("d.item_id", "i.name").
+		OrderBy("sum(d.views) DESC", "d.item_id").
+		Limit(limit)
+
+	return a.queryItemStats(ctx, op, q)
+}
+
+// Get items which views grow fastest: views since recentFrom are compared with views of the same
+// number of days before it. Velocity is growth of views per day, only growing items are returned
+func (a *AnalyticsRepository) GetTrending(ctx context.Context, recentFrom time.Time, days int, limit uint64) ([]domain.ItemStats, error) {
+	const op = "repository.analytics.GetTrending"
+
+	recent := "COALESCE(sum(d.views) FILTER (WHERE d.day >= ?::date), 0)"
+	previous := "COALESCE(sum(d.views) FILTER (WHERE d.day < ?::date), 0)"
+
+	q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("d.item_id", "i.name").
+		Column(recent, recentFrom).
+		Column(previous, recentFrom).
+		Column(fmt.Sprintf("(%s - %s)::float / ?", recent, previous), recentFrom, recentFrom, days).
+		From("item_view_days d").
+		Join("items i ON i.id = d.item_id").
+		Where("d.day >= ?::date AND i.deleted_at IS NULL", recentFrom.AddDate(0, 0, -days)).
+		GroupBy("d.item_id", "i.name").
+		Having(fmt.Sprintf("%s > %s", recent, previous), recentFrom, recentFrom).
+		OrderBy("5 DESC", "3 DESC", "d.item_id").
+		Limit(limit)
+
+	return a.queryItemStats(ctx, op, q)
+}
+
+func (a *AnalyticsRepository) queryItemStats(ctx context.Context, op string, q squirrel.SelectBuilder) ([]domain.ItemStats, error) {
+	sql, args, err := q.ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, a.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.ItemStats
+	for rows.Next() {
+		var item domain.ItemStats
+		if err := rows.Scan(&item.ItemId, &item.Name, &item.Views, &item.PrevViews, &item.Velocity); err != nil {
+			a.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		stats = append(stats, item)
+	}
+
+	return stats, rows.Err()
+}
+
+// Get most frequent search queries since provided day
+func (a *AnalyticsRepository) GetTopQueries(ctx context.Context, from time.Time, zeroOnly bool, limit uint64) ([]domain.QueryStats, error) {
+	const op = "repository.analytics.GetTopQueries"
+
+	q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("query", "sum(searches)", "sum(zero_results)").
+		From("search_query_days").
+		Where("day >= ?::date", from).
+		GroupBy("query").
+		OrderBy("sum(searches) DESC", "query").
+		Limit(limit)
+	if zeroOnly {
+		q = q.Having("sum(zero_results) > 0")
+	}
+
+	sql, args, err := q.ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, a.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.QueryStats
+	for rows.Next() {
+		var query domain.QueryStats
+		if err := rows.Scan(&query.Query, &query.Searches, &query.ZeroResults); err != nil {
+			a.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		stats = append(stats, query)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,325 @@
+package apikey
+
+import (
+	domain "cloth-mini-app/internal/domain/apikey"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+var (
+	apiKeyColumns = []string{"id", "prefix", "name", "scopes", "rate_limit", "daily_quota", "created_at", "revoked_at"}
+
+	// sql package is shadowed by query variables
+	errNoRows = sql.ErrNoRows
+)
+
+type APIKeyRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAPIKeyRepository(logger *slog.Logger, db *postgresql.Storage) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (domain.APIKey, error) {
+	var (
+		key    domain.APIKey
+		scopes []string
+	)
+	err := row.Scan(&key.ID, &key.Prefix, &key.Name, pq.Array(&scopes), &key.RateLimit, &key.DailyQuota, &key.CreatedAt, &key.RevokedAt)
+	if err != nil {
+		return key, err
+	}
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, domain.Scope(scope))
+	}
+
+	return key, nil
+}
+
+func (a *APIKeyRepository) GetAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	const op = "repository.apikey.GetAPIKeys"
+
+	sql, _, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(apiKeyColumns...).
+		From("api_key").
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := a.db.QueryContext(ctx, sql)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			a.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (a *APIKeyRepository) GetAPIKey(ctx context.Context, keyId int) (domain.APIKey, error) {
+	return a.getAPIKey(ctx, "repository.apikey.GetAPIKey", squirrel.Eq{"id": keyId})
+}
+
+// Get key by hash, revoked keys are returned too
+func (a *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	return a.getAPIKey(ctx, "repository.apikey.GetAPIKeyByHash", squirrel.Eq{"key_hash": keyHash})
+}
+
+func (a *APIKeyRepository) getAPIKey(ctx context.Context, op string, where squirrel.Eq) (domain.APIKey, error) {
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(apiKeyColumns...).
+		From("api_key").
+		Where(where).
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.APIKey{}, err
+	}
+
+	key, err := scanAPIKey(a.db.QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return key, domain.ErrAPIKeyNotFound
+		}
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return key, err
+	}
+
+	return key, nil
+}
+
+// Create key and return it with id
+func (a *APIKeyRepository) Create(ctx context.Context, key domain.APIKey, keyHash string) (domain.APIKey, error) {
+	const op = "repository.apikey.Create"
+
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("api_key").
+		Columns("name", "prefix", "key_hash", "scopes", "rate_limit", "daily_quota").
+		Values(key.Name, key.Prefix, keyHash, pq.Array(scopes), key.RateLimit, key.DailyQuota).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return key, err
+	}
+
+	err = a.db.QueryRowContext(ctx, sql, args...).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return key, err
+	}
+
+	return key, nil
+}
+
+// Replace key of active api key, previous key stops working immediately
+func (a *APIKeyRepository) Rotate(ctx context.Context, keyId int, prefix, keyHash string) error {
+	const op = "repository.apikey.Rotate"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("api_key").
+		Set("prefix", prefix).
+		Set("key_hash", keyHash).
+		Where("id = ? AND revoked_at IS NULL", keyId).
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	result, err := a.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// key doesn't exist or is revoked
+		if _, err := a.GetAPIKey(ctx, keyId); err != nil {
+			return err
+		}
+		return domain.ErrAPIKeyRevoked
+	}
+
+	return nil
+}
+
+// Revoke key. Revoking of revoked key does nothing
+func (a *APIKeyRepository) Revoke(ctx context.Context, keyId int) error {
+	const op = "repository.apikey.Revoke"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("api_key").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where("id = ? AND revoked_at IS NULL", keyId).
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	result, err := a.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		_, err := a.GetAPIKey(ctx, keyId)
+		return err
+	}
+
+	return nil
+}
+
+// Count request of key if daily quota isn't reached. Counter is checked and increased by one statement,
+// so concurrent requests can't exceed quota. Returns false if quota is reached
+func (a *APIKeyRepository) ConsumeQuota(ctx context.Context, keyId int, day time.Time, quota int) (bool, error) {
+	const op = "repository.apikey.ConsumeQuota"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("api_key_usage AS u").
+		Columns("api_key_id", "day", "requests").
+		Values(keyId, day, 1).
+		Suffix("ON CONFLICT (api_key_id, day) DO UPDATE SET requests = u.requests + 1 WHERE u.requests < ? RETURNING requests", quota).
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return false, err
+	}
+
+	var requests int
+	err = a.db.QueryRowContext(ctx, sql, args...).Scan(&requests)
+	if err != nil {
+		// row isn't updated, quota is reached
+		if errors.Is(err, errNoRows) {
+			return false, nil
+		}
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Count rejected request of key
+func (a *APIKeyRepository) AddRejected(ctx context.Context, keyId int, day time.Time) error {
+	const op = "repository.apikey.AddRejected"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("api_key_usage AS u").
+		Columns("api_key_id", "day", "rejected").
+		Values(keyId, day, 1).
+		Suffix("ON CONFLICT (api_key_id, day) DO UPDATE SET rejected = u.rejected + 1").
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = a.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Get usage of key since day, latest days first
+func (a *APIKeyRepository) GetUsage(ctx context.Context, keyId int, since time.Time) ([]domain.Usage, error) {
+	const op = "repository.apikey.GetUsage"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("day", "requests", "rejected").
+		From("api_key_usage").
+		Where("api_key_id = ? AND day >= ?", keyId, since).
+		OrderBy("day DESC").
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := a.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []domain.Usage
+	for rows.Next() {
+		var day domain.Usage
+		if err := rows.Scan(&day.Day, &day.Requests, &day.Rejected); err != nil {
+			a.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		usage = append(usage, day)
+	}
+
+	return usage, rows.Err()
+}
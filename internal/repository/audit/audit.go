@@ -0,0 +1,179 @@
+package audit
+
+import (
+	domain "cloth-mini-app/internal/domain/audit"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type AuditRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAuditRepository(logger *slog.Logger, db *postgresql.Storage) *AuditRepository {
+	return &AuditRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Store entry. Entry is a part of transaction from context, so it's stored only with change
+func (a *AuditRepository) Create(ctx context.Context, entry domain.Entry) error {
+	const op = "repository.audit.Create"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("audit_log").
+		Columns("user_id", "api_key_id", "action", "entity_type", "entity_id", "before", "after", "request_id").
+		Values(nullId(entry.Actor.UserId), nullId(entry.Actor.APIKeyId), entry.Action, entry.EntityType, entry.EntityId, nullJSON(entry.Before), nullJSON(entry.After), entry.RequestId).
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, a.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Get id of last entry of entity types, 0 if there are no entries
+func (a *AuditRepository) LastEntryId(ctx context.Context, entityTypes ...domain.EntityType) (int64, error) {
+	const op = "repository.audit.LastEntryId"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("COALESCE(max(id), 0)").
+		From("audit_log").
+		Where(squirrel.Eq{"entity_type": entityTypes}).
+		ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var id int64
+	err = postgresql.Conn(ctx, a.db).QueryRowContext(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Get entries by filter, latest first
+func (a *AuditRepository) GetEntries(ctx context.Context, filter domain.Filter) ([]domain.Entry, error) {
+	const op = "repository.audit.GetEntries"
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("a.id", "a.user_id", "a.api_key_id", "COALESCE(u.login, k.name, '')", "a.action", "a.entity_type", "a.entity_id", "a.before", "a.after", "a.request_id", "a.created_at").
+		From("audit_log a").
+		LeftJoin("users u ON u.id = a.user_id").
+		LeftJoin("api_key k ON k.id = a.api_key_id").
+		Where(a.filterEntries(filter)).
+		OrderBy("a.created_at DESC", "a.id DESC").
+		Limit(filter.Limit)
+	if filter.Offset != 0 {
+		psql = psql.Offset(filter.Offset)
+	}
+
+	sql, args, err := psql.ToSql()
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := a.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.Entry
+	for rows.Next() {
+		var (
+			entry         domain.Entry
+			userId, keyId *int
+			before, after []byte
+		)
+		err := rows.Scan(&entry.ID, &userId, &keyId, &entry.ActorName, &entry.Action, &entry.EntityType, &entry.EntityId, &before, &after, &entry.RequestId, &entry.CreatedAt)
+		if err != nil {
+			a.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		if userId != nil {
+			entry.Actor.UserId = *userId
+		}
+		if keyId != nil {
+			entry.Actor.APIKeyId = *keyId
+		}
+		entry.Before = before
+		entry.After = after
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (a *AuditRepository) filterEntries(filter domain.Filter) squirrel.And {
+	where := squirrel.And{}
+
+	if filter.UserId != nil {
+		where = append(where, squirrel.Eq{"a.user_id": *filter.UserId})
+	}
+	if filter.APIKeyId != nil {
+		where = append(where, squirrel.Eq{"a.api_key_id": *filter.APIKeyId})
+	}
+	if filter.Action != "" {
+		where = append(where, squirrel.Eq{"a.action": filter.Action})
+	}
+	if filter.EntityType != "" {
+		where = append(where, squirrel.Eq{"a.entity_type": filter.EntityType})
+	}
+	if filter.EntityId != "" {
+		where = append(where, squirrel.Eq{"a.entity_id": filter.EntityId})
+	}
+	if filter.From != nil {
+		where = append(where, squirrel.GtOrEq{"a.created_at": *filter.From})
+	}
+	if filter.To != nil {
+		where = append(where, squirrel.Lt{"a.created_at": *filter.To})
+	}
+
+	return where
+}
+
+func nullId(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
+
+func nullJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
@@ -6,12 +6,20 @@ import (
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/Masterminds/squirrel"
 )
 
+var (
+	brandColumns = []string{"id", "name", "slug", "description", "country", "logo_id"}
+
+	// sql package is shadowed by query variables
+	errNoRows = sql.ErrNoRows
+)
+
 type BrandRepository struct {
 	db     *sql.DB
 	logger *slog.Logger
@@ -29,7 +37,7 @@ func (b *BrandRepository) GetBrands(ctx context.Context) ([]domain.Brand, error)
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
-	sql, _, err := psql.Select("id", "name").From("Brand").ToSql()
+	sql, _, err := psql.Select(brandColumns...).From("Brand").OrderBy("id").ToSql()
 	if err != nil {
 		b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
 	}
@@ -45,7 +53,7 @@ func (b *BrandRepository) GetBrands(ctx context.Context) ([]domain.Brand, error)
 	var brands []domain.Brand
 	for rows.Next() {
 		var brand domain.Brand
-		if err := rows.Scan(&brand.ID, &brand.Name); err != nil {
+		if err := rows.Scan(&brand.ID, &brand.Name, &brand.Slug, &brand.Description, &brand.Country, &brand.LogoId); err != nil {
 			b.logger.Error(op, sl.Err(err))
 
 			return nil, err
@@ -60,7 +68,7 @@ func (b *BrandRepository) GetBrand(ctx context.Context, brandId int) (domain.Bra
 	const op = "repository.Brand.GetBrand"
 
 	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
-		Select("id", "name").
+		Select(brandColumns...).
 		From("Brand").
 		Where("id = ?", brandId).
 		ToSql()
@@ -69,8 +77,11 @@ func (b *BrandRepository) GetBrand(ctx context.Context, brandId int) (domain.Bra
 	}
 
 	var brand domain.Brand
-	err = b.db.QueryRow(sql, args...).Scan(&brand.ID, &brand.Name)
+	err = postgresql.Conn(ctx, b.db).QueryRowContext(ctx, sql, args...).Scan(&brand.ID, &brand.Name, &brand.Slug, &brand.Description, &brand.Country, &brand.LogoId)
 	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return brand, domain.ErrBrandNotFound
+		}
 		b.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
 
 		return brand, err
@@ -78,3 +89,290 @@ func (b *BrandRepository) GetBrand(ctx context.Context, brandId int) (domain.Bra
 
 	return brand, nil
 }
+
+// Get brand with amount of related items
+func (b *BrandRepository) GetBrandWithItemsCount(ctx context.Context, brandId int) (domain.Brand, error) {
+	const op = "repository.Brand.GetBrandWithItemsCount"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("b.id", "b.name", "b.slug", "b.description", "b.country", "b.logo_id", "count(i.id)").
+		From("brand b").
+		LeftJoin("items i on i.brand_id = b.id AND i.deleted_at IS NULL").
+		Where("b.id = ?", brandId).
+		GroupBy("b.id").
+		ToSql()
+	if err != nil {
+		b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Brand{}, err
+	}
+
+	var brand domain.Brand
+	err = b.db.QueryRow(sql, args...).Scan(
+		&brand.ID,
+		&brand.Name,
+		&brand.Slug,
+		&brand.Description,
+		&brand.Country,
+		&brand.LogoId,
+		&brand.ItemsCount,
+	)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return brand, domain.ErrBrandNotFound
+		}
+		b.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return brand, err
+	}
+
+	return brand, nil
+}
+
+// Create brand and return its id
+func (b *BrandRepository) Create(ctx context.Context, brand domain.BrandCreate) (int, error) {
+	const op = "repository.Brand.Create"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("brand").
+		Columns("name", "slug", "description", "country").
+		Values(brand.Name, brand.Slug, brand.Description, brand.Country).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var brandId int
+	err = postgresql.Conn(ctx, b.db).QueryRowContext(ctx, sql, args...).Scan(&brandId)
+	if err != nil {
+		if postgresql.IsDuplicateKeyError(err) {
+			return 0, domain.ErrBrandExists
+		}
+		b.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return brandId, nil
+}
+
+// Update brand fields that are not nil
+func (b *BrandRepository) Update(ctx context.Context, brand domain.BrandUpdate) error {
+	const op = "repository.Brand.Update"
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).Update("brand")
+
+	setState := b.updateSetStatements(brand)
+	if len(setState) == 0 {
+		return nil
+	}
+	for col, value := range setState {
+		psql = psql.Set(col, value)
+	}
+
+	sql, args, err := psql.Where("id = ?", brand.ID).ToSql()
+	if err != nil {
+		b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	result, err := postgresql.Conn(ctx, b.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		if postgresql.IsDuplicateKeyError(err) {
+			return domain.ErrBrandExists
+		}
+		b.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+// Prepare update set statements
+// field => value
+func (b *BrandRepository) updateSetStatements(brand domain.BrandUpdate) map[string]any {
+	data := make(map[string]any)
+
+	if brand.Name != nil {
+		data["name"] = *brand.Name
+	}
+	if brand.Slug != nil {
+		data["slug"] = *brand.Slug
+	}
+	if brand.Description != nil {
+		data["description"] = *brand.Description
+	}
+	if brand.Country != nil {
+		data["country"] = *brand.Country
+	}
+
+	return data
+}
+
+// Set brand logo and return previous logo id (nil if brand had no logo)
+func (b *BrandRepository) SetLogo(ctx context.Context, brandId int, logoId *string) (*string, error) {
+	const op = "repository.Brand.SetLogo"
+
+	var prevLogoId *string
+	err := postgresql.WrapTx(ctx, b.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			b.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+			return postgresql.ErrGetTransaction
+		}
+
+		query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select("logo_id").
+			From("brand").
+			Where("id = ?", brandId).
+			Suffix("for update").
+			ToSql()
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		err = tx.QueryRow(query, args...).Scan(&prevLogoId)
+		if err != nil {
+			if errors.Is(err, errNoRows) {
+				return domain.ErrBrandNotFound
+			}
+			b.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Update("brand").
+			Set("logo_id", logoId).
+			Where("id = ?", brandId).
+			ToSql()
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prevLogoId, nil
+}
+
+// Delete brand if there are no items of this brand.
+// items.brand_id is ON DELETE CASCADE, so without the check all brand items would be deleted.
+// Return deleted brand
+func (b *BrandRepository) Delete(ctx context.Context, brandId int) (domain.Brand, error) {
+	const op = "repository.Brand.Delete"
+
+	var brand domain.Brand
+	err := postgresql.WrapTx(ctx, b.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			b.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+			return postgresql.ErrGetTransaction
+		}
+
+		// lock conflicts with FK check of concurrent item insert, so item can't be added after count
+		query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select(brandColumns...).
+			From("brand").
+			Where("id = ?", brandId).
+			Suffix("for update").
+			ToSql()
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		err = tx.QueryRow(query, args...).Scan(&brand.ID, &brand.Name, &brand.Slug, &brand.Description, &brand.Country, &brand.LogoId)
+		if err != nil {
+			if errors.Is(err, errNoRows) {
+				return domain.ErrBrandNotFound
+			}
+			b.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		// items in trash reference brand until they are purged
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select("count(*)").
+			From("items").
+			Where("brand_id = ?", brandId).
+			ToSql()
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		var itemsCount int
+		err = tx.QueryRow(query, args...).Scan(&itemsCount)
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		if itemsCount > 0 {
+			return domain.ErrBrandHasItems
+		}
+
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Delete("").
+			From("brand").
+			Where("id = ?", brandId).
+			ToSql()
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			b.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Brand{}, err
+	}
+
+	return brand, nil
+}
+
+func checkAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrBrandNotFound
+	}
+
+	return nil
+}
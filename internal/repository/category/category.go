@@ -6,10 +6,44 @@ import (
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+const (
+	// category with all its parents, depth 0 is category itself
+	ancestorsQuery = `WITH RECURSIVE tree AS (
+		SELECT id, parent_id, 0 AS depth FROM category WHERE id = ?
+		UNION ALL
+		SELECT c.id, c.parent_id, t.depth + 1 FROM category c JOIN tree t ON c.id = t.parent_id
+	)`
+	// category with all its subcategories
+	descendantsQuery = `WITH RECURSIVE tree AS (
+		SELECT id FROM category WHERE id = ?
+		UNION ALL
+		SELECT c.id FROM category c JOIN tree t ON c.parent_id = t.id
+	)`
+	// subtree of one category and ancestors of other, they are the same category for new attribute
+	subtreeAncestorsQuery = `WITH RECURSIVE subtree AS (
+		SELECT id FROM category WHERE id = ?
+		UNION ALL
+		SELECT c.id FROM category c JOIN subtree s ON c.parent_id = s.id
+	), ancestors AS (
+		SELECT id, parent_id FROM category WHERE id = ?
+		UNION ALL
+		SELECT c.id, c.parent_id FROM category c JOIN ancestors a ON c.id = a.parent_id
+	)`
+)
+
+var (
+	categoryColumns = []string{"id", "parent_id", "type", "name"}
+
+	// sql package is shadowed by query variables
+	errNoRows = sql.ErrNoRows
 )
 
 type CategoryRepository struct {
@@ -29,7 +63,7 @@ func (c *CategoryRepository) GetCategories(ctx context.Context) ([]domain.Catego
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
-	sql, _, err := psql.Select("id", "type", "name").From("category").ToSql()
+	sql, _, err := psql.Select(categoryColumns...).From("category").OrderBy("id").ToSql()
 	if err != nil {
 		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
 	}
@@ -45,7 +79,7 @@ func (c *CategoryRepository) GetCategories(ctx context.Context) ([]domain.Catego
 	var categories []domain.Category
 	for rows.Next() {
 		var category domain.Category
-		if err := rows.Scan(&category.CategoryId, &category.Type, &category.Name); err != nil {
+		if err := rows.Scan(&category.CategoryId, &category.ParentId, &category.Type, &category.Name); err != nil {
 			c.logger.Error(op, sl.Err(err))
 
 			return nil, err
@@ -55,3 +89,439 @@ func (c *CategoryRepository) GetCategories(ctx context.Context) ([]domain.Catego
 
 	return categories, nil
 }
+
+func (c *CategoryRepository) GetCategory(ctx context.Context, categoryId int) (domain.Category, error) {
+	const op = "repository.Category.GetCategory"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(categoryColumns...).
+		From("category").
+		Where("id = ?", categoryId).
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Category{}, err
+	}
+
+	var category domain.Category
+	err = postgresql.Conn(ctx, c.db).QueryRowContext(ctx, sql, args...).Scan(&category.CategoryId, &category.ParentId, &category.Type, &category.Name)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return category, domain.ErrCategoryNotFound
+		}
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return category, err
+	}
+
+	return category, nil
+}
+
+// Get attributes of category including attributes inherited from parents.
+// Parents attributes go first
+func (c *CategoryRepository) GetAttributes(ctx context.Context, categoryId int) ([]domain.Attribute, error) {
+	const op = "repository.Category.GetAttributes"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("a.category_id", "a.code", "a.name", "a.value_type", "a.options", "a.required").
+		Prefix(ancestorsQuery, categoryId).
+		From("category_attribute a").
+		Join("tree t ON t.id = a.category_id").
+		OrderBy("t.depth DESC", "a.id").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := c.db.Query(sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attributes []domain.Attribute
+	for rows.Next() {
+		var attr domain.Attribute
+		err := rows.Scan(&attr.CategoryId, &attr.Code, &attr.Name, &attr.ValueType, pq.Array(&attr.Options), &attr.Required)
+		if err != nil {
+			c.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		attributes = append(attributes, attr)
+	}
+
+	return attributes, nil
+}
+
+// Create category and return its id
+func (c *CategoryRepository) Create(ctx context.Context, category domain.CategoryCreate) (int, error) {
+	const op = "repository.Category.Create"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("category").
+		Columns("parent_id", "type", "name").
+		Values(category.ParentId, category.Type, category.Name).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var categoryId int
+	err = postgresql.Conn(ctx, c.db).QueryRowContext(ctx, sql, args...).Scan(&categoryId)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return categoryId, nil
+}
+
+// Rename category or move it to another parent (ParentId = 0 moves category to root).
+// Category can be moved only inside tree of the same kind and not into its own subtree
+func (c *CategoryRepository) Update(ctx context.Context, category domain.CategoryUpdate) error {
+	const op = "repository.Category.Update"
+
+	if category.Name == nil && category.ParentId == nil {
+		return nil
+	}
+
+	return postgresql.WrapTx(ctx, c.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			c.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+			return postgresql.ErrGetTransaction
+		}
+
+		// concurrent moves checked against old tree could make a cycle
+		if category.ParentId != nil {
+			_, err := tx.Exec("LOCK TABLE category IN SHARE ROW EXCLUSIVE MODE")
+			if err != nil {
+				c.logger.Error(fmt.Sprintf("%s: lock table", op), sl.Err(err))
+
+				return err
+			}
+		}
+
+		current, err := c.getForUpdate(tx, category.ID)
+		if err != nil {
+			return err
+		}
+
+		psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).Update("category")
+		if category.Name != nil {
+			psql = psql.Set("name", *category.Name)
+		}
+		if category.ParentId != nil {
+			var parentId *int
+			if *category.ParentId != 0 {
+				err = c.checkNewParent(tx, current, *category.ParentId)
+				if err != nil {
+					return err
+				}
+				err = c.checkInheritedCodes(tx, category.ID, *category.ParentId)
+				if err != nil {
+					return err
+				}
+				parentId = category.ParentId
+			}
+			psql = psql.Set("parent_id", parentId)
+		}
+
+		query, args, err := psql.Where("id = ?", category.ID).ToSql()
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		return nil
+	})
+}
+
+// lock category row, so concurrent moves of the same category are serialized
+func (c *CategoryRepository) getForUpdate(tx *sql.Tx, categoryId int) (domain.Category, error) {
+	const op = "repository.Category.getForUpdate"
+
+	query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(categoryColumns...).
+		From("category").
+		Where("id = ?", categoryId).
+		Suffix("for update").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Category{}, err
+	}
+
+	var category domain.Category
+	err = tx.QueryRow(query, args...).Scan(&category.CategoryId, &category.ParentId, &category.Type, &category.Name)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return category, domain.ErrCategoryNotFound
+		}
+		c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return category, err
+	}
+
+	return category, nil
+}
+
+func (c *CategoryRepository) checkNewParent(tx *sql.Tx, category domain.Category, parentId int) error {
+	const op = "repository.Category.checkNewParent"
+
+	query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("c.type", "EXISTS (SELECT 1 FROM tree WHERE id = c.id)").
+		Prefix(descendantsQuery, category.CategoryId).
+		From("category c").
+		Where("c.id = ?", parentId).
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	var (
+		parentKind   domain.Kind
+		inOwnSubtree bool
+	)
+	err = tx.QueryRow(query, args...).Scan(&parentKind, &inOwnSubtree)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return fmt.Errorf("parent %w", domain.ErrCategoryNotFound)
+		}
+		c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return err
+	}
+
+	if inOwnSubtree {
+		return domain.ErrCategoryCycle
+	}
+	if parentKind != category.Type {
+		return domain.ErrCategoryKind
+	}
+
+	return nil
+}
+
+// Attribute codes must be unique along every path of tree, so attributes of moved subtree
+// can't repeat attributes of new parent and its ancestors
+func (c *CategoryRepository) checkInheritedCodes(tx *sql.Tx, categoryId, parentId int) error {
+	const op = "repository.Category.checkInheritedCodes"
+
+	query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("EXISTS (SELECT 1 FROM category_attribute d JOIN category_attribute a ON a.code = d.code "+
+			"WHERE d.category_id IN (SELECT id FROM subtree) AND a.category_id IN (SELECT id FROM ancestors))").
+		Prefix(subtreeAncestorsQuery, categoryId, parentId).
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	var conflict bool
+	err = tx.QueryRow(query, args...).Scan(&conflict)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return err
+	}
+	if conflict {
+		return domain.ErrAttributeConflict
+	}
+
+	return nil
+}
+
+// Delete category without subcategories and items
+func (c *CategoryRepository) Delete(ctx context.Context, categoryId int) error {
+	const op = "repository.Category.Delete"
+
+	return postgresql.WrapTx(ctx, c.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			c.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+			return postgresql.ErrGetTransaction
+		}
+
+		// lock conflicts with FK check of concurrent item or subcategory insert
+		_, err := c.getForUpdate(tx, categoryId)
+		if err != nil {
+			return err
+		}
+
+		query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select().
+			Column(squirrel.Expr("EXISTS (SELECT 1 FROM category WHERE parent_id = ?)", categoryId)).
+			Column(squirrel.Expr("EXISTS (SELECT 1 FROM items WHERE category_id = ?)", categoryId)).
+			ToSql()
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		var hasChildren, hasItems bool
+		err = tx.QueryRow(query, args...).Scan(&hasChildren, &hasItems)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		if hasChildren || hasItems {
+			return domain.ErrCategoryNotEmpty
+		}
+
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Delete("").
+			From("category").
+			Where("id = ?", categoryId).
+			ToSql()
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Add attribute definition to category. Code must be unique across category, its parents and subcategories
+func (c *CategoryRepository) CreateAttribute(ctx context.Context, attr domain.Attribute) error {
+	const op = "repository.Category.CreateAttribute"
+
+	return postgresql.WrapTx(ctx, c.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			c.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+			return postgresql.ErrGetTransaction
+		}
+
+		// tree can't be moved and the same code can't be added to other category of the path during check
+		_, err := tx.Exec("LOCK TABLE category IN SHARE MODE")
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s: lock table", op), sl.Err(err))
+
+			return err
+		}
+		_, err = tx.Exec("LOCK TABLE category_attribute IN SHARE ROW EXCLUSIVE MODE")
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s: lock table", op), sl.Err(err))
+
+			return err
+		}
+
+		query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select().
+			Column(squirrel.Expr("EXISTS (SELECT 1 FROM category_attribute WHERE code = ? "+
+				"AND (category_id IN (SELECT id FROM subtree) OR category_id IN (SELECT id FROM ancestors)))", attr.Code)).
+			Prefix(subtreeAncestorsQuery, attr.CategoryId, attr.CategoryId).
+			ToSql()
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		var exists bool
+		err = tx.QueryRow(query, args...).Scan(&exists)
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+		if exists {
+			return domain.ErrAttributeExists
+		}
+
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Insert("category_attribute").
+			Columns("category_id", "code", "name", "value_type", "options", "required").
+			Values(attr.CategoryId, attr.Code, attr.Name, attr.ValueType, pq.Array(attr.Options), attr.Required).
+			ToSql()
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			if postgresql.IsDuplicateKeyError(err) {
+				return domain.ErrAttributeExists
+			}
+			c.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Remove attribute definition. Values already stored in items are kept
+func (c *CategoryRepository) DeleteAttribute(ctx context.Context, categoryId int, code string) error {
+	const op = "repository.Category.DeleteAttribute"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("").
+		From("category_attribute").
+		Where("category_id = ?", categoryId).
+		Where("code = ?", code).
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	result, err := postgresql.Conn(ctx, c.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrAttributeNotFound
+	}
+
+	return nil
+}
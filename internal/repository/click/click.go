@@ -0,0 +1,297 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/click"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
+type ClickRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewClickRepository(logger *slog.Logger, db *postgresql.Storage) *ClickRepository {
+	return &ClickRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Record click and return its id
+func (c *ClickRepository) Create(ctx context.Context, click domain.Click) (int, error) {
+	const op = "repository.click.Create"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("clicks").
+		Columns("item_id", "referrer", "ua_hash", "utm_source", "utm_medium", "utm_campaign", "utm_content", "utm_term").
+		Values(click.ItemId, click.Referrer, click.UAHash, click.Source, click.Medium, click.Campaign.Campaign, click.Content, click.Term).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var id int
+	if err := postgresql.Conn(ctx, c.db).QueryRowContext(ctx, sql, args...).Scan(&id); err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Get clicks per item, most clicked first
+func (c *ClickRepository) GetItemStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error) {
+	const op = "repository.click.GetItemStats"
+
+	q := c.statsQuery(filter, "i.id", "i.name").
+		GroupBy("i.id", "i.name").
+		OrderBy("count(*) DESC", "i.id")
+
+	return c.queryStats(ctx, op, q)
+}
+
+// Get clicks per brand of items, most clicked first
+func (c *ClickRepository) GetBrandStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error) {
+	const op = "repository.click.GetBrandStats"
+
+	q := c.statsQuery(filter, "b.id", "b.name").
+		Join("brand b ON b.id = i.brand_id").
+		GroupBy("b.id", "b.name").
+		OrderBy("count(*) DESC", "b.id")
+
+	return c.queryStats(ctx, op, q)
+}
+
+// Get clicks per day (UTC), latest first
+func (c *ClickRepository) GetDayStats(ctx context.Context, filter domain.StatsFilter) ([]domain.DayStats, error) {
+	const op = "repository.click.GetDayStats"
+
+	sql, args, err := c.statsQuery(filter, "(cl.created_at AT TIME ZONE 'UTC')::date AS day").
+		GroupBy("day").
+		OrderBy("day DESC").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, c.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.DayStats
+	for rows.Next() {
+		var day domain.DayStats
+		if err := rows.Scan(&day.Day, &day.Clicks, &day.Visitors); err != nil {
+			c.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		stats = append(stats, day)
+	}
+
+	return stats, rows.Err()
+}
+
+// Select columns with number of clicks and visitors of clicks matching filter
+func (c *ClickRepository) statsQuery(filter domain.StatsFilter, columns ...string) squirrel.SelectBuilder {
+	q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(append(columns, "count(*)", "count(DISTINCT cl.ua_hash)")...).
+		From("clicks cl").
+		Join("items i ON i.id = cl.item_id")
+
+	if filter.From != nil {
+		q = q.Where("cl.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("cl.created_at < ?", *filter.To)
+	}
+	if filter.ItemId != nil {
+		q = q.Where("cl.item_id = ?", *filter.ItemId)
+	}
+	if filter.BrandId != nil {
+		q = q.Where("i.brand_id = ?", *filter.BrandId)
+	}
+	if filter.Limit != 0 {
+		q = q.Limit(filter.Limit)
+	}
+
+	return q.Offset(filter.Offset)
+}
+
+func (c *ClickRepository) queryStats(ctx context.Context, op string, q squirrel.SelectBuilder) ([]domain.Stats, error) {
+	sql, args, err := q.ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, c.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []domain.Stats
+	for rows.Next() {
+		var row domain.Stats
+		if err := rows.Scan(&row.ID, &row.Name, &row.Clicks, &row.Visitors); err != nil {
+			c.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		stats = append(stats, row)
+	}
+
+	return stats, rows.Err()
+}
+
+func (c *ClickRepository) GetTemplates(ctx context.Context) ([]domain.Template, error) {
+	const op = "repository.click.GetTemplates"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("domain", "params", "updated_at").
+		From("affiliate_templates").
+		OrderBy("domain").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, c.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []domain.Template
+	for rows.Next() {
+		var template domain.Template
+		if err := rows.Scan(&template.Domain, &template.Params, &template.UpdatedAt); err != nil {
+			c.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+// Find template of host or the closest parent domain of it. ErrTemplateNotFound if there is none
+func (c *ClickRepository) FindTemplate(ctx context.Context, host string) (domain.Template, error) {
+	const op = "repository.click.FindTemplate"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("domain", "params", "updated_at").
+		From("affiliate_templates").
+		Where("(domain = ? OR right(?::text, length(domain) + 1) = '.' || domain)", host, host).
+		OrderBy("length(domain) DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Template{}, err
+	}
+
+	var template domain.Template
+	err = postgresql.Conn(ctx, c.db).QueryRowContext(ctx, sql, args...).
+		Scan(&template.Domain, &template.Params, &template.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Template{}, domain.ErrTemplateNotFound
+		}
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Template{}, err
+	}
+
+	return template, nil
+}
+
+// Create or replace template of domain
+func (c *ClickRepository) SaveTemplate(ctx context.Context, template domain.Template) error {
+	const op = "repository.click.SaveTemplate"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("affiliate_templates").
+		Columns("domain", "params").
+		Values(template.Domain, template.Params).
+		Suffix("ON CONFLICT (domain) DO UPDATE SET params = EXCLUDED.params, updated_at = now()").
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, c.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *ClickRepository) DeleteTemplate(ctx context.Context, domainName string) error {
+	const op = "repository.click.DeleteTemplate"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("affiliate_templates").
+		Where("domain = ?", domainName).
+		ToSql()
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, c.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		c.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrTemplateNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/export"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
+var jobColumns = []string{
+	"id", "format", "filter", "status", "object_id", "items", "size", "error", "user_id", "api_key_id", "created_at", "updated_at",
+}
+
+type ExportJobRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewExportJobRepository(logger *slog.Logger, db *postgresql.Storage) *ExportJobRepository {
+	return &ExportJobRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Create job waiting for processing and return its id
+func (e *ExportJobRepository) Create(ctx context.Context, job domain.Job) (int, error) {
+	const op = "repository.exportjob.Create"
+
+	filter, err := json.Marshal(job.Filter)
+	if err != nil {
+		e.logger.Error(op, sl.Err(err))
+
+		return 0, err
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("export_jobs").
+		Columns("format", "filter", "status", "user_id", "api_key_id").
+		Values(job.Format, filter, domain.StatusPending, nullId(job.Actor.UserId), nullId(job.Actor.APIKeyId)).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var id int
+	err = postgresql.Conn(ctx, e.db).QueryRowContext(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (e *ExportJobRepository) GetJob(ctx context.Context, id int) (domain.Job, error) {
+	const op = "repository.exportjob.GetJob"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(jobColumns...).
+		From("export_jobs").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	job, err := scanJob(postgresql.Conn(ctx, e.db).QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Job{}, domain.ErrJobNotFound
+		}
+		e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	return job, nil
+}
+
+// Get jobs, latest first
+func (e *ExportJobRepository) GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error) {
+	const op = "repository.exportjob.GetJobs"
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(jobColumns...).
+		From("export_jobs").
+		OrderBy("id DESC").
+		Limit(limit)
+	if offset != 0 {
+		psql = psql.Offset(offset)
+	}
+
+	sql, args, err := psql.ToSql()
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, e.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]domain.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			e.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Reserve pending job until provided time and mark it running. Running job is taken again
+// when its reservation expires, e.g. instance was stopped. ErrJobNotFound if there are no jobs
+func (e *ExportJobRepository) Reserve(ctx context.Context, until time.Time) (domain.Job, error) {
+	const op = "repository.exportjob.Reserve"
+
+	next := squirrel.Select("id").
+		From("export_jobs").
+		Where(squirrel.Eq{"status": []domain.Status{domain.StatusPending, domain.StatusRunning}}).
+		Where("(reserved_to IS NULL OR reserved_to < now())").
+		OrderBy("id").
+		Limit(1).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("export_jobs").
+		Set("status", domain.StatusRunning).
+		Set("reserved_to", until).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Expr("id = (?)", next)).
+		Suffix("RETURNING " + strings.Join(jobColumns, ", ")).
+		ToSql()
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	job, err := scanJob(postgresql.Conn(ctx, e.db).QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Job{}, domain.ErrJobNotFound
+		}
+		e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	return job, nil
+}
+
+// Mark running job done with stored file
+func (e *ExportJobRepository) Complete(ctx context.Context, id int, result domain.Result) error {
+	const op = "repository.exportjob.Complete"
+
+	return e.finish(ctx, op, id, map[string]any{
+		"status":    domain.StatusDone,
+		"object_id": result.ObjectId,
+		"items":     result.Items,
+		"size":      result.Size,
+	})
+}
+
+// Mark running job failed with error message
+func (e *ExportJobRepository) Fail(ctx context.Context, id int, message string) error {
+	const op = "repository.exportjob.Fail"
+
+	return e.finish(ctx, op, id, map[string]any{
+		"status": domain.StatusFailed,
+		"error":  message,
+	})
+}
+
+// Mark jobs done before provided time expired, deleteFn deletes their files.
+// Jobs are locked while files are deleted, so instances don't expire the same jobs
+func (e *ExportJobRepository) ExpireJobs(ctx context.Context, doneBefore time.Time, limit uint64, deleteFn func(objectIds []string) error) (int, error) {
+	const op = "repository.exportjob.ExpireJobs"
+
+	var expired int
+	err := postgresql.WrapTx(ctx, e.db, func(ctx context.Context) error {
+		sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select("id", "object_id").
+			From("export_jobs").
+			Where("status = ? AND updated_at < ?", domain.StatusDone, doneBefore).
+			OrderBy("id").
+			Limit(limit).
+			Suffix("FOR UPDATE SKIP LOCKED").
+			ToSql()
+		if err != nil {
+			e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		rows, err := postgresql.Conn(ctx, e.db).QueryContext(ctx, sql, args...)
+		if err != nil {
+			e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return err
+		}
+		defer rows.Close()
+
+		var (
+			jobIds    []int
+			objectIds []string
+		)
+		for rows.Next() {
+			var (
+				id       int
+				objectId *string
+			)
+			if err := rows.Scan(&id, &objectId); err != nil {
+				e.logger.Error(op, sl.Err(err))
+
+				return err
+			}
+			jobIds = append(jobIds, id)
+			if objectId != nil {
+				objectIds = append(objectIds, *objectId)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			e.logger.Error(op, sl.Err(err))
+
+			return err
+		}
+		if len(jobIds) == 0 {
+			return nil
+		}
+
+		if err := deleteFn(objectIds); err != nil {
+			return err
+		}
+
+		sql, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Update("export_jobs").
+			Set("status", domain.StatusExpired).
+			Set("object_id", nil).
+			Set("updated_at", squirrel.Expr("now()")).
+			Where(squirrel.Eq{"id": jobIds}).
+			ToSql()
+		if err != nil {
+			e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		if _, err = postgresql.Conn(ctx, e.db).ExecContext(ctx, sql, args...); err != nil {
+			e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return err
+		}
+		expired = len(jobIds)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+func (e *ExportJobRepository) finish(ctx context.Context, op string, id int, values map[string]any) error {
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("export_jobs").
+		SetMap(values).
+		Set("reserved_to", nil).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where("id = ? AND status = ?", id, domain.StatusRunning).
+		ToSql()
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, e.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		e.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrJobNotFound
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner) (domain.Job, error) {
+	var (
+		job           domain.Job
+		filter        []byte
+		objectId      *string
+		userId, keyId *int
+	)
+	err := row.Scan(
+		&job.ID, &job.Format, &filter, &job.Status, &objectId, &job.Items, &job.Size, &job.Error,
+		&userId, &keyId, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	if objectId != nil {
+		job.ObjectId = *objectId
+	}
+	if userId != nil {
+		job.Actor.UserId = *userId
+	}
+	if keyId != nil {
+		job.Actor.APIKeyId = *keyId
+	}
+
+	if err := json.Unmarshal(filter, &job.Filter); err != nil {
+		return domain.Job{}, err
+	}
+
+	return job, nil
+}
+
+func nullId(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
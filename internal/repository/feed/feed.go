@@ -0,0 +1,265 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/feed"
+	idomain "cloth-mini-app/internal/domain/item"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
+// Hash of item data rendered into entry. Version of item is incremented on every change of item and its images,
+// brand and category names are changed separately. salt is data of feeds settings
+const sourceExpr = "md5(concat_ws('|', ?::text, i.version, b.name, c.name))"
+
+type FeedRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewFeedRepository(logger *slog.Logger, db *postgresql.Storage) *FeedRepository {
+	return &FeedRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Get published items without entries or with entries rendered from other data, limit items at once
+func (f *FeedRepository) StaleItems(ctx context.Context, salt string, limit uint64) ([]domain.StaleItem, error) {
+	const op = "repository.feed.StaleItems"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("i.id", sourceExpr).
+		From("items i").
+		Join("brand b ON b.id = i.brand_id").
+		Join("category c ON c.id = i.category_id").
+		LeftJoin("feed_entries e ON e.item_id = i.id").
+		Where("i.status = ? AND i.deleted_at IS NULL", idomain.StatusPublished).
+		Where("e.source IS DISTINCT FROM "+sourceExpr, salt, salt).
+		OrderBy("i.id").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, f.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.StaleItem
+	for rows.Next() {
+		var item domain.StaleItem
+		if err := rows.Scan(&item.ItemId, &item.Source); err != nil {
+			f.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Delete entries of items which aren't published anymore and return their number
+func (f *FeedRepository) DeleteRemoved(ctx context.Context) (int, error) {
+	const op = "repository.feed.DeleteRemoved"
+
+	published := squirrel.Select("1").
+		From("items i").
+		Where("i.id = feed_entries.item_id AND i.status = ? AND i.deleted_at IS NULL", idomain.StatusPublished).
+		Prefix("NOT EXISTS (").
+		Suffix(")")
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("feed_entries").
+		Where(published).
+		ToSql()
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	res, err := postgresql.Conn(ctx, f.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		f.logger.Error(op, sl.Err(err))
+
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+// Create or replace entries
+func (f *FeedRepository) SaveEntries(ctx context.Context, entries []domain.Entry) error {
+	const op = "repository.feed.SaveEntries"
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("feed_entries").
+		Columns("item_id", "source", "yml", "google_xml", "google_tsv")
+	for _, entry := range entries {
+		psql = psql.Values(entry.ItemId, entry.Source, entry.YML, entry.GoogleXML, entry.GoogleTSV)
+	}
+
+	sql, args, err := psql.
+		Suffix(`ON CONFLICT (item_id) DO UPDATE SET source = EXCLUDED.source, yml = EXCLUDED.yml,
+			google_xml = EXCLUDED.google_xml, google_tsv = EXCLUDED.google_tsv, updated_at = now()`).
+		ToSql()
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, f.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Pass entries to fn in batches ordered by item id
+func (f *FeedRepository) IterateEntries(ctx context.Context, batchSize uint64, fn func(entries []domain.Entry) error) error {
+	const op = "repository.feed.IterateEntries"
+
+	lastId := 0
+	for {
+		sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select("item_id", "source", "yml", "google_xml", "google_tsv").
+			From("feed_entries").
+			Where("item_id > ?", lastId).
+			OrderBy("item_id").
+			Limit(batchSize).
+			ToSql()
+		if err != nil {
+			f.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		entries, err := f.queryEntries(ctx, sql, args)
+		if err != nil {
+			f.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		if err := fn(entries); err != nil {
+			return err
+		}
+		if uint64(len(entries)) < batchSize {
+			return nil
+		}
+
+		lastId = entries[len(entries)-1].ItemId
+	}
+}
+
+func (f *FeedRepository) queryEntries(ctx context.Context, query string, args []any) ([]domain.Entry, error) {
+	rows, err := postgresql.Conn(ctx, f.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.Entry
+	for rows.Next() {
+		var entry domain.Entry
+		if err := rows.Scan(&entry.ItemId, &entry.Source, &entry.YML, &entry.GoogleXML, &entry.GoogleTSV); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Get generated feed by name. ErrFeedNotFound if it isn't generated yet
+func (f *FeedRepository) GetFeed(ctx context.Context, name string) (domain.Feed, error) {
+	const op = "repository.feed.GetFeed"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("name", "object_id", "etag", "size", "event_id", "generated_at").
+		From("feeds").
+		Where("name = ?", name).
+		ToSql()
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Feed{}, err
+	}
+
+	var feed domain.Feed
+	err = postgresql.Conn(ctx, f.db).QueryRowContext(ctx, sql, args...).
+		Scan(&feed.Name, &feed.ObjectId, &feed.ETag, &feed.Size, &feed.EventId, &feed.GeneratedAt)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Feed{}, domain.ErrFeedNotFound
+		}
+		f.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Feed{}, err
+	}
+
+	return feed, nil
+}
+
+// Create or replace generated feed
+func (f *FeedRepository) SaveFeed(ctx context.Context, feed domain.Feed) error {
+	const op = "repository.feed.SaveFeed"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("feeds").
+		Columns("name", "object_id", "etag", "size", "event_id", "generated_at").
+		Values(feed.Name, feed.ObjectId, feed.ETag, feed.Size, feed.EventId, squirrel.Expr("now()")).
+		Suffix(`ON CONFLICT (name) DO UPDATE SET object_id = EXCLUDED.object_id, etag = EXCLUDED.etag,
+			size = EXCLUDED.size, event_id = EXCLUDED.event_id, generated_at = EXCLUDED.generated_at`).
+		ToSql()
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, f.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		f.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
@@ -2,10 +2,12 @@ package image
 
 import (
 	domain "cloth-mini-app/internal/domain/image"
+	idomain "cloth-mini-app/internal/domain/item"
 	sl "cloth-mini-app/internal/logger"
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -13,14 +15,13 @@ import (
 	"github.com/Masterminds/squirrel"
 )
 
-var (
-	errMaxImages = fmt.Errorf("reached max images per item")
-)
-
 const (
 	maxImagesPerItem = 4
 )
 
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
 type ImageRepository struct {
 	db     *sql.DB
 	logger *slog.Logger
@@ -33,79 +34,88 @@ func NewImageRepository(logger *slog.Logger, db *postgresql.Storage) *ImageRepos
 	}
 }
 
-// insert image data to db with SELECT FOR UPDATE
-func (i *ImageRepository) Insert(ctx context.Context, itemId int, objectId string) error {
+// insert image data to db with SELECT FOR UPDATE. Joins transaction from context if there is one
+func (i *ImageRepository) Insert(ctx context.Context, itemId int, objectId string, meta *domain.ImageMeta) error {
 	const op = "repository.image.Insert"
 
-	tx, err := i.db.BeginTx(ctx, nil)
-	if err != nil {
-		i.logger.Error(fmt.Sprintf("%s: %s", op, "failet start transaction"), sl.Err(err))
-
-		return err
-	}
-	defer tx.Rollback()
+	return postgresql.WrapTx(ctx, i.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			i.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
 
-	imagePerItem, err := i.getImagesForUpdate(itemId)
-	if err != nil {
-		return err
-	}
+			return postgresql.ErrGetTransaction
+		}
 
-	if imagePerItem >= maxImagesPerItem {
-		i.logger.Debug("the number of images per item has reached the maximum", slog.Attr{Key: "itemId", Value: slog.IntValue(itemId)})
+		imagePerItem, err := i.getImagesForUpdate(tx, itemId)
+		if err != nil {
+			return err
+		}
 
-		return errMaxImages
-	}
+		if imagePerItem >= maxImagesPerItem {
+			i.logger.Debug("the number of images per item has reached the maximum", slog.Attr{Key: "itemId", Value: slog.IntValue(itemId)})
 
-	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
-		Insert("images").
-		Columns("item_id", "object_id", "uploaded_at").
-		Values(itemId, objectId, time.Now()).
-		ToSql()
-	if err != nil {
-		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
-
-		return err
-	}
+			return domain.ErrMaxImages
+		}
 
-	_, err = i.db.Exec(sql, args...)
-	if err != nil {
-		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+		sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Insert("images").
+			Columns("item_id", "object_id", "uploaded_at", "blurhash", "dominant_color", "width", "height").
+			Values(append([]any{itemId, objectId, time.Now()}, metaValues(meta)...)...).
+			ToSql()
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
 
-		return err
-	}
+			return err
+		}
 
-	if err = tx.Commit(); err != nil {
-		i.logger.Error(fmt.Sprintf("%s : failed commit transaction", op), sl.Err(err))
+		_, err = tx.Exec(sql, args...)
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
 
-		return err
-	}
+			return err
+		}
 
-	return nil
+		return i.newItemVersion(ctx, itemId)
+	})
 }
 
-// lock needed rows and return number of images related to provided itemId
-func (i *ImageRepository) getImagesForUpdate(itemId int) (int, error) {
+// lock item row and return number of images related to provided itemId. Item in trash isn't found.
+// Item row is locked (not image rows), so concurrent inserts for item without images are serialized too
+func (i *ImageRepository) getImagesForUpdate(tx *sql.Tx, itemId int) (int, error) {
 	const op = "repository.image.getItemsForUpdate"
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	sql, args, err := psql.Select("*").From("images").Where("item_id = ?", itemId).Suffix("for update").ToSql()
+	query, args, err := psql.Select("id").From("items").Where("id = ? AND deleted_at IS NULL", itemId).Suffix("for update").ToSql()
 	if err != nil {
 		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
 
 		return 0, err
 	}
 
-	rows, err := i.db.Query(sql, args...)
+	var id int
+	err = tx.QueryRow(query, args...).Scan(&id)
 	if err != nil {
-		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrItemNotFound
+		}
+		i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return 0, err
+	}
+
+	query, args, err = psql.Select("count(*)").From("images").Where("item_id = ?", itemId).ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
 
 		return 0, err
 	}
-	defer rows.Close()
 
 	var imageCnt int
-	for rows.Next() {
-		imageCnt++
+	err = tx.QueryRow(query, args...).Scan(&imageCnt)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return 0, err
 	}
 
 	return imageCnt, nil
@@ -144,13 +154,222 @@ func (i *ImageRepository) GetImages(ctx context.Context, itemId int) ([]string,
 	return imageIds, nil
 }
 
-func (i *ImageRepository) Delete(ctx context.Context, imageId string) error {
+// Get images with metadata for provided items. Return itemId => images
+func (i *ImageRepository) GetItemsImages(ctx context.Context, itemIds []int) (map[int][]domain.Image, error) {
+	const op = "repository.image.GetItemsImages"
+
+	itemsImages := make(map[int][]domain.Image, len(itemIds))
+	if len(itemIds) == 0 {
+		return itemsImages, nil
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("id", "item_id", "object_id", "uploaded_at", "blurhash", "dominant_color", "width", "height").
+		From("images").
+		Where(squirrel.Eq{"item_id": itemIds}).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	images, err := i.queryImages(op, sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		itemsImages[image.ItemId] = append(itemsImages[image.ItemId], image)
+	}
+
+	return itemsImages, nil
+}
+
+// Get images of items matching filter ordered by upload
+func (i *ImageRepository) FindImages(ctx context.Context, filter domain.ImageFilter) ([]domain.Image, error) {
+	const op = "repository.image.FindImages"
+
+	q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("im.id", "im.item_id", "im.object_id", "im.uploaded_at", "im.blurhash", "im.dominant_color", "im.width", "im.height").
+		From("images im").
+		OrderBy("im.id")
+	if filter.ItemId != nil {
+		q = q.Where("im.item_id = ?", *filter.ItemId)
+	}
+	if filter.ObjectIds != nil {
+		q = q.Where(squirrel.Eq{"im.object_id": filter.ObjectIds})
+	}
+	if filter.PublishedOnly {
+		q = q.Join("items i ON i.id = im.item_id").
+			Where("i.status = ? AND i.deleted_at IS NULL", idomain.StatusPublished)
+	}
+
+	sql, args, err := q.ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	return i.queryImages(op, sql, args)
+}
+
+// Check that object is an image known to app: image of item, temp image or brand logo.
+// Storage keeps generated files too, they mustn't be served as images
+func (i *ImageRepository) IsImage(ctx context.Context, objectId string) (bool, error) {
+	const op = "repository.image.IsImage"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select().
+		Column(`EXISTS (SELECT 1 FROM images WHERE object_id = ?)
+			OR EXISTS (SELECT 1 FROM temp_images WHERE object_id = ?)
+			OR EXISTS (SELECT 1 FROM brand WHERE logo_id = ?)`, objectId, objectId, objectId).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return false, err
+	}
+
+	var exists bool
+	if err := postgresql.Conn(ctx, i.db).QueryRowContext(ctx, sql, args...).Scan(&exists); err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Get images without metadata with id greater than afterId
+func (i *ImageRepository) GetImagesWithoutMeta(ctx context.Context, afterId int, limit uint64) ([]domain.Image, error) {
+	const op = "repository.image.GetImagesWithoutMeta"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("id", "item_id", "object_id", "uploaded_at", "blurhash", "dominant_color", "width", "height").
+		From("images").
+		Where("blurhash IS NULL").
+		Where("id > ?", afterId).
+		OrderBy("id").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	return i.queryImages(op, sql, args)
+}
+
+func (i *ImageRepository) queryImages(op string, sql string, args []any) ([]domain.Image, error) {
+	rows, err := i.db.Query(sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []domain.Image
+	for rows.Next() {
+		var image domain.Image
+		var meta imageMeta
+		if err := rows.Scan(
+			&image.ID,
+			&image.ItemId,
+			&image.ObjectId,
+			&image.UploadedAt,
+			&meta.BlurHash,
+			&meta.DominantColor,
+			&meta.Width,
+			&meta.Height,
+		); err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		image.Meta = meta.toDomain()
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// Set metadata of stored image
+func (i *ImageRepository) UpdateMeta(ctx context.Context, imageId int, meta domain.ImageMeta) error {
+	const op = "repository.image.UpdateMeta"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("images").
+		Set("blurhash", meta.BlurHash).
+		Set("dominant_color", meta.DominantColor).
+		Set("width", meta.Width).
+		Set("height", meta.Height).
+		Where("id = ?", imageId).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = i.db.Exec(sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete image of item and return deleted image. Item gets new version
+func (i *ImageRepository) Delete(ctx context.Context, imageId string) (domain.Image, error) {
 	const op = "repository.image.Delete"
 
 	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
 		Delete("").
 		From("images").
 		Where("object_id = ?", imageId).
+		Suffix("RETURNING id, item_id, object_id, uploaded_at").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Image{}, err
+	}
+
+	var image domain.Image
+	err = postgresql.Conn(ctx, i.db).QueryRowContext(ctx, sql, args...).Scan(&image.ID, &image.ItemId, &image.ObjectId, &image.UploadedAt)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Image{}, domain.ErrImageNotFound
+		}
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Image{}, err
+	}
+
+	err = i.newItemVersion(ctx, image.ItemId)
+	if err != nil {
+		return domain.Image{}, err
+	}
+
+	return image, nil
+}
+
+// Images are a part of item, so item gets new version when they are changed
+func (i *ImageRepository) newItemVersion(ctx context.Context, itemId int) error {
+	const op = "repository.image.newItemVersion"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("items").
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where("id = ?", itemId).
 		ToSql()
 	if err != nil {
 		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
@@ -158,7 +377,7 @@ func (i *ImageRepository) Delete(ctx context.Context, imageId string) error {
 		return err
 	}
 
-	_, err = i.db.Exec(sql, args...)
+	_, err = postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
 	if err != nil {
 		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
 
@@ -168,13 +387,13 @@ func (i *ImageRepository) Delete(ctx context.Context, imageId string) error {
 	return nil
 }
 
-func (i *ImageRepository) InsertTempImage(ctx context.Context, objectId string) error {
+func (i *ImageRepository) InsertTempImage(ctx context.Context, objectId string, meta *domain.ImageMeta) error {
 	const op = "repository.image.InsertTempImage"
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
 		Insert("temp_images").
-		Columns("object_id", "uploaded_at").
-		Values(objectId, time.Now())
+		Columns("object_id", "uploaded_at", "blurhash", "dominant_color", "width", "height").
+		Values(append([]any{objectId, time.Now()}, metaValues(meta)...)...)
 
 	sql, args, err := psql.ToSql()
 	if err != nil {
@@ -198,6 +417,98 @@ func (i *ImageRepository) InsertTempImage(ctx context.Context, objectId string)
 	return nil
 }
 
+// Register temp image that will be uploaded directly to storage.
+// Image can't be attached to item until it is confirmed
+func (i *ImageRepository) InsertPendingTempImage(ctx context.Context, objectId string) error {
+	const op = "repository.image.InsertPendingTempImage"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("temp_images").
+		Columns("object_id", "uploaded_at", "confirmed").
+		Values(objectId, time.Now(), false).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = i.db.Exec(sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Check that temp image is registered for direct upload and isn't confirmed yet
+func (i *ImageRepository) IsPendingTempImage(ctx context.Context, objectId string) (bool, error) {
+	const op = "repository.image.IsPendingTempImage"
+
+	query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select().
+		Column("EXISTS (SELECT 1 FROM temp_images WHERE object_id = ? AND confirmed = false)", objectId).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return false, err
+	}
+
+	var exists bool
+	if err := postgresql.Conn(ctx, i.db).QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Mark pending temp image as confirmed and store its metadata.
+// Image confirmed concurrently or not registered isn't found
+func (i *ImageRepository) ConfirmTempImage(ctx context.Context, objectId string, meta *domain.ImageMeta) error {
+	const op = "repository.image.ConfirmTempImage"
+
+	metaSet := metaValues(meta)
+	query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("temp_images").
+		Set("confirmed", true).
+		Set("uploaded_at", time.Now()).
+		Set("blurhash", metaSet[0]).
+		Set("dominant_color", metaSet[1]).
+		Set("width", metaSet[2]).
+		Set("height", metaSet[3]).
+		Where("object_id = ? AND confirmed = false", objectId).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, i.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUploadNotFound
+	}
+
+	return nil
+}
+
 func (i *ImageRepository) DeleteTempImage(ctx context.Context, deleteFn func([]domain.TempImage) ([]domain.TempImage, error)) error {
 	const op = "repository.image.DeleteTempImage"
 
@@ -226,7 +537,7 @@ func (i *ImageRepository) getTempImages(ctx context.Context) ([]domain.TempImage
 	const op = "repository.image.getTempImages"
 
 	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
-		Select("id", "object_id", "uploaded_at").
+		Select("id", "object_id", "uploaded_at", "confirmed").
 		From("temp_images").
 		Suffix("for update").
 		ToSql()
@@ -247,7 +558,7 @@ func (i *ImageRepository) getTempImages(ctx context.Context) ([]domain.TempImage
 	var images []domain.TempImage
 	for rows.Next() {
 		var image domain.TempImage
-		if err := rows.Scan(&image.ID, &image.ObjectId, &image.UploadedAt); err != nil {
+		if err := rows.Scan(&image.ID, &image.ObjectId, &image.UploadedAt, &image.Confirmed); err != nil {
 			i.logger.Error(op, sl.Err(err))
 
 			return nil, err
@@ -283,3 +594,33 @@ func (i *ImageRepository) deleteTempImage(ctx context.Context, imageIds []uint)
 
 	return nil
 }
+
+// nullable metadata columns
+type imageMeta struct {
+	BlurHash      sql.NullString
+	DominantColor sql.NullString
+	Width         sql.NullInt64
+	Height        sql.NullInt64
+}
+
+func (m imageMeta) toDomain() *domain.ImageMeta {
+	if !m.BlurHash.Valid {
+		return nil
+	}
+
+	return &domain.ImageMeta{
+		BlurHash:      m.BlurHash.String,
+		DominantColor: m.DominantColor.String,
+		Width:         int(m.Width.Int64),
+		Height:        int(m.Height.Int64),
+	}
+}
+
+// values for blurhash, dominant_color, width, height columns
+func metaValues(meta *domain.ImageMeta) []any {
+	if meta == nil {
+		return []any{nil, nil, nil, nil}
+	}
+
+	return []any{meta.BlurHash, meta.DominantColor, meta.Width, meta.Height}
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/importjob"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
+// columns of job without rows
+var jobColumns = []string{
+	"id", "file_name", "status", "dry_run", "create_brands", "columns", "total", "processed", "valid", "created",
+	"errors", "new_brands", "error", "user_id", "api_key_id", "created_at", "updated_at",
+}
+
+type ImportJobRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewImportJobRepository(logger *slog.Logger, db *postgresql.Storage) *ImportJobRepository {
+	return &ImportJobRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Create job with rows of file and return its id
+func (i *ImportJobRepository) Create(ctx context.Context, job domain.Job) (int, error) {
+	const op = "repository.importjob.Create"
+
+	columns, err := json.Marshal(job.Columns)
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return 0, err
+	}
+	rows, err := json.Marshal(job.Rows)
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return 0, err
+	}
+	newBrands, err := json.Marshal(nonNil(job.NewBrands))
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return 0, err
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("import_jobs").
+		Columns("file_name", "status", "dry_run", "create_brands", "columns", "rows", "total", "new_brands", "user_id", "api_key_id").
+		Values(job.FileName, job.Status, job.DryRun, job.CreateBrands, columns, rows, job.Total, newBrands, nullId(job.Actor.UserId), nullId(job.Actor.APIKeyId)).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var id int
+	err = postgresql.Conn(ctx, i.db).QueryRowContext(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Get job without rows
+func (i *ImportJobRepository) GetJob(ctx context.Context, id int) (domain.Job, error) {
+	const op = "repository.importjob.GetJob"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(jobColumns...).
+		From("import_jobs").
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	job, err := i.scanJob(postgresql.Conn(ctx, i.db).QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Job{}, domain.ErrJobNotFound
+		}
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	return job, nil
+}
+
+// Get jobs without rows, latest first
+func (i *ImportJobRepository) GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error) {
+	const op = "repository.importjob.GetJobs"
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(jobColumns...).
+		From("import_jobs").
+		OrderBy("id DESC").
+		Limit(limit)
+	if offset != 0 {
+		psql = psql.Offset(offset)
+	}
+
+	sql, args, err := psql.ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, i.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]domain.Job, 0)
+	for rows.Next() {
+		job, err := i.scanJob(rows)
+		if err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Reserve job waiting for processing until provided time and get it with rows.
+// Job reserved by other instance is skipped until reservation expires. ErrJobNotFound if there are no jobs
+func (i *ImportJobRepository) Reserve(ctx context.Context, until time.Time) (domain.Job, error) {
+	const op = "repository.importjob.Reserve"
+
+	next := squirrel.Select("id").
+		From("import_jobs").
+		Where(squirrel.Eq{"status": []domain.Status{domain.StatusValidating, domain.StatusImporting}}).
+		Where("(reserved_to IS NULL OR reserved_to < now())").
+		OrderBy("id").
+		Limit(1).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("import_jobs").
+		Set("reserved_to", until).
+		Where(squirrel.Expr("id = (?)", next)).
+		Suffix("RETURNING rows, " + strings.Join(jobColumns, ", ")).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	var rows []byte
+	job, err := i.scanJob(postgresql.Conn(ctx, i.db).QueryRowContext(ctx, sql, args...), &rows)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Job{}, domain.ErrJobNotFound
+		}
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	err = json.Unmarshal(rows, &job.Rows)
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return domain.Job{}, err
+	}
+
+	return job, nil
+}
+
+// Process batch of job rows and add its result to job counters in one transaction.
+// Reservation of job is extended until provided time
+func (i *ImportJobRepository) ProcessBatch(ctx context.Context, id int, until time.Time, batchFn func(ctx context.Context) (domain.Progress, error)) error {
+	const op = "repository.importjob.ProcessBatch"
+
+	return postgresql.WrapTx(ctx, i.db, func(ctx context.Context) error {
+		progress, err := batchFn(ctx)
+		if err != nil {
+			return err
+		}
+
+		errs, err := json.Marshal(nonNil(progress.Errors))
+		if err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return err
+		}
+
+		sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Update("import_jobs").
+			Set("processed", squirrel.Expr("processed + ?", progress.Processed)).
+			Set("valid", squirrel.Expr("valid + ?", progress.Valid)).
+			Set("created", squirrel.Expr("created + ?", progress.Created)).
+			Set("errors", squirrel.Expr("errors || ?::jsonb", errs)).
+			Set("reserved_to", until).
+			Set("updated_at", squirrel.Expr("now()")).
+			Where("id = ?", id).
+			ToSql()
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Move job from one stage to another, message is stored for failed job. ErrJobState if job isn't in from stage.
+// Import stage keeps reservation, so instance which validated job imports it, counter of processed rows is reset
+func (i *ImportJobRepository) ChangeStatus(ctx context.Context, id int, from, to domain.Status, message string) error {
+	const op = "repository.importjob.ChangeStatus"
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("import_jobs").
+		Set("status", to).
+		Set("error", message).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where("id = ? AND status = ?", id, from)
+	if to == domain.StatusImporting {
+		psql = psql.Set("processed", 0)
+	} else {
+		psql = psql.Set("reserved_to", nil)
+	}
+
+	sql, args, err := psql.ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		if _, err := i.GetJob(ctx, id); err != nil {
+			return err
+		}
+
+		return domain.ErrJobState
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Scan job columns, extra destinations go before them
+func (i *ImportJobRepository) scanJob(row scanner, extra ...any) (domain.Job, error) {
+	var (
+		job                      domain.Job
+		columns, errs, newBrands []byte
+		userId, keyId            *int
+	)
+	dest := append(extra,
+		&job.ID, &job.FileName, &job.Status, &job.DryRun, &job.CreateBrands, &columns, &job.Total, &job.Processed,
+		&job.Valid, &job.Created, &errs, &newBrands, &job.Error, &userId, &keyId, &job.CreatedAt, &job.UpdatedAt,
+	)
+	err := row.Scan(dest...)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	if userId != nil {
+		job.Actor.UserId = *userId
+	}
+	if keyId != nil {
+		job.Actor.APIKeyId = *keyId
+	}
+
+	for _, field := range []struct {
+		data []byte
+		v    any
+	}{{columns, &job.Columns}, {errs, &job.Errors}, {newBrands, &job.NewBrands}} {
+		if err := json.Unmarshal(field.data, field.v); err != nil {
+			return domain.Job{}, err
+		}
+	}
+
+	return job, nil
+}
+
+func nullId(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+
+	return s
+}
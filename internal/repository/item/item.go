@@ -6,6 +6,8 @@ import (
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -16,8 +18,18 @@ import (
 
 const (
 	limitMax = 100 // max records per query if limit doesn't specified
+
+	// category with all its subcategories
+	categoryTreeQuery = `WITH RECURSIVE tree AS (
+		SELECT id FROM category WHERE id = ?
+		UNION ALL
+		SELECT c.id FROM category c JOIN tree t ON c.parent_id = t.id
+	) SELECT id FROM tree`
 )
 
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
 type ItemRepository struct {
 	db     *sql.DB
 	logger *slog.Logger
@@ -43,20 +55,195 @@ func (i *ItemRepository) GetItems(ctx context.Context, params domain.ItemInputDa
 		limit = uint64(*params.Limit)
 	}
 
-	filter := i.filterItems(params)
-
 	if limit == 0 {
 		limit = limitMax
 	}
 
+	q := i.itemsQuery(params).
+		Limit(limit).
+		Offset(offset)
+
+	sql, args, _ := q.ToSql()
+	// fmt.Println(sql, args)
+
+	rows, err := i.db.Query(sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.ItemAPI
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Pass items matching params to fn in batches ordered by id. Limit and offset of params are ignored.
+// Batches are fetched by id of last item, so only one batch is kept in memory
+func (i *ItemRepository) IterateItems(ctx context.Context, params domain.ItemInputData, batchSize uint64, fn func(items []domain.ItemAPI) error) error {
+	const op = "repository.item.IterateItems"
+
+	var lastId uint
+	for {
+		sql, args, err := i.itemsQuery(params).
+			Where("i.id > ?", lastId).
+			OrderBy("i.id").
+			Limit(batchSize).
+			ToSql()
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		items, err := i.queryItems(ctx, sql, args)
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		if err := fn(items); err != nil {
+			return err
+		}
+		if uint64(len(items)) < batchSize {
+			return nil
+		}
+
+		lastId = items[len(items)-1].ID
+	}
+}
+
+// Get items with brand and category by ids, items in trash are skipped
+func (i *ItemRepository) GetItemsByIds(ctx context.Context, ids []int) ([]domain.ItemAPI, error) {
+	const op = "repository.item.GetItemsByIds"
+
+	sql, args, err := i.itemsQuery(domain.ItemInputData{}).
+		Where(squirrel.Eq{"i.id": ids}).
+		OrderBy("i.id").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	items, err := i.queryItems(ctx, sql, args)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Get newest items matching params ordered by creation time. Offset of params is ignored
+func (i *ItemRepository) GetNewestItems(ctx context.Context, params domain.ItemInputData, limit uint64) ([]domain.ItemAPI, error) {
+	const op = "repository.item.GetNewestItems"
+
+	sql, args, err := i.itemsQuery(params).
+		OrderBy("i.created_at DESC", "i.id DESC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	items, err := i.queryItems(ctx, sql, args)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Get times of items with statuses ordered by creation time, newest first. Items in trash are skipped
+func (i *ItemRepository) GetItemsTimes(ctx context.Context, statuses []domain.Status) ([]domain.ItemTimes, error) {
+	const op = "repository.item.GetItemsTimes"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("id", "created_at", "updated_at").
+		From("items").
+		Where("deleted_at IS NULL").
+		Where(squirrel.Eq{"status": statuses}).
+		OrderBy("created_at DESC", "id DESC").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, i.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.ItemTimes
+	for rows.Next() {
+		var item domain.ItemTimes
+		if err := rows.Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (i *ItemRepository) queryItems(ctx context.Context, query string, args []any) ([]domain.ItemAPI, error) {
+	rows, err := postgresql.Conn(ctx, i.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.ItemAPI
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Select items with brand and category matching params
+func (i *ItemRepository) itemsQuery(params domain.ItemInputData) squirrel.SelectBuilder {
+	filter := i.filterItems(params)
+
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
-	q := psql.Select("i.id", "i.name", "i.description", "i.sex", "i.price", "i.discount", "i.outer_link", "i.created_at", "i.updated_at", "c.type", "c.name AS category_name", "b.name").
+	q := psql.Select("i.id", "i.name", "i.description", "i.sex", "i.price", "i.discount", "i.outer_link", "i.created_at", "i.updated_at", "i.version", "i.status", "i.publish_at", "i.unpublish_at", "i.attributes", "c.id AS category_id", "c.type", "c.name AS category_name", "b.id AS brand_id", "b.name").
 		From("items i").
 		LeftJoin("brand b on i.brand_id = b.id").
 		LeftJoin("category c on i.category_id = c.id").
-		Limit(limit).
-		Offset(offset)
+		Where("i.deleted_at IS NULL")
 
 	minPrice, minPriceOk := filter["min_price"]
 	maxPrice, maxPriceOk := filter["max_price"]
@@ -80,44 +267,51 @@ func (i *ItemRepository) GetItems(ctx context.Context, params domain.ItemInputDa
 		delete(filter, "i.name")
 	}
 
-	q = q.Where(filter)
-
-	sql, args, _ := q.ToSql()
-	// fmt.Println(sql, args, filter)
+	// parent category includes items of all subcategories
+	categoryId, categoryOk := filter["c.id"]
+	if categoryOk {
+		q = q.Where(squirrel.Expr(fmt.Sprintf("i.category_id IN (%s)", categoryTreeQuery), categoryId))
+		delete(filter, "c.id")
+	}
 
-	rows, err := i.db.Query(sql, args...)
-	if err != nil {
-		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+	return q.Where(filter)
+}
 
-		return nil, err
+// Scan row of itemsQuery
+func scanItem(rows *sql.Rows) (domain.ItemAPI, error) {
+	var (
+		item       domain.ItemAPI
+		attributes []byte
+	)
+	if err := rows.Scan(
+		&item.ID,
+		&item.Name,
+		&item.Description,
+		&item.Sex,
+		&item.Price,
+		&item.Discount,
+		&item.OuterLink,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&item.Version,
+		&item.Status,
+		&item.PublishAt,
+		&item.UnpublishAt,
+		&attributes,
+		&item.CategoryId,
+		&item.CategoryType,
+		&item.CategoryName,
+		&item.BrandId,
+		&item.BrandName,
+	); err != nil {
+		return domain.ItemAPI{}, err
 	}
-	defer rows.Close()
 
-	var items []domain.ItemAPI
-	for rows.Next() {
-		var item domain.ItemAPI
-		if err := rows.Scan(
-			&item.ID,
-			&item.Name,
-			&item.Description,
-			&item.Sex,
-			&item.Price,
-			&item.Discount,
-			&item.OuterLink,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-			&item.CategoryType,
-			&item.CategoryName,
-			&item.BrandName,
-		); err != nil {
-			i.logger.Error(op, sl.Err(err))
-
-			return nil, err
-		}
-		items = append(items, item)
+	if err := json.Unmarshal(attributes, &item.Attributes); err != nil {
+		return domain.ItemAPI{}, err
 	}
 
-	return items, nil
+	return item, nil
 }
 
 // Prepare data for where statement
@@ -151,10 +345,14 @@ func (i *ItemRepository) filterItems(params domain.ItemInputData) map[string]any
 		filter["i.discount"] = params.Discount
 	}
 
+	if len(params.Statuses) != 0 {
+		filter["i.status"] = params.Statuses
+	}
+
 	return filter
 }
 
-// Update item record by ID
+// Update item record by ID if it still has provided version. Version is incremented
 func (i *ItemRepository) Update(ctx context.Context, data domain.ItemUpdate) error {
 	const op = "repository.item.Update"
 
@@ -169,19 +367,28 @@ func (i *ItemRepository) Update(ctx context.Context, data domain.ItemUpdate) err
 		psql = psql.Set(col, value)
 	}
 
-	sql, args, err := psql.Set("updated_at", time.Now()).Where("id = ?", data.ID).ToSql()
+	sql, args, err := psql.Set("updated_at", time.Now()).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("id = ? AND version = ? AND deleted_at IS NULL", data.ID, data.Version).
+		ToSql()
 	if err != nil {
 		i.logger.Error(op, sl.Err(err))
 		return err
 	}
 
-	_, err = i.db.Exec(sql, args...)
+	res, err := postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
 	if err != nil {
 		i.logger.Error(op, sl.Err(err))
 		return err
 	}
 
-	return nil
+	// item is changed between reading and updating
+	err = i.checkAffected(op, res)
+	if errors.Is(err, domain.ErrItemNotFound) {
+		return domain.ErrVersionConflict
+	}
+
+	return err
 }
 
 // Prepare update set statements
@@ -213,6 +420,11 @@ func (i *ItemRepository) updateSetStatements(item domain.ItemUpdate) map[string]
 	if item.OuterLink != nil {
 		data["outer_link"] = *item.OuterLink
 	}
+	if item.Attributes != nil {
+		// attributes are validated by service, so marshal can't fail
+		attributes, _ := json.Marshal(item.Attributes)
+		data["attributes"] = attributes
+	}
 
 	return data
 }
@@ -221,11 +433,11 @@ func (i *ItemRepository) GetItemById(ctx context.Context, id int) (domain.ItemAP
 	const op = "repository.item.ItemById"
 
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
-	sql, args, err := psql.Select("i.id", "i.name", "i.description", "i.sex", "i.price", "i.discount", "i.outer_link", "i.created_at", "i.updated_at", "c.id as category_id", "c.type", "c.name AS category_name", "b.id as brand_id", "b.name").
+	sql, args, err := psql.Select("i.id", "i.name", "i.description", "i.sex", "i.price", "i.discount", "i.outer_link", "i.created_at", "i.updated_at", "i.version", "i.status", "i.publish_at", "i.unpublish_at", "i.attributes", "c.id as category_id", "c.type", "c.name AS category_name", "b.id as brand_id", "b.name").
 		From("items i").
 		LeftJoin("brand b on i.brand_id = b.id").
 		LeftJoin("category c on i.category_id = c.id").
-		Where(squirrel.Expr("i.id = ?", id)).
+		Where(squirrel.Expr("i.id = ? AND i.deleted_at IS NULL", id)).
 		ToSql()
 	if err != nil {
 		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
@@ -233,8 +445,11 @@ func (i *ItemRepository) GetItemById(ctx context.Context, id int) (domain.ItemAP
 		return domain.ItemAPI{}, err
 	}
 
-	var item domain.ItemAPI
-	err = i.db.QueryRow(sql, args...).Scan(
+	var (
+		item       domain.ItemAPI
+		attributes []byte
+	)
+	err = postgresql.Conn(ctx, i.db).QueryRowContext(ctx, sql, args...).Scan(
 		&item.ID,
 		&item.Name,
 		&item.Description,
@@ -244,6 +459,11 @@ func (i *ItemRepository) GetItemById(ctx context.Context, id int) (domain.ItemAP
 		&item.OuterLink,
 		&item.CreatedAt,
 		&item.UpdatedAt,
+		&item.Version,
+		&item.Status,
+		&item.PublishAt,
+		&item.UnpublishAt,
+		&attributes,
 		&item.CategoryId,
 		&item.CategoryType,
 		&item.CategoryName,
@@ -251,21 +471,31 @@ func (i *ItemRepository) GetItemById(ctx context.Context, id int) (domain.ItemAP
 		&item.BrandName,
 	)
 	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.ItemAPI{}, domain.ErrItemNotFound
+		}
 		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
 
 		return domain.ItemAPI{}, err
 	}
 
+	if err := json.Unmarshal(attributes, &item.Attributes); err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return domain.ItemAPI{}, err
+	}
+
 	return item, nil
 }
 
+// Move item to trash. Item with images is kept until it's purged
 func (i *ItemRepository) Delete(ctx context.Context, id int) error {
 	const op = "repository.item.Delete"
 
 	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
-		Delete("").
-		From("items").
-		Where("id = ?", id).
+		Update("items").
+		Set("deleted_at", time.Now()).
+		Where("id = ? AND deleted_at IS NULL", id).
 		ToSql()
 	if err != nil {
 		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
@@ -273,12 +503,330 @@ func (i *ItemRepository) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
-	_, err = i.db.Exec(sql, args...)
+	res, err := postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
 	if err != nil {
 		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
 
 		return err
 	}
 
+	return i.checkAffected(op, res)
+}
+
+// Change item status and publication time
+func (i *ItemRepository) SetStatus(ctx context.Context, status domain.ItemStatusUpdate) error {
+	const op = "repository.item.SetStatus"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("items").
+		Set("status", status.Status).
+		Set("publish_at", status.PublishAt).
+		Set("unpublish_at", status.UnpublishAt).
+		Set("updated_at", time.Now()).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("id = ? AND deleted_at IS NULL", status.ID).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return i.checkAffected(op, res)
+}
+
+// Publish scheduled items with publish_at before now and return their ids
+func (i *ItemRepository) PublishScheduled(ctx context.Context, now time.Time) ([]int, error) {
+	const op = "repository.item.PublishScheduled"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("items").
+		Set("status", domain.StatusPublished).
+		Set("updated_at", now).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("status = ? AND publish_at <= ? AND deleted_at IS NULL", domain.StatusScheduled, now).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	return i.queryIds(ctx, op, sql, args)
+}
+
+// Archive published items with unpublish_at before now and return their ids
+func (i *ItemRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]int, error) {
+	const op = "repository.item.ArchiveExpired"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("items").
+		Set("status", domain.StatusArchived).
+		Set("updated_at", now).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("status = ? AND unpublish_at <= ? AND deleted_at IS NULL", domain.StatusPublished, now).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	return i.queryIds(ctx, op, sql, args)
+}
+
+func (i *ItemRepository) queryIds(ctx context.Context, op, query string, args []any) ([]int, error) {
+	rows, err := postgresql.Conn(ctx, i.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Get items in trash, recently deleted first
+func (i *ItemRepository) GetDeletedItems(ctx context.Context, limit, offset uint64) ([]domain.ItemAPI, error) {
+	const op = "repository.item.GetDeletedItems"
+
+	if limit == 0 {
+		limit = limitMax
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("i.id", "i.name", "i.description", "i.sex", "i.price", "i.discount", "i.outer_link", "i.created_at", "i.updated_at", "i.version", "i.status", "i.publish_at", "i.unpublish_at", "i.deleted_at", "i.attributes", "c.id AS category_id", "c.type", "c.name AS category_name", "b.id AS brand_id", "b.name").
+		From("items i").
+		LeftJoin("brand b on i.brand_id = b.id").
+		LeftJoin("category c on i.category_id = c.id").
+		Where("i.deleted_at IS NOT NULL").
+		OrderBy("i.deleted_at DESC", "i.id DESC").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := i.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.ItemAPI
+	for rows.Next() {
+		var (
+			item       domain.ItemAPI
+			attributes []byte
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.Name,
+			&item.Description,
+			&item.Sex,
+			&item.Price,
+			&item.Discount,
+			&item.OuterLink,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.Version,
+			&item.Status,
+			&item.PublishAt,
+			&item.UnpublishAt,
+			&item.DeletedAt,
+			&attributes,
+			&item.CategoryId,
+			&item.CategoryType,
+			&item.CategoryName,
+			&item.BrandId,
+			&item.BrandName,
+		); err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+
+		if err := json.Unmarshal(attributes, &item.Attributes); err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Restore item from trash
+func (i *ItemRepository) Restore(ctx context.Context, id int) error {
+	const op = "repository.item.Restore"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("items").
+		Set("deleted_at", nil).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, i.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return i.checkAffected(op, res)
+}
+
+// Permanently delete up to limit items deleted before provided time, images are deleted by cascade.
+// purgeFn gets object ids of their images and is called before commit,
+// so items stay in trash if objects can't be deleted. Returns number of purged items
+func (i *ItemRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit uint64, purgeFn func(objectIds []string) error) (int, error) {
+	const op = "repository.item.PurgeDeleted"
+
+	var purged int
+	err := postgresql.WrapTx(ctx, i.db, func(ctx context.Context) error {
+		tx, ok := postgresql.TxFromCtx(ctx)
+		if !ok {
+			return postgresql.ErrGetTransaction
+		}
+
+		// locked items can't be restored while their images are deleted
+		query, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select("id").
+			From("items").
+			Where("deleted_at < ?", deletedBefore).
+			OrderBy("deleted_at").
+			Limit(limit).
+			Suffix("FOR UPDATE SKIP LOCKED").
+			ToSql()
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		itemIds, err := i.queryStrings(ctx, tx, op, query, args)
+		if err != nil {
+			return err
+		}
+		if len(itemIds) == 0 {
+			return nil
+		}
+
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Select("object_id").
+			From("images").
+			Where(squirrel.Eq{"item_id": itemIds}).
+			ToSql()
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		objectIds, err := i.queryStrings(ctx, tx, op, query, args)
+		if err != nil {
+			return err
+		}
+
+		if err := purgeFn(objectIds); err != nil {
+			return err
+		}
+
+		query, args, err = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+			Delete("").
+			From("items").
+			Where(squirrel.Eq{"id": itemIds}).
+			ToSql()
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+			return err
+		}
+		purged = len(itemIds)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// Query single column as strings
+func (i *ItemRepository) queryStrings(ctx context.Context, tx *sql.Tx, op, query string, args []any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, query), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			i.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// Item doesn't exist if statement by id affected no rows
+func (i *ItemRepository) checkAffected(op string, res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		i.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrItemNotFound
+	}
+
 	return nil
 }
@@ -1,11 +1,13 @@
 package repository
 
 import (
+	imdomain "cloth-mini-app/internal/domain/image"
 	domain "cloth-mini-app/internal/domain/item"
 	sl "cloth-mini-app/internal/logger"
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
@@ -41,11 +43,21 @@ func (i *ItemImageRepository) Create(ctx context.Context, item domain.ItemCreate
 		}
 		itemID = id
 
+		err = i.checkTempImagesConfirmed(ctx, item.Images)
+		if err != nil {
+			return err
+		}
+
 		err = i.createImage(ctx, id, item.Images)
 		if err != nil {
 			return err
 		}
 
+		err = i.copyTempImagesMeta(ctx, id)
+		if err != nil {
+			return err
+		}
+
 		err = i.deleteFromTempImageTable(ctx, item.Images)
 		if err != nil {
 			return err
@@ -75,10 +87,20 @@ func (i *ItemImageRepository) createItem(ctx context.Context, item domain.ItemCr
 		return 0, errGetTransaction
 	}
 
+	attributes, err := json.Marshal(item.Attributes)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : marshal attributes", op), sl.Err(err))
+
+		return 0, err
+	}
+	if item.Attributes == nil {
+		attributes = []byte("{}")
+	}
+
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
 		Insert("items").
-		Columns("brand_id", "name", "description", "sex", "category_id", "price", "discount", "outer_link", "created_at").
-		Values(item.BrandId, item.Name, item.Description, item.Sex, item.CategoryId, item.Price, item.Discount, item.OuterLink, time.Now()).
+		Columns("brand_id", "name", "description", "sex", "category_id", "price", "discount", "outer_link", "attributes", "status", "publish_at", "unpublish_at", "created_at").
+		Values(item.BrandId, item.Name, item.Description, item.Sex, item.CategoryId, item.Price, item.Discount, item.OuterLink, attributes, item.Status, item.PublishAt, item.UnpublishAt, time.Now()).
 		Suffix("RETURNING id")
 
 	sql, args, err := psql.ToSql()
@@ -99,6 +121,67 @@ func (i *ItemImageRepository) createItem(ctx context.Context, item domain.ItemCr
 	return itemId, nil
 }
 
+// Replace images of item with provided set. Images removed from item are kept in storage,
+// so they can be attached again with provided metadata. Images missed in meta get it from backfill
+func (i *ItemImageRepository) SetImages(ctx context.Context, itemId int, imageIds []string, meta map[string]imdomain.ImageMeta) error {
+	const op = "repository.item_image.SetImages"
+
+	deleteQuery := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("images").
+		Where("item_id = ?", itemId)
+	if len(imageIds) > 0 {
+		deleteQuery = deleteQuery.Where(squirrel.NotEq{"object_id": imageIds})
+	}
+
+	sql, args, err := deleteQuery.ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	conn := postgresql.Conn(ctx, i.db)
+
+	_, err = conn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	if len(imageIds) == 0 {
+		return nil
+	}
+
+	insertQuery := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("images").
+		Columns("item_id", "object_id", "uploaded_at", "blurhash", "dominant_color", "width", "height").
+		Suffix("ON CONFLICT (object_id) DO NOTHING")
+	for _, imageId := range imageIds {
+		values := []any{itemId, imageId, time.Now(), nil, nil, nil, nil}
+		if m, ok := meta[imageId]; ok {
+			values = append(values[:3], m.BlurHash, m.DominantColor, m.Width, m.Height)
+		}
+		insertQuery = insertQuery.Values(values...)
+	}
+
+	sql, args, err = insertQuery.ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
 type Image struct {
 	ItemId   uint
 	FileId   string
@@ -155,6 +238,85 @@ func (i *ItemImageRepository) createImage(ctx context.Context, itemId uint, imag
 	return nil
 }
 
+// images uploaded by presigned url can't be attached until upload is confirmed
+func (i *ItemImageRepository) checkTempImagesConfirmed(ctx context.Context, imageIds []string) error {
+	const op = "repository.item_image.checkTempImagesConfirmed"
+
+	if len(imageIds) == 0 {
+		return nil
+	}
+
+	tx, ok := postgresql.TxFromCtx(ctx)
+	if !ok {
+		i.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+		return errGetTransaction
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("count(*)").
+		From("temp_images").
+		Where(squirrel.Eq{"object_id": imageIds}).
+		Where("confirmed = false").
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	var notConfirmed int
+	err = tx.QueryRow(sql, args...).Scan(&notConfirmed)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	if notConfirmed > 0 {
+		return imdomain.ErrNotConfirmed
+	}
+
+	return nil
+}
+
+// metadata is calculated on temp image upload, so it's moved with image
+func (i *ItemImageRepository) copyTempImagesMeta(ctx context.Context, itemId uint) error {
+	const op = "repository.item_image.copyTempImagesMeta"
+
+	tx, ok := postgresql.TxFromCtx(ctx)
+	if !ok {
+		i.logger.Error(fmt.Sprintf("%s : failed get transaction from context", op))
+
+		return errGetTransaction
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("images").
+		Set("blurhash", squirrel.Expr("t.blurhash")).
+		Set("dominant_color", squirrel.Expr("t.dominant_color")).
+		Set("width", squirrel.Expr("t.width")).
+		Set("height", squirrel.Expr("t.height")).
+		From("temp_images t").
+		Where("t.object_id = images.object_id").
+		Where("images.item_id = ?", itemId).
+		ToSql()
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = tx.Exec(sql, args...)
+	if err != nil {
+		i.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
 func (i *ItemImageRepository) deleteFromTempImageTable(ctx context.Context, imageIds []string) error {
 	const op = "repository.item_image.deleteFromTempImageTable"
 
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/linkcheck"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// Failed checks in a row after saved check. Failures of previous url aren't counted
+const failuresExpr = `CASE WHEN EXCLUDED.failures = 0 THEN 0
+	WHEN link_checks.url = EXCLUDED.url THEN link_checks.failures + 1 ELSE 1 END`
+
+type LinkCheckRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewLinkCheckRepository(logger *slog.Logger, db *postgresql.Storage) *LinkCheckRepository {
+	return &LinkCheckRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Get links of items which aren't checked, changed or checked before provided time. Unchecked links go first
+func (l *LinkCheckRepository) DueLinks(ctx context.Context, checkedBefore time.Time, limit uint64) ([]domain.Link, error) {
+	const op = "repository.linkcheck.DueLinks"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("i.id", "i.outer_link").
+		From("items i").
+		LeftJoin("link_checks l ON l.item_id = i.id").
+		Where("i.deleted_at IS NULL").
+		Where("(l.item_id IS NULL OR l.url <> i.outer_link OR l.checked_at < ?)", checkedBefore).
+		OrderBy("l.checked_at NULLS FIRST", "i.id").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		l.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, l.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		l.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []domain.Link
+	for rows.Next() {
+		var link domain.Link
+		if err := rows.Scan(&link.ItemId, &link.URL); err != nil {
+			l.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// Save results of checks. Link is marked broken when it fails brokenAfter checks in a row
+func (l *LinkCheckRepository) SaveResults(ctx context.Context, results []domain.Result, brokenAfter int) error {
+	const op = "repository.linkcheck.SaveResults"
+
+	return postgresql.WrapTx(ctx, l.db, func(ctx context.Context) error {
+		for _, result := range results {
+			failures := 0
+			if !result.OK() {
+				failures = 1
+			}
+
+			var statusCode, redirectURL any
+			if result.StatusCode != 0 {
+				statusCode = result.StatusCode
+			}
+			if result.RedirectURL != "" {
+				redirectURL = result.RedirectURL
+			}
+
+			sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+				Insert("link_checks").
+				Columns("item_id", "url", "status_code", "redirect_url", "error", "failures", "broken", "checked_at").
+				Values(result.ItemId, result.URL, statusCode, redirectURL, result.Error, failures, failures >= brokenAfter, result.CheckedAt).
+				Suffix(fmt.Sprintf(`ON CONFLICT (item_id) DO UPDATE SET url = EXCLUDED.url, status_code = EXCLUDED.status_code,
+					redirect_url = EXCLUDED.redirect_url, error = EXCLUDED.error, checked_at = EXCLUDED.checked_at,
+					failures = %s, broken = (%s) >= ?`, failuresExpr, failuresExpr), brokenAfter).
+				ToSql()
+			if err != nil {
+				l.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+				return err
+			}
+
+			_, err = postgresql.Conn(ctx, l.db).ExecContext(ctx, sql, args...)
+			if err != nil {
+				l.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Get last checks of item links, broken and failing links first
+func (l *LinkCheckRepository) GetChecks(ctx context.Context, filter domain.ReportFilter) ([]domain.Check, error) {
+	const op = "repository.linkcheck.GetChecks"
+
+	q := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("l.item_id", "l.url", "l.status_code", "l.redirect_url", "l.error", "l.checked_at", "l.failures", "l.broken",
+			"i.name", "b.name").
+		From("link_checks l").
+		Join("items i ON i.id = l.item_id").
+		Join("brand b ON b.id = i.brand_id").
+		Where("i.deleted_at IS NULL").
+		OrderBy("l.broken DESC", "l.failures DESC", "l.item_id").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+	if filter.Broken != nil {
+		q = q.Where("l.broken = ?", *filter.Broken)
+	}
+
+	sql, args, err := q.ToSql()
+	if err != nil {
+		l.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, l.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		l.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []domain.Check
+	for rows.Next() {
+		var (
+			check       domain.Check
+			statusCode  *int
+			redirectURL *string
+		)
+		if err := rows.Scan(&check.ItemId, &check.URL, &statusCode, &redirectURL, &check.Error, &check.CheckedAt,
+			&check.Failures, &check.Broken, &check.ItemName, &check.BrandName); err != nil {
+			l.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		if statusCode != nil {
+			check.StatusCode = *statusCode
+		}
+		if redirectURL != nil {
+			check.RedirectURL = *redirectURL
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, rows.Err()
+}
+
+// Count items by state of their links
+func (l *LinkCheckRepository) GetSummary(ctx context.Context) (domain.Summary, error) {
+	const op = "repository.linkcheck.GetSummary"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("count(*)", "count(l.item_id)",
+			"count(*) FILTER (WHERE l.failures > 0 AND NOT l.broken)", "count(*) FILTER (WHERE l.broken)").
+		From("items i").
+		LeftJoin("link_checks l ON l.item_id = i.id").
+		Where("i.deleted_at IS NULL").
+		ToSql()
+	if err != nil {
+		l.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Summary{}, err
+	}
+
+	var summary domain.Summary
+	err = postgresql.Conn(ctx, l.db).QueryRowContext(ctx, sql, args...).
+		Scan(&summary.Items, &summary.Checked, &summary.Failing, &summary.Broken)
+	if err != nil {
+		l.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Summary{}, err
+	}
+
+	return summary, nil
+}
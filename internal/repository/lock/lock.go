@@ -5,29 +5,58 @@ import (
 	"cloth-mini-app/internal/storage/postgresql"
 	"context"
 	"database/sql"
+	"fmt"
+	"sync"
 )
 
 type LockRepository struct {
 	db *sql.DB
+
+	// advisory lock belongs to session, so it's released on the connection it was taken on
+	mu    sync.Mutex
+	conns map[domain.AdvisoryLockId]*sql.Conn
 }
 
 func NewLockRepository(db *postgresql.Storage) *LockRepository {
 	return &LockRepository{
-		db: db.DB,
+		db:    db.DB,
+		conns: make(map[domain.AdvisoryLockId]*sql.Conn),
 	}
 }
 
+// Wait for advisory lock. Connection is held until lock is released
 func (l *LockRepository) AdvisoryLock(ctx context.Context, id domain.AdvisoryLockId) error {
-	_, err := l.db.Exec("SELECT pg_advisory_lock($1)", id)
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", id)
 	if err != nil {
+		conn.Close()
+
 		return err
 	}
 
+	l.mu.Lock()
+	l.conns[id] = conn
+	l.mu.Unlock()
+
 	return nil
 }
 
 func (l *LockRepository) AdvisoryUnlock(ctx context.Context, id domain.AdvisoryLockId) error {
-	_, err := l.db.Exec("SELECT pg_advisory_unlock($1)", id)
+	l.mu.Lock()
+	conn, ok := l.conns[id]
+	delete(l.conns, id)
+	l.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("advisory lock %d isn't held", id)
+	}
+	defer conn.Close()
+
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", id)
 	if err != nil {
 		return err
 	}
@@ -210,3 +210,28 @@ func (o *OutboxRepository) ChangeStatus(ctx context.Context, eventsId []int) err
 
 	return nil
 }
+
+// Get id of the latest event, 0 if there are no events
+func (o *OutboxRepository) LastEventId(ctx context.Context) (int, error) {
+	const op = "repository.outbox.LastEventId"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("COALESCE(max(id), 0)").
+		From("outbox").
+		ToSql()
+	if err != nil {
+		o.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var id int
+	err = postgresql.Conn(ctx, o.db).QueryRowContext(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		o.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return id, nil
+}
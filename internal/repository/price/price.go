@@ -0,0 +1,104 @@
+package repository
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/item"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type PriceHistoryRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewPriceHistoryRepository(logger *slog.Logger, db *postgresql.Storage) *PriceHistoryRepository {
+	return &PriceHistoryRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Record change of item price. Must be called in transaction of the change, actor is taken from context
+func (p *PriceHistoryRepository) Create(ctx context.Context, change domain.PriceChange) error {
+	const op = "repository.price.Create"
+
+	actor, _ := adomain.ActorFromContext(ctx)
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("price_history").
+		Columns("item_id", "price", "discount", "prev_price", "prev_discount", "source", "user_id", "api_key_id").
+		Values(change.ItemId, change.Price, change.Discount, change.PrevPrice, change.PrevDiscount, change.Source,
+			nullId(actor.UserId), nullId(actor.APIKeyId)).
+		ToSql()
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, p.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Get price changes of item, latest first
+func (p *PriceHistoryRepository) GetHistory(ctx context.Context, itemId int, limit, offset uint64) ([]domain.PriceChange, error) {
+	const op = "repository.price.GetHistory"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("id", "item_id", "price", "discount", "prev_price", "prev_discount", "source",
+			"COALESCE(user_id, 0)", "COALESCE(api_key_id, 0)", "created_at").
+		From("price_history").
+		Where("item_id = ?", itemId).
+		OrderBy("id DESC").
+		Limit(limit).
+		Offset(offset).
+		ToSql()
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, p.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		p.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]domain.PriceChange, 0)
+	for rows.Next() {
+		var change domain.PriceChange
+		if err := rows.Scan(&change.ID, &change.ItemId, &change.Price, &change.Discount, &change.PrevPrice, &change.PrevDiscount,
+			&change.Source, &change.Actor.UserId, &change.Actor.APIKeyId, &change.CreatedAt); err != nil {
+			p.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func nullId(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
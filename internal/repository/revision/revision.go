@@ -0,0 +1,208 @@
+package repository
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/item"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// current state of item i, same as in migration of item_revisions plus metadata of images,
+// so images removed from item get it back on rollback
+const snapshotQuery = `jsonb_build_object(
+	'brand_id', i.brand_id,
+	'name', i.name,
+	'description', i.description,
+	'sex', i.sex,
+	'category_id', i.category_id,
+	'price', i.price,
+	'discount', COALESCE(i.discount, 0),
+	'outer_link', i.outer_link,
+	'attributes', i.attributes,
+	'images', COALESCE((SELECT jsonb_agg(im.object_id ORDER BY im.object_id) FROM images im WHERE im.item_id = i.id), '[]'::jsonb),
+	'image_meta', COALESCE((SELECT jsonb_object_agg(im.object_id, jsonb_build_object(
+		'blurhash', im.blurhash,
+		'dominant_color', im.dominant_color,
+		'width', im.width,
+		'height', im.height
+	)) FROM images im WHERE im.item_id = i.id AND im.blurhash IS NOT NULL), '{}'::jsonb)
+)`
+
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
+type RevisionRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewRevisionRepository(logger *slog.Logger, db *postgresql.Storage) *RevisionRepository {
+	return &RevisionRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Store current state of item as revision of its current version. Must be called in transaction
+// of the change, so revision matches stored item. Actor is taken from context
+func (r *RevisionRepository) Create(ctx context.Context, itemId int, restoredFrom *int) error {
+	const op = "repository.revision.Create"
+
+	actor, _ := adomain.ActorFromContext(ctx)
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("item_revisions").
+		Columns("item_id", "version", "snapshot", "restored_from", "user_id", "api_key_id").
+		Select(squirrel.Select("i.id", "i.version", snapshotQuery).
+			Column("?::int", restoredFrom).
+			Column("?::int", nullId(actor.UserId)).
+			Column("?::int", nullId(actor.APIKeyId)).
+			From("items i").
+			Where("i.id = ?", itemId)).
+		ToSql()
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, r.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrItemNotFound
+	}
+
+	return nil
+}
+
+// Get revisions of item, latest first
+func (r *RevisionRepository) GetRevisions(ctx context.Context, itemId int, limit, offset uint64) ([]domain.Revision, error) {
+	const op = "repository.revision.GetRevisions"
+
+	psql := r.selectRevisions().
+		Where("r.item_id = ?", itemId).
+		OrderBy("r.version DESC").
+		Limit(limit)
+	if offset != 0 {
+		psql = psql.Offset(offset)
+	}
+
+	sql, args, err := psql.ToSql()
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, r.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]domain.Revision, 0)
+	for rows.Next() {
+		revision, err := r.scanRevision(rows)
+		if err != nil {
+			r.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// Get revision of item version
+func (r *RevisionRepository) GetRevision(ctx context.Context, itemId, version int) (domain.Revision, error) {
+	const op = "repository.revision.GetRevision"
+
+	sql, args, err := r.selectRevisions().
+		Where("r.item_id = ? AND r.version = ?", itemId, version).
+		ToSql()
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Revision{}, err
+	}
+
+	revision, err := r.scanRevision(postgresql.Conn(ctx, r.db).QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Revision{}, domain.ErrRevisionNotFound
+		}
+		r.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Revision{}, err
+	}
+
+	return revision, nil
+}
+
+func (r *RevisionRepository) selectRevisions() squirrel.SelectBuilder {
+	return squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("r.id", "r.item_id", "r.version", "r.snapshot", "r.restored_from", "r.user_id", "r.api_key_id", "COALESCE(u.login, k.name, '')", "r.created_at").
+		From("item_revisions r").
+		LeftJoin("users u ON u.id = r.user_id").
+		LeftJoin("api_key k ON k.id = r.api_key_id")
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func (r *RevisionRepository) scanRevision(row scanner) (domain.Revision, error) {
+	var (
+		revision      domain.Revision
+		snapshot      []byte
+		userId, keyId *int
+	)
+	err := row.Scan(&revision.ID, &revision.ItemId, &revision.Version, &snapshot, &revision.RestoredFrom, &userId, &keyId, &revision.ActorName, &revision.CreatedAt)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+	if userId != nil {
+		revision.Actor.UserId = *userId
+	}
+	if keyId != nil {
+		revision.Actor.APIKeyId = *keyId
+	}
+
+	err = json.Unmarshal(snapshot, &revision.Snapshot)
+	if err != nil {
+		return domain.Revision{}, err
+	}
+
+	return revision, nil
+}
+
+func nullId(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
@@ -0,0 +1,416 @@
+package repository
+
+import (
+	domain "cloth-mini-app/internal/domain/scrape"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// sql package is shadowed by query variables
+var errNoRows = sql.ErrNoRows
+
+type ScrapeRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewScrapeRepository(logger *slog.Logger, db *postgresql.Storage) *ScrapeRepository {
+	return &ScrapeRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+// Get items with outer links which aren't scraped, changed or scraped before provided time. Unscraped items go first
+func (s *ScrapeRepository) DueTargets(ctx context.Context, scrapedBefore time.Time, limit uint64) ([]domain.Target, error) {
+	const op = "repository.scrape.DueTargets"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("i.id", "i.outer_link", "i.price", "COALESCE(i.discount, 0)", "i.version").
+		From("items i").
+		LeftJoin("scrape_results r ON r.item_id = i.id").
+		Where("i.deleted_at IS NULL AND i.outer_link <> ''").
+		Where("(r.item_id IS NULL OR r.url <> i.outer_link OR r.scraped_at < ?)", scrapedBefore).
+		OrderBy("r.scraped_at NULLS FIRST", "i.id").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, s.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []domain.Target
+	for rows.Next() {
+		var target domain.Target
+		if err := rows.Scan(&target.ItemId, &target.URL, &target.Price, &target.Discount, &target.Version); err != nil {
+			s.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+// Save last scrape of item page
+func (s *ScrapeRepository) SaveResult(ctx context.Context, result domain.Result) error {
+	const op = "repository.scrape.SaveResult"
+
+	var price, discount, available any
+	extractor := ""
+	if result.Offer != nil {
+		price, discount, available = result.Offer.Price, result.Offer.Discount, result.Offer.Available
+		extractor = result.Offer.Extractor
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("scrape_results").
+		Columns("item_id", "url", "price", "discount", "available", "extractor", "error", "scraped_at").
+		Values(result.ItemId, result.URL, price, discount, available, extractor, result.Error, result.ScrapedAt).
+		Suffix(`ON CONFLICT (item_id) DO UPDATE SET url = EXCLUDED.url, price = EXCLUDED.price, discount = EXCLUDED.discount,
+			available = EXCLUDED.available, extractor = EXCLUDED.extractor, error = EXCLUDED.error, scraped_at = EXCLUDED.scraped_at`).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, s.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *ScrapeRepository) GetRules(ctx context.Context) ([]domain.Rule, error) {
+	const op = "repository.scrape.GetRules"
+
+	sql, args, err := s.selectRules().
+		OrderBy("domain").
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, s.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			s.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// Find rule of host or the closest parent domain of it. ErrRuleNotFound if there is none
+func (s *ScrapeRepository) FindRule(ctx context.Context, host string) (domain.Rule, error) {
+	const op = "repository.scrape.FindRule"
+
+	sql, args, err := s.selectRules().
+		Where("(domain = ? OR right(?::text, length(domain) + 1) = '.' || domain)", host, host).
+		OrderBy("length(domain) DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Rule{}, err
+	}
+
+	rule, err := scanRule(postgresql.Conn(ctx, s.db).QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Rule{}, domain.ErrRuleNotFound
+		}
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Rule{}, err
+	}
+
+	return rule, nil
+}
+
+func (s *ScrapeRepository) selectRules() squirrel.SelectBuilder {
+	return squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("domain", "price_selector", "old_price_selector", "availability_selector", "in_stock_text", "auto_apply", "updated_at").
+		From("scrape_rules")
+}
+
+func scanRule(row interface{ Scan(dest ...any) error }) (domain.Rule, error) {
+	var rule domain.Rule
+	err := row.Scan(&rule.Domain, &rule.PriceSelector, &rule.OldPriceSelector, &rule.AvailabilitySelector,
+		&rule.InStockText, &rule.AutoApply, &rule.UpdatedAt)
+
+	return rule, err
+}
+
+// Create or replace rule of domain
+func (s *ScrapeRepository) SaveRule(ctx context.Context, rule domain.Rule) error {
+	const op = "repository.scrape.SaveRule"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("scrape_rules").
+		Columns("domain", "price_selector", "old_price_selector", "availability_selector", "in_stock_text", "auto_apply").
+		Values(rule.Domain, rule.PriceSelector, rule.OldPriceSelector, rule.AvailabilitySelector, rule.InStockText, rule.AutoApply).
+		Suffix(`ON CONFLICT (domain) DO UPDATE SET price_selector = EXCLUDED.price_selector,
+			old_price_selector = EXCLUDED.old_price_selector, availability_selector = EXCLUDED.availability_selector,
+			in_stock_text = EXCLUDED.in_stock_text, auto_apply = EXCLUDED.auto_apply, updated_at = now()`).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, s.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *ScrapeRepository) DeleteRule(ctx context.Context, domainName string) error {
+	const op = "repository.scrape.DeleteRule"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("scrape_rules").
+		Where("domain = ?", domainName).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	res, err := postgresql.Conn(ctx, s.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// Create pending proposal of item or replace it with newer scraped price
+func (s *ScrapeRepository) SaveProposal(ctx context.Context, proposal domain.Proposal) error {
+	const op = "repository.scrape.SaveProposal"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("price_proposals").
+		Columns("item_id", "url", "price", "discount", "available", "version").
+		Values(proposal.ItemId, proposal.URL, proposal.Price, proposal.Discount, proposal.Available, proposal.Version).
+		Suffix(`ON CONFLICT (item_id) WHERE status = 'pending' DO UPDATE SET url = EXCLUDED.url, price = EXCLUDED.price,
+			discount = EXCLUDED.discount, available = EXCLUDED.available, version = EXCLUDED.version, created_at = now()`).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, s.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete pending proposal of item, e.g. when shop price is the same as item price again
+func (s *ScrapeRepository) DeletePendingProposal(ctx context.Context, itemId int) error {
+	const op = "repository.scrape.DeletePendingProposal"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("price_proposals").
+		Where("item_id = ? AND status = ?", itemId, domain.StatusPending).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = postgresql.Conn(ctx, s.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Get proposals with current prices of items, latest first
+func (s *ScrapeRepository) GetProposals(ctx context.Context, filter domain.ProposalFilter) ([]domain.Proposal, error) {
+	const op = "repository.scrape.GetProposals"
+
+	q := s.selectProposals().
+		OrderBy("p.created_at DESC", "p.id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+	if filter.Status != "" {
+		q = q.Where("p.status = ?", filter.Status)
+	}
+
+	sql, args, err := q.ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := postgresql.Conn(ctx, s.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := make([]domain.Proposal, 0)
+	for rows.Next() {
+		proposal, err := scanProposal(rows)
+		if err != nil {
+			s.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, rows.Err()
+}
+
+func (s *ScrapeRepository) GetProposal(ctx context.Context, id int64) (domain.Proposal, error) {
+	const op = "repository.scrape.GetProposal"
+
+	sql, args, err := s.selectProposals().
+		Where("p.id = ?", id).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.Proposal{}, err
+	}
+
+	proposal, err := scanProposal(postgresql.Conn(ctx, s.db).QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return domain.Proposal{}, domain.ErrProposalNotFound
+		}
+		s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return domain.Proposal{}, err
+	}
+
+	return proposal, nil
+}
+
+func (s *ScrapeRepository) selectProposals() squirrel.SelectBuilder {
+	return squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("p.id", "p.item_id", "i.name", "p.url", "p.price", "p.discount", "p.available", "i.price", "COALESCE(i.discount, 0)",
+			"p.version", "p.status", "p.created_at", "p.resolved_at").
+		From("price_proposals p").
+		Join("items i ON i.id = p.item_id").
+		Where("i.deleted_at IS NULL")
+}
+
+func scanProposal(row interface{ Scan(dest ...any) error }) (domain.Proposal, error) {
+	var proposal domain.Proposal
+	err := row.Scan(&proposal.ID, &proposal.ItemId, &proposal.ItemName, &proposal.URL, &proposal.Price, &proposal.Discount,
+		&proposal.Available, &proposal.ItemPrice, &proposal.ItemDiscount, &proposal.Version, &proposal.Status,
+		&proposal.CreatedAt, &proposal.ResolvedAt)
+
+	return proposal, err
+}
+
+// Mark pending proposal applied or rejected and run change in the same transaction,
+// so proposal is resolved only once. ErrProposalResolved if it isn't pending
+func (s *ScrapeRepository) ResolveProposal(ctx context.Context, id int64, status domain.ProposalStatus, change func(ctx context.Context) error) error {
+	const op = "repository.scrape.ResolveProposal"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Update("price_proposals").
+		Set("status", status).
+		Set("resolved_at", squirrel.Expr("now()")).
+		Where("id = ? AND status = ?", id, domain.StatusPending).
+		ToSql()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	return postgresql.WrapTx(ctx, s.db, func(ctx context.Context) error {
+		res, err := postgresql.Conn(ctx, s.db).ExecContext(ctx, sql, args...)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			s.logger.Error(op, sl.Err(err))
+
+			return err
+		}
+		if affected == 0 {
+			return domain.ErrProposalResolved
+		}
+
+		return change(ctx)
+	})
+}
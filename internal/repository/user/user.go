@@ -0,0 +1,217 @@
+package user
+
+import (
+	domain "cloth-mini-app/internal/domain/user"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/postgresql"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var (
+	userColumns = []string{"id", "login", "password_hash", "role", "created_at"}
+
+	// sql package is shadowed by query variables
+	errNoRows = sql.ErrNoRows
+)
+
+type UserRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewUserRepository(logger *slog.Logger, db *postgresql.Storage) *UserRepository {
+	return &UserRepository{
+		db:     db.DB,
+		logger: logger,
+	}
+}
+
+func (u *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+	const op = "repository.user.GetUsers"
+
+	sql, _, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(userColumns...).
+		From("users").
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return nil, err
+	}
+
+	rows, err := u.db.QueryContext(ctx, sql)
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.ID, &user.Login, &user.PasswordHash, &user.Role, &user.CreatedAt); err != nil {
+			u.logger.Error(op, sl.Err(err))
+
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (u *UserRepository) GetUser(ctx context.Context, userId int) (domain.User, error) {
+	return u.getUser(ctx, "repository.user.GetUser", squirrel.Eq{"id": userId})
+}
+
+func (u *UserRepository) GetUserByLogin(ctx context.Context, login string) (domain.User, error) {
+	return u.getUser(ctx, "repository.user.GetUserByLogin", squirrel.Eq{"login": login})
+}
+
+func (u *UserRepository) getUser(ctx context.Context, op string, where squirrel.Eq) (domain.User, error) {
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select(userColumns...).
+		From("users").
+		Where(where).
+		ToSql()
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return domain.User{}, err
+	}
+
+	var user domain.User
+	err = u.db.QueryRowContext(ctx, sql, args...).Scan(&user.ID, &user.Login, &user.PasswordHash, &user.Role, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return user, domain.ErrUserNotFound
+		}
+		u.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return user, err
+	}
+
+	return user, nil
+}
+
+// Create user with hashed password and return its id
+func (u *UserRepository) Create(ctx context.Context, user domain.User) (int, error) {
+	const op = "repository.user.Create"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("users").
+		Columns("login", "password_hash", "role").
+		Values(user.Login, user.PasswordHash, user.Role).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var userId int
+	err = u.db.QueryRowContext(ctx, sql, args...).Scan(&userId)
+	if err != nil {
+		if postgresql.IsDuplicateKeyError(err) {
+			return 0, domain.ErrUserExists
+		}
+		u.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+func (u *UserRepository) CountUsers(ctx context.Context) (int, error) {
+	const op = "repository.user.CountUsers"
+
+	var count int
+	err := u.db.QueryRowContext(ctx, "SELECT count(*) FROM users").Scan(&count)
+	if err != nil {
+		u.logger.Error(op, sl.Err(err))
+
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Store refresh token hash. Expired tokens of user are deleted, so unused tokens don't pile up
+func (u *UserRepository) CreateRefreshToken(ctx context.Context, tokenHash string, userId int, expiresAt time.Time) error {
+	const op = "repository.user.CreateRefreshToken"
+
+	_, err := u.db.ExecContext(ctx, "DELETE FROM refresh_token WHERE user_id = $1 AND expires_at < now()", userId)
+	if err != nil {
+		u.logger.Error(op, sl.Err(err))
+
+		return err
+	}
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Insert("refresh_token").
+		Columns("token_hash", "user_id", "expires_at").
+		Values(tokenHash, userId, expiresAt).
+		ToSql()
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return err
+	}
+
+	_, err = u.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete refresh token and return its user. Token is deleted in the same statement,
+// so concurrent requests can't use it twice. Expired token is deleted too, but isn't accepted
+func (u *UserRepository) UseRefreshToken(ctx context.Context, tokenHash string) (int, error) {
+	const op = "repository.user.UseRefreshToken"
+
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Delete("refresh_token").
+		Where("token_hash = ?", tokenHash).
+		Suffix("RETURNING user_id, expires_at > now()").
+		ToSql()
+	if err != nil {
+		u.logger.Error(fmt.Sprintf("%s : building sql query", op), sl.Err(err))
+
+		return 0, err
+	}
+
+	var (
+		userId int
+		valid  bool
+	)
+	err = u.db.QueryRowContext(ctx, sql, args...).Scan(&userId, &valid)
+	if err != nil {
+		if errors.Is(err, errNoRows) {
+			return 0, domain.ErrTokenInvalid
+		}
+		u.logger.Error(fmt.Sprintf("%s: %s", op, sql), sl.Err(err))
+
+		return 0, err
+	}
+
+	if !valid {
+		return 0, domain.ErrTokenInvalid
+	}
+
+	return userId, nil
+}
@@ -0,0 +1,271 @@
+package scraper
+
+import (
+	domain "cloth-mini-app/internal/domain/scrape"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Finds offer on parsed shop page. Returns domain.ErrNoOffer if page has no offer in its format,
+// so next extractor is tried
+type Extractor interface {
+	Name() string
+	Extract(page *html.Node) (domain.Offer, error)
+}
+
+// schema.org availability values meaning item can be bought
+var inStock = map[string]bool{
+	"InStock":             true,
+	"LimitedAvailability": true,
+	"OnlineOnly":          true,
+	"InStoreOnly":         true,
+}
+
+// Extractor of schema.org Product in JSON-LD scripts
+type JSONLD struct{}
+
+func (JSONLD) Name() string {
+	return domain.ExtractorJSONLD
+}
+
+func (JSONLD) Extract(page *html.Node) (domain.Offer, error) {
+	for node := range page.Descendants() {
+		if node.Type != html.ElementNode || node.Data != "script" || !strings.Contains(attribute(node, "type"), "ld+json") {
+			continue
+		}
+
+		var data any
+		if err := json.Unmarshal([]byte(scriptText(node)), &data); err != nil {
+			continue
+		}
+		if offer, ok := findProduct(data); ok {
+			return offer, nil
+		}
+	}
+
+	return domain.Offer{}, domain.ErrNoOffer
+}
+
+func scriptText(node *html.Node) string {
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(child.Data)
+	}
+
+	return b.String()
+}
+
+// Find first Product with offers in JSON-LD value, products may be nested in @graph or arrays
+func findProduct(data any) (domain.Offer, bool) {
+	switch value := data.(type) {
+	case []any:
+		for _, v := range value {
+			if offer, ok := findProduct(v); ok {
+				return offer, true
+			}
+		}
+	case map[string]any:
+		if hasType(value, "Product") {
+			if offer, ok := productOffer(value["offers"]); ok {
+				return offer, true
+			}
+		}
+		for _, key := range []string{"@graph", "mainEntity", "itemListElement"} {
+			if offer, ok := findProduct(value[key]); ok {
+				return offer, true
+			}
+		}
+	}
+
+	return domain.Offer{}, false
+}
+
+// Offer with the lowest price, item is available if any of offers is available
+func productOffer(data any) (domain.Offer, bool) {
+	var (
+		best      domain.Offer
+		available *bool
+		found     bool
+	)
+	for _, offer := range offers(data) {
+		price, ok := jsonPrice(offer["price"])
+		if !ok {
+			price, ok = jsonPrice(offer["lowPrice"])
+		}
+		if !ok {
+			continue
+		}
+
+		if availability, ok := offer["availability"].(string); ok {
+			inStock := inStock[availability[strings.LastIndexByte(availability, '/')+1:]]
+			if available == nil || inStock {
+				available = &inStock
+			}
+		}
+
+		current := domain.NewOffer(price, strikethroughPrice(offer["priceSpecification"]))
+		if !found || salePrice(current) < salePrice(best) {
+			best = current
+		}
+		found = true
+	}
+
+	best.Available = available
+
+	return best, found
+}
+
+func salePrice(offer domain.Offer) uint {
+	return offer.Price * (100 - offer.Discount) / 100
+}
+
+// Offers as list of objects, offers can be single object, array or AggregateOffer with nested offers
+func offers(data any) []map[string]any {
+	switch value := data.(type) {
+	case map[string]any:
+		if nested, ok := value["offers"]; ok && hasType(value, "AggregateOffer") {
+			if list := offers(nested); len(list) > 0 {
+				return list
+			}
+		}
+		return []map[string]any{value}
+	case []any:
+		var list []map[string]any
+		for _, v := range value {
+			list = append(list, offers(v)...)
+		}
+		return list
+	}
+
+	return nil
+}
+
+// Price before discount from price specifications with StrikethroughPrice or ListPrice type, 0 if there is none
+func strikethroughPrice(data any) uint {
+	var specs []any
+	switch value := data.(type) {
+	case map[string]any:
+		specs = []any{value}
+	case []any:
+		specs = value
+	}
+
+	for _, spec := range specs {
+		spec, ok := spec.(map[string]any)
+		if !ok {
+			continue
+		}
+		priceType, _ := spec["priceType"].(string)
+		if strings.HasSuffix(priceType, "StrikethroughPrice") || strings.HasSuffix(priceType, "ListPrice") {
+			if price, ok := jsonPrice(spec["price"]); ok {
+				return price
+			}
+		}
+	}
+
+	return 0
+}
+
+func jsonPrice(value any) (uint, bool) {
+	switch price := value.(type) {
+	case float64:
+		if price <= 0 {
+			return 0, false
+		}
+		return uint(price + 0.5), true
+	case string:
+		return parsePrice(price)
+	}
+
+	return 0, false
+}
+
+func hasType(value map[string]any, name string) bool {
+	switch t := value["@type"].(type) {
+	case string:
+		return t == name || strings.HasSuffix(t, "/"+name)
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && (s == name || strings.HasSuffix(s, "/"+name)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Extractor of elements found by CSS selectors of shop rule
+type Selectors struct {
+	price        Selector
+	oldPrice     *Selector
+	availability *Selector
+	inStockText  string
+}
+
+// Compile selectors of rule, rule without price selector has no extractor
+func NewSelectors(rule domain.Rule) (*Selectors, error) {
+	if strings.TrimSpace(rule.PriceSelector) == "" {
+		return nil, domain.ErrSelector
+	}
+
+	price, err := CompileSelector(rule.PriceSelector)
+	if err != nil {
+		return nil, domain.ErrSelector
+	}
+	selectors := &Selectors{
+		price:       price,
+		inStockText: strings.ToLower(strings.TrimSpace(rule.InStockText)),
+	}
+
+	if strings.TrimSpace(rule.OldPriceSelector) != "" {
+		oldPrice, err := CompileSelector(rule.OldPriceSelector)
+		if err != nil {
+			return nil, domain.ErrSelector
+		}
+		selectors.oldPrice = &oldPrice
+	}
+	if strings.TrimSpace(rule.AvailabilitySelector) != "" {
+		availability, err := CompileSelector(rule.AvailabilitySelector)
+		if err != nil {
+			return nil, domain.ErrSelector
+		}
+		selectors.availability = &availability
+	}
+
+	return selectors, nil
+}
+
+func (s *Selectors) Name() string {
+	return domain.ExtractorSelectors
+}
+
+// Availability element must be on page when item is in stock. If in stock text is set, element must contain it
+func (s *Selectors) Extract(page *html.Node) (domain.Offer, error) {
+	node := s.price.First(page)
+	if node == nil {
+		return domain.Offer{}, domain.ErrNoOffer
+	}
+	price, ok := parsePrice(text(node))
+	if !ok {
+		return domain.Offer{}, domain.ErrNoOffer
+	}
+
+	var oldPrice uint
+	if s.oldPrice != nil {
+		if node := s.oldPrice.First(page); node != nil {
+			oldPrice, _ = parsePrice(text(node))
+		}
+	}
+
+	offer := domain.NewOffer(price, oldPrice)
+	if s.availability != nil {
+		node := s.availability.First(page)
+		available := node != nil && strings.Contains(strings.ToLower(text(node)), s.inStockText)
+		offer.Available = &available
+	}
+
+	return offer, nil
+}
@@ -0,0 +1,45 @@
+package scraper
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse price in rubles from text like "4 990,00 ₽", "4,990.50" or "1.299". Kopecks are rounded
+func parsePrice(s string) (uint, bool) {
+	start := strings.IndexFunc(s, unicode.IsDigit)
+	if start == -1 {
+		return 0, false
+	}
+
+	// first run of digits with separators, spaces of any kind group thousands
+	var number strings.Builder
+	for _, r := range s[start:] {
+		if unicode.IsDigit(r) || r == '.' || r == ',' {
+			number.WriteRune(r)
+		} else if !unicode.IsSpace(r) && r != '\'' {
+			break
+		}
+	}
+	digits := strings.TrimRight(number.String(), ".,")
+
+	// last separator followed by 1-2 digits is decimal, other separators group thousands
+	decimal := strings.LastIndexAny(digits, ".,")
+	if decimal != -1 && len(digits)-decimal-1 > 2 {
+		decimal = -1
+	}
+	integer, fraction := digits, ""
+	if decimal != -1 {
+		integer, fraction = digits[:decimal], digits[decimal+1:]
+	}
+	integer = strings.NewReplacer(".", "", ",", "").Replace(integer)
+
+	value, err := strconv.ParseFloat("0"+integer+"."+fraction+"0", 64)
+	if err != nil || value <= 0 || value > math.MaxInt32 {
+		return 0, false
+	}
+
+	return uint(math.Round(value)), true
+}
@@ -0,0 +1,137 @@
+// Package scraper fetches shop pages of items and extracts price, discount and availability.
+// Extractors are tried in order: extractors registered for shop domain, JSON-LD Product,
+// then CSS selectors of shop rule
+package scraper
+
+import (
+	domain "cloth-mini-app/internal/domain/scrape"
+	"cloth-mini-app/internal/safehttp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Pages larger than limit are cut, offer is usually in head or near the top
+const bodyLimit = 1 << 20
+
+type Config struct {
+	// timeout of one request with redirects
+	Timeout   time.Duration
+	UserAgent string
+	// pages on private addresses are scraped too, only for development and tests
+	AllowPrivate bool
+}
+
+type Scraper struct {
+	client *http.Client
+	config Config
+	// domain => extractors of shop pages
+	extractors map[string][]Extractor
+}
+
+func NewScraper(config Config) *Scraper {
+	return &Scraper{
+		// links are provided by editors and partners, so internal services aren't reachable by them
+		client:     safehttp.NewClient(config.Timeout, config.AllowPrivate),
+		config:     config,
+		extractors: make(map[string][]Extractor),
+	}
+}
+
+// Register extractor for pages of domain and its subdomains. Registered extractors are tried before
+// JSON-LD, so shop with broken markup can get own extractor. Must be called before scraping
+func (s *Scraper) Register(domainName string, extractor Extractor) {
+	domainName = strings.TrimPrefix(strings.ToLower(domainName), "www.")
+	s.extractors[domainName] = append(s.extractors[domainName], extractor)
+}
+
+// Fetch page and extract offer from it. Selectors of rule are used if page has no JSON-LD Product, rule may be nil
+func (s *Scraper) Scrape(ctx context.Context, link string, rule *domain.Rule) (domain.Offer, error) {
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return domain.Offer{}, fmt.Errorf("invalid url %q", link)
+	}
+
+	page, err := s.fetch(ctx, link)
+	if err != nil {
+		return domain.Offer{}, err
+	}
+
+	extractors := append(s.domainExtractors(parsed.Hostname()), JSONLD{})
+	if rule != nil && rule.PriceSelector != "" {
+		selectors, err := NewSelectors(*rule)
+		if err != nil {
+			return domain.Offer{}, err
+		}
+		extractors = append(extractors, selectors)
+	}
+
+	return Extract(page, extractors...)
+}
+
+// Extract offer with the first extractor which finds it on page
+func Extract(page *html.Node, extractors ...Extractor) (domain.Offer, error) {
+	for _, extractor := range extractors {
+		offer, err := extractor.Extract(page)
+		if err == nil {
+			offer.Extractor = extractor.Name()
+			return offer, nil
+		}
+		if !errors.Is(err, domain.ErrNoOffer) {
+			return domain.Offer{}, fmt.Errorf("%s: %w", extractor.Name(), err)
+		}
+	}
+
+	return domain.Offer{}, domain.ErrNoOffer
+}
+
+// Extractors of host and its parent domains, closest domain first
+func (s *Scraper) domainExtractors(host string) []Extractor {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+
+	var extractors []Extractor
+	for {
+		extractors = append(extractors, s.extractors[host]...)
+
+		dot := strings.IndexByte(host, '.')
+		if dot == -1 {
+			return extractors
+		}
+		host = host[dot+1:]
+	}
+}
+
+func (s *Scraper) fetch(ctx context.Context, link string) (*html.Node, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "text/html,application/xhtml+xml")
+	if s.config.UserAgent != "" {
+		request.Header.Set("User-Agent", s.config.UserAgent)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		io.Copy(io.Discard, io.LimitReader(response.Body, bodyLimit))
+		return nil, fmt.Errorf("shop responded with status %d", response.StatusCode)
+	}
+
+	return html.Parse(io.LimitReader(response.Body, bodyLimit))
+}
@@ -0,0 +1,195 @@
+package scraper
+
+import (
+	domain "cloth-mini-app/internal/domain/scrape"
+	"cloth-mini-app/internal/safehttp"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Shop serving recorded pages from testdata
+func newShop(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("testdata")))
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestScrape(t *testing.T) {
+	server := newShop(t)
+	scraper := NewScraper(Config{Timeout: time.Second, UserAgent: "scraper-test", AllowPrivate: true})
+
+	rule := &domain.Rule{
+		PriceSelector:        ".product-card .price--sale",
+		OldPriceSelector:     ".product-card > .product-card__prices > span.price--old",
+		AvailabilitySelector: "div[data-stock=true]",
+		InStockText:          "В наличии",
+	}
+	available, soldOut := true, false
+
+	tests := []struct {
+		page  string
+		rule  *domain.Rule
+		offer domain.Offer
+		err   error
+	}{
+		{
+			// the cheapest offer is on sale, item is available in one of sizes
+			page:  "jsonld_product.html",
+			offer: domain.Offer{Price: 9990, Discount: 25, Available: &available, Extractor: domain.ExtractorJSONLD},
+		},
+		{
+			page:  "selectors_shop.html",
+			rule:  rule,
+			offer: domain.Offer{Price: 4990, Discount: 30, Available: &available, Extractor: domain.ExtractorSelectors},
+		},
+		{
+			page: "selectors_shop.html",
+			err:  domain.ErrNoOffer,
+		},
+		{
+			// JSON-LD goes before selectors of rule
+			page:  "out_of_stock.html",
+			rule:  rule,
+			offer: domain.Offer{Price: 12990, Available: &soldOut, Extractor: domain.ExtractorJSONLD},
+		},
+		{
+			page: "no_offer.html",
+			rule: rule,
+			err:  domain.ErrNoOffer,
+		},
+	}
+
+	for _, tt := range tests {
+		offer, err := scraper.Scrape(context.Background(), server.URL+"/"+tt.page, tt.rule)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: expected error %v, got %v", tt.page, tt.err, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		if offer.Price != tt.offer.Price || offer.Discount != tt.offer.Discount || offer.Extractor != tt.offer.Extractor {
+			t.Errorf("%s: expected offer %+v, got %+v", tt.page, tt.offer, offer)
+		}
+		if offer.Available == nil || *offer.Available != *tt.offer.Available {
+			t.Errorf("%s: expected availability %v, got %v", tt.page, *tt.offer.Available, offer.Available)
+		}
+	}
+
+	if _, err := scraper.Scrape(context.Background(), server.URL+"/gone", rule); err == nil || errors.Is(err, domain.ErrNoOffer) {
+		t.Errorf("expected status error for gone page, got %v", err)
+	}
+}
+
+type fixedExtractor struct{}
+
+func (fixedExtractor) Name() string {
+	return "fixed"
+}
+
+func (fixedExtractor) Extract(page *html.Node) (domain.Offer, error) {
+	return domain.Offer{Price: 100}, nil
+}
+
+func TestScrapeRegisteredExtractor(t *testing.T) {
+	server := newShop(t)
+	scraper := NewScraper(Config{Timeout: time.Second, AllowPrivate: true})
+	scraper.Register("127.0.0.1", fixedExtractor{})
+
+	offer, err := scraper.Scrape(context.Background(), server.URL+"/jsonld_product.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offer.Price != 100 || offer.Extractor != "fixed" {
+		t.Errorf("expected offer of registered extractor, got %+v", offer)
+	}
+}
+
+func TestScrapeRefusesPrivateAddresses(t *testing.T) {
+	server := newShop(t)
+	scraper := NewScraper(Config{Timeout: time.Second})
+
+	for _, link := range []string{server.URL + "/jsonld_product.html", "http://169.254.169.254/latest/meta-data/"} {
+		_, err := scraper.Scrape(context.Background(), link, nil)
+		if !errors.Is(err, safehttp.ErrForbiddenAddress) {
+			t.Errorf("%s: expected forbidden address error, got %v", link, err)
+		}
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		text  string
+		price uint
+		ok    bool
+	}{
+		{text: "4 990 ₽", price: 4990, ok: true},
+		{text: "4 990,00 руб.", price: 4990, ok: true},
+		{text: "4 990.50", price: 4991, ok: true},
+		{text: "$1,299.99", price: 1300, ok: true},
+		{text: "1.299", price: 1299, ok: true},
+		{text: "Цена: 12 345 руб.", price: 12345, ok: true},
+		{text: "490", price: 490, ok: true},
+		{text: "0,00", ok: false},
+		{text: "бесплатно", ok: false},
+	}
+
+	for _, tt := range tests {
+		price, ok := parsePrice(tt.text)
+		if ok != tt.ok || price != tt.price {
+			t.Errorf("%q: expected %d %v, got %d %v", tt.text, tt.price, tt.ok, price, ok)
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	page, err := html.Parse(strings.NewReader(`<div id="main" class="card big"><p><span data-x="1">a</span></p>
+		<span class="price">b</span></div><span class="price">c</span>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector string
+		text     string
+	}{
+		{selector: "span", text: "a"},
+		{selector: "#main > span.price", text: "b"},
+		{selector: "div.card.big span[data-x]", text: "a"},
+		{selector: "body > span.price", text: "c"},
+		{selector: "div > span[data-x='1']", text: ""},
+		{selector: "em, .price", text: "b"},
+	}
+
+	for _, tt := range tests {
+		selector, err := CompileSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.selector, err)
+		}
+
+		node := selector.First(page)
+		if (node == nil) != (tt.text == "") || (node != nil && text(node) != tt.text) {
+			t.Errorf("%s: expected %q, got %v", tt.selector, tt.text, node)
+		}
+	}
+
+	for _, invalid := range []string{"", "div >", "> div", "div[", "a:hover", "div,"} {
+		if _, err := CompileSelector(invalid); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package scraper
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var errSelector = errors.New("invalid selector")
+
+// Compiled CSS selector. Supported subset: type, #id, .class, [attr], [attr=value],
+// descendant and child combinators and groups separated by commas
+type Selector struct {
+	groups [][]step
+}
+
+// Compound selector with combinator to previous step
+type step struct {
+	// previous step must match parent, otherwise any ancestor
+	child bool
+	tag   string
+	id    string
+	class []string
+	attrs []attr
+}
+
+type attr struct {
+	name  string
+	value string
+	// only presence of attribute is checked
+	any bool
+}
+
+func CompileSelector(s string) (Selector, error) {
+	var selector Selector
+	for _, group := range strings.Split(s, ",") {
+		steps, err := compileGroup(group)
+		if err != nil {
+			return Selector{}, err
+		}
+		selector.groups = append(selector.groups, steps)
+	}
+
+	return selector, nil
+}
+
+func compileGroup(s string) ([]step, error) {
+	var (
+		steps []step
+		child bool
+	)
+	for _, token := range tokenize(s) {
+		if token == ">" {
+			if len(steps) == 0 || child {
+				return nil, errSelector
+			}
+			child = true
+			continue
+		}
+
+		compiled, err := compileStep(token)
+		if err != nil {
+			return nil, err
+		}
+		compiled.child = child
+		child = false
+		steps = append(steps, compiled)
+	}
+	if len(steps) == 0 || child {
+		return nil, errSelector
+	}
+
+	return steps, nil
+}
+
+// Split group by whitespace outside of brackets and quotes, > is separate token
+func tokenize(s string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quote   rune
+		bracket bool
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case bracket:
+		case r == '>':
+			flush()
+			tokens = append(tokens, ">")
+			continue
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return tokens
+}
+
+func compileStep(s string) (step, error) {
+	var compiled step
+
+	end := strings.IndexAny(s, "#.[")
+	if end == -1 {
+		end = len(s)
+	}
+	compiled.tag = strings.ToLower(s[:end])
+	if compiled.tag == "*" {
+		compiled.tag = ""
+	}
+	if !validName(compiled.tag) {
+		return step{}, errSelector
+	}
+	s = s[end:]
+
+	for s != "" {
+		switch s[0] {
+		case '#', '.':
+			end := strings.IndexAny(s[1:], "#.[")
+			if end == -1 {
+				end = len(s) - 1
+			}
+			name := s[1 : end+1]
+			if name == "" || !validName(name) {
+				return step{}, errSelector
+			}
+			if s[0] == '#' {
+				compiled.id = name
+			} else {
+				compiled.class = append(compiled.class, name)
+			}
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return step{}, errSelector
+			}
+			a, err := compileAttr(s[1:end])
+			if err != nil {
+				return step{}, err
+			}
+			compiled.attrs = append(compiled.attrs, a)
+			s = s[end+1:]
+		default:
+			return step{}, errSelector
+		}
+	}
+
+	return compiled, nil
+}
+
+func compileAttr(s string) (attr, error) {
+	name, value, found := strings.Cut(s, "=")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || !validName(name) {
+		return attr{}, errSelector
+	}
+	if !found {
+		return attr{name: name, any: true}, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return attr{name: name, value: value}, nil
+}
+
+func validName(name string) bool {
+	for _, r := range name {
+		if !(r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// First element matching selector in document order, nil if there is none
+func (s Selector) First(root *html.Node) *html.Node {
+	for node := range root.Descendants() {
+		if node.Type == html.ElementNode && s.Match(node) {
+			return node
+		}
+	}
+
+	return nil
+}
+
+func (s Selector) Match(node *html.Node) bool {
+	for _, steps := range s.groups {
+		if matchSteps(node, steps) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Node matches last step, its ancestors match previous steps
+func matchSteps(node *html.Node, steps []step) bool {
+	last := steps[len(steps)-1]
+	if !last.match(node) {
+		return false
+	}
+	if len(steps) == 1 {
+		return true
+	}
+
+	for parent := node.Parent; parent != nil && parent.Type == html.ElementNode; parent = parent.Parent {
+		if matchSteps(parent, steps[:len(steps)-1]) {
+			return true
+		}
+		if last.child {
+			return false
+		}
+	}
+
+	return false
+}
+
+func (s step) match(node *html.Node) bool {
+	if node.Type != html.ElementNode || (s.tag != "" && node.Data != s.tag) {
+		return false
+	}
+	if s.id != "" && attribute(node, "id") != s.id {
+		return false
+	}
+	if len(s.class) > 0 {
+		classes := strings.Fields(attribute(node, "class"))
+		for _, class := range s.class {
+			if !slices.Contains(classes, class) {
+				return false
+			}
+		}
+	}
+	for _, a := range s.attrs {
+		value, ok := lookup(node, a.name)
+		if !ok || (!a.any && value != a.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookup(node *html.Node, name string) (string, bool) {
+	for _, a := range node.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+
+	return "", false
+}
+
+func attribute(node *html.Node, name string) string {
+	value, _ := lookup(node, name)
+
+	return value
+}
+
+// Text of element: content attribute of meta and microdata elements or its text nodes
+func text(node *html.Node) string {
+	if content, ok := lookup(node, "content"); ok {
+		return strings.TrimSpace(content)
+	}
+
+	var b strings.Builder
+	for child := range node.Descendants() {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,163 @@
+package analytics
+
+import (
+	domain "cloth-mini-app/internal/domain/analytics"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	statsLimit = 50 // rows of stats if limit isn't provided
+	statsDays  = 7  // period of stats if it isn't provided
+)
+
+type AnalyticsRepository interface {
+	// Append batch of events
+	SaveEvents(ctx context.Context, views []domain.View, searches []domain.Search) error
+	// Aggregate events into daily stats
+	Rollup(ctx context.Context) error
+	// Delete events created before provided time
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
+	// Get items with most views since day
+	GetPopular(ctx context.Context, from time.Time, limit uint64) ([]domain.ItemStats, error)
+	// Get items which views grow fastest
+	GetTrending(ctx context.Context, recentFrom time.Time, days int, limit uint64) ([]domain.ItemStats, error)
+	// Get most frequent search queries since day
+	GetTopQueries(ctx context.Context, from time.Time, zeroOnly bool, limit uint64) ([]domain.QueryStats, error)
+}
+
+// Events are collected in memory and written by batches, so requests don't wait for database.
+// Events of full buffer are dropped, stats are approximate anyway
+type AnalyticsService struct {
+	logger *slog.Logger
+	repo   AnalyticsRepository
+	// events kept until flush
+	bufferSize int
+	// raw events are deleted after this time, daily stats are kept
+	retention time.Duration
+
+	mu       sync.Mutex
+	views    []domain.View
+	searches []domain.Search
+	dropped  int
+}
+
+func NewAnalyticsService(logger *slog.Logger, repo AnalyticsRepository, bufferSize int, retention time.Duration) *AnalyticsService {
+	return &AnalyticsService{
+		logger:     logger,
+		repo:       repo,
+		bufferSize: bufferSize,
+		retention:  retention,
+	}
+}
+
+// Record view of item page, it's written on next flush
+func (a *AnalyticsService) RecordView(itemId int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.views)+len(a.searches) >= a.bufferSize {
+		a.dropped++
+		return
+	}
+	a.views = append(a.views, domain.View{ItemId: itemId, CreatedAt: time.Now()})
+}
+
+// Record catalog search with number of found items, it's written on next flush
+func (a *AnalyticsService) RecordSearch(query string, results int) {
+	query = domain.NormalizeQuery(query)
+	if query == "" {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.views)+len(a.searches) >= a.bufferSize {
+		a.dropped++
+		return
+	}
+	a.searches = append(a.searches, domain.Search{Query: query, Results: results, CreatedAt: time.Now()})
+}
+
+// Write collected events and return their number. Events of failed batch are lost
+func (a *AnalyticsService) Flush(ctx context.Context) (int, error) {
+	const op = "service.analytics.Flush"
+
+	a.mu.Lock()
+	views, searches, dropped := a.views, a.searches, a.dropped
+	a.views, a.searches, a.dropped = nil, nil, 0
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		a.logger.Warn(fmt.Sprintf("%s: events buffer is full, events dropped", op), slog.Int("dropped", dropped))
+	}
+	if len(views) == 0 && len(searches) == 0 {
+		return 0, nil
+	}
+
+	if err := a.repo.SaveEvents(ctx, views, searches); err != nil {
+		return 0, err
+	}
+
+	return len(views) + len(searches), nil
+}
+
+// Aggregate events into daily stats and delete events older than retention
+func (a *AnalyticsService) Rollup(ctx context.Context) error {
+	const op = "service.analytics.Rollup"
+
+	if err := a.repo.Rollup(ctx); err != nil {
+		return err
+	}
+
+	// events of whole days are deleted, so partly deleted day isn't recounted
+	before := time.Now().Add(-a.retention).UTC().Truncate(24 * time.Hour)
+	deleted, err := a.repo.DeleteEventsBefore(ctx, before)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("%s: deleting old events", op), sl.Err(err))
+
+		return err
+	}
+	if deleted > 0 {
+		a.logger.Info(fmt.Sprintf("%s: old events deleted", op), slog.Int64("deleted", deleted))
+	}
+
+	return nil
+}
+
+// Get most viewed items for period, 7 days by default
+func (a *AnalyticsService) GetPopular(ctx context.Context, filter domain.StatsFilter) ([]domain.ItemStats, error) {
+	filter = statsFilter(filter)
+
+	return a.repo.GetPopular(ctx, filter.From(time.Now()), filter.Limit)
+}
+
+// Get items which views grow fastest compared with previous period of the same length
+func (a *AnalyticsService) GetTrending(ctx context.Context, filter domain.StatsFilter) ([]domain.ItemStats, error) {
+	filter = statsFilter(filter)
+
+	return a.repo.GetTrending(ctx, filter.From(time.Now()), filter.Days, filter.Limit)
+}
+
+// Get most frequent search queries for period with number of searches which found nothing
+func (a *AnalyticsService) GetTopQueries(ctx context.Context, filter domain.StatsFilter) ([]domain.QueryStats, error) {
+	filter = statsFilter(filter)
+
+	return a.repo.GetTopQueries(ctx, filter.From(time.Now()), filter.ZeroOnly, filter.Limit)
+}
+
+func statsFilter(filter domain.StatsFilter) domain.StatsFilter {
+	if filter.Days <= 0 {
+		filter.Days = statsDays
+	}
+	if filter.Limit == 0 {
+		filter.Limit = statsLimit
+	}
+
+	return filter
+}
@@ -0,0 +1,219 @@
+package apikey
+
+import (
+	domain "cloth-mini-app/internal/domain/apikey"
+	apperr "cloth-mini-app/internal/domain/apperror"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/ratelimit"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	keyLength = 32
+	// length of key prefix shown in key list
+	shownPrefixLength = 12
+	// days of usage returned by default
+	usageDays = 30
+)
+
+type APIKeyRepository interface {
+	GetAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	GetAPIKey(ctx context.Context, keyId int) (domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error)
+	// Create key and return it with id
+	Create(ctx context.Context, key domain.APIKey, keyHash string) (domain.APIKey, error)
+	Rotate(ctx context.Context, keyId int, prefix, keyHash string) error
+	Revoke(ctx context.Context, keyId int) error
+	// Count request if daily quota isn't reached, returns false if it is
+	ConsumeQuota(ctx context.Context, keyId int, day time.Time, quota int) (bool, error)
+	AddRejected(ctx context.Context, keyId int, day time.Time) error
+	GetUsage(ctx context.Context, keyId int, since time.Time) ([]domain.Usage, error)
+}
+
+type APIKeyService struct {
+	logger *slog.Logger
+	repo   APIKeyRepository
+	// rate limits of keys by id, limits are per instance
+	limiter *ratelimit.Keyed
+}
+
+func NewAPIKeyService(logger *slog.Logger, repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		logger:  logger,
+		repo:    repo,
+		limiter: ratelimit.NewKeyed(),
+	}
+}
+
+func (a *APIKeyService) GetAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return a.repo.GetAPIKeys(ctx)
+}
+
+// Create key. Key is returned only once, only its hash is stored
+func (a *APIKeyService) Create(ctx context.Context, create domain.APIKeyCreate) (domain.APIKeySecret, error) {
+	var verr apperr.FieldErrors
+	validScopes := len(create.Scopes) != 0
+	for _, scope := range create.Scopes {
+		validScopes = validScopes && scope.Valid()
+	}
+	if !validScopes {
+		verr.Add("scopes", domain.ErrScope.Message)
+	}
+	if create.RateLimit <= 0 {
+		verr.Add("rate_limit", "rate limit must be positive")
+	}
+	if create.DailyQuota <= 0 {
+		verr.Add("daily_quota", "daily quota must be positive")
+	}
+	if err := verr.Err(); err != nil {
+		return domain.APIKeySecret{}, err
+	}
+
+	key, err := newKey()
+	if err != nil {
+		a.logger.Error("failed generate api key", sl.Err(err))
+
+		return domain.APIKeySecret{}, err
+	}
+
+	apiKey, err := a.repo.Create(ctx, domain.APIKey{
+		Prefix:     key[:shownPrefixLength],
+		Name:       create.Name,
+		Scopes:     create.Scopes,
+		RateLimit:  create.RateLimit,
+		DailyQuota: create.DailyQuota,
+	}, hashKey(key))
+	if err != nil {
+		return domain.APIKeySecret{}, err
+	}
+
+	return domain.APIKeySecret{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+// Replace key, scopes and limits are kept. Previous key stops working immediately
+func (a *APIKeyService) Rotate(ctx context.Context, keyId int) (domain.APIKeySecret, error) {
+	key, err := newKey()
+	if err != nil {
+		a.logger.Error("failed generate api key", sl.Err(err))
+
+		return domain.APIKeySecret{}, err
+	}
+
+	err = a.repo.Rotate(ctx, keyId, key[:shownPrefixLength], hashKey(key))
+	if err != nil {
+		return domain.APIKeySecret{}, err
+	}
+
+	apiKey, err := a.repo.GetAPIKey(ctx, keyId)
+	if err != nil {
+		return domain.APIKeySecret{}, err
+	}
+
+	return domain.APIKeySecret{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+func (a *APIKeyService) Revoke(ctx context.Context, keyId int) error {
+	err := a.repo.Revoke(ctx, keyId)
+	if err != nil {
+		return err
+	}
+
+	a.limiter.Delete(strconv.Itoa(keyId))
+
+	return nil
+}
+
+// Get usage of key for last 30 days
+func (a *APIKeyService) GetUsage(ctx context.Context, keyId int) ([]domain.Usage, error) {
+	if _, err := a.repo.GetAPIKey(ctx, keyId); err != nil {
+		return nil, err
+	}
+
+	since := today().AddDate(0, 0, -usageDays+1)
+
+	return a.repo.GetUsage(ctx, keyId, since)
+}
+
+// Find active key. Unknown and revoked keys are invalid
+func (a *APIKeyService) Authenticate(ctx context.Context, key string) (domain.APIKey, error) {
+	if !strings.HasPrefix(key, domain.KeyPrefix) {
+		return domain.APIKey{}, domain.ErrAPIKeyInvalid
+	}
+
+	apiKey, err := a.repo.GetAPIKeyByHash(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return domain.APIKey{}, domain.ErrAPIKeyInvalid
+		}
+		return domain.APIKey{}, err
+	}
+	if apiKey.RevokedAt != nil {
+		return domain.APIKey{}, domain.ErrAPIKeyInvalid
+	}
+
+	return apiKey, nil
+}
+
+// Count request of key against its rate limit and daily quota.
+// If request is rejected, returns duration after which request can be repeated
+func (a *APIKeyService) Consume(ctx context.Context, key domain.APIKey) (time.Duration, error) {
+	day := today()
+
+	retryAfter := a.limiter.Reserve(strconv.Itoa(key.ID), ratelimit.PerMinute(key.RateLimit))
+	if retryAfter > 0 {
+		if err := a.repo.AddRejected(ctx, key.ID, day); err != nil {
+			return 0, err
+		}
+		return retryAfter, domain.ErrRateLimited
+	}
+
+	ok, err := a.repo.ConsumeQuota(ctx, key.ID, day, key.DailyQuota)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if err := a.repo.AddRejected(ctx, key.ID, day); err != nil {
+			return 0, err
+		}
+		// quota is reset at midnight UTC
+		return time.Until(day.AddDate(0, 0, 1)), domain.ErrQuotaExceeded
+	}
+
+	return 0, nil
+}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+func newKey() (string, error) {
+	key := make([]byte, keyLength)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("reading random bytes: %w", err)
+	}
+
+	return domain.KeyPrefix + base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// Keys are stored as hash, so leaked table can't be used for requests
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
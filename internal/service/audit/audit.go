@@ -0,0 +1,57 @@
+package audit
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	domain "cloth-mini-app/internal/domain/audit"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+const (
+	// entries returned if limit isn't set
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+type AuditRepository interface {
+	// Get entries by filter, latest first
+	GetEntries(ctx context.Context, filter domain.Filter) ([]domain.Entry, error)
+}
+
+type AuditService struct {
+	logger    *slog.Logger
+	auditRepo AuditRepository
+}
+
+func NewAuditService(logger *slog.Logger, auditRepo AuditRepository) *AuditService {
+	return &AuditService{
+		logger:    logger,
+		auditRepo: auditRepo,
+	}
+}
+
+func (a *AuditService) GetEntries(ctx context.Context, filter domain.Filter) ([]domain.Entry, error) {
+	var verr apperr.FieldErrors
+	if filter.Action != "" && !filter.Action.Valid() {
+		verr.Add("action", domain.ErrAction.Message)
+	}
+	if filter.EntityType != "" && !filter.EntityType.Valid() {
+		verr.Add("entity_type", domain.ErrEntityType.Message)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		verr.Add("to", "to must be after from")
+	}
+	if filter.Limit > maxLimit {
+		verr.Add("limit", fmt.Sprintf("limit must not exceed %d", maxLimit))
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+
+	return a.auditRepo.GetEntries(ctx, filter)
+}
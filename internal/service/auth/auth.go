@@ -0,0 +1,257 @@
+package auth
+
+import (
+	domain "cloth-mini-app/internal/domain/user"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	refreshTokenLength = 32
+
+	// compared with password of unknown login, so response time doesn't reveal existing logins
+	dummyPasswordHash = "$2a$10$6gzmEE7uSUBLyZyWhuohuu.cRlTqNnXLZd3qGlgO2WmxybDdOddnu"
+)
+
+type UserRepository interface {
+	GetUsers(ctx context.Context) ([]domain.User, error)
+	GetUser(ctx context.Context, userId int) (domain.User, error)
+	GetUserByLogin(ctx context.Context, login string) (domain.User, error)
+	// Create user with hashed password and return its id
+	Create(ctx context.Context, user domain.User) (int, error)
+	CountUsers(ctx context.Context) (int, error)
+	CreateRefreshToken(ctx context.Context, tokenHash string, userId int, expiresAt time.Time) error
+	// Delete refresh token and return its user
+	UseRefreshToken(ctx context.Context, tokenHash string) (int, error)
+}
+
+type AuthService struct {
+	logger     *slog.Logger
+	userRepo   UserRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthService(logger *slog.Logger, userRepo UserRepository, secret string, accessTTL, refreshTTL time.Duration) *AuthService {
+	return &AuthService{
+		logger:     logger,
+		userRepo:   userRepo,
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Access token claims, role is stored by name
+type accessClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Check credentials and issue tokens
+func (a *AuthService) Login(ctx context.Context, login, password string) (domain.TokenPair, error) {
+	user, err := a.userRepo.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+
+			return domain.TokenPair{}, domain.ErrInvalidCredentials
+		}
+		return domain.TokenPair{}, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return domain.TokenPair{}, domain.ErrInvalidCredentials
+	}
+
+	return a.issueTokens(ctx, user)
+}
+
+// Exchange refresh token for new pair. Refresh token can be used only once
+func (a *AuthService) Refresh(ctx context.Context, refreshToken string) (domain.TokenPair, error) {
+	userId, err := a.userRepo.UseRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	// role could be changed since previous login
+	user, err := a.userRepo.GetUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.TokenPair{}, domain.ErrTokenInvalid
+		}
+		return domain.TokenPair{}, err
+	}
+
+	return a.issueTokens(ctx, user)
+}
+
+// Revoke refresh token. Access token stays valid until it expires
+func (a *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	_, err := a.userRepo.UseRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, domain.ErrTokenInvalid) {
+		return nil
+	}
+
+	return err
+}
+
+// Check access token signature and expiration
+func (a *AuthService) ParseAccessToken(token string) (domain.Claims, error) {
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return domain.Claims{}, domain.ErrTokenInvalid
+	}
+
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return domain.Claims{}, domain.ErrTokenInvalid
+	}
+	role, err := domain.ParseRole(claims.Role)
+	if err != nil {
+		return domain.Claims{}, domain.ErrTokenInvalid
+	}
+
+	return domain.Claims{
+		UserId: userId,
+		Role:   role,
+	}, nil
+}
+
+func (a *AuthService) GetUser(ctx context.Context, userId int) (domain.User, error) {
+	return a.userRepo.GetUser(ctx, userId)
+}
+
+func (a *AuthService) GetUsers(ctx context.Context) ([]domain.User, error) {
+	return a.userRepo.GetUsers(ctx)
+}
+
+// Create user and return its id, password is stored as bcrypt hash
+func (a *AuthService) CreateUser(ctx context.Context, user domain.UserCreate) (int, error) {
+	if !user.Role.Valid() {
+		return 0, domain.ErrRole
+	}
+	if len(user.Password) < domain.PasswordMinLength {
+		return 0, domain.ErrPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		// password is longer than 72 bytes
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return 0, domain.ErrPassword
+		}
+		return 0, err
+	}
+
+	return a.userRepo.Create(ctx, domain.User{
+		Login:        user.Login,
+		PasswordHash: string(hash),
+		Role:         user.Role,
+	})
+}
+
+// Create admin if there are no users yet, so fresh installation can be logged in
+func (a *AuthService) EnsureAdmin(ctx context.Context, login, password string) error {
+	count, err := a.userRepo.CountUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if login == "" || password == "" {
+		a.logger.Warn("there are no users, set ADMIN_LOGIN and ADMIN_PASSWORD to create admin")
+
+		return nil
+	}
+
+	_, err = a.CreateUser(ctx, domain.UserCreate{
+		Login:    login,
+		Password: password,
+		Role:     domain.RoleAdmin,
+	})
+	if err != nil {
+		// admin is created by another instance
+		if errors.Is(err, domain.ErrUserExists) {
+			return nil
+		}
+		return err
+	}
+
+	a.logger.Info("admin user created", slog.String("login", login))
+
+	return nil
+}
+
+func (a *AuthService) issueTokens(ctx context.Context, user domain.User) (domain.TokenPair, error) {
+	now := time.Now()
+	accessExpiresAt := now.Add(a.accessTTL)
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims{
+		Role: user.Role.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(accessExpiresAt),
+		},
+	}).SignedString(a.secret)
+	if err != nil {
+		a.logger.Error("failed sign access token", sl.Err(err))
+
+		return domain.TokenPair{}, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		a.logger.Error("failed generate refresh token", sl.Err(err))
+
+		return domain.TokenPair{}, err
+	}
+
+	refreshExpiresAt := now.Add(a.refreshTTL)
+	err = a.userRepo.CreateRefreshToken(ctx, hashToken(refreshToken), user.ID, refreshExpiresAt)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	return domain.TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func newRefreshToken() (string, error) {
+	token := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("reading random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Refresh tokens are stored as hash, so leaked table can't be used for login
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
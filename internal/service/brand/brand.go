@@ -1,27 +1,238 @@
 package brand
 
 import (
+	adomain "cloth-mini-app/internal/domain/audit"
 	domain "cloth-mini-app/internal/domain/brand"
+	imdomain "cloth-mini-app/internal/domain/image"
+	"cloth-mini-app/internal/dto"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/blob"
 	"context"
 	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
 type BrandRepository interface {
 	GetBrands(ctx context.Context) ([]domain.Brand, error)
+	GetBrand(ctx context.Context, brandId int) (domain.Brand, error)
+	// Get brand with amount of related items
+	GetBrandWithItemsCount(ctx context.Context, brandId int) (domain.Brand, error)
+	// Create brand and return its id
+	Create(ctx context.Context, brand domain.BrandCreate) (int, error)
+	// Update brand fields that are not nil
+	Update(ctx context.Context, brand domain.BrandUpdate) error
+	// Set brand logo and return previous logo id
+	SetLogo(ctx context.Context, brandId int, logoId *string) (*string, error)
+	// Delete brand without items and return it
+	Delete(ctx context.Context, brandId int) (domain.Brand, error)
+}
+
+type AuditFacade interface {
+	// Run change and record it in audit log in one transaction
+	Record(ctx context.Context, change func(ctx context.Context) (adomain.Change, error)) error
 }
 
 type BrandService struct {
-	logger    *slog.Logger
-	BrandRepo BrandRepository
+	logger      *slog.Logger
+	BrandRepo   BrandRepository
+	storage     blob.Storage
+	auditFacade AuditFacade
 }
 
-func NewBrandService(logger *slog.Logger, BrandRepo BrandRepository) *BrandService {
+func NewBrandService(logger *slog.Logger, BrandRepo BrandRepository, storage blob.Storage, auditFacade AuditFacade) *BrandService {
 	return &BrandService{
-		logger:    logger,
-		BrandRepo: BrandRepo,
+		logger:      logger,
+		BrandRepo:   BrandRepo,
+		storage:     storage,
+		auditFacade: auditFacade,
 	}
 }
 
 func (b *BrandService) GetBrands(ctx context.Context) ([]domain.Brand, error) {
 	return b.BrandRepo.GetBrands(ctx)
 }
+
+// Get brand by id with amount of items
+func (b *BrandService) GetBrand(ctx context.Context, brandId int) (domain.Brand, error) {
+	return b.BrandRepo.GetBrandWithItemsCount(ctx, brandId)
+}
+
+// Create brand. If slug isn't provided it's made from name
+func (b *BrandService) Create(ctx context.Context, brand domain.BrandCreate) (int, error) {
+	if brand.Slug == "" {
+		brand.Slug = domain.Slugify(brand.Name)
+	}
+	if !domain.ValidSlug(brand.Slug) {
+		return 0, domain.ErrBrandSlug
+	}
+	brand.Country = strings.ToUpper(brand.Country)
+
+	var brandId int
+	err := b.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		var err error
+		brandId, err = b.BrandRepo.Create(ctx, brand)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := b.BrandRepo.GetBrand(ctx, brandId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return brandChange(adomain.ActionCreate, brandId, nil, &after), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return brandId, nil
+}
+
+// Update brand fields. Slug isn't changed on rename, links to brand page must stay valid
+func (b *BrandService) Update(ctx context.Context, brand domain.BrandUpdate) error {
+	if brand.Slug != nil && !domain.ValidSlug(*brand.Slug) {
+		return domain.ErrBrandSlug
+	}
+	if brand.Country != nil {
+		country := strings.ToUpper(*brand.Country)
+		brand.Country = &country
+	}
+
+	return b.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := b.BrandRepo.GetBrand(ctx, brand.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = b.BrandRepo.Update(ctx, brand)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := b.BrandRepo.GetBrand(ctx, brand.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return brandChange(adomain.ActionUpdate, brand.ID, &before, &after), nil
+	})
+}
+
+// Delete brand and its logo. Brand with items can't be deleted
+func (b *BrandService) Delete(ctx context.Context, brandId int) error {
+	var brand domain.Brand
+	err := b.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		var err error
+		brand, err = b.BrandRepo.Delete(ctx, brandId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return brandChange(adomain.ActionDelete, brandId, &brand, nil), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if brand.LogoId != nil {
+		b.removeLogo(ctx, *brand.LogoId)
+	}
+
+	return nil
+}
+
+// Store logo image and set it to brand. Previous logo is removed from storage
+func (b *BrandService) UploadLogo(ctx context.Context, brandId int, file []byte) (string, error) {
+	if err := imdomain.CheckImageType(file); err != nil {
+		return "", err
+	}
+
+	logoId := uuid.New().String()
+	err := b.storage.Put(ctx, dto.FileDTO{
+		ID:          logoId,
+		ContentType: imdomain.DetectImageContentType(file),
+		Buffer:      file,
+	})
+	if err != nil {
+		b.logger.Error("failed store brand logo", sl.Err(err))
+
+		return "", err
+	}
+
+	prevLogoId, err := b.setLogo(ctx, brandId, &logoId)
+	if err != nil {
+		b.removeLogo(ctx, logoId)
+
+		return "", err
+	}
+
+	if prevLogoId != nil {
+		b.removeLogo(ctx, *prevLogoId)
+	}
+
+	return logoId, nil
+}
+
+// Remove brand logo
+func (b *BrandService) DeleteLogo(ctx context.Context, brandId int) error {
+	prevLogoId, err := b.setLogo(ctx, brandId, nil)
+	if err != nil {
+		return err
+	}
+
+	if prevLogoId != nil {
+		b.removeLogo(ctx, *prevLogoId)
+	}
+
+	return nil
+}
+
+// Set brand logo with audit entry and return previous logo id
+func (b *BrandService) setLogo(ctx context.Context, brandId int, logoId *string) (*string, error) {
+	var prevLogoId *string
+	err := b.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := b.BrandRepo.GetBrand(ctx, brandId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		prevLogoId, err = b.BrandRepo.SetLogo(ctx, brandId, logoId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after := before
+		after.LogoId = logoId
+
+		return brandChange(adomain.ActionUpdate, brandId, &before, &after), nil
+	})
+
+	return prevLogoId, err
+}
+
+// Audit change of brand, nil state isn't recorded
+func brandChange(action adomain.Action, brandId int, before, after *domain.Brand) adomain.Change {
+	change := adomain.Change{
+		Action:     action,
+		EntityType: adomain.EntityBrand,
+		EntityId:   strconv.Itoa(brandId),
+	}
+	if before != nil {
+		change.Before = *before
+	}
+	if after != nil {
+		change.After = *after
+	}
+
+	return change
+}
+
+func (b *BrandService) removeLogo(ctx context.Context, logoId string) {
+	if err := b.storage.Delete(ctx, logoId); err != nil {
+		b.logger.Error("failed delete brand logo from storage", slog.String("logo_id", logoId), sl.Err(err))
+	}
+}
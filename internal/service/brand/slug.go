@@ -0,0 +1,63 @@
+package brand
+
+import (
+	domain "cloth-mini-app/internal/domain/brand"
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Same rule as slug backfill of migration 20250420110000
+var migrationSlugRule = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func migrationSlug(name string) string {
+	return strings.Trim(strings.ToLower(migrationSlugRule.ReplaceAllString(name, "-")), "-")
+}
+
+// Make slugs filled by migration the same as for brands created by api: migration drops cyrillic,
+// so such brands got slug like brand-7. Only slugs that migration could produce are replaced,
+// slugs set by editors are kept. Returns amount of updated brands
+func (b *BrandService) BackfillSlugs(ctx context.Context) (int, error) {
+	brands, err := b.BrandRepo.GetBrands(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int
+	for _, brand := range brands {
+		id := strconv.Itoa(brand.ID)
+		generated := migrationSlug(brand.Name)
+		if brand.Slug != generated && brand.Slug != generated+"-"+id && brand.Slug != "brand-"+id {
+			continue
+		}
+
+		slug := domain.Slugify(brand.Name)
+		if slug == "" || slug == brand.Slug {
+			continue
+		}
+
+		// same as migration, slug taken by other brand gets id
+		for _, candidate := range []string{slug, slug + "-" + id} {
+			if candidate == brand.Slug {
+				break
+			}
+
+			err = b.Update(ctx, domain.BrandUpdate{ID: brand.ID, Slug: &candidate})
+			if errors.Is(err, domain.ErrBrandExists) {
+				continue
+			}
+			if err != nil {
+				return updated, err
+			}
+
+			b.logger.Info("brand slug updated", slog.Int("brand_id", brand.ID), slog.String("from", brand.Slug), slog.String("to", candidate))
+			updated++
+			break
+		}
+	}
+
+	return updated, nil
+}
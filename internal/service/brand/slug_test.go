@@ -0,0 +1,90 @@
+package brand
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/brand"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// Brands in memory, slug is unique as in db
+type brandsStub struct {
+	BrandRepository
+	brands []domain.Brand
+}
+
+func (r *brandsStub) GetBrands(context.Context) ([]domain.Brand, error) {
+	return append([]domain.Brand(nil), r.brands...), nil
+}
+
+func (r *brandsStub) GetBrand(_ context.Context, brandId int) (domain.Brand, error) {
+	for _, brand := range r.brands {
+		if brand.ID == brandId {
+			return brand, nil
+		}
+	}
+
+	return domain.Brand{}, domain.ErrBrandNotFound
+}
+
+func (r *brandsStub) Update(_ context.Context, update domain.BrandUpdate) error {
+	for _, brand := range r.brands {
+		if brand.ID != update.ID && brand.Slug == *update.Slug {
+			return domain.ErrBrandExists
+		}
+	}
+	for i := range r.brands {
+		if r.brands[i].ID == update.ID {
+			r.brands[i].Slug = *update.Slug
+		}
+	}
+
+	return nil
+}
+
+type auditStub struct{}
+
+func (auditStub) Record(ctx context.Context, change func(ctx context.Context) (adomain.Change, error)) error {
+	_, err := change(ctx)
+	return err
+}
+
+func TestBackfillSlugs(t *testing.T) {
+	repo := &brandsStub{brands: []domain.Brand{
+		{ID: 1, Name: "Nike", Slug: "nike"},
+		{ID: 2, Name: "Снежная Королева", Slug: "brand-2"},
+		{ID: 3, Name: "Zara Дом", Slug: "zara"},
+		// slug set by editor is kept
+		{ID: 4, Name: "Глория Джинс", Slug: "gloria"},
+		{ID: 5, Name: "Kira", Slug: "kira"},
+		// transliterated slug is taken by other brand
+		{ID: 6, Name: "Кира", Slug: "brand-6"},
+		{ID: 7, Name: "★", Slug: "brand-7"},
+	}}
+	service := NewBrandService(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, nil, auditStub{})
+
+	updated, err := service.BackfillSlugs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 3 {
+		t.Errorf("expected 3 updated brands, got %d", updated)
+	}
+
+	expected := map[int]string{
+		1: "nike",
+		2: "snezhnaya-koroleva",
+		3: "zara-dom",
+		4: "gloria",
+		5: "kira",
+		6: "kira-6",
+		7: "brand-7",
+	}
+	for _, brand := range repo.brands {
+		if brand.Slug != expected[brand.ID] {
+			t.Errorf("%s: expected slug %q, got %q", brand.Name, expected[brand.ID], brand.Slug)
+		}
+	}
+}
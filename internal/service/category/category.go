@@ -1,27 +1,233 @@
 package category
 
 import (
+	adomain "cloth-mini-app/internal/domain/audit"
 	domain "cloth-mini-app/internal/domain/category"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
 )
 
 type CategoryRepository interface {
 	GetCategories(ctx context.Context) ([]domain.Category, error)
+	GetCategory(ctx context.Context, categoryId int) (domain.Category, error)
+	// Get attributes of category including inherited from parents
+	GetAttributes(ctx context.Context, categoryId int) ([]domain.Attribute, error)
+	// Create category and return its id
+	Create(ctx context.Context, category domain.CategoryCreate) (int, error)
+	// Rename or move category. Moved category and its subcategories can't have attribute codes of new parents
+	Update(ctx context.Context, category domain.CategoryUpdate) error
+	// Delete category without subcategories and items
+	Delete(ctx context.Context, categoryId int) error
+	// Add attribute if its code isn't used in category, its parents and subcategories
+	CreateAttribute(ctx context.Context, attr domain.Attribute) error
+	DeleteAttribute(ctx context.Context, categoryId int, code string) error
+}
+
+type AuditFacade interface {
+	// Run change and record it in audit log in one transaction
+	Record(ctx context.Context, change func(ctx context.Context) (adomain.Change, error)) error
 }
 
 type CategoryService struct {
 	logger       *slog.Logger
 	categoryRepo CategoryRepository
+	auditFacade  AuditFacade
 }
 
-func NewCategoryService(logger *slog.Logger, categoryRepo CategoryRepository) *CategoryService {
+func NewCategoryService(logger *slog.Logger, categoryRepo CategoryRepository, auditFacade AuditFacade) *CategoryService {
 	return &CategoryService{
 		logger:       logger,
 		categoryRepo: categoryRepo,
+		auditFacade:  auditFacade,
 	}
 }
 
 func (c *CategoryService) GetCategories(ctx context.Context) ([]domain.Category, error) {
 	return c.categoryRepo.GetCategories(ctx)
 }
+
+// Get categories as tree
+func (c *CategoryService) GetTree(ctx context.Context) ([]domain.CategoryNode, error) {
+	categories, err := c.categoryRepo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.BuildTree(categories), nil
+}
+
+// Get category with its attributes (own and inherited)
+func (c *CategoryService) GetCategory(ctx context.Context, categoryId int) (domain.Category, []domain.Attribute, error) {
+	category, err := c.categoryRepo.GetCategory(ctx, categoryId)
+	if err != nil {
+		return domain.Category{}, nil, err
+	}
+
+	attributes, err := c.categoryRepo.GetAttributes(ctx, categoryId)
+	if err != nil {
+		return domain.Category{}, nil, err
+	}
+
+	return category, attributes, nil
+}
+
+// Create category. Subcategory gets type of parent
+func (c *CategoryService) Create(ctx context.Context, category domain.CategoryCreate) (int, error) {
+	if category.ParentId != nil {
+		parent, err := c.categoryRepo.GetCategory(ctx, *category.ParentId)
+		if err != nil {
+			if errors.Is(err, domain.ErrCategoryNotFound) {
+				return 0, fmt.Errorf("parent %w", err)
+			}
+			return 0, err
+		}
+
+		if category.Type != 0 && category.Type != parent.Type {
+			return 0, domain.ErrCategoryKind
+		}
+		category.Type = parent.Type
+	}
+
+	if !category.Type.Valid() {
+		return 0, domain.ErrCategoryKind
+	}
+
+	var categoryId int
+	err := c.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		var err error
+		categoryId, err = c.categoryRepo.Create(ctx, category)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := c.categoryRepo.GetCategory(ctx, categoryId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionCreate,
+			EntityType: adomain.EntityCategory,
+			EntityId:   strconv.Itoa(categoryId),
+			After:      after,
+		}, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return categoryId, nil
+}
+
+func (c *CategoryService) Update(ctx context.Context, category domain.CategoryUpdate) error {
+	return c.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := c.categoryRepo.GetCategory(ctx, category.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = c.categoryRepo.Update(ctx, category)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := c.categoryRepo.GetCategory(ctx, category.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionUpdate,
+			EntityType: adomain.EntityCategory,
+			EntityId:   strconv.Itoa(category.ID),
+			Before:     before,
+			After:      after,
+		}, nil
+	})
+}
+
+func (c *CategoryService) Delete(ctx context.Context, categoryId int) error {
+	return c.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := c.categoryRepo.GetCategory(ctx, categoryId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = c.categoryRepo.Delete(ctx, categoryId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionDelete,
+			EntityType: adomain.EntityCategory,
+			EntityId:   strconv.Itoa(categoryId),
+			Before:     before,
+		}, nil
+	})
+}
+
+// Add attribute to category. Code must be unique across category, its parents and subcategories,
+// so item has one definition of every attribute code
+func (c *CategoryService) CreateAttribute(ctx context.Context, attr domain.Attribute) error {
+	if err := attr.Validate(); err != nil {
+		return err
+	}
+
+	_, err := c.categoryRepo.GetCategory(ctx, attr.CategoryId)
+	if err != nil {
+		return err
+	}
+
+	return c.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		err := c.categoryRepo.CreateAttribute(ctx, attr)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionCreate,
+			EntityType: adomain.EntityAttribute,
+			EntityId:   attributeId(attr.CategoryId, attr.Code),
+			After:      attr,
+		}, nil
+	})
+}
+
+func (c *CategoryService) DeleteAttribute(ctx context.Context, categoryId int, code string) error {
+	attributes, err := c.categoryRepo.GetAttributes(ctx, categoryId)
+	if err != nil {
+		return err
+	}
+
+	// inherited attributes are deleted from parent, so they aren't found here
+	idx := slices.IndexFunc(attributes, func(a domain.Attribute) bool {
+		return a.CategoryId == categoryId && a.Code == code
+	})
+	if idx == -1 {
+		return domain.ErrAttributeNotFound
+	}
+
+	return c.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		err := c.categoryRepo.DeleteAttribute(ctx, categoryId, code)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionDelete,
+			EntityType: adomain.EntityAttribute,
+			EntityId:   attributeId(categoryId, code),
+			Before:     attributes[idx],
+		}, nil
+	})
+}
+
+// Attribute is identified by category and code
+func attributeId(categoryId int, code string) string {
+	return fmt.Sprintf("%d/%s", categoryId, code)
+}
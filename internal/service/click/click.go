@@ -0,0 +1,172 @@
+package click
+
+import (
+	domain "cloth-mini-app/internal/domain/click"
+	idomain "cloth-mini-app/internal/domain/item"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	statsLimit = 100 // rows of stats if limit isn't provided
+	// period of stats if it isn't provided
+	statsDays = 30
+)
+
+type ClickRepository interface {
+	// Record click and return its id
+	Create(ctx context.Context, click domain.Click) (int, error)
+	// Get clicks per item, most clicked first
+	GetItemStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error)
+	// Get clicks per brand, most clicked first
+	GetBrandStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error)
+	// Get clicks per day, latest first
+	GetDayStats(ctx context.Context, filter domain.StatsFilter) ([]domain.DayStats, error)
+	GetTemplates(ctx context.Context) ([]domain.Template, error)
+	// Find template of host or its closest parent domain
+	FindTemplate(ctx context.Context, host string) (domain.Template, error)
+	// Create or replace template of domain
+	SaveTemplate(ctx context.Context, template domain.Template) error
+	DeleteTemplate(ctx context.Context, domainName string) error
+}
+
+type ItemRepository interface {
+	GetItemById(ctx context.Context, id int) (idomain.ItemAPI, error)
+}
+
+type ClickService struct {
+	logger    *slog.Logger
+	clickRepo ClickRepository
+	itemRepo  ItemRepository
+}
+
+func NewClickService(logger *slog.Logger, cr ClickRepository, ir ItemRepository) *ClickService {
+	return &ClickService{
+		logger:    logger,
+		clickRepo: cr,
+		itemRepo:  ir,
+	}
+}
+
+// Record click on outer link of published item and get link to shop with affiliate params of its domain.
+// Failed recording doesn't stop redirect, user gets to shop anyway
+func (c *ClickService) Redirect(ctx context.Context, itemId int, visit domain.Visit) (string, error) {
+	const op = "service.click.Redirect"
+
+	item, err := c.itemRepo.GetItemById(ctx, itemId)
+	if err != nil {
+		return "", err
+	}
+	if item.Status != idomain.StatusPublished {
+		return "", idomain.ErrItemNotFound
+	}
+
+	link, err := url.Parse(item.OuterLink)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Hostname() == "" {
+		return "", domain.ErrOuterLink
+	}
+
+	uaHash := sha256.Sum256([]byte(visit.UserAgent))
+	clickId, err := c.clickRepo.Create(ctx, domain.Click{
+		ItemId:   itemId,
+		Referrer: visit.Referrer,
+		UAHash:   hex.EncodeToString(uaHash[:]),
+		Campaign: visit.Campaign,
+	})
+	if err != nil {
+		c.logger.Error(fmt.Sprintf("%s: recording click", op), slog.Int("item_id", itemId), sl.Err(err))
+	}
+
+	template, err := c.clickRepo.FindTemplate(ctx, domain.NormalizeDomain(link.Hostname()))
+	if err != nil {
+		if !errors.Is(err, domain.ErrTemplateNotFound) {
+			c.logger.Error(fmt.Sprintf("%s: finding affiliate template", op), slog.Int("item_id", itemId), sl.Err(err))
+		}
+
+		return link.String(), nil
+	}
+
+	values := map[string]string{
+		domain.PlaceholderItemId:   strconv.Itoa(itemId),
+		domain.PlaceholderBrandId:  strconv.Itoa(int(item.BrandId)),
+		domain.PlaceholderSource:   value(visit.Source),
+		domain.PlaceholderMedium:   value(visit.Medium),
+		domain.PlaceholderCampaign: value(visit.Campaign.Campaign),
+		domain.PlaceholderContent:  value(visit.Content),
+		domain.PlaceholderTerm:     value(visit.Term),
+	}
+	if clickId != 0 {
+		values[domain.PlaceholderClickId] = strconv.Itoa(clickId)
+	}
+	template.Apply(link, values)
+
+	return link.String(), nil
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// Get clicks per item, most clicked first
+func (c *ClickService) GetItemStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error) {
+	return c.clickRepo.GetItemStats(ctx, statsFilter(filter))
+}
+
+// Get clicks per brand, most clicked first
+func (c *ClickService) GetBrandStats(ctx context.Context, filter domain.StatsFilter) ([]domain.Stats, error) {
+	return c.clickRepo.GetBrandStats(ctx, statsFilter(filter))
+}
+
+// Get clicks per day, latest first
+func (c *ClickService) GetDayStats(ctx context.Context, filter domain.StatsFilter) ([]domain.DayStats, error) {
+	return c.clickRepo.GetDayStats(ctx, statsFilter(filter))
+}
+
+// Stats are for last 30 days and 100 rows by default
+func statsFilter(filter domain.StatsFilter) domain.StatsFilter {
+	if filter.From == nil && filter.To == nil {
+		from := time.Now().AddDate(0, 0, -statsDays)
+		filter.From = &from
+	}
+	if filter.Limit == 0 {
+		filter.Limit = statsLimit
+	}
+
+	return filter
+}
+
+func (c *ClickService) GetTemplates(ctx context.Context) ([]domain.Template, error) {
+	return c.clickRepo.GetTemplates(ctx)
+}
+
+// Create or replace template of shop domain, it's applied to subdomains too
+func (c *ClickService) SaveTemplate(ctx context.Context, template domain.Template) error {
+	template.Domain = domain.NormalizeDomain(template.Domain)
+	if !domain.ValidDomain(template.Domain) {
+		return domain.ErrDomain
+	}
+
+	template.Params = strings.TrimPrefix(strings.TrimSpace(template.Params), "?")
+	if !domain.ValidParams(template.Params) {
+		return domain.ErrTemplate
+	}
+
+	return c.clickRepo.SaveTemplate(ctx, template)
+}
+
+func (c *ClickService) DeleteTemplate(ctx context.Context, domainName string) error {
+	return c.clickRepo.DeleteTemplate(ctx, domain.NormalizeDomain(domainName))
+}
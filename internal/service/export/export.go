@@ -0,0 +1,302 @@
+package export
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/export"
+	imdomain "cloth-mini-app/internal/domain/image"
+	idomain "cloth-mini-app/internal/domain/item"
+	"cloth-mini-app/internal/dto"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/storage/blob"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	jobsLimit = 20 // jobs per page if limit isn't provided
+	// time job is reserved by instance, export of whole catalog must fit in it
+	jobLease = 30 * time.Minute
+	// lifetime of presigned download link
+	linkTTL = time.Hour
+	// files deleted in one transaction
+	expireBatch = 100
+)
+
+type ItemRepository interface {
+	// Pass items matching params to fn in batches ordered by id
+	IterateItems(ctx context.Context, params idomain.ItemInputData, batchSize uint64, fn func(items []idomain.ItemAPI) error) error
+}
+
+type ImageRepository interface {
+	// Get images with metadata for items (itemId => images)
+	GetItemsImages(ctx context.Context, itemIds []int) (map[int][]imdomain.Image, error)
+}
+
+type ExportJobRepository interface {
+	// Create job waiting for processing and return its id
+	Create(ctx context.Context, job domain.Job) (int, error)
+	GetJob(ctx context.Context, id int) (domain.Job, error)
+	// Get jobs, latest first
+	GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error)
+	// Reserve pending job and mark it running
+	Reserve(ctx context.Context, until time.Time) (domain.Job, error)
+	// Mark running job done with stored file
+	Complete(ctx context.Context, id int, result domain.Result) error
+	// Mark running job failed with error message
+	Fail(ctx context.Context, id int, message string) error
+	// Mark jobs done before provided time expired, deleteFn deletes their files
+	ExpireJobs(ctx context.Context, doneBefore time.Time, limit uint64, deleteFn func(objectIds []string) error) (int, error)
+}
+
+type BlobStorage interface {
+	// Put file of info.Size bytes read from r
+	PutStream(ctx context.Context, info dto.FileInfo, r io.Reader) error
+	// Open file for reading, reader must be closed
+	Open(ctx context.Context, objectId string) (io.ReadCloser, dto.FileInfo, error)
+	// Get presigned url for direct download. Return blob.ErrNotSupported if backend can't do it
+	PresignedGet(ctx context.Context, objectId string, fileName string, expires time.Duration) (string, error)
+	// Delete file, missing file isn't an error
+	Delete(ctx context.Context, objectId string) error
+}
+
+type ExportService struct {
+	logger    *slog.Logger
+	itemRepo  ItemRepository
+	imageRepo ImageRepository
+	jobRepo   ExportJobRepository
+	storage   BlobStorage
+	// base url of app for image and download links
+	publicURL string
+	// items fetched by one query
+	batchSize int
+	// files of done jobs are deleted after this time
+	retention time.Duration
+}
+
+func NewExportService(logger *slog.Logger, ir ItemRepository, imr ImageRepository, jr ExportJobRepository, storage BlobStorage, publicURL string, batchSize int, retention time.Duration) *ExportService {
+	return &ExportService{
+		logger:    logger,
+		itemRepo:  ir,
+		imageRepo: imr,
+		jobRepo:   jr,
+		storage:   storage,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		batchSize: batchSize,
+		retention: retention,
+	}
+}
+
+// Write items matching filter to w in format and return number of exported items.
+// Items are fetched in batches, so catalog isn't loaded into memory. Limit and offset of filter are ignored
+func (e *ExportService) Write(ctx context.Context, w io.Writer, format domain.Format, filter idomain.ItemInputData) (int, error) {
+	if !format.Valid() {
+		return 0, domain.ErrFormat
+	}
+	filter.Limit, filter.Offset = nil, nil
+
+	writer, err := newRecordWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = e.itemRepo.IterateItems(ctx, filter, uint64(e.batchSize), func(items []idomain.ItemAPI) error {
+		itemIds := make([]int, 0, len(items))
+		for _, item := range items {
+			itemIds = append(itemIds, int(item.ID))
+		}
+
+		images, err := e.imageRepo.GetItemsImages(ctx, itemIds)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := writer.Write(newRecord(item, e.imageURLs(images[int(item.ID)]))); err != nil {
+				return err
+			}
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, writer.Close()
+}
+
+func (e *ExportService) imageURLs(images []imdomain.Image) []string {
+	urls := make([]string, 0, len(images))
+	for _, image := range images {
+		urls = append(urls, e.publicURL+"/image/get/"+image.ObjectId)
+	}
+
+	return urls
+}
+
+// Create export job processed in background, file is stored in blob storage
+func (e *ExportService) CreateJob(ctx context.Context, format domain.Format, filter idomain.ItemInputData) (int, error) {
+	if !format.Valid() {
+		return 0, domain.ErrFormat
+	}
+	filter.Limit, filter.Offset = nil, nil
+
+	actor, _ := adomain.ActorFromContext(ctx)
+
+	return e.jobRepo.Create(ctx, domain.Job{
+		Format: format,
+		Filter: filter,
+		Actor:  actor,
+	})
+}
+
+func (e *ExportService) GetJob(ctx context.Context, id int) (domain.Job, error) {
+	return e.jobRepo.GetJob(ctx, id)
+}
+
+// Get export jobs, latest first
+func (e *ExportService) GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error) {
+	if limit == 0 {
+		limit = jobsLimit
+	}
+
+	return e.jobRepo.GetJobs(ctx, limit, offset)
+}
+
+// Get link for downloading file of done job. Link is signed by storage, so file is downloaded
+// directly from it. If storage can't sign links, file is downloaded through app (see Open)
+func (e *ExportService) DownloadURL(ctx context.Context, job domain.Job) (string, error) {
+	if err := job.FileError(); err != nil {
+		return "", err
+	}
+
+	url, err := e.storage.PresignedGet(ctx, job.ObjectId, job.Format.FileName(), linkTTL)
+	if errors.Is(err, blob.ErrNotSupported) {
+		return fmt.Sprintf("%s/export/jobs/%d/download", e.publicURL, job.ID), nil
+	}
+
+	return url, err
+}
+
+// Open file of done job for reading, reader must be closed
+func (e *ExportService) Open(ctx context.Context, id int) (io.ReadCloser, domain.Job, error) {
+	job, err := e.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, domain.Job{}, err
+	}
+	if err := job.FileError(); err != nil {
+		return nil, domain.Job{}, err
+	}
+
+	file, _, err := e.storage.Open(ctx, job.ObjectId)
+	if err != nil {
+		return nil, domain.Job{}, err
+	}
+
+	return file, job, nil
+}
+
+// Export items of next waiting job to blob storage. Returns false if there are no jobs.
+// File is written to temp file first, storage needs its size before upload
+func (e *ExportService) ProcessNextJob(ctx context.Context) (bool, error) {
+	const op = "service.export.ProcessNextJob"
+
+	job, err := e.jobRepo.Reserve(ctx, time.Now().Add(jobLease))
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	result, err := e.export(ctx, job)
+	if err != nil {
+		e.logger.Error(fmt.Sprintf("%s: export job failed", op), slog.Int("job_id", job.ID), sl.Err(err))
+
+		if failErr := e.jobRepo.Fail(ctx, job.ID, err.Error()); failErr != nil {
+			return true, failErr
+		}
+		return true, err
+	}
+
+	e.logger.Info(fmt.Sprintf("%s: export job done", op), slog.Int("job_id", job.ID), slog.Int("items", result.Items))
+
+	return true, e.jobRepo.Complete(ctx, job.ID, result)
+}
+
+func (e *ExportService) export(ctx context.Context, job domain.Job) (domain.Result, error) {
+	file, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return domain.Result{}, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	items, err := e.Write(ctx, file, job.Format, job.Filter)
+	if err != nil {
+		return domain.Result{}, err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return domain.Result{}, err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return domain.Result{}, err
+	}
+
+	// id isn't guessable, file is downloaded only by link of job
+	objectId := fmt.Sprintf("export-%s.%s", uuid.New().String(), job.Format)
+	err = e.storage.PutStream(ctx, dto.FileInfo{
+		ID:          objectId,
+		ContentType: job.Format.ContentType(),
+		Size:        size,
+	}, file)
+	if err != nil {
+		return domain.Result{}, err
+	}
+
+	return domain.Result{
+		ObjectId: objectId,
+		Items:    items,
+		Size:     size,
+	}, nil
+}
+
+// Delete files of jobs done earlier than retention period. Returns number of expired jobs
+func (e *ExportService) ExpireFiles(ctx context.Context) (int, error) {
+	const op = "service.export.ExpireFiles"
+
+	total := 0
+	for {
+		expired, err := e.jobRepo.ExpireJobs(ctx, time.Now().Add(-e.retention), expireBatch, func(objectIds []string) error {
+			for _, objectId := range objectIds {
+				if err := e.storage.Delete(ctx, objectId); err != nil {
+					e.logger.Error(fmt.Sprintf("%s: deleting export file", op), slog.String("object_id", objectId), sl.Err(err))
+
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += expired
+
+		if expired < expireBatch {
+			return total, nil
+		}
+	}
+}
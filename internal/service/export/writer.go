@@ -0,0 +1,220 @@
+package export
+
+import (
+	domain "cloth-mini-app/internal/domain/export"
+	idomain "cloth-mini-app/internal/domain/item"
+	"cloth-mini-app/internal/xlsx"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Excel opens csv without BOM in locale encoding, so cyrillic names are broken
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Columns of csv and xlsx exports, NDJSON objects have the same keys
+var columns = []string{
+	"id", "brand_id", "brand", "category_id", "category", "name", "description", "sex", "price", "discount",
+	"outer_link", "status", "publish_at", "created_at", "updated_at", "attributes", "images",
+}
+
+// Exported item
+type record struct {
+	ID          uint           `json:"id"`
+	BrandId     uint           `json:"brand_id"`
+	Brand       string         `json:"brand"`
+	CategoryId  int            `json:"category_id"`
+	Category    string         `json:"category"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Sex         string         `json:"sex"`
+	Price       int            `json:"price"`
+	Discount    *int           `json:"discount"`
+	OuterLink   string         `json:"outer_link"`
+	Status      string         `json:"status"`
+	PublishAt   *time.Time     `json:"publish_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   *time.Time     `json:"updated_at"`
+	Attributes  map[string]any `json:"attributes"`
+	Images      []string       `json:"images"`
+}
+
+func newRecord(item idomain.ItemAPI, imageURLs []string) record {
+	attributes := item.Attributes
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	if imageURLs == nil {
+		imageURLs = []string{}
+	}
+
+	return record{
+		ID:          item.ID,
+		BrandId:     item.BrandId,
+		Brand:       item.BrandName,
+		CategoryId:  item.CategoryId,
+		Category:    item.CategoryName,
+		Name:        item.Name,
+		Description: item.Description,
+		Sex:         item.Sex.String(),
+		Price:       item.Price,
+		Discount:    item.Discount,
+		OuterLink:   item.OuterLink,
+		Status:      item.Status.String(),
+		PublishAt:   item.PublishAt,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Attributes:  attributes,
+		Images:      imageURLs,
+	}
+}
+
+// Cells of table row in order of columns. Attributes are json object, image urls are separated by spaces
+func (r record) cells() ([]any, error) {
+	attributes, err := json.Marshal(r.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var discount any
+	if r.Discount != nil {
+		discount = *r.Discount
+	}
+
+	return []any{
+		r.ID, r.BrandId, r.Brand, r.CategoryId, r.Category, r.Name, r.Description, r.Sex, r.Price, discount,
+		r.OuterLink, r.Status, formatTime(r.PublishAt), formatTime(&r.CreatedAt), formatTime(r.UpdatedAt),
+		string(attributes), strings.Join(r.Images, " "),
+	}, nil
+}
+
+func formatTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Writer of exported items in one of formats. Close flushes buffered data
+type recordWriter interface {
+	Write(r record) error
+	Close() error
+}
+
+func newRecordWriter(w io.Writer, format domain.Format) (recordWriter, error) {
+	switch format {
+	case domain.FormatCSV:
+		return newCSVWriter(w)
+	case domain.FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case domain.FormatXLSX:
+		return newXLSXWriter(w)
+	}
+
+	return nil, domain.ErrFormat
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := w.Write(utf8BOM); err != nil {
+		return nil, err
+	}
+
+	writer := &csvWriter{w: csv.NewWriter(w)}
+
+	return writer, writer.w.Write(columns)
+}
+
+func (c *csvWriter) Write(r record) error {
+	cells, err := r.cells()
+	if err != nil {
+		return err
+	}
+
+	row := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		row = append(row, csvValue(cell))
+	}
+
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+// Spreadsheet runs cell starting with these characters as formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+func csvValue(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		// names and descriptions come from imports and shop pages, they are shown as text
+		if v != "" && strings.ContainsRune(csvFormulaPrefixes, rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+
+	b, _ := json.Marshal(cell)
+
+	return string(b)
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(r record) error {
+	return n.enc.Encode(r)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type xlsxWriter struct {
+	w *xlsx.Writer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	writer, err := xlsx.NewWriter(w, "Items")
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]any, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column)
+	}
+
+	return &xlsxWriter{w: writer}, writer.WriteRow(header)
+}
+
+func (x *xlsxWriter) Write(r record) error {
+	cells, err := r.cells()
+	if err != nil {
+		return err
+	}
+
+	return x.w.WriteRow(cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	return x.w.Close()
+}
@@ -0,0 +1,352 @@
+package feed
+
+import (
+	cdomain "cloth-mini-app/internal/domain/category"
+	domain "cloth-mini-app/internal/domain/feed"
+	imdomain "cloth-mini-app/internal/domain/image"
+	idomain "cloth-mini-app/internal/domain/item"
+	"cloth-mini-app/internal/dto"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+type FeedRepository interface {
+	// Get published items without entries or with entries rendered from other data
+	StaleItems(ctx context.Context, salt string, limit uint64) ([]domain.StaleItem, error)
+	// Delete entries of items which aren't published anymore and return their number
+	DeleteRemoved(ctx context.Context) (int, error)
+	// Create or replace entries
+	SaveEntries(ctx context.Context, entries []domain.Entry) error
+	// Pass entries to fn in batches ordered by item id
+	IterateEntries(ctx context.Context, batchSize uint64, fn func(entries []domain.Entry) error) error
+	GetFeed(ctx context.Context, name string) (domain.Feed, error)
+	// Create or replace generated feed
+	SaveFeed(ctx context.Context, feed domain.Feed) error
+}
+
+type ItemRepository interface {
+	GetItemsByIds(ctx context.Context, ids []int) ([]idomain.ItemAPI, error)
+}
+
+type ImageRepository interface {
+	// Get images with metadata for items (itemId => images)
+	GetItemsImages(ctx context.Context, itemIds []int) (map[int][]imdomain.Image, error)
+}
+
+type CategoryRepository interface {
+	GetCategories(ctx context.Context) ([]cdomain.Category, error)
+}
+
+type OutboxRepository interface {
+	// Get id of last outbox event, 0 if there are no events
+	LastEventId(ctx context.Context) (int, error)
+}
+
+type BlobStorage interface {
+	// Put file of info.Size bytes read from r
+	PutStream(ctx context.Context, info dto.FileInfo, r io.Reader) error
+	// Open file for reading, reader must be closed
+	Open(ctx context.Context, objectId string) (io.ReadCloser, dto.FileInfo, error)
+	// Delete file, missing file isn't an error
+	Delete(ctx context.Context, objectId string) error
+}
+
+type FeedService struct {
+	logger       *slog.Logger
+	feedRepo     FeedRepository
+	itemRepo     ItemRepository
+	imageRepo    ImageRepository
+	categoryRepo CategoryRepository
+	outboxRepo   OutboxRepository
+	storage      BlobStorage
+	shop         domain.Shop
+	// items rendered or entries fetched by one query
+	batchSize int
+}
+
+func NewFeedService(logger *slog.Logger, fr FeedRepository, ir ItemRepository, imr ImageRepository, cr CategoryRepository, or OutboxRepository, storage BlobStorage, shop domain.Shop, batchSize int) *FeedService {
+	shop.URL = strings.TrimSuffix(shop.URL, "/")
+
+	return &FeedService{
+		logger:       logger,
+		feedRepo:     fr,
+		itemRepo:     ir,
+		imageRepo:    imr,
+		categoryRepo: cr,
+		outboxRepo:   or,
+		storage:      storage,
+		shop:         shop,
+		batchSize:    batchSize,
+	}
+}
+
+// Get generated feed. ErrFeedNotFound for unknown name or feed not generated yet
+func (f *FeedService) GetFeed(ctx context.Context, name string) (domain.Feed, error) {
+	if !domain.ValidFeed(name) {
+		return domain.Feed{}, domain.ErrFeedNotFound
+	}
+
+	return f.feedRepo.GetFeed(ctx, name)
+}
+
+// Open file of generated feed for reading, reader must be closed
+func (f *FeedService) Open(ctx context.Context, feed domain.Feed) (io.ReadCloser, error) {
+	file, _, err := f.storage.Open(ctx, feed.ObjectId)
+
+	return file, err
+}
+
+// Render entries of changed items and assemble feeds again if there are new outbox events or changed entries.
+// Returns false if feeds are up to date
+func (f *FeedService) Regenerate(ctx context.Context) (bool, error) {
+	const op = "service.feed.Regenerate"
+
+	// event is read before entries, so changes made during regeneration are picked up next time
+	eventId, err := f.outboxRepo.LastEventId(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := f.renderEntries(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	feeds := make(map[string]domain.Feed, len(domain.Feeds()))
+	outdated := changed
+	for _, name := range domain.Feeds() {
+		feed, err := f.feedRepo.GetFeed(ctx, name)
+		if err != nil && !errors.Is(err, domain.ErrFeedNotFound) {
+			return false, err
+		}
+		if err != nil || feed.EventId < eventId {
+			outdated = true
+		}
+		feeds[name] = feed
+	}
+	if !outdated {
+		return false, nil
+	}
+
+	if err := f.assemble(ctx, feeds, eventId); err != nil {
+		return false, err
+	}
+
+	f.logger.Info(fmt.Sprintf("%s: feeds regenerated", op), slog.Int("event_id", eventId))
+
+	return true, nil
+}
+
+// Delete entries of unpublished items and render entries of stale ones. Returns true if entries changed
+func (f *FeedService) renderEntries(ctx context.Context) (bool, error) {
+	removed, err := f.feedRepo.DeleteRemoved(ctx)
+	if err != nil {
+		return false, err
+	}
+	changed := removed > 0
+
+	salt := f.shop.Currency + "|" + f.shop.URL
+	for {
+		stale, err := f.feedRepo.StaleItems(ctx, salt, uint64(f.batchSize))
+		if err != nil {
+			return false, err
+		}
+		if len(stale) == 0 {
+			return changed, nil
+		}
+
+		itemIds := make([]int, 0, len(stale))
+		for _, item := range stale {
+			itemIds = append(itemIds, item.ItemId)
+		}
+
+		items, err := f.itemRepo.GetItemsByIds(ctx, itemIds)
+		if err != nil {
+			return false, err
+		}
+		images, err := f.imageRepo.GetItemsImages(ctx, itemIds)
+		if err != nil {
+			return false, err
+		}
+
+		sources := make(map[int]string, len(stale))
+		for _, item := range stale {
+			sources[item.ItemId] = item.Source
+		}
+
+		entries := make([]domain.Entry, 0, len(items))
+		for _, item := range items {
+			entry, err := f.render(item, images[int(item.ID)], sources[int(item.ID)])
+			if err != nil {
+				return false, err
+			}
+			entries = append(entries, entry)
+		}
+		// items unpublished since they were found are removed on next run
+		if len(entries) == 0 {
+			return changed, nil
+		}
+
+		if err := f.feedRepo.SaveEntries(ctx, entries); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+}
+
+// Write all feeds to temp files from entries and replace stored ones
+func (f *FeedService) assemble(ctx context.Context, feeds map[string]domain.Feed, eventId int) error {
+	categories, err := f.categoryRepo.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	frames, err := f.frames(categories, time.Now())
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]*feedFile, len(frames))
+	defer func() {
+		for _, file := range files {
+			file.remove()
+		}
+	}()
+
+	for name, frame := range frames {
+		file, err := newFeedFile()
+		if err != nil {
+			return err
+		}
+		files[name] = file
+
+		if err := file.writeUnhashed(frame.stamp); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, frame.header); err != nil {
+			return err
+		}
+	}
+
+	err = f.feedRepo.IterateEntries(ctx, uint64(f.batchSize), func(entries []domain.Entry) error {
+		for name, frame := range frames {
+			for _, entry := range entries {
+				if _, err := io.WriteString(files[name], frame.entry(entry)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, frame := range frames {
+		if _, err := io.WriteString(files[name], frame.footer); err != nil {
+			return err
+		}
+		if err := f.replace(ctx, feeds[name], name, files[name], eventId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Upload feed file under name derived from content and delete previous file
+func (f *FeedService) replace(ctx context.Context, old domain.Feed, name string, file *feedFile, eventId int) error {
+	const op = "service.feed.replace"
+
+	etag := hex.EncodeToString(file.hash.Sum(nil))
+	if old.ETag == etag {
+		// content is the same, only event is updated
+		old.EventId = eventId
+		return f.feedRepo.SaveFeed(ctx, old)
+	}
+
+	if _, err := file.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	objectId := fmt.Sprintf("feed-%s-%s", etag[:16], name)
+	err := f.storage.PutStream(ctx, dto.FileInfo{
+		ID:          objectId,
+		ContentType: domain.ContentType(name),
+		Size:        file.size,
+	}, file.file)
+	if err != nil {
+		return err
+	}
+
+	err = f.feedRepo.SaveFeed(ctx, domain.Feed{
+		Name:     name,
+		ObjectId: objectId,
+		ETag:     etag,
+		Size:     file.size,
+		EventId:  eventId,
+	})
+	if err != nil {
+		return err
+	}
+
+	if old.ObjectId != "" && old.ObjectId != objectId {
+		if err := f.storage.Delete(ctx, old.ObjectId); err != nil {
+			// old file is only garbage, feed is already replaced
+			f.logger.Error(fmt.Sprintf("%s: deleting old feed file", op), slog.String("object_id", old.ObjectId), sl.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// Temp file counting size and hash of written content
+type feedFile struct {
+	file *os.File
+	hash hash.Hash
+	size int64
+}
+
+func newFeedFile() (*feedFile, error) {
+	file, err := os.CreateTemp("", "feed-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &feedFile{
+		file: file,
+		hash: sha256.New(),
+	}, nil
+}
+
+func (f *feedFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	f.hash.Write(p[:n])
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Write part of content that isn't hashed
+func (f *feedFile) writeUnhashed(s string) error {
+	n, err := io.WriteString(f.file, s)
+	f.size += int64(n)
+
+	return err
+}
+
+func (f *feedFile) remove() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
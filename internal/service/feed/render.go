@@ -0,0 +1,210 @@
+package feed
+
+import (
+	cdomain "cloth-mini-app/internal/domain/category"
+	domain "cloth-mini-app/internal/domain/feed"
+	imdomain "cloth-mini-app/internal/domain/image"
+	idomain "cloth-mini-app/internal/domain/item"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of sex in Yandex.Market param
+var ymlSex = map[idomain.Sex]string{
+	idomain.SexMale:   "Мужской",
+	idomain.SexFemale: "Женский",
+	idomain.SexUnisex: "Унисекс",
+}
+
+var googleTSVColumns = []string{
+	"id", "title", "description", "link", "image_link", "additional_image_link", "availability",
+	"price", "sale_price", "brand", "product_type", "gender", "condition",
+}
+
+type ymlParam struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type ymlOffer struct {
+	XMLName     xml.Name   `xml:"offer"`
+	ID          int        `xml:"id,attr"`
+	Available   bool       `xml:"available,attr"`
+	URL         string     `xml:"url"`
+	Price       int        `xml:"price"`
+	OldPrice    int        `xml:"oldprice,omitempty"`
+	CurrencyId  string     `xml:"currencyId"`
+	CategoryId  int        `xml:"categoryId"`
+	Pictures    []string   `xml:"picture"`
+	Vendor      string     `xml:"vendor"`
+	Name        string     `xml:"name"`
+	Description string     `xml:"description"`
+	Params      []ymlParam `xml:"param"`
+}
+
+type googleItem struct {
+	XMLName              xml.Name `xml:"item"`
+	ID                   int      `xml:"g:id"`
+	Title                string   `xml:"g:title"`
+	Description          string   `xml:"g:description"`
+	Link                 string   `xml:"g:link"`
+	ImageLink            string   `xml:"g:image_link,omitempty"`
+	AdditionalImageLinks []string `xml:"g:additional_image_link"`
+	Availability         string   `xml:"g:availability"`
+	Price                string   `xml:"g:price"`
+	SalePrice            string   `xml:"g:sale_price,omitempty"`
+	Brand                string   `xml:"g:brand"`
+	ProductType          string   `xml:"g:product_type"`
+	Gender               string   `xml:"g:gender"`
+	Condition            string   `xml:"g:condition"`
+}
+
+// Render entries of published item. Items in feeds are available, shop has them in stock
+func (f *FeedService) render(item idomain.ItemAPI, images []imdomain.Image, source string) (domain.Entry, error) {
+	imageURLs := make([]string, 0, len(images))
+	for _, image := range images {
+		imageURLs = append(imageURLs, f.shop.URL+"/image/get/"+image.ObjectId)
+	}
+
+	offer := ymlOffer{
+		ID:          int(item.ID),
+		Available:   true,
+		URL:         item.OuterLink,
+		Price:       item.SalePrice(),
+		CurrencyId:  f.shop.Currency,
+		CategoryId:  item.CategoryId,
+		Pictures:    imageURLs,
+		Vendor:      item.BrandName,
+		Name:        item.Name,
+		Description: item.Description,
+		Params:      []ymlParam{{Name: "Пол", Value: ymlSex[item.Sex]}},
+	}
+	if offer.Price != item.Price {
+		offer.OldPrice = item.Price
+	}
+
+	google := googleItem{
+		ID:           int(item.ID),
+		Title:        item.Name,
+		Description:  item.Description,
+		Link:         item.OuterLink,
+		Availability: "in_stock",
+		Price:        f.price(item.Price),
+		Brand:        item.BrandName,
+		ProductType:  item.CategoryName,
+		Gender:       item.Sex.String(),
+		Condition:    "new",
+	}
+	if len(imageURLs) != 0 {
+		google.ImageLink = imageURLs[0]
+		google.AdditionalImageLinks = imageURLs[1:]
+	}
+	if item.SalePrice() != item.Price {
+		google.SalePrice = f.price(item.SalePrice())
+	}
+
+	ymlEntry, err := xml.Marshal(offer)
+	if err != nil {
+		return domain.Entry{}, err
+	}
+	googleEntry, err := xml.Marshal(google)
+	if err != nil {
+		return domain.Entry{}, err
+	}
+
+	return domain.Entry{
+		ItemId:    int(item.ID),
+		Source:    source,
+		YML:       string(ymlEntry),
+		GoogleXML: string(googleEntry),
+		GoogleTSV: tsvLine(
+			strconv.Itoa(google.ID), google.Title, google.Description, google.Link, google.ImageLink,
+			strings.Join(google.AdditionalImageLinks, ","), google.Availability, google.Price, google.SalePrice,
+			google.Brand, google.ProductType, google.Gender, google.Condition,
+		),
+	}, nil
+}
+
+func (f *FeedService) price(price int) string {
+	return fmt.Sprintf("%d %s", price, f.shop.Currency)
+}
+
+// Tabs and line breaks separate values, so they are replaced by spaces
+func tsvLine(values ...string) string {
+	cleaned := make([]string, 0, len(values))
+	for _, value := range values {
+		cleaned = append(cleaned, strings.Join(strings.Fields(value), " "))
+	}
+
+	return strings.Join(cleaned, "\t") + "\n"
+}
+
+// Start and end of feed file around entries
+type frame struct {
+	// beginning of file with generation date. It isn't hashed, so feed with the same content keeps its ETag
+	stamp  string
+	header string
+	footer string
+	entry  func(entry domain.Entry) string
+}
+
+func (f *FeedService) frames(categories []cdomain.Category, now time.Time) (map[string]frame, error) {
+	var shop strings.Builder
+	shop.WriteString(`<shop>`)
+	for _, element := range [][2]string{{"name", f.shop.Name}, {"company", f.shop.Company}, {"url", f.shop.URL}} {
+		if err := writeElement(&shop, element[0], element[1], nil); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(&shop, `<currencies><currency id="%s" rate="1"/></currencies><categories>`, f.shop.Currency)
+	for _, category := range categories {
+		attrs := []xml.Attr{{Name: xml.Name{Local: "id"}, Value: strconv.Itoa(category.CategoryId)}}
+		if category.ParentId != nil {
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "parentId"}, Value: strconv.Itoa(*category.ParentId)})
+		}
+		if err := writeElement(&shop, "category", category.Name, attrs); err != nil {
+			return nil, err
+		}
+	}
+	shop.WriteString(`</categories><offers>`)
+
+	var channel strings.Builder
+	channel.WriteString(xml.Header)
+	channel.WriteString(`<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0"><channel>`)
+	for _, element := range [][2]string{{"title", f.shop.Name}, {"link", f.shop.URL}, {"description", f.shop.Company}} {
+		if err := writeElement(&channel, element[0], element[1], nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]frame{
+		domain.FeedYandex: {
+			stamp:  xml.Header + fmt.Sprintf(`<yml_catalog date="%s">`, now.Format(time.RFC3339)),
+			header: shop.String(),
+			footer: `</offers></shop></yml_catalog>`,
+			entry:  func(entry domain.Entry) string { return entry.YML },
+		},
+		domain.FeedGoogleXML: {
+			header: channel.String(),
+			footer: `</channel></rss>`,
+			entry:  func(entry domain.Entry) string { return entry.GoogleXML },
+		},
+		domain.FeedGoogleTSV: {
+			header: tsvLine(googleTSVColumns...),
+			entry:  func(entry domain.Entry) string { return entry.GoogleTSV },
+		},
+	}, nil
+}
+
+func writeElement(w io.Writer, tag, value string, attrs []xml.Attr) error {
+	enc := xml.NewEncoder(w)
+	if err := enc.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: tag}, Attr: attrs}); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
@@ -0,0 +1,300 @@
+package image
+
+import (
+	"archive/zip"
+	domain "cloth-mini-app/internal/domain/image"
+	"cloth-mini-app/internal/dto"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	archiveWorkersCnt  = 10
+	archiveItemIdGroup = "item_id"
+	archiveChunkSize   = 10 // files fetched from storage at once while writing archive
+	archiveMaxImages   = 100
+)
+
+// Compile rule for mapping archive file names to items.
+// Rule must contain named group item_id, e.g. ^(?P<item_id>\d+)_\d+\.jpg$
+func NewArchiveNameRule(pattern string) (*regexp.Regexp, error) {
+	rule, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.SubexpIndex(archiveItemIdGroup) < 0 {
+		return nil, fmt.Errorf("archive name rule must contain named group %s", archiveItemIdGroup)
+	}
+
+	return rule, nil
+}
+
+type archiveFile struct {
+	idx  int
+	file *zip.File
+}
+
+type archiveFileResult struct {
+	idx    int
+	result domain.ArchiveFileResult
+}
+
+// Attach images from zip archive to items and return result per file in archive order.
+// Use worker pull with archiveWorkersCnt workers (same as MinioClient.GetMany)
+func (i *ImageService) CreateFromArchive(ctx context.Context, archive *zip.Reader) []domain.ArchiveFileResult {
+	files := make([]*zip.File, 0, len(archive.File))
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || isArchiveMetadata(file.Name) {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	var worker = func(fileCh <-chan archiveFile, resultCh chan<- archiveFileResult, wg *sync.WaitGroup) {
+		for file := range fileCh {
+			resultCh <- archiveFileResult{
+				idx:    file.idx,
+				result: i.attachArchiveFile(ctx, file.file),
+			}
+			wg.Done()
+		}
+	}
+
+	var wg sync.WaitGroup
+	fileCh := make(chan archiveFile, len(files))
+	resultCh := make(chan archiveFileResult, len(files))
+
+	for range archiveWorkersCnt {
+		go worker(fileCh, resultCh, &wg)
+	}
+
+	for idx, file := range files {
+		wg.Add(1)
+		fileCh <- archiveFile{idx: idx, file: file}
+	}
+	close(fileCh)
+
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	results := make([]domain.ArchiveFileResult, len(files))
+	for result := range resultCh {
+		results[result.idx] = result.result
+	}
+
+	return results
+}
+
+// Validate file from archive and attach it to item resolved from file name
+func (i *ImageService) attachArchiveFile(ctx context.Context, file *zip.File) domain.ArchiveFileResult {
+	result := domain.ArchiveFileResult{
+		FileName: file.Name,
+	}
+
+	itemId, err := i.itemIdFromFileName(path.Base(file.Name))
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+	result.ItemId = itemId
+
+	image, err := readArchiveFile(file)
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+
+	if err = domain.CheckImageType(image); err != nil {
+		result.Err = err
+
+		return result
+	}
+
+	fileId, err := i.CreateItemImage(ctx, itemId, image)
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+	result.FileId = fileId
+
+	return result
+}
+
+func (i *ImageService) itemIdFromFileName(name string) (int, error) {
+	match := i.archiveNameRule.FindStringSubmatch(name)
+	if match == nil {
+		return 0, domain.ErrFileName
+	}
+
+	itemId, err := strconv.Atoi(match[i.archiveNameRule.SubexpIndex(archiveItemIdGroup)])
+	if err != nil {
+		return 0, domain.ErrFileName
+	}
+
+	return itemId, nil
+}
+
+// read file from archive. Size from file header isn't trusted, so reading is limited too
+func readArchiveFile(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > maxUploadSize {
+		return nil, domain.ErrImageSize
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	image, err := io.ReadAll(io.LimitReader(reader, maxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(image) > maxUploadSize {
+		return nil, domain.ErrImageSize
+	}
+
+	return image, nil
+}
+
+// files added to archive by macOS and hidden files
+func isArchiveMetadata(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".")
+}
+
+// Entries for archive with all images of item. Names are <item_id>_<n>
+// (ordered by upload), so archive can be uploaded back with default naming rule.
+// Images of unpublished items are available only if publishedOnly is false (for editors)
+func (i *ImageService) ItemArchiveEntries(ctx context.Context, itemId int, publishedOnly bool) ([]domain.ArchiveEntry, error) {
+	itemImages, err := i.imageRepo.FindImages(ctx, domain.ImageFilter{
+		ItemId:        &itemId,
+		PublishedOnly: publishedOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(itemImages) == 0 {
+		return nil, domain.ErrNoImages
+	}
+
+	entries := make([]domain.ArchiveEntry, 0, len(itemImages))
+	for n, image := range itemImages {
+		entries = append(entries, domain.ArchiveEntry{
+			ObjectId: image.ObjectId,
+			Name:     fmt.Sprintf("%d_%d", itemId, n+1),
+		})
+	}
+
+	return entries, nil
+}
+
+// Entries for archive with provided images of items. Names are image ids, duplicates are skipped.
+// ErrImageNotFound if any id isn't an image of item or item isn't published and publishedOnly is set
+func (i *ImageService) ImagesArchiveEntries(ctx context.Context, imageIds []string, publishedOnly bool) ([]domain.ArchiveEntry, error) {
+	if len(imageIds) == 0 {
+		return nil, domain.ErrNoImages
+	}
+	if len(imageIds) > archiveMaxImages {
+		return nil, fmt.Errorf("%w: max %d images per archive", domain.ErrImageLimit, archiveMaxImages)
+	}
+
+	ids := make([]string, 0, len(imageIds))
+	seen := make(map[string]bool, len(imageIds))
+	for _, id := range imageIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	images, err := i.imageRepo.FindImages(ctx, domain.ImageFilter{
+		ObjectIds:     ids,
+		PublishedOnly: publishedOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(images) != len(ids) {
+		return nil, domain.ErrImageNotFound
+	}
+
+	entries := make([]domain.ArchiveEntry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, domain.ArchiveEntry{
+			ObjectId: id,
+			Name:     id,
+		})
+	}
+
+	return entries, nil
+}
+
+// Write zip archive with entries to w.
+// Files are fetched by chunks with worker pool (GetImageMany), so only one chunk is kept in memory.
+// Nothing is written to w if first chunk can't be fetched
+func (i *ImageService) WriteArchive(ctx context.Context, w io.Writer, entries []domain.ArchiveEntry) error {
+	archive := zip.NewWriter(w)
+
+	for start := 0; start < len(entries); start += archiveChunkSize {
+		chunk := entries[start:min(start+archiveChunkSize, len(entries))]
+
+		ids := make([]string, 0, len(chunk))
+		for _, entry := range chunk {
+			ids = append(ids, entry.ObjectId)
+		}
+
+		files, err := i.GetImageMany(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		// worker pool returns files in random order
+		filesById := make(map[string]dto.FileDTO, len(files))
+		for _, file := range files {
+			filesById[file.ID] = file
+		}
+
+		for _, entry := range chunk {
+			file := filesById[entry.ObjectId]
+
+			// images are already compressed
+			fileWriter, err := archive.CreateHeader(&zip.FileHeader{
+				Name:   entry.Name + imageExtension(file.Buffer),
+				Method: zip.Store,
+			})
+			if err != nil {
+				return err
+			}
+
+			if _, err = fileWriter.Write(file.Buffer); err != nil {
+				return err
+			}
+		}
+	}
+
+	return archive.Close()
+}
+
+// Extension is detected by content, images uploaded before detection are stored as jpeg
+func imageExtension(file []byte) string {
+	if domain.DetectImageContentType(file) == "image/png" {
+		return ".png"
+	}
+
+	return ".jpg"
+}
@@ -1,38 +1,80 @@
 package image
 
 import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/image"
 	"cloth-mini-app/internal/dto"
 	sl "cloth-mini-app/internal/logger"
-	"cloth-mini-app/internal/storage/minio"
+	"cloth-mini-app/internal/storage/blob"
 	"context"
+	"errors"
 	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-type MinioClient interface {
-	Put(ctx context.Context, file dto.FileDTO) error
-	Get(ctx context.Context, objectId string) (dto.FileDTO, error)
-	GetMany(ctx context.Context, objectIds []string) ([]dto.FileDTO, error)
-}
+const (
+	maxUploadSize = 10 << 20 // max size of single image uploaded by presigned url or in archive
+	uploadURLTTL  = time.Minute * 15
+	metaDecoders  = 4 // images decoded for metadata at once, decoded image takes 4 bytes per pixel
+)
 
 type ImageRepository interface {
-	Insert(ctx context.Context, itemId int, objectID string) error
-	Delete(ctx context.Context, imageId string) error
-	InsertTempImage(ctx context.Context, imageId string) error
+	Insert(ctx context.Context, itemId int, objectID string, meta *domain.ImageMeta) error
+	// Delete image and return deleted image
+	Delete(ctx context.Context, imageId string) (domain.Image, error)
+	InsertTempImage(ctx context.Context, imageId string, meta *domain.ImageMeta) error
+	InsertPendingTempImage(ctx context.Context, imageId string) error
+	// Check that temp image waits for confirmation
+	IsPendingTempImage(ctx context.Context, imageId string) (bool, error)
+	// Mark pending temp image as confirmed with its metadata
+	ConfirmTempImage(ctx context.Context, imageId string, meta *domain.ImageMeta) error
+	GetImagesWithoutMeta(ctx context.Context, afterId int, limit uint64) ([]domain.Image, error)
+	GetItemsImages(ctx context.Context, itemIds []int) (map[int][]domain.Image, error)
+	// Get images of items matching filter ordered by upload
+	FindImages(ctx context.Context, filter domain.ImageFilter) ([]domain.Image, error)
+	// Check that object is image of item, temp image or brand logo
+	IsImage(ctx context.Context, objectId string) (bool, error)
+	UpdateMeta(ctx context.Context, imageId int, meta domain.ImageMeta) error
+}
+
+type RevisionRepository interface {
+	// Store current state of item as revision of its current version
+	Create(ctx context.Context, itemId int, restoredFrom *int) error
 }
 
+type AuditFacade interface {
+	// Run change and record it in audit log in one transaction
+	Record(ctx context.Context, change func(ctx context.Context) (adomain.Change, error)) error
+}
+
+// Images attached to items are audited. Temp images aren't, they are a part of item creation
 type ImageService struct {
-	logger    *slog.Logger
-	storage   MinioClient
-	imageRepo ImageRepository
+	logger          *slog.Logger
+	storage         blob.Storage
+	imageRepo       ImageRepository
+	revisionRepo    RevisionRepository
+	auditFacade     AuditFacade
+	archiveNameRule *regexp.Regexp
+	// metadata isn't computed for images with more pixels
+	metaMaxPixels int
+	// shared by all requests and archive workers, so memory of decoded images is bounded
+	decodeSem chan struct{}
 }
 
-func NewImageService(logger *slog.Logger, storage MinioClient, imageRepo ImageRepository) *ImageService {
+func NewImageService(logger *slog.Logger, storage blob.Storage, imageRepo ImageRepository, revisionRepo RevisionRepository, auditFacade AuditFacade, archiveNameRule *regexp.Regexp, metaMaxPixels int) *ImageService {
 	return &ImageService{
-		logger:    logger,
-		storage:   storage,
-		imageRepo: imageRepo,
+		logger:          logger,
+		storage:         storage,
+		imageRepo:       imageRepo,
+		revisionRepo:    revisionRepo,
+		auditFacade:     auditFacade,
+		archiveNameRule: archiveNameRule,
+		metaMaxPixels:   metaMaxPixels,
+		decodeSem:       make(chan struct{}, metaDecoders),
 	}
 }
 
@@ -42,7 +84,7 @@ func (i *ImageService) CreateItemImage(ctx context.Context, itemId int, file []b
 
 	err := i.storage.Put(ctx, dto.FileDTO{
 		ID:          objectID,
-		ContentType: minio.ImageContentType,
+		ContentType: domain.DetectImageContentType(file),
 		Buffer:      file,
 	})
 	if err != nil {
@@ -50,19 +92,56 @@ func (i *ImageService) CreateItemImage(ctx context.Context, itemId int, file []b
 		return "", err
 	}
 
-	err = i.imageRepo.Insert(ctx, itemId, objectID)
+	// decoding is slow, so it's done before transaction is opened
+	meta := i.imageMeta(file)
+
+	err = i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		err := i.imageRepo.Insert(ctx, itemId, objectID, meta)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.revisionRepo.Create(ctx, itemId, nil)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionCreate,
+			EntityType: adomain.EntityImage,
+			EntityId:   objectID,
+			After: domain.Image{
+				ItemId:   itemId,
+				ObjectId: objectID,
+				Meta:     meta,
+			},
+		}, nil
+	})
 	if err != nil {
-		// todo. что тогда делать с изображением в хранилище s3???
+		// image isn't attached to item, so nobody will reference it
+		i.removeObject(ctx, objectID)
+
 		return "", err
 	}
 
 	return objectID, nil
 }
 
-// Get image from storage
+// Get image from storage. Only images known to app are served, storage keeps other files too (e.g. exports)
 func (i *ImageService) GetImage(ctx context.Context, imageId string) (file dto.FileDTO, err error) {
+	isImage, err := i.imageRepo.IsImage(ctx, imageId)
+	if err != nil {
+		return file, err
+	}
+	if !isImage {
+		return file, domain.ErrImageNotFound
+	}
+
 	file, err = i.storage.Get(ctx, imageId)
 	if err != nil {
+		if errors.Is(err, blob.ErrObjectNotFound) {
+			return file, domain.ErrImageNotFound
+		}
 		i.logger.Error("failed getting image from storage", sl.Err(err))
 
 		return
@@ -75,6 +154,9 @@ func (i *ImageService) GetImage(ctx context.Context, imageId string) (file dto.F
 func (i *ImageService) GetImageMany(ctx context.Context, imageIds []string) ([]dto.FileDTO, error) {
 	files, err := i.storage.GetMany(ctx, imageIds)
 	if err != nil {
+		if errors.Is(err, blob.ErrObjectNotFound) {
+			return nil, domain.ErrImageNotFound
+		}
 		i.logger.Error("failed getting image from storage", sl.Err(err))
 
 		return nil, err
@@ -84,19 +166,36 @@ func (i *ImageService) GetImageMany(ctx context.Context, imageIds []string) ([]d
 }
 
 func (i *ImageService) Delete(ctx context.Context, imageId string) error {
-	return i.imageRepo.Delete(ctx, imageId)
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		image, err := i.imageRepo.Delete(ctx, imageId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.revisionRepo.Create(ctx, image.ItemId, nil)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionDelete,
+			EntityType: adomain.EntityImage,
+			EntityId:   imageId,
+			Before:     image,
+		}, nil
+	})
 }
 
 // Store temp image to storages
 func (i *ImageService) CreateTempImage(ctx context.Context, file []byte, uuid string) (string, error) {
-	err := i.imageRepo.InsertTempImage(ctx, uuid)
+	err := i.imageRepo.InsertTempImage(ctx, uuid, i.imageMeta(file))
 	if err != nil {
 		return "", err
 	}
 
 	err = i.storage.Put(ctx, dto.FileDTO{
 		ID:          uuid,
-		ContentType: minio.ImageContentType,
+		ContentType: domain.DetectImageContentType(file),
 		Buffer:      file,
 	})
 	if err != nil {
@@ -106,3 +205,106 @@ func (i *ImageService) CreateTempImage(ctx context.Context, file []byte, uuid st
 
 	return uuid, nil
 }
+
+// Register temp image and return presigned url for direct upload to storage.
+// Object id is generated here, so client can't overwrite existing files
+func (i *ImageService) CreateUploadURL(ctx context.Context, contentType string, size int64) (domain.UploadURL, error) {
+	if !domain.IsAllowedImageType(contentType) {
+		return domain.UploadURL{}, domain.ErrImageType
+	}
+	if size <= 0 || size > maxUploadSize {
+		return domain.UploadURL{}, domain.ErrImageSize
+	}
+
+	objectID := uuid.New().String()
+
+	url, err := i.storage.PresignedPut(ctx, objectID, contentType, size, uploadURLTTL)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotSupported) {
+			return domain.UploadURL{}, domain.ErrDirectUpload
+		}
+		i.logger.Error("failed presign upload url", sl.Err(err))
+
+		return domain.UploadURL{}, err
+	}
+
+	err = i.imageRepo.InsertPendingTempImage(ctx, objectID)
+	if err != nil {
+		return domain.UploadURL{}, err
+	}
+
+	return domain.UploadURL{
+		ObjectId: objectID,
+		URL:      url,
+		Headers: map[string]string{
+			"Content-Type":   contentType,
+			"Content-Length": strconv.FormatInt(size, 10),
+		},
+		ExpiresAt: time.Now().Add(uploadURLTTL),
+	}, nil
+}
+
+// Validate image uploaded by presigned url.
+// After confirmation image can be attached to item as temp image.
+// Image is read and processed before confirmation, so no transaction is held meanwhile
+func (i *ImageService) ConfirmUpload(ctx context.Context, imageId string) error {
+	pending, err := i.imageRepo.IsPendingTempImage(ctx, imageId)
+	if err != nil {
+		return err
+	}
+	if !pending {
+		return domain.ErrUploadNotFound
+	}
+
+	info, err := i.storage.Stat(ctx, imageId)
+	if err != nil {
+		if errors.Is(err, blob.ErrObjectNotFound) {
+			return domain.ErrNotUploaded
+		}
+		i.logger.Error("failed getting image info from storage", sl.Err(err))
+
+		return err
+	}
+
+	if info.Size > maxUploadSize {
+		i.removeObject(ctx, imageId)
+
+		return domain.ErrImageSize
+	}
+
+	file, err := i.storage.Get(ctx, imageId)
+	if err != nil {
+		i.logger.Error("failed getting image from storage", sl.Err(err))
+
+		return err
+	}
+
+	if err = domain.CheckImageType(file.Buffer); err != nil {
+		i.removeObject(ctx, imageId)
+
+		return err
+	}
+
+	return i.imageRepo.ConfirmTempImage(ctx, imageId, i.imageMeta(file.Buffer))
+}
+
+// Metadata isn't required for storing image, so failed calculation is only logged.
+// Such images can be processed later by backfill
+func (i *ImageService) imageMeta(file []byte) *domain.ImageMeta {
+	meta, err := i.computeMeta(file)
+	if err != nil {
+		i.logger.Warn("failed compute image metadata", sl.Err(err))
+
+		return nil
+	}
+
+	return &meta
+}
+
+// Remove from storage file that didn't pass validation or wasn't saved to db.
+// Temp image record (if exists) will be removed by background task
+func (i *ImageService) removeObject(ctx context.Context, imageId string) {
+	if err := i.storage.Delete(ctx, imageId); err != nil {
+		i.logger.Error("failed delete object from storage", sl.Err(err))
+	}
+}
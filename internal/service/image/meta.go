@@ -0,0 +1,207 @@
+package image
+
+import (
+	"bytes"
+	domain "cloth-mini-app/internal/domain/image"
+	"cloth-mini-app/internal/dto"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"math"
+
+	"github.com/buckket/go-blurhash"
+)
+
+const (
+	blurHashXComponents = 4
+	blurHashYComponents = 3
+	metaThumbnailSize   = 64 // metadata is calculated on downscaled image, full size isn't needed for placeholder
+	dominantColorShift  = 4  // colors are grouped by 4 high bits of every channel
+
+	backfillBatchSize = 100
+)
+
+// Compute placeholder metadata: blurhash, dominant color and original dimensions. Image with more
+// than maxPixels pixels isn't decoded, small compressed file can take gigabytes of memory when decoded
+func ComputeImageMeta(file []byte, maxPixels int) (domain.ImageMeta, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(file))
+	if err != nil {
+		return domain.ImageMeta{}, err
+	}
+	if maxPixels > 0 && int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return domain.ImageMeta{}, domain.ErrImageDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(file))
+	if err != nil {
+		return domain.ImageMeta{}, err
+	}
+
+	bounds := img.Bounds()
+	thumb := thumbnail(img, metaThumbnailSize)
+
+	hash, err := blurhash.Encode(blurHashXComponents, blurHashYComponents, thumb)
+	if err != nil {
+		return domain.ImageMeta{}, err
+	}
+
+	return domain.ImageMeta{
+		BlurHash:      hash,
+		DominantColor: dominantColor(thumb),
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+	}, nil
+}
+
+// downscale image with nearest neighbor, so the longest side is not greater than size
+func thumbnail(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	scale := math.Max(float64(width), float64(height)) / float64(size)
+	if scale < 1 {
+		scale = 1
+	}
+
+	thumbWidth := max(1, int(float64(width)/scale))
+	thumbHeight := max(1, int(float64(height)/scale))
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := range thumbHeight {
+		for x := range thumbWidth {
+			thumb.Set(x, y, img.At(
+				bounds.Min.X+int(float64(x)*scale),
+				bounds.Min.Y+int(float64(y)*scale),
+			))
+		}
+	}
+
+	return thumb
+}
+
+type colorBucket struct {
+	count   int
+	r, g, b int
+}
+
+// Most frequent color group. Return average color of the group in #rrggbb format
+func dominantColor(img *image.NRGBA) string {
+	buckets := make(map[int]*colorBucket)
+
+	var dominant *colorBucket
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A == 0 {
+				continue
+			}
+
+			key := int(c.R>>dominantColorShift)<<8 | int(c.G>>dominantColorShift)<<4 | int(c.B>>dominantColorShift)
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = &colorBucket{}
+				buckets[key] = bucket
+			}
+			bucket.count++
+			bucket.r += int(c.R)
+			bucket.g += int(c.G)
+			bucket.b += int(c.B)
+
+			if dominant == nil || bucket.count > dominant.count {
+				dominant = bucket
+			}
+		}
+	}
+
+	if dominant == nil {
+		return hexColor(color.NRGBA{})
+	}
+
+	return hexColor(color.NRGBA{
+		R: uint8(dominant.r / dominant.count),
+		G: uint8(dominant.g / dominant.count),
+		B: uint8(dominant.b / dominant.count),
+	})
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Compute metadata when one of decoders is free
+func (i *ImageService) computeMeta(file []byte) (domain.ImageMeta, error) {
+	i.decodeSem <- struct{}{}
+	defer func() { <-i.decodeSem }()
+
+	return ComputeImageMeta(file, i.metaMaxPixels)
+}
+
+// Calculate metadata for stored images that don't have it. Return amount of updated images
+func (i *ImageService) BackfillMeta(ctx context.Context) (int, error) {
+	var updated, afterId int
+	for {
+		images, err := i.imageRepo.GetImagesWithoutMeta(ctx, afterId, backfillBatchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(images) == 0 {
+			return updated, nil
+		}
+		afterId = images[len(images)-1].ID
+
+		files := i.getBackfillFiles(ctx, images)
+		for _, image := range images {
+			file, ok := files[image.ObjectId]
+			if !ok {
+				continue
+			}
+
+			meta, err := i.computeMeta(file.Buffer)
+			if err != nil {
+				i.logger.Warn("failed compute image metadata", slog.String("object_id", image.ObjectId), sl.Err(err))
+				continue
+			}
+
+			if err = i.imageRepo.UpdateMeta(ctx, image.ID, meta); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+
+		i.logger.Info("image metadata backfill", slog.Int("updated", updated))
+	}
+}
+
+// Get files with worker pool. If some file is missed in storage, files are fetched one by one
+func (i *ImageService) getBackfillFiles(ctx context.Context, images []domain.Image) map[string]dto.FileDTO {
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		ids = append(ids, image.ObjectId)
+	}
+
+	files, err := i.storage.GetMany(ctx, ids)
+	if err != nil {
+		files = make([]dto.FileDTO, 0, len(ids))
+		for _, id := range ids {
+			file, err := i.storage.Get(ctx, id)
+			if err != nil {
+				i.logger.Warn("failed getting image from storage", slog.String("object_id", id), sl.Err(err))
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+
+	filesById := make(map[string]dto.FileDTO, len(files))
+	for _, file := range files {
+		filesById[file.ID] = file
+	}
+
+	return filesById
+}
@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"bytes"
+	domain "cloth-mini-app/internal/domain/importjob"
+	"cloth-mini-app/internal/xlsx"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Read rows of csv or xlsx file by its extension. Header is the first row, trailing empty rows are dropped.
+// File with more than limit rows after header is rejected
+func readTable(fileName string, file []byte, limit int) ([]string, [][]string, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		rows, err = readCSV(file, limit+1)
+	case ".xlsx":
+		rows, err = xlsx.ReadRows(bytes.NewReader(file), int64(len(file)), limit+1)
+		if errors.Is(err, xlsx.ErrRowLimit) {
+			err = domain.ErrTooManyRows
+		} else if err != nil {
+			err = domain.ErrFileFormat
+		}
+	default:
+		err = domain.ErrFileFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for len(rows) > 0 && emptyRow(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows) == 0 || emptyRow(rows[0]) {
+		return nil, nil, domain.ErrFileFormat
+	}
+	if len(rows) == 1 {
+		return nil, nil, domain.ErrEmptyFile
+	}
+
+	return rows[0], rows[1:], nil
+}
+
+// Read csv in UTF-8. Delimiter is taken from header: spreadsheets in some locales save csv with semicolons
+func readCSV(file []byte, limit int) ([][]string, error) {
+	file = bytes.TrimPrefix(file, utf8BOM)
+	if !utf8.Valid(file) {
+		return nil, domain.ErrEncoding
+	}
+
+	reader := csv.NewReader(bytes.NewReader(file))
+	reader.Comma = delimiter(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, domain.ErrFileFormat
+		}
+		if len(rows) == limit {
+			return nil, domain.ErrTooManyRows
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func delimiter(file []byte) rune {
+	header, _, _ := bytes.Cut(file, []byte("\n"))
+
+	best, count := ',', bytes.Count(header, []byte(","))
+	for _, comma := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(comma))); n > count {
+			best, count = comma, n
+		}
+	}
+
+	return best
+}
+
+func emptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
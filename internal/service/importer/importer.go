@@ -0,0 +1,335 @@
+package importer
+
+import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	adomain "cloth-mini-app/internal/domain/audit"
+	bdomain "cloth-mini-app/internal/domain/brand"
+	cdomain "cloth-mini-app/internal/domain/category"
+	domain "cloth-mini-app/internal/domain/importjob"
+	idomain "cloth-mini-app/internal/domain/item"
+	sl "cloth-mini-app/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	jobsLimit = 20 // jobs per page if limit isn't provided
+	// time job is reserved by instance, reservation is extended by every batch
+	jobLease = 5 * time.Minute
+)
+
+type ImportJobRepository interface {
+	// Create job with rows of file and return its id
+	Create(ctx context.Context, job domain.Job) (int, error)
+	// Get job without rows
+	GetJob(ctx context.Context, id int) (domain.Job, error)
+	// Get jobs without rows, latest first
+	GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error)
+	// Reserve job waiting for processing and get it with rows
+	Reserve(ctx context.Context, until time.Time) (domain.Job, error)
+	// Process batch of rows and add its result to job counters in one transaction
+	ProcessBatch(ctx context.Context, id int, until time.Time, batchFn func(ctx context.Context) (domain.Progress, error)) error
+	// Move job from one stage to another
+	ChangeStatus(ctx context.Context, id int, from, to domain.Status, message string) error
+}
+
+type ItemService interface {
+	// Create item with creation event, audit entry and revision
+	Create(ctx context.Context, item idomain.ItemCreate) error
+}
+
+type BrandService interface {
+	GetBrands(ctx context.Context) ([]bdomain.Brand, error)
+	// Create brand and return its id
+	Create(ctx context.Context, brand bdomain.BrandCreate) (int, error)
+}
+
+type CategoryRepository interface {
+	GetCategories(ctx context.Context) ([]cdomain.Category, error)
+	// Get attributes of category including inherited from parents
+	GetAttributes(ctx context.Context, categoryId int) ([]cdomain.Attribute, error)
+}
+
+type ImportService struct {
+	logger       *slog.Logger
+	jobRepo      ImportJobRepository
+	itemService  ItemService
+	brandService BrandService
+	categoryRepo CategoryRepository
+	// rows handled in one transaction
+	batchSize int
+	// max rows in file after header
+	maxRows int
+}
+
+func NewImportService(logger *slog.Logger, jr ImportJobRepository, is ItemService, bs BrandService, cr CategoryRepository, batchSize, maxRows int) *ImportService {
+	return &ImportService{
+		logger:       logger,
+		jobRepo:      jr,
+		itemService:  is,
+		brandService: bs,
+		categoryRepo: cr,
+		batchSize:    batchSize,
+		maxRows:      maxRows,
+	}
+}
+
+// Parse file and create import job. Rows are validated and imported in background
+func (i *ImportService) CreateJob(ctx context.Context, upload domain.Upload) (int, error) {
+	header, rows, err := readTable(upload.FileName, upload.File, i.maxRows)
+	if err != nil {
+		return 0, err
+	}
+
+	columns, err := domain.ResolveColumns(header, upload.Mapping)
+	if err != nil {
+		return 0, err
+	}
+
+	refs, err := i.loadReferences(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	actor, _ := adomain.ActorFromContext(ctx)
+
+	return i.jobRepo.Create(ctx, domain.Job{
+		FileName:     upload.FileName,
+		Status:       domain.StatusValidating,
+		DryRun:       upload.DryRun,
+		CreateBrands: upload.CreateBrands,
+		Columns:      columns,
+		Rows:         rows,
+		Total:        len(rows),
+		NewBrands:    refs.missingBrands(columns, rows),
+		Actor:        actor,
+	})
+}
+
+func (i *ImportService) GetJob(ctx context.Context, id int) (domain.Job, error) {
+	return i.jobRepo.GetJob(ctx, id)
+}
+
+// Get import jobs, latest first
+func (i *ImportService) GetJobs(ctx context.Context, limit, offset uint64) ([]domain.Job, error) {
+	if limit == 0 {
+		limit = jobsLimit
+	}
+
+	return i.jobRepo.GetJobs(ctx, limit, offset)
+}
+
+// Import valid rows of checked dry run
+func (i *ImportService) Commit(ctx context.Context, id int) error {
+	return i.jobRepo.ChangeStatus(ctx, id, domain.StatusValidated, domain.StatusImporting, "")
+}
+
+// Process stage of job waiting in queue: validate rows or import them. Items are created by actor
+// who uploaded file. Returns false if there are no jobs. Job stopped by unexpected error is failed
+func (i *ImportService) ProcessNextJob(ctx context.Context) (bool, error) {
+	const op = "service.importer.ProcessNextJob"
+
+	job, err := i.jobRepo.Reserve(ctx, time.Now().Add(jobLease))
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	ctx = adomain.WithActor(ctx, job.Actor)
+
+	if job.Status == domain.StatusValidating {
+		err = i.validate(ctx, job)
+		if err != nil {
+			return true, i.fail(ctx, job, err)
+		}
+
+		next := domain.StatusImporting
+		if job.DryRun {
+			next = domain.StatusValidated
+		}
+		err = i.jobRepo.ChangeStatus(ctx, job.ID, job.Status, next, "")
+		if err != nil || job.DryRun {
+			return true, err
+		}
+
+		// validation errors are needed to skip invalid rows
+		validated, err := i.jobRepo.GetJob(ctx, job.ID)
+		if err != nil {
+			return true, err
+		}
+		job.Status, job.Processed, job.Errors = next, 0, validated.Errors
+	}
+
+	err = i.importRows(ctx, job)
+	if err != nil {
+		return true, i.fail(ctx, job, err)
+	}
+
+	i.logger.Info(fmt.Sprintf("%s: import job done", op), slog.Int("job_id", job.ID))
+
+	return true, i.jobRepo.ChangeStatus(ctx, job.ID, domain.StatusImporting, domain.StatusDone, "")
+}
+
+func (i *ImportService) fail(ctx context.Context, job domain.Job, cause error) error {
+	const op = "service.importer.fail"
+
+	i.logger.Error(fmt.Sprintf("%s: import job failed", op), slog.Int("job_id", job.ID), sl.Err(cause))
+
+	err := i.jobRepo.ChangeStatus(ctx, job.ID, job.Status, domain.StatusFailed, cause.Error())
+	if err != nil {
+		return err
+	}
+
+	return cause
+}
+
+// Check rows from processed one in batches, progress is saved after every batch
+func (i *ImportService) validate(ctx context.Context, job domain.Job) error {
+	refs, err := i.loadReferences(ctx)
+	if err != nil {
+		return err
+	}
+	// brands created on import are valid in dry run
+	if job.CreateBrands {
+		for _, name := range job.NewBrands {
+			refs.brands[nameKey(name)] = 0
+		}
+	}
+
+	return i.processRows(ctx, job, func(ctx context.Context, idx int, row []string, progress *domain.Progress) error {
+		_, errs, err := i.buildItem(ctx, refs, job, idx, row)
+		if err != nil {
+			return err
+		}
+
+		if len(errs) != 0 {
+			progress.Errors = append(progress.Errors, errs...)
+		} else {
+			progress.Valid++
+		}
+
+		return nil
+	})
+}
+
+// Create items of rows which passed validation. Each batch is created in one transaction,
+// items get creation events, audit entries and revisions as created one by one
+func (i *ImportService) importRows(ctx context.Context, job domain.Job) error {
+	refs, err := i.loadReferences(ctx)
+	if err != nil {
+		return err
+	}
+
+	if job.CreateBrands {
+		err = i.createBrands(ctx, refs, job.NewBrands)
+		if err != nil {
+			return err
+		}
+	}
+
+	invalid := make(map[int]bool, len(job.Errors))
+	for _, rowErr := range job.Errors {
+		invalid[rowErr.Row] = true
+	}
+
+	return i.processRows(ctx, job, func(ctx context.Context, idx int, row []string, progress *domain.Progress) error {
+		if invalid[domain.RowNumber(idx)] {
+			return nil
+		}
+
+		item, errs, err := i.buildItem(ctx, refs, job, idx, row)
+		if err != nil {
+			return err
+		}
+		if len(errs) == 0 {
+			err = i.itemService.Create(ctx, item)
+			errs, err = rowErrors(idx, err)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(errs) != 0 {
+			progress.Errors = append(progress.Errors, errs...)
+		} else {
+			progress.Created++
+		}
+
+		return nil
+	})
+}
+
+type rowFunc func(ctx context.Context, idx int, row []string, progress *domain.Progress) error
+
+// Handle not empty rows of job from processed one in batches
+func (i *ImportService) processRows(ctx context.Context, job domain.Job, handle rowFunc) error {
+	for start := job.Processed; start < len(job.Rows); start += i.batchSize {
+		end := min(start+i.batchSize, len(job.Rows))
+
+		err := i.jobRepo.ProcessBatch(ctx, job.ID, time.Now().Add(jobLease), func(ctx context.Context) (domain.Progress, error) {
+			progress := domain.Progress{Processed: end - start}
+			for idx := start; idx < end; idx++ {
+				if emptyRow(job.Rows[idx]) {
+					continue
+				}
+
+				if err := handle(ctx, idx, job.Rows[idx], &progress); err != nil {
+					return domain.Progress{}, err
+				}
+			}
+
+			return progress, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Create brands missing in catalog. Brand which can't be created is skipped, its rows get errors
+func (i *ImportService) createBrands(ctx context.Context, refs *references, names []string) error {
+	const op = "service.importer.createBrands"
+
+	for _, name := range names {
+		if _, ok := refs.brands[nameKey(name)]; ok {
+			continue
+		}
+
+		brandId, err := i.brandService.Create(ctx, bdomain.BrandCreate{Name: strings.TrimSpace(name)})
+		if errors.Is(err, bdomain.ErrBrandExists) || errors.Is(err, bdomain.ErrBrandSlug) {
+			i.logger.Warn(fmt.Sprintf("%s: brand isn't created", op), slog.String("brand", name), sl.Err(err))
+
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		refs.brands[nameKey(name)] = brandId
+	}
+
+	return nil
+}
+
+// Get field errors of row from validation error of item service, other errors are returned as is
+func rowErrors(idx int, err error) ([]domain.RowError, error) {
+	var appErr *apperr.Error
+	if err == nil || !errors.As(err, &appErr) || appErr.Kind != apperr.KindValidation {
+		return nil, err
+	}
+
+	errs := make([]domain.RowError, 0, len(appErr.Fields))
+	for _, field := range appErr.Fields {
+		errs = append(errs, domain.RowError{Row: domain.RowNumber(idx), Field: field.Field, Message: field.Message})
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,243 @@
+package importer
+
+import (
+	cdomain "cloth-mini-app/internal/domain/category"
+	domain "cloth-mini-app/internal/domain/importjob"
+	idomain "cloth-mini-app/internal/domain/item"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Brands and categories resolved by name, attributes of categories are loaded on demand
+type references struct {
+	// name in lower case => brand id
+	brands map[string]int
+	// name in lower case => categories, names of categories in different subtrees can repeat
+	categories map[string][]cdomain.Category
+	schemas    map[int][]cdomain.Attribute
+}
+
+func (i *ImportService) loadReferences(ctx context.Context) (*references, error) {
+	brands, err := i.brandService.GetBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := i.categoryRepo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &references{
+		brands:     make(map[string]int, len(brands)),
+		categories: make(map[string][]cdomain.Category, len(categories)),
+		schemas:    make(map[int][]cdomain.Attribute),
+	}
+	for _, brand := range brands {
+		refs.brands[nameKey(brand.Name)] = brand.ID
+	}
+	for _, category := range categories {
+		key := nameKey(category.Name)
+		refs.categories[key] = append(refs.categories[key], category)
+	}
+
+	return refs, nil
+}
+
+// Get brand names of rows missing in catalog, in order of first appearance
+func (r *references) missingBrands(columns map[string]int, rows [][]string) []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, row := range rows {
+		name := cell(columns, row, domain.FieldBrand)
+		key := nameKey(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if _, ok := r.brands[key]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
+
+// Build item from row. Field errors are returned with row number, returned error isn't validation error
+func (i *ImportService) buildItem(ctx context.Context, refs *references, job domain.Job, idx int, row []string) (idomain.ItemCreate, []domain.RowError, error) {
+	var errs []domain.RowError
+	invalid := func(field, message string) {
+		errs = append(errs, domain.RowError{Row: domain.RowNumber(idx), Field: field, Message: message})
+	}
+	value := func(field string) string {
+		return cell(job.Columns, row, field)
+	}
+	required := func(field string) string {
+		v := value(field)
+		if v == "" {
+			invalid(field, "is required")
+		}
+		return v
+	}
+
+	var item idomain.ItemCreate
+	item.Name = required(domain.FieldName)
+	item.Description = required(domain.FieldDescription)
+	item.OuterLink = required(domain.FieldOuterLink)
+	if item.OuterLink != "" && !idomain.ValidOuterLink(item.OuterLink) {
+		invalid(domain.FieldOuterLink, "must be http or https url")
+	}
+
+	if name := required(domain.FieldBrand); name != "" {
+		brandId, ok := refs.brands[nameKey(name)]
+		if ok {
+			item.BrandId = brandId
+		} else {
+			invalid(domain.FieldBrand, fmt.Sprintf("brand %q not found", name))
+		}
+	}
+
+	if sex := required(domain.FieldSex); sex != "" {
+		parsed, err := idomain.ParseSex(strings.ToLower(sex))
+		if err != nil {
+			invalid(domain.FieldSex, idomain.ErrSex.Message)
+		}
+		item.Sex = parsed
+	}
+
+	if price := required(domain.FieldPrice); price != "" {
+		parsed, err := strconv.ParseUint(price, 10, 32)
+		if err != nil || parsed == 0 {
+			invalid(domain.FieldPrice, "must be positive integer")
+		}
+		item.Price = uint(parsed)
+	}
+
+	if discount := value(domain.FieldDiscount); discount != "" {
+		parsed, err := strconv.ParseUint(discount, 10, 32)
+		if err != nil {
+			invalid(domain.FieldDiscount, "must be non-negative integer")
+		}
+		item.Discount = uint(parsed)
+	}
+
+	if status := value(domain.FieldStatus); status != "" {
+		parsed, err := idomain.ParseStatus(strings.ToLower(status))
+		if err != nil || (parsed != idomain.StatusDraft && parsed != idomain.StatusPublished) {
+			invalid(domain.FieldStatus, "item can be imported as draft or published")
+		}
+		item.Status = parsed
+	}
+
+	name := required(domain.FieldCategory)
+	if name == "" {
+		return item, errs, nil
+	}
+	categories := refs.categories[nameKey(name)]
+	switch len(categories) {
+	case 0:
+		invalid(domain.FieldCategory, fmt.Sprintf("category %q not found", name))
+		return item, errs, nil
+	case 1:
+		item.CategoryId = categories[0].CategoryId
+	default:
+		invalid(domain.FieldCategory, fmt.Sprintf("there are several categories named %q", name))
+		return item, errs, nil
+	}
+
+	schema, ok := refs.schemas[item.CategoryId]
+	if !ok {
+		var err error
+		schema, err = i.categoryRepo.GetAttributes(ctx, item.CategoryId)
+		if err != nil {
+			return item, nil, err
+		}
+		refs.schemas[item.CategoryId] = schema
+	}
+
+	fields := make([]string, 0, len(job.Columns))
+	for field := range job.Columns {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	item.Attributes = make(map[string]any)
+	for _, field := range fields {
+		code, ok := strings.CutPrefix(field, domain.AttributePrefix)
+		if !ok || value(field) == "" {
+			continue
+		}
+
+		parsed, err := attributeValue(schema, code, value(field))
+		if err != nil {
+			invalid(field, err.Error())
+			continue
+		}
+		item.Attributes[code] = parsed
+	}
+
+	for _, attrErr := range cdomain.ValidateAttributes(schema, item.Attributes) {
+		if _, ok := item.Attributes[attrErr.Code]; !ok && hasAttributeError(errs, attrErr.Code) {
+			continue
+		}
+		invalid(domain.AttributePrefix+attrErr.Code, attrErr.Message)
+	}
+
+	return item, errs, nil
+}
+
+// Convert cell to attribute value as it's decoded from json: numbers are float64.
+// Attribute missing in schema is kept as string, so it's reported as unknown
+func attributeValue(schema []cdomain.Attribute, code, value string) (any, error) {
+	idx := slices.IndexFunc(schema, func(attr cdomain.Attribute) bool {
+		return attr.Code == code
+	})
+	if idx == -1 {
+		return value, nil
+	}
+
+	switch schema[idx].ValueType {
+	case cdomain.ValueInt:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be %s", cdomain.ValueInt)
+		}
+		return float64(number), nil
+	case cdomain.ValueBool:
+		switch strings.ToLower(value) {
+		case "true", "1", "yes", "да":
+			return true, nil
+		case "false", "0", "no", "нет":
+			return false, nil
+		}
+		return nil, fmt.Errorf("must be %s", cdomain.ValueBool)
+	}
+
+	return value, nil
+}
+
+// Attribute with unparsed value isn't reported as missing required attribute
+func hasAttributeError(errs []domain.RowError, code string) bool {
+	return slices.ContainsFunc(errs, func(rowErr domain.RowError) bool {
+		return rowErr.Field == domain.AttributePrefix+code
+	})
+}
+
+// Get trimmed value of field column in row, empty if field isn't imported
+func cell(columns map[string]int, row []string, field string) string {
+	idx, ok := columns[field]
+	if !ok || idx >= len(row) {
+		return ""
+	}
+
+	return strings.TrimSpace(row[idx])
+}
+
+// Brands and categories are matched by name ignoring case and surrounding spaces
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -1,11 +1,18 @@
 package item
 
 import (
+	apperr "cloth-mini-app/internal/domain/apperror"
+	adomain "cloth-mini-app/internal/domain/audit"
+	bdomain "cloth-mini-app/internal/domain/brand"
+	cdomain "cloth-mini-app/internal/domain/category"
+	imdomain "cloth-mini-app/internal/domain/image"
 	domain "cloth-mini-app/internal/domain/item"
 	sl "cloth-mini-app/internal/logger"
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
+	"strconv"
+	"time"
 )
 
 type ItemRepository interface {
@@ -15,13 +22,17 @@ type ItemRepository interface {
 	GetItemById(ctx context.Context, id int) (domain.ItemAPI, error)
 	// Update item record
 	Update(ctx context.Context, data domain.ItemUpdate) error
-	// Delete item
+	// Move item to trash
 	Delete(ctx context.Context, id int) error
+	// Get items in trash, recently deleted first
+	GetDeletedItems(ctx context.Context, limit, offset uint64) ([]domain.ItemAPI, error)
+	// Restore item from trash
+	Restore(ctx context.Context, id int) error
 }
 
 type ImageRepository interface {
-	// Get images fileIds
-	GetImages(ctx context.Context, itemId int) ([]string, error)
+	// Get images with metadata for items (itemId => images)
+	GetItemsImages(ctx context.Context, itemIds []int) (map[int][]imdomain.Image, error)
 }
 
 type ItemImageRepository interface {
@@ -30,8 +41,43 @@ type ItemImageRepository interface {
 	Create(ctx context.Context, item domain.ItemCreate) (uint, error)
 }
 
+type BrandRepository interface {
+	GetBrand(ctx context.Context, brandId int) (bdomain.Brand, error)
+}
+
+type CategoryRepository interface {
+	GetCategory(ctx context.Context, categoryId int) (cdomain.Category, error)
+	// Get attributes of category including inherited from parents
+	GetAttributes(ctx context.Context, categoryId int) ([]cdomain.Attribute, error)
+}
+
+type RevisionRepository interface {
+	// Store current state of item as revision of its current version
+	Create(ctx context.Context, itemId int, restoredFrom *int) error
+	// Get revisions of item, latest first
+	GetRevisions(ctx context.Context, itemId int, limit, offset uint64) ([]domain.Revision, error)
+	GetRevision(ctx context.Context, itemId, version int) (domain.Revision, error)
+}
+
+type PriceHistoryRepository interface {
+	// Record change of item price
+	Create(ctx context.Context, change domain.PriceChange) error
+	// Get price changes of item, latest first
+	GetHistory(ctx context.Context, itemId int, limit, offset uint64) ([]domain.PriceChange, error)
+}
+
 type OutboxFacade interface {
-	CreateItemWithNotification(ctx context.Context, item domain.ItemCreate) error
+	// Create item with creation event and return its id
+	CreateItemWithNotification(ctx context.Context, item domain.ItemCreate) (uint, error)
+	// Change item status with event if item is published or archived
+	SetItemStatusWithNotification(ctx context.Context, status domain.ItemStatusUpdate, prev domain.Status) error
+	// Bring item back to revision with rollback event
+	RollbackItemWithNotification(ctx context.Context, revision domain.Revision, version int) error
+}
+
+type AuditFacade interface {
+	// Run change and record it in audit log in one transaction
+	Record(ctx context.Context, change func(ctx context.Context) (adomain.Change, error)) error
 }
 
 type ItemService struct {
@@ -39,17 +85,27 @@ type ItemService struct {
 	itemRepo      ItemRepository
 	imageRepo     ImageRepository
 	itemImageRepo ItemImageRepository
+	brandRepo     BrandRepository
+	categoryRepo  CategoryRepository
+	revisionRepo  RevisionRepository
+	priceRepo     PriceHistoryRepository
 	outboxFacade  OutboxFacade
+	auditFacade   AuditFacade
 }
 
 // Get item service object that represent the rest.ItemService interface
-func NewItemService(logger *slog.Logger, ir ItemRepository, imr ImageRepository, itimr ItemImageRepository, obxf OutboxFacade) *ItemService {
+func NewItemService(logger *slog.Logger, ir ItemRepository, imr ImageRepository, itimr ItemImageRepository, br BrandRepository, cr CategoryRepository, rr RevisionRepository, pr PriceHistoryRepository, obxf OutboxFacade, adtf AuditFacade) *ItemService {
 	return &ItemService{
 		logger:        logger,
 		itemRepo:      ir,
 		imageRepo:     imr,
 		itemImageRepo: itimr,
+		brandRepo:     br,
+		categoryRepo:  cr,
+		revisionRepo:  rr,
+		priceRepo:     pr,
 		outboxFacade:  obxf,
+		auditFacade:   adtf,
 	}
 }
 
@@ -60,6 +116,20 @@ func (i *ItemService) GetItems(ctx context.Context, params domain.ItemInputData)
 		return nil, err
 	}
 
+	itemIds := make([]int, 0, len(items))
+	for _, item := range items {
+		itemIds = append(itemIds, int(item.ID))
+	}
+
+	images, err := i.imageRepo.GetItemsImages(ctx, itemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range items {
+		items[idx].Images = images[int(items[idx].ID)]
+	}
+
 	return items, nil
 }
 
@@ -68,21 +138,61 @@ type ItemUpdateData struct {
 	Data map[string]any
 }
 
-// Prepare data to update
+// Update item if client changes its latest version, otherwise ErrVersionConflict is returned
 func (i *ItemService) Update(ctx context.Context, item domain.ItemUpdate) error {
 	if item.ID == 0 {
-		err := fmt.Errorf("error: empty or invalid id")
-		i.logger.Error("update item", sl.Err(err))
+		i.logger.Error("update item", sl.Err(domain.ErrItemNotFound))
 
-		return err
+		return domain.ErrItemNotFound
 	}
 
-	err := i.itemRepo.Update(ctx, item)
+	if item.Version == 0 {
+		return domain.ErrVersionRequired
+	}
+
+	err := i.validateUpdate(ctx, item)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := i.itemRepo.GetItemById(ctx, item.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+		// repository checks version too, here conflict is found without updating
+		if before.Version != item.Version {
+			return adomain.Change{}, domain.ErrVersionConflict
+		}
+
+		err = i.itemRepo.Update(ctx, item)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.revisionRepo.Create(ctx, item.ID, nil)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := i.itemRepo.GetItemById(ctx, item.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.recordPrice(ctx, before, after)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionUpdate,
+			EntityType: adomain.EntityItem,
+			EntityId:   strconv.Itoa(item.ID),
+			Before:     before,
+			After:      after,
+		}, nil
+	})
 }
 
 func (i *ItemService) GetItemById(ctx context.Context, id int) (domain.ItemAPI, error) {
@@ -91,23 +201,277 @@ func (i *ItemService) GetItemById(ctx context.Context, id int) (domain.ItemAPI,
 		return item, err
 	}
 
-	imagesId, err := i.imageRepo.GetImages(ctx, int(item.ID))
+	images, err := i.imageRepo.GetItemsImages(ctx, []int{int(item.ID)})
 	if err != nil {
 		return item, err
 	}
 
-	item.ImageId = imagesId
+	item.Images = images[int(item.ID)]
+	for _, image := range item.Images {
+		item.ImageId = append(item.ImageId, image.ObjectId)
+	}
 
 	return item, err
 }
 
+// Create item. Item is draft if status isn't set, so it isn't shown in catalog until it's published
 func (i *ItemService) Create(ctx context.Context, item domain.ItemCreate) error {
-	// _, err := i.itemImageRepo.Create(ctx, item)
+	if item.Status == 0 {
+		item.Status = domain.StatusDraft
+	}
+	item.Publication = item.Publication.WithDefaults(time.Now())
 
-	// return err
-	return i.outboxFacade.CreateItemWithNotification(ctx, item)
+	err := i.validateCreate(ctx, item)
+	if err != nil {
+		return err
+	}
+
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		itemId, err := i.outboxFacade.CreateItemWithNotification(ctx, item)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.revisionRepo.Create(ctx, int(itemId), nil)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := i.itemRepo.GetItemById(ctx, int(itemId))
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionCreate,
+			EntityType: adomain.EntityItem,
+			EntityId:   strconv.Itoa(int(itemId)),
+			After:      after,
+		}, nil
+	})
 }
 
 func (i *ItemService) Delete(ctx context.Context, id int) error {
-	return i.itemRepo.Delete(ctx, id)
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := i.itemRepo.GetItemById(ctx, id)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.itemRepo.Delete(ctx, id)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionDelete,
+			EntityType: adomain.EntityItem,
+			EntityId:   strconv.Itoa(id),
+			Before:     before,
+		}, nil
+	})
+}
+
+// Change status of item. Published item keeps its publish time if it isn't provided
+func (i *ItemService) ChangeStatus(ctx context.Context, status domain.ItemStatusUpdate) error {
+	if !status.Status.Valid() {
+		return domain.ErrStatus
+	}
+
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := i.itemRepo.GetItemById(ctx, status.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		if !before.Status.CanChangeTo(status.Status) {
+			return adomain.Change{}, domain.ErrStatusTransition
+		}
+
+		if status.Status == before.Status && status.PublishAt == nil {
+			status.PublishAt = before.PublishAt
+		}
+		now := time.Now()
+		status.Publication = status.Publication.WithDefaults(now)
+
+		var verr apperr.FieldErrors
+		status.Publication.Validate(now, &verr)
+		if err := verr.Err(); err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.outboxFacade.SetItemStatusWithNotification(ctx, status, before.Status)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := i.itemRepo.GetItemById(ctx, status.ID)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionUpdate,
+			EntityType: adomain.EntityItem,
+			EntityId:   strconv.Itoa(status.ID),
+			Before:     before,
+			After:      after,
+		}, nil
+	})
+}
+
+// Get items in trash with their images
+func (i *ItemService) GetDeletedItems(ctx context.Context, limit, offset uint64) ([]domain.ItemAPI, error) {
+	items, err := i.itemRepo.GetDeletedItems(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	itemIds := make([]int, 0, len(items))
+	for _, item := range items {
+		itemIds = append(itemIds, int(item.ID))
+	}
+
+	images, err := i.imageRepo.GetItemsImages(ctx, itemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range items {
+		items[idx].Images = images[int(items[idx].ID)]
+	}
+
+	return items, nil
+}
+
+func (i *ItemService) Restore(ctx context.Context, id int) error {
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		err := i.itemRepo.Restore(ctx, id)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := i.itemRepo.GetItemById(ctx, id)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionRestore,
+			EntityType: adomain.EntityItem,
+			EntityId:   strconv.Itoa(id),
+			After:      after,
+		}, nil
+	})
+}
+
+// Check item before creating, so client gets invalid fields instead of db constraint errors
+func (i *ItemService) validateCreate(ctx context.Context, item domain.ItemCreate) error {
+	var verr apperr.FieldErrors
+
+	if !item.Sex.Valid() {
+		verr.Add("sex", domain.ErrSex.Message)
+	}
+
+	if !domain.ValidOuterLink(item.OuterLink) {
+		verr.Add("outer_link", domain.ErrOuterLink.Message)
+	}
+
+	if !domain.StatusDraft.CanChangeTo(item.Status) {
+		verr.Add("status", "item can be created as draft, scheduled or published")
+	}
+	item.Publication.Validate(time.Now(), &verr)
+
+	err := i.validateBrand(ctx, &verr, item.BrandId)
+	if err != nil {
+		return err
+	}
+
+	err = i.validateCategory(ctx, &verr, item.CategoryId, item.Attributes)
+	if err != nil {
+		return err
+	}
+
+	return verr.Err()
+}
+
+// Check changed fields. Attributes are checked when they are changed or item is moved to another category,
+// missing part (category or attributes) is taken from stored item
+func (i *ItemService) validateUpdate(ctx context.Context, item domain.ItemUpdate) error {
+	var verr apperr.FieldErrors
+
+	if item.Sex != nil && !item.Sex.Valid() {
+		verr.Add("sex", domain.ErrSex.Message)
+	}
+
+	if item.OuterLink != nil && !domain.ValidOuterLink(*item.OuterLink) {
+		verr.Add("outer_link", domain.ErrOuterLink.Message)
+	}
+
+	if item.BrandId != nil {
+		err := i.validateBrand(ctx, &verr, *item.BrandId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if item.Attributes != nil || item.CategoryId != nil {
+		current, err := i.itemRepo.GetItemById(ctx, item.ID)
+		if err != nil {
+			return err
+		}
+
+		categoryId := current.CategoryId
+		if item.CategoryId != nil {
+			categoryId = *item.CategoryId
+		}
+		attributes := current.Attributes
+		if item.Attributes != nil {
+			attributes = item.Attributes
+		}
+
+		err = i.validateCategory(ctx, &verr, categoryId, attributes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return verr.Err()
+}
+
+// Add field error if brand doesn't exist. Returned error is not validation error
+func (i *ItemService) validateBrand(ctx context.Context, verr *apperr.FieldErrors, brandId int) error {
+	_, err := i.brandRepo.GetBrand(ctx, brandId)
+	if errors.Is(err, bdomain.ErrBrandNotFound) {
+		verr.Add("brand_id", err.Error())
+
+		return nil
+	}
+
+	return err
+}
+
+// Add field errors if category doesn't exist or attributes don't match category schema.
+// Returned error is not validation error
+func (i *ItemService) validateCategory(ctx context.Context, verr *apperr.FieldErrors, categoryId int, attributes map[string]any) error {
+	_, err := i.categoryRepo.GetCategory(ctx, categoryId)
+	if err != nil {
+		if errors.Is(err, cdomain.ErrCategoryNotFound) {
+			verr.Add("category_id", err.Error())
+
+			return nil
+		}
+		return err
+	}
+
+	schema, err := i.categoryRepo.GetAttributes(ctx, categoryId)
+	if err != nil {
+		return err
+	}
+
+	for _, attrErr := range cdomain.ValidateAttributes(schema, attributes) {
+		verr.Add("attributes."+attrErr.Code, attrErr.Message)
+	}
+
+	return nil
 }
@@ -0,0 +1,33 @@
+package item
+
+import (
+	domain "cloth-mini-app/internal/domain/item"
+	"context"
+)
+
+const priceHistoryLimit = 50 // price changes per page if limit isn't provided
+
+// Get price changes of item, latest first
+func (i *ItemService) GetPriceHistory(ctx context.Context, itemId int, limit, offset uint64) ([]domain.PriceChange, error) {
+	_, err := i.itemRepo.GetItemById(ctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit == 0 {
+		limit = priceHistoryLimit
+	}
+
+	return i.priceRepo.GetHistory(ctx, itemId, limit, offset)
+}
+
+// Record price change in transaction of item change if price or discount is changed.
+// Source of change is taken from context
+func (i *ItemService) recordPrice(ctx context.Context, before, after domain.ItemAPI) error {
+	change, changed := domain.NewPriceChange(before, after, domain.PriceSourceFromContext(ctx))
+	if !changed {
+		return nil
+	}
+
+	return i.priceRepo.Create(ctx, change)
+}
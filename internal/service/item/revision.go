@@ -0,0 +1,92 @@
+package item
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	domain "cloth-mini-app/internal/domain/item"
+	"context"
+	"strconv"
+)
+
+const revisionsLimit = 50 // revisions per page if limit isn't provided
+
+// Get revisions of item, latest first
+func (i *ItemService) GetRevisions(ctx context.Context, itemId int, limit, offset uint64) ([]domain.Revision, error) {
+	_, err := i.itemRepo.GetItemById(ctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit == 0 {
+		limit = revisionsLimit
+	}
+
+	return i.revisionRepo.GetRevisions(ctx, itemId, limit, offset)
+}
+
+func (i *ItemService) GetRevision(ctx context.Context, itemId, version int) (domain.Revision, error) {
+	return i.revisionRepo.GetRevision(ctx, itemId, version)
+}
+
+// Get fields changed between two versions of item. from can be later than to, then changes are reversed
+func (i *ItemService) DiffRevisions(ctx context.Context, itemId, from, to int) ([]domain.FieldChange, error) {
+	fromRevision, err := i.revisionRepo.GetRevision(ctx, itemId, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := i.revisionRepo.GetRevision(ctx, itemId, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromRevision.Snapshot.Diff(toRevision.Snapshot), nil
+}
+
+// Bring item fields and images back to revision. Rollback is a new version of item with its own revision,
+// so it can be rolled back too. Revision that doesn't match current brands and categories isn't restored
+func (i *ItemService) RollbackItem(ctx context.Context, itemId, version int) error {
+	return i.auditFacade.Record(ctx, func(ctx context.Context) (adomain.Change, error) {
+		before, err := i.itemRepo.GetItemById(ctx, itemId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		revision, err := i.revisionRepo.GetRevision(ctx, itemId, version)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.validateUpdate(ctx, revision.Snapshot.ItemUpdate(itemId, before.Version))
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.outboxFacade.RollbackItemWithNotification(ctx, revision, before.Version)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.revisionRepo.Create(ctx, itemId, &version)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		after, err := i.itemRepo.GetItemById(ctx, itemId)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		err = i.recordPrice(domain.WithPriceSource(ctx, domain.PriceSourceRollback), before, after)
+		if err != nil {
+			return adomain.Change{}, err
+		}
+
+		return adomain.Change{
+			Action:     adomain.ActionUpdate,
+			EntityType: adomain.EntityItem,
+			EntityId:   strconv.Itoa(itemId),
+			Before:     before,
+			After:      after,
+		}, nil
+	})
+}
@@ -0,0 +1,96 @@
+package linkcheck
+
+import (
+	domain "cloth-mini-app/internal/domain/linkcheck"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const reportLimit = 100 // checks in report if limit isn't provided
+
+type LinkCheckRepository interface {
+	// Get links which aren't checked, changed or checked before provided time
+	DueLinks(ctx context.Context, checkedBefore time.Time, limit uint64) ([]domain.Link, error)
+	// Save results, link is broken after brokenAfter failed checks in a row
+	SaveResults(ctx context.Context, results []domain.Result, brokenAfter int) error
+	// Get last checks of links, broken first
+	GetChecks(ctx context.Context, filter domain.ReportFilter) ([]domain.Check, error)
+	GetSummary(ctx context.Context) (domain.Summary, error)
+}
+
+type Checker interface {
+	// Check links and return results in the same order
+	Check(ctx context.Context, links []domain.Link) []domain.Result
+}
+
+type LinkCheckService struct {
+	logger  *slog.Logger
+	repo    LinkCheckRepository
+	checker Checker
+	// link is checked again after this time
+	recheck time.Duration
+	// links checked at once
+	batchSize int
+	// failed checks in a row to mark link broken
+	brokenAfter int
+}
+
+func NewLinkCheckService(logger *slog.Logger, repo LinkCheckRepository, checker Checker, recheck time.Duration, batchSize, brokenAfter int) *LinkCheckService {
+	return &LinkCheckService{
+		logger:      logger,
+		repo:        repo,
+		checker:     checker,
+		recheck:     recheck,
+		batchSize:   batchSize,
+		brokenAfter: brokenAfter,
+	}
+}
+
+// Check next batch of links which aren't checked recently and return number of checked links
+func (l *LinkCheckService) CheckLinks(ctx context.Context) (int, error) {
+	const op = "service.linkcheck.CheckLinks"
+
+	links, err := l.repo.DueLinks(ctx, time.Now().Add(-l.recheck), uint64(l.batchSize))
+	if err != nil {
+		return 0, err
+	}
+	if len(links) == 0 {
+		return 0, nil
+	}
+
+	results := l.checker.Check(ctx, links)
+	if err := l.repo.SaveResults(ctx, results, l.brokenAfter); err != nil {
+		return 0, err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.OK() {
+			failed++
+		}
+	}
+	l.logger.Info(fmt.Sprintf("%s: links checked", op), slog.Int("checked", len(results)), slog.Int("failed", failed))
+
+	return len(results), nil
+}
+
+// Get numbers of checked links and last checks, broken and failing links first
+func (l *LinkCheckService) GetReport(ctx context.Context, filter domain.ReportFilter) (domain.Summary, []domain.Check, error) {
+	if filter.Limit == 0 {
+		filter.Limit = reportLimit
+	}
+
+	summary, err := l.repo.GetSummary(ctx)
+	if err != nil {
+		return domain.Summary{}, nil, err
+	}
+
+	checks, err := l.repo.GetChecks(ctx, filter)
+	if err != nil {
+		return domain.Summary{}, nil, err
+	}
+
+	return summary, checks, nil
+}
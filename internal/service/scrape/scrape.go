@@ -0,0 +1,256 @@
+package scrape
+
+import (
+	cdomain "cloth-mini-app/internal/domain/click"
+	idomain "cloth-mini-app/internal/domain/item"
+	domain "cloth-mini-app/internal/domain/scrape"
+	sl "cloth-mini-app/internal/logger"
+	"cloth-mini-app/internal/scraper"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const proposalsLimit = 100 // proposals per page if limit isn't provided
+
+type ScrapeRepository interface {
+	// Get items which aren't scraped, changed or scraped before provided time
+	DueTargets(ctx context.Context, scrapedBefore time.Time, limit uint64) ([]domain.Target, error)
+	// Save last scrape of item page
+	SaveResult(ctx context.Context, result domain.Result) error
+	GetRules(ctx context.Context) ([]domain.Rule, error)
+	// Find rule of host or its closest parent domain
+	FindRule(ctx context.Context, host string) (domain.Rule, error)
+	// Create or replace rule of domain
+	SaveRule(ctx context.Context, rule domain.Rule) error
+	DeleteRule(ctx context.Context, domainName string) error
+	// Create pending proposal of item or replace it
+	SaveProposal(ctx context.Context, proposal domain.Proposal) error
+	DeletePendingProposal(ctx context.Context, itemId int) error
+	// Get proposals, latest first
+	GetProposals(ctx context.Context, filter domain.ProposalFilter) ([]domain.Proposal, error)
+	GetProposal(ctx context.Context, id int64) (domain.Proposal, error)
+	// Mark pending proposal resolved and run change in the same transaction
+	ResolveProposal(ctx context.Context, id int64, status domain.ProposalStatus, change func(ctx context.Context) error) error
+}
+
+type Scraper interface {
+	// Fetch page and extract offer from it, rule may be nil
+	Scrape(ctx context.Context, link string, rule *domain.Rule) (domain.Offer, error)
+}
+
+type ItemService interface {
+	// Update item if change is based on its latest version
+	Update(ctx context.Context, item idomain.ItemUpdate) error
+}
+
+type ScrapeService struct {
+	logger      *slog.Logger
+	repo        ScrapeRepository
+	scraper     Scraper
+	itemService ItemService
+	// item page is scraped again after this time
+	rescrape time.Duration
+	// items scraped at once
+	batchSize int
+	// scraped prices of all shops are applied without review
+	autoApply bool
+	// price changed by larger share is proposed for review even if it can be applied, 0 is no limit
+	maxChange float64
+}
+
+func NewScrapeService(logger *slog.Logger, repo ScrapeRepository, s Scraper, is ItemService, rescrape time.Duration, batchSize int, autoApply bool, maxChange float64) *ScrapeService {
+	return &ScrapeService{
+		logger:      logger,
+		repo:        repo,
+		scraper:     s,
+		itemService: is,
+		rescrape:    rescrape,
+		batchSize:   batchSize,
+		autoApply:   autoApply,
+		maxChange:   maxChange,
+	}
+}
+
+// Scrape next batch of item pages and return number of scraped pages. Changed price is applied
+// if shop rule or config allows it and it isn't changed too much, otherwise it's proposed for review
+func (s *ScrapeService) ScrapePrices(ctx context.Context) (int, error) {
+	const op = "service.scrape.ScrapePrices"
+
+	targets, err := s.repo.DueTargets(ctx, time.Now().Add(-s.rescrape), uint64(s.batchSize))
+	if err != nil {
+		return 0, err
+	}
+
+	var found, changed int
+	for _, target := range targets {
+		rule, err := s.findRule(ctx, target.URL)
+		if err != nil {
+			return 0, err
+		}
+
+		result := domain.Result{
+			ItemId:    target.ItemId,
+			URL:       target.URL,
+			ScrapedAt: time.Now(),
+		}
+		offer, err := s.scraper.Scrape(ctx, target.URL, rule)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Offer = &offer
+			found++
+		}
+
+		if err := s.repo.SaveResult(ctx, result); err != nil {
+			return 0, err
+		}
+		if result.Offer == nil {
+			continue
+		}
+
+		if offer.Price == uint(target.Price) && offer.Discount == uint(target.Discount) {
+			if err := s.repo.DeletePendingProposal(ctx, target.ItemId); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		changed++
+
+		if s.autoApply || (rule != nil && rule.AutoApply) {
+			// broken markup or wrong selector may give price of other item or currency
+			if s.maxChange <= 0 || target.PriceChange(offer) <= s.maxChange {
+				s.apply(ctx, target, offer)
+				continue
+			}
+			s.logger.Warn(fmt.Sprintf("%s: scraped price changed too much, proposed for review", op),
+				slog.Int("item_id", target.ItemId), slog.Int("price", target.Price), slog.Uint64("scraped_price", uint64(offer.Price)))
+		}
+
+		err = s.repo.SaveProposal(ctx, domain.Proposal{
+			ItemId:    target.ItemId,
+			URL:       target.URL,
+			Price:     offer.Price,
+			Discount:  offer.Discount,
+			Available: offer.Available,
+			Version:   target.Version,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(targets) > 0 {
+		s.logger.Info(fmt.Sprintf("%s: item pages scraped", op), slog.Int("scraped", len(targets)),
+			slog.Int("found", found), slog.Int("changed", changed))
+	}
+
+	return len(targets), nil
+}
+
+// Rule of shop domain of link, nil if there is none
+func (s *ScrapeService) findRule(ctx context.Context, link string) (*domain.Rule, error) {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return nil, nil
+	}
+
+	rule, err := s.repo.FindRule(ctx, cdomain.NormalizeDomain(parsed.Hostname()))
+	if err != nil {
+		if errors.Is(err, domain.ErrRuleNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// Apply scraped price to item. Item changed since it was selected is scraped again next time
+func (s *ScrapeService) apply(ctx context.Context, target domain.Target, offer domain.Offer) {
+	const op = "service.scrape.apply"
+
+	err := s.itemService.Update(idomain.WithPriceSource(ctx, idomain.PriceSourceScraper), idomain.ItemUpdate{
+		ID:       target.ItemId,
+		Price:    &offer.Price,
+		Discount: &offer.Discount,
+		Version:  target.Version,
+	})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("%s: applying scraped price", op), slog.Int("item_id", target.ItemId), sl.Err(err))
+	}
+}
+
+// Get proposals of scraped prices, latest first
+func (s *ScrapeService) GetProposals(ctx context.Context, filter domain.ProposalFilter) ([]domain.Proposal, error) {
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, domain.ErrStatus
+	}
+	if filter.Limit == 0 {
+		filter.Limit = proposalsLimit
+	}
+
+	return s.repo.GetProposals(ctx, filter)
+}
+
+// Apply proposed price to item. Proposal made for older version of item isn't applied
+func (s *ScrapeService) ApplyProposal(ctx context.Context, id int64) error {
+	proposal, err := s.repo.GetProposal(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.ResolveProposal(ctx, id, domain.StatusApplied, func(ctx context.Context) error {
+		err := s.itemService.Update(idomain.WithPriceSource(ctx, idomain.PriceSourceScraper), idomain.ItemUpdate{
+			ID:       proposal.ItemId,
+			Price:    &proposal.Price,
+			Discount: &proposal.Discount,
+			Version:  proposal.Version,
+		})
+		if errors.Is(err, idomain.ErrVersionConflict) {
+			return domain.ErrProposalOutdated
+		}
+
+		return err
+	})
+}
+
+func (s *ScrapeService) RejectProposal(ctx context.Context, id int64) error {
+	return s.repo.ResolveProposal(ctx, id, domain.StatusRejected, func(context.Context) error {
+		return nil
+	})
+}
+
+func (s *ScrapeService) GetRules(ctx context.Context) ([]domain.Rule, error) {
+	return s.repo.GetRules(ctx)
+}
+
+// Create or replace rule of shop domain, it's applied to subdomains too. Selectors are checked by compiling them
+func (s *ScrapeService) SaveRule(ctx context.Context, rule domain.Rule) error {
+	rule.Domain = cdomain.NormalizeDomain(rule.Domain)
+	if !cdomain.ValidDomain(rule.Domain) {
+		return domain.ErrDomain
+	}
+
+	rule.PriceSelector = strings.TrimSpace(rule.PriceSelector)
+	rule.OldPriceSelector = strings.TrimSpace(rule.OldPriceSelector)
+	rule.AvailabilitySelector = strings.TrimSpace(rule.AvailabilitySelector)
+	rule.InStockText = strings.TrimSpace(rule.InStockText)
+
+	// rule without selectors only turns on auto apply for shop with JSON-LD
+	if rule.PriceSelector != "" || rule.OldPriceSelector != "" || rule.AvailabilitySelector != "" {
+		if _, err := scraper.NewSelectors(rule); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.SaveRule(ctx, rule)
+}
+
+func (s *ScrapeService) DeleteRule(ctx context.Context, domainName string) error {
+	return s.repo.DeleteRule(ctx, cdomain.NormalizeDomain(domainName))
+}
@@ -0,0 +1,210 @@
+package sitemap
+
+import (
+	"bytes"
+	idomain "cloth-mini-app/internal/domain/item"
+	domain "cloth-mini-app/internal/domain/sitemap"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	NS      string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	NS       string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	NS      string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published"`
+	Link      atomLink     `xml:"link"`
+	Summary   string       `xml:"summary"`
+	Author    atomAuthor   `xml:"author"`
+	Category  atomCategory `xml:"category"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+	Category    string  `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// Links of new arrivals feed
+type channel struct {
+	title string
+	// page of brand or category
+	link string
+	// url of feed itself
+	self string
+}
+
+func renderURLSet(urls []domain.URL) ([]byte, error) {
+	set := urlSet{
+		NS:   sitemapNS,
+		URLs: make([]sitemapURL, 0, len(urls)),
+	}
+	for _, url := range urls {
+		converted := sitemapURL{Loc: url.Loc}
+		if url.LastMod != nil {
+			converted.LastMod = url.LastMod.UTC().Format(time.RFC3339)
+		}
+		set.URLs = append(set.URLs, converted)
+	}
+
+	return marshal(set)
+}
+
+func renderIndex(publicURL string, pages int) ([]byte, error) {
+	index := sitemapIndex{
+		NS:       sitemapNS,
+		Sitemaps: make([]sitemapURL, 0, pages),
+	}
+	for page := 1; page <= pages; page++ {
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: fmt.Sprintf("%s/sitemap-%d.xml", publicURL, page)})
+	}
+
+	return marshal(index)
+}
+
+func (s *SitemapService) renderFeed(format domain.Format, ch channel, items []idomain.ItemAPI) ([]byte, error) {
+	// feed is updated when its newest item is created or changed
+	updated := time.Now()
+	for idx, item := range items {
+		if t := itemUpdated(item); idx == 0 || t.After(updated) {
+			updated = t
+		}
+	}
+
+	if format == domain.FormatAtom {
+		feed := atomFeed{
+			NS:      "http://www.w3.org/2005/Atom",
+			ID:      ch.self,
+			Title:   ch.title,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Links:   []atomLink{{Href: ch.self, Rel: "self"}, {Href: ch.link}},
+			Entries: make([]atomEntry, 0, len(items)),
+		}
+		for _, item := range items {
+			link := s.itemURL(item)
+			feed.Entries = append(feed.Entries, atomEntry{
+				ID:        link,
+				Title:     item.Name,
+				Updated:   itemUpdated(item).UTC().Format(time.RFC3339),
+				Published: item.CreatedAt.UTC().Format(time.RFC3339),
+				Link:      atomLink{Href: link},
+				Summary:   item.Description,
+				Author:    atomAuthor{Name: item.BrandName},
+				Category:  atomCategory{Term: item.CategoryName},
+			})
+		}
+
+		return marshal(feed)
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         ch.title,
+			Link:          ch.link,
+			Description:   ch.title,
+			LastBuildDate: updated.UTC().Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(items)),
+		},
+	}
+	for _, item := range items {
+		link := s.itemURL(item)
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.Name,
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			Description: item.Description,
+			PubDate:     item.CreatedAt.UTC().Format(time.RFC1123Z),
+			Category:    item.CategoryName,
+		})
+	}
+
+	return marshal(feed)
+}
+
+func (s *SitemapService) itemURL(item idomain.ItemAPI) string {
+	return fmt.Sprintf("%s/item/get/%d", s.publicURL, item.ID)
+}
+
+func itemUpdated(item idomain.ItemAPI) time.Time {
+	if item.UpdatedAt != nil {
+		return *item.UpdatedAt
+	}
+
+	return item.CreatedAt
+}
+
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,270 @@
+package sitemap
+
+import (
+	adomain "cloth-mini-app/internal/domain/audit"
+	bdomain "cloth-mini-app/internal/domain/brand"
+	cdomain "cloth-mini-app/internal/domain/category"
+	idomain "cloth-mini-app/internal/domain/item"
+	domain "cloth-mini-app/internal/domain/sitemap"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type ItemRepository interface {
+	// Get times of items with statuses, newest first
+	GetItemsTimes(ctx context.Context, statuses []idomain.Status) ([]idomain.ItemTimes, error)
+	// Get newest items matching params ordered by creation time
+	GetNewestItems(ctx context.Context, params idomain.ItemInputData, limit uint64) ([]idomain.ItemAPI, error)
+}
+
+type BrandRepository interface {
+	GetBrands(ctx context.Context) ([]bdomain.Brand, error)
+	GetBrand(ctx context.Context, brandId int) (bdomain.Brand, error)
+}
+
+type CategoryRepository interface {
+	GetCategories(ctx context.Context) ([]cdomain.Category, error)
+	GetCategory(ctx context.Context, categoryId int) (cdomain.Category, error)
+}
+
+type OutboxRepository interface {
+	// Get id of last outbox event, 0 if there are no events
+	LastEventId(ctx context.Context) (int, error)
+}
+
+type AuditRepository interface {
+	// Get id of last entry of entity types, 0 if there are no entries
+	LastEntryId(ctx context.Context, entityTypes ...adomain.EntityType) (int64, error)
+}
+
+// State of data files are built from. Items changes are seen by outbox events,
+// brands and categories don't have events, their changes are seen by audit log
+type version struct {
+	eventId int
+	entryId int64
+}
+
+// File or urls being built or built. Requests of the same entry wait for the first one
+type cacheEntry struct {
+	ready chan struct{}
+	value any
+	err   error
+}
+
+type SitemapService struct {
+	logger       *slog.Logger
+	itemRepo     ItemRepository
+	brandRepo    BrandRepository
+	categoryRepo CategoryRepository
+	outboxRepo   OutboxRepository
+	auditRepo    AuditRepository
+	// base url of app in links
+	publicURL string
+	// urls in one sitemap file
+	pageSize int
+	// items in new arrivals feed
+	feedSize int
+
+	// rendered files and urls of sitemap are cached until data changes, see version
+	mu      sync.Mutex
+	version version
+	cache   map[string]*cacheEntry
+}
+
+func NewSitemapService(logger *slog.Logger, ir ItemRepository, br BrandRepository, cr CategoryRepository, or OutboxRepository, ar AuditRepository, publicURL string, pageSize, feedSize int) *SitemapService {
+	if pageSize <= 0 || pageSize > domain.MaxURLs {
+		pageSize = domain.MaxURLs
+	}
+
+	return &SitemapService{
+		logger:       logger,
+		itemRepo:     ir,
+		brandRepo:    br,
+		categoryRepo: cr,
+		outboxRepo:   or,
+		auditRepo:    ar,
+		publicURL:    strings.TrimSuffix(publicURL, "/"),
+		pageSize:     pageSize,
+		feedSize:     feedSize,
+		version:      version{eventId: -1},
+	}
+}
+
+// Get sitemap file. Page 0 is sitemap.xml, it's index of pages 1..n if urls don't fit in one file
+func (s *SitemapService) Sitemap(ctx context.Context, page int) ([]byte, error) {
+	return s.cached(ctx, fmt.Sprintf("sitemap-%d", page), func() ([]byte, error) {
+		// urls are shared by all pages
+		value, err := s.load(ctx, "urls", func() (any, error) {
+			return s.siteURLs(ctx)
+		})
+		if err != nil {
+			return nil, err
+		}
+		urls := value.([]domain.URL)
+
+		pages := (len(urls) + s.pageSize - 1) / s.pageSize
+		switch {
+		case pages <= 1 && page == 0:
+			return renderURLSet(urls)
+		case pages <= 1 || page > pages || page < 0:
+			return nil, domain.ErrPageNotFound
+		case page == 0:
+			return renderIndex(s.publicURL, pages)
+		}
+
+		end := min(page*s.pageSize, len(urls))
+
+		return renderURLSet(urls[(page-1)*s.pageSize : end])
+	})
+}
+
+// Get feed of newest published items of brand or category with its subcategories
+func (s *SitemapService) Feed(ctx context.Context, source domain.Source, id int, format domain.Format) ([]byte, error) {
+	if !format.Valid() {
+		return nil, domain.ErrFormat
+	}
+
+	return s.cached(ctx, fmt.Sprintf("feed-%s-%d-%s", source, id, format), func() ([]byte, error) {
+		params := idomain.ItemInputData{
+			Statuses: []idomain.Status{idomain.StatusPublished},
+		}
+
+		var title string
+		switch source {
+		case domain.SourceBrand:
+			brand, err := s.brandRepo.GetBrand(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			brandId := uint(brand.ID)
+			params.BrandId = &brandId
+			title = brand.Name
+		case domain.SourceCategory:
+			category, err := s.categoryRepo.GetCategory(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			categoryId := uint(category.CategoryId)
+			params.CategoryId = &categoryId
+			title = category.Name
+		}
+
+		items, err := s.itemRepo.GetNewestItems(ctx, params, uint64(s.feedSize))
+		if err != nil {
+			return nil, err
+		}
+
+		return s.renderFeed(format, channel{
+			title: "New arrivals: " + title,
+			link:  fmt.Sprintf("%s/%s/%d", s.publicURL, source, id),
+			self:  fmt.Sprintf("%s/feed/%s/%d/%s", s.publicURL, source, id, format),
+		}, items)
+	})
+}
+
+// Get file from cache or build it
+func (s *SitemapService) cached(ctx context.Context, key string, build func() ([]byte, error)) ([]byte, error) {
+	value, err := s.load(ctx, key, func() (any, error) {
+		return build()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]byte), nil
+}
+
+// Get value from cache or build it. Cache is dropped when items, brands or categories change.
+// Different values are built at once, requests of the same value wait for one build
+func (s *SitemapService) load(ctx context.Context, key string, build func() (any, error)) (any, error) {
+	current, err := s.currentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if current != s.version {
+		s.version = current
+		s.cache = make(map[string]*cacheEntry)
+	}
+	entry, ok := s.cache[key]
+	if !ok {
+		entry = &cacheEntry{ready: make(chan struct{})}
+		s.cache[key] = entry
+	}
+	s.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.ready:
+			return entry.value, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry.value, entry.err = build()
+	if entry.err != nil {
+		// failed build isn't cached, next request tries again
+		s.mu.Lock()
+		if s.cache[key] == entry {
+			delete(s.cache, key)
+		}
+		s.mu.Unlock()
+	}
+	close(entry.ready)
+
+	return entry.value, entry.err
+}
+
+func (s *SitemapService) currentVersion(ctx context.Context) (version, error) {
+	eventId, err := s.outboxRepo.LastEventId(ctx)
+	if err != nil {
+		return version{}, err
+	}
+	entryId, err := s.auditRepo.LastEntryId(ctx, adomain.EntityBrand, adomain.EntityCategory)
+	if err != nil {
+		return version{}, err
+	}
+
+	return version{eventId: eventId, entryId: entryId}, nil
+}
+
+// Pages of published items, newest first, then brands and categories
+func (s *SitemapService) siteURLs(ctx context.Context) ([]domain.URL, error) {
+	items, err := s.itemRepo.GetItemsTimes(ctx, []idomain.Status{idomain.StatusPublished})
+	if err != nil {
+		return nil, err
+	}
+	brands, err := s.brandRepo.GetBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]domain.URL, 0, len(items)+len(brands)+len(categories))
+	for _, item := range items {
+		lastMod := item.CreatedAt
+		if item.UpdatedAt != nil {
+			lastMod = *item.UpdatedAt
+		}
+		urls = append(urls, domain.URL{
+			Loc:     fmt.Sprintf("%s/item/get/%d", s.publicURL, item.ID),
+			LastMod: &lastMod,
+		})
+	}
+	for _, brand := range brands {
+		urls = append(urls, domain.URL{Loc: fmt.Sprintf("%s/brand/%d", s.publicURL, brand.ID)})
+	}
+	for _, category := range categories {
+		urls = append(urls, domain.URL{Loc: fmt.Sprintf("%s/category/%d", s.publicURL, category.CategoryId)})
+	}
+
+	return urls, nil
+}
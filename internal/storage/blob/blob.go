@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"cloth-mini-app/internal/dto"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	BackendMinio  = "minio"
+	BackendLocal  = "local"
+	BackendMemory = "memory"
+
+	getManyWorkersCnt = 10
+)
+
+var (
+	ErrObjectNotFound = fmt.Errorf("object not found")
+	ErrNotSupported   = fmt.Errorf("operation is not supported by storage backend")
+)
+
+// Blob storage for images and generated files. Implemented by minio (S3), local filesystem and in-memory backends
+type Storage interface {
+	// Put file to storage, existing file with the same id is overwritten
+	Put(ctx context.Context, file dto.FileDTO) error
+	// Put file of info.Size bytes read from r, file isn't kept in memory
+	PutStream(ctx context.Context, info dto.FileInfo, r io.Reader) error
+	// Get file from storage
+	Get(ctx context.Context, objectId string) (dto.FileDTO, error)
+	// Open file for reading, reader must be closed
+	Open(ctx context.Context, objectId string) (io.ReadCloser, dto.FileInfo, error)
+	// Get many files from storage
+	GetMany(ctx context.Context, objectIds []string) ([]dto.FileDTO, error)
+	// Get file info without reading file
+	Stat(ctx context.Context, objectId string) (dto.FileInfo, error)
+	// Get first length bytes of file
+	GetHead(ctx context.Context, objectId string, length int64) ([]byte, error)
+	// Get presigned url for direct upload. Return ErrNotSupported if backend can't do it
+	PresignedPut(ctx context.Context, objectId string, contentType string, size int64, expires time.Duration) (string, error)
+	// Get presigned url for direct download of file saved as fileName. Return ErrNotSupported if backend can't do it
+	PresignedGet(ctx context.Context, objectId string, fileName string, expires time.Duration) (string, error)
+	// Delete file, missing file isn't an error
+	Delete(ctx context.Context, objectId string) error
+}
+
+// Get many files with get function.
+// Use worker pull with 10 workers (amount workers - getManyWorkersCnt)
+func GetMany(ctx context.Context, get func(ctx context.Context, objectId string) (dto.FileDTO, error), objectIds []string) ([]dto.FileDTO, error) {
+	var worker = func(objectIdCh <-chan string, fileCh chan<- dto.FileDTO, errCh chan<- error, wg *sync.WaitGroup) {
+		for id := range objectIdCh {
+			file, err := get(ctx, id)
+			if err != nil {
+				errCh <- err
+			} else {
+				fileCh <- file
+			}
+			wg.Done()
+		}
+	}
+
+	var wg sync.WaitGroup
+	objectIdCh := make(chan string, len(objectIds))
+	fileCh := make(chan dto.FileDTO, len(objectIds))
+	errCh := make(chan error, len(objectIds))
+
+	for range getManyWorkersCnt {
+		go worker(objectIdCh, fileCh, errCh, &wg)
+	}
+
+	for _, id := range objectIds {
+		wg.Add(1)
+		objectIdCh <- id
+	}
+	close(objectIdCh)
+
+	go func() {
+		wg.Wait()
+		close(fileCh)
+		close(errCh)
+	}()
+
+	files := make([]dto.FileDTO, 0, len(objectIds))
+	errors := make([]error, 0)
+	for file := range fileCh {
+		files = append(files, file)
+	}
+	for err := range errCh {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("failed getting files: err list: %v", errors)
+	}
+
+	return files, nil
+}
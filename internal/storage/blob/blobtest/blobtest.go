@@ -0,0 +1,215 @@
+// Package blobtest checks that storage backend behaves as blob.Storage expects
+package blobtest
+
+import (
+	"cloth-mini-app/internal/dto"
+	"cloth-mini-app/internal/storage/blob"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Run common tests of blob storage. newStorage returns new empty storage for every test
+func Run(t *testing.T, newStorage func(t *testing.T) blob.Storage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage blob.Storage)
+	}{
+		{name: "put get", test: testPutGet},
+		{name: "put stream", test: testPutStream},
+		{name: "get head", test: testGetHead},
+		{name: "get many", test: testGetMany},
+		{name: "delete", test: testDelete},
+		{name: "not found", test: testNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func testPutGet(t *testing.T, storage blob.Storage) {
+	ctx := context.Background()
+
+	files := []struct {
+		name string
+		file dto.FileDTO
+	}{
+		{name: "image", file: dto.FileDTO{ID: "image-1", ContentType: "image/jpeg", Buffer: []byte("jpeg data")}},
+		{name: "empty file", file: dto.FileDTO{ID: "empty", ContentType: "text/plain", Buffer: []byte{}}},
+		// existing file is overwritten
+		{name: "overwrite", file: dto.FileDTO{ID: "image-1", ContentType: "image/png", Buffer: []byte("png data")}},
+	}
+	for _, tt := range files {
+		if err := storage.Put(ctx, tt.file); err != nil {
+			t.Fatalf("%s: put: %v", tt.name, err)
+		}
+
+		file, err := storage.Get(ctx, tt.file.ID)
+		if err != nil {
+			t.Fatalf("%s: get: %v", tt.name, err)
+		}
+		if file.ID != tt.file.ID || file.ContentType != tt.file.ContentType || string(file.Buffer) != string(tt.file.Buffer) {
+			t.Errorf("%s: expected %s %q %q, got %s %q %q", tt.name,
+				tt.file.ID, tt.file.ContentType, tt.file.Buffer, file.ID, file.ContentType, file.Buffer)
+		}
+
+		info, err := storage.Stat(ctx, tt.file.ID)
+		if err != nil {
+			t.Fatalf("%s: stat: %v", tt.name, err)
+		}
+		if info.Size != int64(len(tt.file.Buffer)) || info.ContentType != tt.file.ContentType {
+			t.Errorf("%s: expected size %d and type %q, got %d %q", tt.name,
+				len(tt.file.Buffer), tt.file.ContentType, info.Size, info.ContentType)
+		}
+	}
+}
+
+func testPutStream(t *testing.T, storage blob.Storage) {
+	ctx := context.Background()
+
+	err := storage.PutStream(ctx, dto.FileInfo{ID: "export-1.csv", ContentType: "text/csv", Size: 8}, strings.NewReader("a,b\n1,2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, info, err := storage.Open(ctx, "export-1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "a,b\n1,2\n" || info.ContentType != "text/csv" || info.Size != int64(len(content)) {
+		t.Errorf("unexpected file %q %+v", content, info)
+	}
+}
+
+func testGetHead(t *testing.T, storage blob.Storage) {
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, dto.FileDTO{ID: "file", Buffer: []byte("content")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		length int64
+		head   string
+	}{
+		{length: 3, head: "con"},
+		{length: 7, head: "content"},
+		// file shorter than length is returned whole
+		{length: 100, head: "content"},
+	}
+	for _, tt := range tests {
+		head, err := storage.GetHead(ctx, "file", tt.length)
+		if err != nil {
+			t.Fatalf("%d: %v", tt.length, err)
+		}
+		if string(head) != tt.head {
+			t.Errorf("%d: expected %q, got %q", tt.length, tt.head, head)
+		}
+	}
+}
+
+func testGetMany(t *testing.T, storage blob.Storage) {
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := storage.Put(ctx, dto.FileDTO{ID: id, Buffer: []byte(id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		ids  []string
+		err  bool
+	}{
+		{name: "all", ids: []string{"a", "b", "c"}},
+		{name: "one", ids: []string{"b"}},
+		{name: "none", ids: []string{}},
+		{name: "missing", ids: []string{"a", "missing"}, err: true},
+	}
+	for _, tt := range tests {
+		files, err := storage.GetMany(ctx, tt.ids)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %d files", tt.name, len(files))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		ids := make([]string, 0, len(files))
+		for _, file := range files {
+			if string(file.Buffer) != file.ID {
+				t.Errorf("%s: file %s has content %q", tt.name, file.ID, file.Buffer)
+			}
+			ids = append(ids, file.ID)
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, tt.ids) {
+			t.Errorf("%s: expected files %v, got %v", tt.name, tt.ids, ids)
+		}
+	}
+}
+
+func testDelete(t *testing.T, storage blob.Storage) {
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, dto.FileDTO{ID: "image-1", ContentType: "image/jpeg", Buffer: []byte("data")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// missing file isn't an error, so delete can be repeated
+	for range 2 {
+		if err := storage.Delete(ctx, "image-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := storage.Get(ctx, "image-1"); !errors.Is(err, blob.ErrObjectNotFound) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+func testNotFound(t *testing.T, storage blob.Storage) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "get", call: func() error {
+			_, err := storage.Get(ctx, "missing")
+			return err
+		}},
+		{name: "open", call: func() error {
+			_, _, err := storage.Open(ctx, "missing")
+			return err
+		}},
+		{name: "stat", call: func() error {
+			_, err := storage.Stat(ctx, "missing")
+			return err
+		}},
+		{name: "get head", call: func() error {
+			_, err := storage.GetHead(ctx, "missing", 10)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, blob.ErrObjectNotFound) {
+			t.Errorf("%s: expected not found, got %v", tt.name, err)
+		}
+	}
+}
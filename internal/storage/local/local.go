@@ -0,0 +1,253 @@
+package local
+
+import (
+	"bytes"
+	"cloth-mini-app/internal/dto"
+	"cloth-mini-app/internal/storage/blob"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	metaFileSuffix     = ".meta"
+	defaultContentType = "application/octet-stream"
+)
+
+var (
+	errObjectId = fmt.Errorf("incorrect object id")
+)
+
+// Blob storage in local directory. Intended for development and tests
+type LocalStorage struct {
+	root string
+}
+
+// file attributes stored next to file
+type fileMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+// Create local storage object, root directory is created if not exists
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	const op = "storage.local.New"
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &LocalStorage{
+		root: root,
+	}, nil
+}
+
+// Put file to store
+func (l *LocalStorage) Put(ctx context.Context, file dto.FileDTO) error {
+	const op = "storage.local.Put"
+
+	err := l.put(file.ID, file.ContentType, bytes.NewReader(file.Buffer))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Put file read from r, size isn't checked
+func (l *LocalStorage) PutStream(ctx context.Context, info dto.FileInfo, r io.Reader) error {
+	const op = "storage.local.PutStream"
+
+	err := l.put(info.ID, info.ContentType, r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (l *LocalStorage) put(objectId, contentType string, r io.Reader) error {
+	path, err := l.path(objectId)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(fileMeta{ContentType: contentType})
+	if err != nil {
+		return err
+	}
+
+	if err = writeFile(path+metaFileSuffix, bytes.NewReader(meta)); err != nil {
+		return err
+	}
+
+	return writeFile(path, r)
+}
+
+// Open file for reading, reader must be closed
+func (l *LocalStorage) Open(ctx context.Context, objectId string) (io.ReadCloser, dto.FileInfo, error) {
+	const op = "storage.local.Open"
+
+	info, err := l.Stat(ctx, objectId)
+	if err != nil {
+		return nil, dto.FileInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	path, _ := l.path(objectId)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, dto.FileInfo{}, fmt.Errorf("%s: %w", op, notFoundErr(err))
+	}
+
+	return file, info, nil
+}
+
+// Get file from storage
+func (l *LocalStorage) Get(ctx context.Context, objectId string) (dto.FileDTO, error) {
+	const op = "storage.local.Get"
+
+	info, err := l.Stat(ctx, objectId)
+	if err != nil {
+		return dto.FileDTO{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	path, _ := l.path(objectId)
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		return dto.FileDTO{}, fmt.Errorf("%s: %w", op, notFoundErr(err))
+	}
+
+	return dto.FileDTO{
+		ID:          objectId,
+		ContentType: info.ContentType,
+		Buffer:      buffer,
+	}, nil
+}
+
+// Get many files from storage
+// Use worker pull (see blob.GetMany)
+func (l *LocalStorage) GetMany(ctx context.Context, objectIds []string) ([]dto.FileDTO, error) {
+	return blob.GetMany(ctx, l.Get, objectIds)
+}
+
+// Get file info without reading file
+func (l *LocalStorage) Stat(ctx context.Context, objectId string) (dto.FileInfo, error) {
+	const op = "storage.local.Stat"
+
+	path, err := l.path(objectId)
+	if err != nil {
+		return dto.FileInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return dto.FileInfo{}, fmt.Errorf("%s: %w", op, notFoundErr(err))
+	}
+
+	contentType := defaultContentType
+	rawMeta, err := os.ReadFile(path + metaFileSuffix)
+	if err == nil {
+		var meta fileMeta
+		if err = json.Unmarshal(rawMeta, &meta); err == nil && meta.ContentType != "" {
+			contentType = meta.ContentType
+		}
+	}
+
+	return dto.FileInfo{
+		ID:          objectId,
+		ContentType: contentType,
+		Size:        stat.Size(),
+	}, nil
+}
+
+// Get first length bytes of file
+func (l *LocalStorage) GetHead(ctx context.Context, objectId string, length int64) ([]byte, error) {
+	const op = "storage.local.GetHead"
+
+	path, err := l.path(objectId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, notFoundErr(err))
+	}
+	defer file.Close()
+
+	buffer, err := io.ReadAll(io.LimitReader(file, length))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buffer, nil
+}
+
+// Files are not served by local storage, so direct upload isn't possible
+func (l *LocalStorage) PresignedPut(ctx context.Context, objectId string, contentType string, size int64, expires time.Duration) (string, error) {
+	return "", fmt.Errorf("storage.local.PresignedPut: %w", blob.ErrNotSupported)
+}
+
+// Files are not served by local storage, they are downloaded through app
+func (l *LocalStorage) PresignedGet(ctx context.Context, objectId string, fileName string, expires time.Duration) (string, error) {
+	return "", fmt.Errorf("storage.local.PresignedGet: %w", blob.ErrNotSupported)
+}
+
+// Delete file from storage
+func (l *LocalStorage) Delete(ctx context.Context, objectId string) error {
+	const op = "storage.local.Delete"
+
+	path, err := l.path(objectId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, p := range []string{path, path + metaFileSuffix} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// object id must be plain file name, so files outside root can't be accessed
+func (l *LocalStorage) path(objectId string) (string, error) {
+	if objectId == "" || objectId != filepath.Base(objectId) || objectId == "." || objectId == ".." {
+		return "", errObjectId
+	}
+
+	return filepath.Join(l.root, objectId), nil
+}
+
+// write to temp file and rename, so readers never see partially written file
+func writeFile(path string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func notFoundErr(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return blob.ErrObjectNotFound
+	}
+
+	return err
+}
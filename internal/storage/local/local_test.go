@@ -0,0 +1,110 @@
+package local
+
+import (
+	"cloth-mini-app/internal/dto"
+	"cloth-mini-app/internal/storage/blob"
+	"cloth-mini-app/internal/storage/blob/blobtest"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestStorage(t *testing.T) *LocalStorage {
+	storage, err := NewLocalStorage(filepath.Join(t.TempDir(), "files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return storage
+}
+
+func TestStorage(t *testing.T) {
+	blobtest.Run(t, func(t *testing.T) blob.Storage {
+		return newTestStorage(t)
+	})
+}
+
+// File without content type is served as binary
+func TestDefaultContentType(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, dto.FileDTO{ID: "export-1.csv", Buffer: []byte("a,b\n1,2\n")}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := storage.Get(ctx, "export-1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.ContentType != defaultContentType {
+		t.Errorf("expected %q, got %q", defaultContentType, file.ContentType)
+	}
+}
+
+func TestDeleteMetaFile(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	if err := storage.Put(ctx, dto.FileDTO{ID: "image-1", ContentType: "image/jpeg", Buffer: []byte("data")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Delete(ctx, "image-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(storage.root, "image-1"+metaFileSuffix)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected meta file to be deleted, got %v", err)
+	}
+}
+
+func TestPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewLocalStorage(filepath.Join(dir, "files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// file next to storage root must not be reachable
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{
+		"",
+		".",
+		"..",
+		"../secret",
+		"./secret",
+		"sub/file",
+		secret,
+		"/etc/passwd",
+	}
+	for _, id := range ids {
+		if _, err := storage.path(id); !errors.Is(err, errObjectId) {
+			t.Errorf("%q: expected invalid object id, got %v", id, err)
+		}
+
+		if file, err := storage.Get(ctx, id); !errors.Is(err, errObjectId) {
+			t.Errorf("%q: expected get to be refused, got %q %v", id, file.Buffer, err)
+		}
+		if err := storage.Put(ctx, dto.FileDTO{ID: id, Buffer: []byte("overwritten")}); !errors.Is(err, errObjectId) {
+			t.Errorf("%q: expected put to be refused, got %v", id, err)
+		}
+		if err := storage.Delete(ctx, id); !errors.Is(err, errObjectId) {
+			t.Errorf("%q: expected delete to be refused, got %v", id, err)
+		}
+		if _, err := storage.GetMany(ctx, []string{id}); err == nil {
+			t.Errorf("%q: expected get many to be refused", id)
+		}
+	}
+
+	content, err := os.ReadFile(secret)
+	if err != nil || string(content) != "secret" {
+		t.Errorf("file outside root is changed: %q, %v", content, err)
+	}
+}
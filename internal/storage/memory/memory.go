@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"bytes"
+	"cloth-mini-app/internal/dto"
+	"cloth-mini-app/internal/storage/blob"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Blob storage in process memory. Files are lost on restart, intended for development and tests
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string]dto.FileDTO
+}
+
+// Create in-memory storage object
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		files: make(map[string]dto.FileDTO),
+	}
+}
+
+// Put file to store
+func (m *MemoryStorage) Put(ctx context.Context, file dto.FileDTO) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file.Buffer = append([]byte(nil), file.Buffer...)
+	m.files[file.ID] = file
+
+	return nil
+}
+
+// Put file read from r
+func (m *MemoryStorage) PutStream(ctx context.Context, info dto.FileInfo, r io.Reader) error {
+	buffer, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("storage.memory.PutStream: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[info.ID] = dto.FileDTO{
+		ID:          info.ID,
+		ContentType: info.ContentType,
+		Buffer:      buffer,
+	}
+
+	return nil
+}
+
+// Open file for reading
+func (m *MemoryStorage) Open(ctx context.Context, objectId string) (io.ReadCloser, dto.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[objectId]
+	if !ok {
+		return nil, dto.FileInfo{}, fmt.Errorf("storage.memory.Open: %w", blob.ErrObjectNotFound)
+	}
+
+	// buffer is replaced on put, so it's read without copying
+	return io.NopCloser(bytes.NewReader(file.Buffer)), dto.FileInfo{
+		ID:          objectId,
+		ContentType: file.ContentType,
+		Size:        int64(len(file.Buffer)),
+	}, nil
+}
+
+// Get file from storage
+func (m *MemoryStorage) Get(ctx context.Context, objectId string) (dto.FileDTO, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[objectId]
+	if !ok {
+		return dto.FileDTO{}, fmt.Errorf("storage.memory.Get: %w", blob.ErrObjectNotFound)
+	}
+
+	file.Buffer = append([]byte(nil), file.Buffer...)
+
+	return file, nil
+}
+
+// Get many files from storage
+// Use worker pull (see blob.GetMany)
+func (m *MemoryStorage) GetMany(ctx context.Context, objectIds []string) ([]dto.FileDTO, error) {
+	return blob.GetMany(ctx, m.Get, objectIds)
+}
+
+// Get file info
+func (m *MemoryStorage) Stat(ctx context.Context, objectId string) (dto.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[objectId]
+	if !ok {
+		return dto.FileInfo{}, fmt.Errorf("storage.memory.Stat: %w", blob.ErrObjectNotFound)
+	}
+
+	return dto.FileInfo{
+		ID:          objectId,
+		ContentType: file.ContentType,
+		Size:        int64(len(file.Buffer)),
+	}, nil
+}
+
+// Get first length bytes of file
+func (m *MemoryStorage) GetHead(ctx context.Context, objectId string, length int64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[objectId]
+	if !ok {
+		return nil, fmt.Errorf("storage.memory.GetHead: %w", blob.ErrObjectNotFound)
+	}
+
+	return append([]byte(nil), file.Buffer[:min(length, int64(len(file.Buffer)))]...), nil
+}
+
+// Files are not served by in-memory storage, so direct upload isn't possible
+func (m *MemoryStorage) PresignedPut(ctx context.Context, objectId string, contentType string, size int64, expires time.Duration) (string, error) {
+	return "", fmt.Errorf("storage.memory.PresignedPut: %w", blob.ErrNotSupported)
+}
+
+// Files are not served by in-memory storage, they are downloaded through app
+func (m *MemoryStorage) PresignedGet(ctx context.Context, objectId string, fileName string, expires time.Duration) (string, error) {
+	return "", fmt.Errorf("storage.memory.PresignedGet: %w", blob.ErrNotSupported)
+}
+
+// Delete file from storage
+func (m *MemoryStorage) Delete(ctx context.Context, objectId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, objectId)
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"cloth-mini-app/internal/dto"
+	"cloth-mini-app/internal/storage/blob"
+	"cloth-mini-app/internal/storage/blob/blobtest"
+	"context"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	blobtest.Run(t, func(t *testing.T) blob.Storage {
+		return NewMemoryStorage()
+	})
+}
+
+func TestStoredFileIsCopied(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	buffer := []byte("data")
+	if err := storage.Put(ctx, dto.FileDTO{ID: "file", Buffer: buffer}); err != nil {
+		t.Fatal(err)
+	}
+	buffer[0] = 'x'
+
+	file, err := storage.Get(ctx, "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Buffer[1] = 'x'
+
+	again, err := storage.Get(ctx, "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again.Buffer) != "data" {
+		t.Errorf("expected stored file not to change, got %q", again.Buffer)
+	}
+}
@@ -4,17 +4,21 @@ import (
 	"bytes"
 	"cloth-mini-app/internal/config"
 	"cloth-mini-app/internal/dto"
+	"cloth-mini-app/internal/storage/blob"
 	"context"
 	"fmt"
 	"io"
-	"sync"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
 const (
-	ImageContentType = "image/jpeg"
+	noSuchKeyCode = "NoSuchKey"
 )
 
 type MinioClient struct {
@@ -68,6 +72,46 @@ func (m *MinioClient) Put(ctx context.Context, file dto.FileDTO) error {
 	return nil
 }
 
+// Put file of info.Size bytes read from r
+func (m *MinioClient) PutStream(ctx context.Context, info dto.FileInfo, r io.Reader) error {
+	const op = "storage.minio.PutStream"
+
+	_, err := m.cl.PutObject(ctx, m.bucketName, info.ID, r, info.Size, minio.PutObjectOptions{
+		ContentType: info.ContentType,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Open file for reading, reader must be closed
+func (m *MinioClient) Open(ctx context.Context, objectId string) (io.ReadCloser, dto.FileInfo, error) {
+	const op = "storage.minio.Open"
+
+	obj, err := m.cl.GetObject(ctx, m.bucketName, objectId, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, dto.FileInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	objInfo, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == noSuchKeyCode {
+			return nil, dto.FileInfo{}, fmt.Errorf("%s: %w", op, blob.ErrObjectNotFound)
+		}
+
+		return nil, dto.FileInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return obj, dto.FileInfo{
+		ID:          objectId,
+		ContentType: objInfo.ContentType,
+		Size:        objInfo.Size,
+	}, nil
+}
+
 // Get file from storage
 func (m *MinioClient) Get(ctx context.Context, objectId string) (dto.FileDTO, error) {
 	const op = "storage.minio.GetImage"
@@ -80,11 +124,15 @@ func (m *MinioClient) Get(ctx context.Context, objectId string) (dto.FileDTO, er
 
 	objInfo, err := obj.Stat()
 	if err != nil {
+		if minio.ToErrorResponse(err).Code == noSuchKeyCode {
+			return dto.FileDTO{}, fmt.Errorf("%s: %w", op, blob.ErrObjectNotFound)
+		}
+
 		return dto.FileDTO{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	buffer := make([]byte, objInfo.Size)
-	_, err = obj.Read(buffer)
+	_, err = io.ReadFull(obj, buffer)
 	if err != nil && err != io.EOF {
 		return dto.FileDTO{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -97,63 +145,87 @@ func (m *MinioClient) Get(ctx context.Context, objectId string) (dto.FileDTO, er
 }
 
 // Get many files from storage
-// Use worker pull with 10 workers (amount workers - workersCnt)
+// Use worker pull (see blob.GetMany)
 func (m *MinioClient) GetMany(ctx context.Context, objectIds []string) ([]dto.FileDTO, error) {
-	_, cancel := context.WithCancel(ctx)
+	return blob.GetMany(ctx, m.Get, objectIds)
+}
 
-	workersCnt := 10
-	var worker = func(objectIdCh <-chan string, fileCh chan<- dto.FileDTO, errCh chan<- error, wg *sync.WaitGroup) {
-		for id := range objectIdCh {
-			defer wg.Done()
+// Get presigned url for direct upload to storage.
+// Content type and content length are signed, so upload must be done with exactly the same headers
+func (m *MinioClient) PresignedPut(ctx context.Context, objectId string, contentType string, size int64, expires time.Duration) (string, error) {
+	const op = "storage.minio.PresignedPut"
 
-			file, err := m.Get(ctx, id)
-			if err != nil {
-				errCh <- err
-				cancel()
-				return
-			}
-			fileCh <- file
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Length", strconv.FormatInt(size, 10))
 
-		}
+	u, err := m.cl.PresignHeader(ctx, http.MethodPut, m.bucketName, objectId, expires, nil, headers)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	var wg sync.WaitGroup
-	objectIdCh := make(chan string, len(objectIds))
-	fileCh := make(chan dto.FileDTO, len(objectIds))
-	errCh := make(chan error, len(objectIds))
+	return u.String(), nil
+}
+
+// Get presigned url for direct download, file is saved by browser as fileName
+func (m *MinioClient) PresignedGet(ctx context.Context, objectId string, fileName string, expires time.Duration) (string, error) {
+	const op = "storage.minio.PresignedGet"
+
+	params := url.Values{}
+	params.Set("response-content-disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
 
-	for range workersCnt {
-		go worker(objectIdCh, fileCh, errCh, &wg)
+	u, err := m.cl.PresignedGetObject(ctx, m.bucketName, objectId, expires, params)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	for _, id := range objectIds {
-		wg.Add(1)
-		objectIdCh <- id
+	return u.String(), nil
+}
+
+// Get file info without downloading file
+func (m *MinioClient) Stat(ctx context.Context, objectId string) (dto.FileInfo, error) {
+	const op = "storage.minio.Stat"
+
+	info, err := m.cl.StatObject(ctx, m.bucketName, objectId, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == noSuchKeyCode {
+			return dto.FileInfo{}, fmt.Errorf("%s: %w", op, blob.ErrObjectNotFound)
+		}
+
+		return dto.FileInfo{}, fmt.Errorf("%s: %w", op, err)
 	}
-	close(objectIdCh)
 
-	go func() {
-		wg.Wait()
-		close(fileCh)
-		close(errCh)
-	}()
+	return dto.FileInfo{
+		ID:          objectId,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+	}, nil
+}
+
+// Get first length bytes of file
+func (m *MinioClient) GetHead(ctx context.Context, objectId string, length int64) ([]byte, error) {
+	const op = "storage.minio.GetHead"
 
-	files := make([]dto.FileDTO, 0, len(objectIds))
-	errors := make([]error, 0)
-	for file := range fileCh {
-		files = append(files, file)
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(0, length-1); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	for err := range errCh {
-		errors = append(errors, err)
+
+	obj, err := m.cl.GetObject(ctx, m.bucketName, objectId, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer obj.Close()
 
-	if len(errors) > 0 {
-		return nil, fmt.Errorf("failed getting files: err list: %v", errors)
+	buffer, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return files, nil
+	return buffer, nil
 }
 
+// Delete file from storage
 func (m *MinioClient) Delete(ctx context.Context, fileId string) error {
 	err := m.cl.RemoveObject(context.Background(), m.bucketName, fileId, minio.RemoveObjectOptions{})
 	if err != nil {
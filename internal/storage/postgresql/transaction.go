@@ -15,14 +15,18 @@ var (
 	ctxTxKey = txKey("tx")
 )
 
+// Query executor, implemented by *sql.DB and *sql.Tx
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Run process in transaction. If context already has transaction, process joins it
+// and transaction is committed by its owner
 func WrapTx(ctx context.Context, db *sql.DB, process func(context.Context) error) error {
-	txStarted := txExist(ctx)
-	fmt.Println("transact exist ", txStarted)
-	if txStarted {
-		err := process(ctx)
-		if err != nil {
-			return err
-		}
+	if txExist(ctx) {
+		return process(ctx)
 	}
 
 	tx, err := db.BeginTx(ctx, nil)
@@ -34,7 +38,6 @@ func WrapTx(ctx context.Context, db *sql.DB, process func(context.Context) error
 	ctx = context.WithValue(ctx, ctxTxKey, tx)
 
 	err = process(ctx)
-	fmt.Println("wrapper ", err)
 	if err != nil {
 		return err
 	}
@@ -60,3 +63,12 @@ func TxFromCtx(ctx context.Context) (*sql.Tx, bool) {
 
 	return tx, true
 }
+
+// Get transaction from context, so query is a part of it, or db if there is no transaction
+func Conn(ctx context.Context, db *sql.DB) Executor {
+	if tx, ok := TxFromCtx(ctx); ok {
+		return tx
+	}
+
+	return db
+}
@@ -0,0 +1,252 @@
+// Package xlsx reads and writes tables of Office Open XML workbooks. Only cell values are supported:
+// styles, formulas and dates formatting are ignored, cell is returned as stored value
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// max unpacked size of workbook part, protects from zip bombs
+	maxPartSize = 64 << 20
+	// used by workbook without relationships
+	defaultSheet = "xl/worksheets/sheet1.xml"
+)
+
+var (
+	ErrInvalid  = errors.New("file isn't xlsx workbook")
+	ErrRowLimit = errors.New("sheet has more rows than allowed")
+)
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// Text of shared string or inline string, rich text is stored in runs
+type text struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t text) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+
+	var s strings.Builder
+	for _, run := range t.Runs {
+		s.WriteString(run.T)
+	}
+
+	return s.String()
+}
+
+type sharedStrings struct {
+	Items []text `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline text   `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// Read rows of the first sheet. Missing cells are empty strings, missing rows are empty, so
+// index of row is its number in sheet minus one. Rows after limit aren't read, ErrRowLimit is returned
+func ReadRows(r io.ReaderAt, size int64, limit int) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var strs sharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(file, &strs); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetName, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := files[sheetName]
+	if !ok {
+		return nil, fmt.Errorf("%w: sheet %s not found", ErrInvalid, sheetName)
+	}
+
+	var sheet worksheet
+	if err := decodePart(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		number := row.R
+		if number == 0 {
+			number = len(rows) + 1
+		}
+		if number > limit {
+			return nil, ErrRowLimit
+		}
+		if number < len(rows)+1 {
+			return nil, fmt.Errorf("%w: rows aren't sorted", ErrInvalid)
+		}
+		for len(rows) < number-1 {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for _, cell := range row.Cells {
+			idx := len(cells)
+			if cell.R != "" {
+				idx, err = columnIndex(cell.R)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if idx < len(cells) {
+				return nil, fmt.Errorf("%w: cells aren't sorted", ErrInvalid)
+			}
+
+			value, err := cellValue(cell.T, cell.V, cell.Inline, strs)
+			if err != nil {
+				return nil, err
+			}
+
+			for len(cells) < idx {
+				cells = append(cells, "")
+			}
+			cells = append(cells, value)
+		}
+
+		rows = append(rows, cells)
+	}
+
+	return rows, nil
+}
+
+func cellValue(cellType, value string, inline text, strs sharedStrings) (string, error) {
+	switch cellType {
+	case "s":
+		idx, err := strconv.Atoi(value)
+		if err != nil || idx < 0 || idx >= len(strs.Items) {
+			return "", fmt.Errorf("%w: invalid shared string %q", ErrInvalid, value)
+		}
+		return strs.Items[idx].String(), nil
+	case "inlineStr":
+		return inline.String(), nil
+	case "b":
+		if value == "1" {
+			return "true", nil
+		}
+		return "false", nil
+	}
+
+	// numbers, formula strings and errors are stored as is
+	return value, nil
+}
+
+// Get name of the first sheet part by workbook relationships
+func firstSheet(files map[string]*zip.File) (string, error) {
+	bookFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: workbook not found", ErrInvalid)
+	}
+
+	var book workbook
+	if err := decodePart(bookFile, &book); err != nil {
+		return "", err
+	}
+	if len(book.Sheets) == 0 {
+		return "", fmt.Errorf("%w: workbook has no sheets", ErrInvalid)
+	}
+
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return defaultSheet, nil
+	}
+
+	var rels relationships
+	if err := decodePart(relsFile, &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.Id != book.Sheets[0].Id {
+			continue
+		}
+		// target is relative to workbook part or absolute in package
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return defaultSheet, nil
+}
+
+func decodePart(file *zip.File, v any) error {
+	part, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+	defer part.Close()
+
+	err = xml.NewDecoder(io.LimitReader(part, maxPartSize)).Decode(v)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalid, file.Name, err)
+	}
+
+	return nil
+}
+
+// Get zero based column index from cell reference, e.g. B3 => 1
+func columnIndex(ref string) (int, error) {
+	idx := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		idx = idx*26 + int(r-'A'+1)
+		letters++
+	}
+	// xlsx has at most 16384 columns (XFD)
+	if letters == 0 || letters > 3 || idx > 16384 {
+		return 0, fmt.Errorf("%w: invalid cell reference %q", ErrInvalid, ref)
+	}
+
+	return idx - 1, nil
+}
@@ -0,0 +1,122 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const (
+	testWorkbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Items" sheetId="1" r:id="rId3"/></sheets>
+</workbook>`
+	testRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/items.xml"/>
+</Relationships>`
+	testStrings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="3" uniqueCount="3">
+<si><t>name</t></si>
+<si><t>price</t></si>
+<si><r><t>Футболка </t></r><r><t>базовая</t></r></si>
+</sst>`
+	testSheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>sale</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>1990</v></c><c r="D2" t="b"><v>1</v></c></row>
+<row r="4"><c r="B4" t="str"><v>10</v></c></row>
+</sheetData>
+</worksheet>`
+)
+
+func testFile(t *testing.T, parts map[string]string) *bytes.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range parts {
+		part, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadRows(t *testing.T) {
+	file := testFile(t, map[string]string{
+		"xl/workbook.xml":            testWorkbook,
+		"xl/_rels/workbook.xml.rels": testRels,
+		"xl/sharedStrings.xml":       testStrings,
+		"xl/worksheets/items.xml":    testSheet,
+	})
+
+	rows, err := ReadRows(file, file.Size(), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := [][]string{
+		{"name", "price", "", "sale"},
+		{"Футболка базовая", "1990", "", "true"},
+		nil,
+		{"", "10"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("expected rows %q, got %q", expected, rows)
+	}
+}
+
+func TestReadRowsLimit(t *testing.T) {
+	file := testFile(t, map[string]string{
+		"xl/workbook.xml":            testWorkbook,
+		"xl/_rels/workbook.xml.rels": testRels,
+		"xl/sharedStrings.xml":       testStrings,
+		"xl/worksheets/items.xml":    testSheet,
+	})
+
+	_, err := ReadRows(file, file.Size(), 3)
+	if !errors.Is(err, ErrRowLimit) {
+		t.Fatalf("expected row limit error, got %v", err)
+	}
+}
+
+func TestReadRowsInvalid(t *testing.T) {
+	notZip := bytes.NewReader([]byte("name,price\n"))
+	if _, err := ReadRows(notZip, notZip.Size(), 10); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid error for csv, got %v", err)
+	}
+
+	noWorkbook := testFile(t, map[string]string{"xl/worksheets/sheet1.xml": testSheet})
+	if _, err := ReadRows(noWorkbook, noWorkbook.Size(), 10); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid error without workbook, got %v", err)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	cases := map[string]int{"A1": 0, "Z10": 25, "AA3": 26, "XFD1": 16383}
+	for ref, expected := range cases {
+		idx, err := columnIndex(ref)
+		if err != nil || idx != expected {
+			t.Errorf("%s: expected %d, got %d (%v)", ref, expected, idx, err)
+		}
+	}
+
+	for _, ref := range []string{"1", "XFE1", "AAAA1"} {
+		if _, err := columnIndex(ref); err == nil {
+			t.Errorf("%s: expected error", ref)
+		}
+	}
+}
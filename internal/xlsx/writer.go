@@ -0,0 +1,179 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxColumns = 16384
+
+var (
+	ErrClosed = errors.New("workbook is already closed")
+
+	// static parts of workbook with one sheet
+	staticParts = []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+	}
+)
+
+// Writer streams rows of one sheet to workbook. Strings are stored inline instead of shared strings table,
+// so rows aren't kept in memory. Close must be called to finish workbook
+type Writer struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	rows    int
+	closed  bool
+}
+
+// Start workbook with one sheet named sheetName in w
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range staticParts {
+		if err := writePart(archive, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+	err := writePart(archive, "xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="`+name.String()+`" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		archive: archive,
+		sheet:   sheet,
+	}, nil
+}
+
+// Write row of cells. Integers and floats are stored as numbers, bools as booleans,
+// nil as empty cell and other values as strings
+func (w *Writer) WriteRow(cells []any) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(cells) > maxColumns {
+		return fmt.Errorf("row has %d cells, max is %d", len(cells), maxColumns)
+	}
+
+	w.rows++
+	row := strconv.Itoa(w.rows)
+
+	var buf strings.Builder
+	buf.WriteString(`<row r="` + row + `">`)
+	for idx, cell := range cells {
+		ref := columnName(idx) + row
+
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			fmt.Fprintf(&buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float32:
+			buf.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(float64(v), 'g', -1, 32) + `</v></c>`)
+		case float64:
+			buf.WriteString(`<c r="` + ref + `"><v>` + strconv.FormatFloat(v, 'g', -1, 64) + `</v></c>`)
+		case bool:
+			value := "0"
+			if v {
+				value = "1"
+			}
+			buf.WriteString(`<c r="` + ref + `" t="b"><v>` + value + `</v></c>`)
+		default:
+			buf.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(&buf, []byte(validText(fmt.Sprint(v)))); err != nil {
+				return err
+			}
+			buf.WriteString(`</t></is></c>`)
+		}
+	}
+	buf.WriteString(`</row>`)
+
+	_, err := io.WriteString(w.sheet, buf.String())
+
+	return err
+}
+
+// Finish sheet and workbook. Underlying writer isn't closed
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	return w.archive.Close()
+}
+
+func writePart(archive *zip.Writer, name, content string) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+
+	return err
+}
+
+// Get column letters from zero based index, e.g. 1 => B
+func columnName(idx int) string {
+	var name []byte
+	for idx++; idx > 0; idx = (idx - 1) / 26 {
+		name = append([]byte{byte('A' + (idx-1)%26)}, name...)
+	}
+
+	return string(name)
+}
+
+// Drop characters which can't be stored in xml: invalid utf-8 and control characters except tab and new lines
+func validText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || r == 0xFFFE || r == 0xFFFF {
+			return -1
+		}
+		return r
+	}, s)
+}
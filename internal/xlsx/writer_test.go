@@ -0,0 +1,57 @@
+package xlsx
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRows(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Items & more")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := [][]any{
+		{"name", "price", "sale"},
+		{"Футболка <базовая> & \"новая\"", 1990, true},
+		{"control\x01char", 10.5, nil, false},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteRow([]any{"late"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+
+	read, err := ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 10)
+	if err != nil {
+		t.Fatalf("written workbook isn't read: %s", err)
+	}
+
+	expected := [][]string{
+		{"name", "price", "sale"},
+		{"Футболка <базовая> & \"новая\"", "1990", "true"},
+		{"controlchar", "10.5", "", "false"},
+	}
+	if !reflect.DeepEqual(read, expected) {
+		t.Fatalf("expected rows %q, got %q", expected, read)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for _, idx := range []int{0, 25, 26, 701, 702, 16383} {
+		idx2, err := columnIndex(columnName(idx) + "1")
+		if err != nil || idx2 != idx {
+			t.Errorf("%d: column %s is read as %d (%v)", idx, columnName(idx), idx2, err)
+		}
+	}
+}
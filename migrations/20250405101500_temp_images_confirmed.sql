@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE public.temp_images ADD COLUMN IF NOT EXISTS confirmed boolean NOT NULL DEFAULT true;
+
+-- Column comments
+COMMENT ON COLUMN public.temp_images.confirmed IS 'false - файл ожидает загрузки по presigned url и проверки';
+
+-- +goose Down
+ALTER TABLE public.temp_images DROP COLUMN IF EXISTS confirmed;
@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE public.images
+    ADD COLUMN IF NOT EXISTS blurhash text NULL,
+    ADD COLUMN IF NOT EXISTS dominant_color text NULL,
+    ADD COLUMN IF NOT EXISTS width int NULL,
+    ADD COLUMN IF NOT EXISTS height int NULL;
+
+ALTER TABLE public.temp_images
+    ADD COLUMN IF NOT EXISTS blurhash text NULL,
+    ADD COLUMN IF NOT EXISTS dominant_color text NULL,
+    ADD COLUMN IF NOT EXISTS width int NULL,
+    ADD COLUMN IF NOT EXISTS height int NULL;
+
+-- Column comments
+COMMENT ON COLUMN public.images.dominant_color IS 'Преобладающий цвет в формате #rrggbb';
+
+-- +goose Down
+ALTER TABLE public.temp_images
+    DROP COLUMN IF EXISTS blurhash,
+    DROP COLUMN IF EXISTS dominant_color,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS height;
+
+ALTER TABLE public.images
+    DROP COLUMN IF EXISTS blurhash,
+    DROP COLUMN IF EXISTS dominant_color,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS height;
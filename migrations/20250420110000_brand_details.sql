@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE public.brand
+    ADD COLUMN IF NOT EXISTS slug text NULL,
+    ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS country text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS logo_id text NULL;
+
+UPDATE public.brand SET slug = trim(both '-' from lower(regexp_replace(name, '[^a-zA-Z0-9]+', '-', 'g'))) WHERE slug IS NULL;
+-- names without latin letters and digits (e.g. cyrillic) give empty slug,
+-- they are transliterated as by api with cmd/brand-slug-backfill
+UPDATE public.brand SET slug = 'brand-' || id WHERE slug = '';
+-- names differing only in punctuation give the same slug, it's kept by the oldest brand
+UPDATE public.brand b SET slug = b.slug || '-' || b.id
+FROM public.brand other
+WHERE other.slug = b.slug AND other.id < b.id;
+
+ALTER TABLE public.brand ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE public.brand ADD CONSTRAINT brand_slug_unique UNIQUE (slug);
+
+-- Column comments
+COMMENT ON COLUMN public.brand.country IS 'Код страны ISO 3166-1 alpha-2';
+COMMENT ON COLUMN public.brand.logo_id IS 'object_id логотипа в хранилище изображений';
+
+-- +goose Down
+ALTER TABLE public.brand DROP CONSTRAINT IF EXISTS brand_slug_unique;
+ALTER TABLE public.brand
+    DROP COLUMN IF EXISTS slug,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS logo_id;
@@ -0,0 +1,66 @@
+-- +goose Up
+ALTER TABLE public.category
+    ADD COLUMN IF NOT EXISTS parent_id int NULL REFERENCES public.category (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT category_type_check CHECK (type IN (1, 2));
+
+CREATE INDEX IF NOT EXISTS category_parent_id_idx ON public.category (parent_id);
+
+-- Column comments
+COMMENT ON COLUMN public.category.type IS 'Вид категории - 1 - одежда, 2 - обувь. Совпадает у всего поддерева';
+COMMENT ON COLUMN public.category.parent_id IS 'Родительская категория, NULL у корневых';
+
+-- Category can't be removed with items
+ALTER TABLE public.items DROP CONSTRAINT IF EXISTS items_category_id_fkey;
+ALTER TABLE public.items ADD CONSTRAINT items_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.category (id) ON DELETE RESTRICT;
+
+ALTER TABLE public.items ADD COLUMN IF NOT EXISTS attributes jsonb NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN public.items.attributes IS 'Значения атрибутов категории: code => value';
+
+CREATE TABLE IF NOT EXISTS public.category_attribute (
+    id int GENERATED BY DEFAULT AS IDENTITY NOT NULL,
+    category_id int NOT NULL,
+    code text NOT NULL,
+    name text NOT NULL,
+    value_type text NOT NULL CHECK (value_type IN ('string', 'int', 'bool', 'enum')),
+    options text[] NOT NULL DEFAULT '{}',
+    required boolean NOT NULL DEFAULT false,
+    CONSTRAINT category_attribute_pk PRIMARY KEY (id),
+    CONSTRAINT category_attribute_code_unique UNIQUE (category_id, code),
+    FOREIGN KEY (category_id) REFERENCES public.category (id) ON DELETE CASCADE
+);
+
+COMMENT ON COLUMN public.category_attribute.options IS 'Допустимые значения для value_type = enum';
+
+-- Root categories
+INSERT INTO public.category (type, name) VALUES (1, 'Одежда');
+INSERT INTO public.category (type, name) VALUES (2, 'Обувь');
+
+UPDATE public.category c SET parent_id = r.id
+FROM public.category r
+WHERE r.parent_id IS NULL AND r.type = c.type AND r.name IN ('Одежда', 'Обувь') AND c.id <> r.id AND c.parent_id IS NULL;
+
+INSERT INTO public.category (type, name, parent_id)
+SELECT 1, 'Пуховики', id FROM public.category WHERE name = 'Верхняя одежда' AND type = 1;
+
+INSERT INTO public.category_attribute (category_id, code, name, value_type, options)
+SELECT id, 'sleeve_length', 'Длина рукава', 'enum', '{short,long,none}' FROM public.category WHERE name = 'Одежда' AND parent_id IS NULL;
+
+INSERT INTO public.category_attribute (category_id, code, name, value_type, options)
+SELECT id, 'sole_type', 'Тип подошвы', 'enum', '{rubber,eva,leather,pu}' FROM public.category WHERE name = 'Обувь' AND parent_id IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS public.category_attribute;
+
+ALTER TABLE public.items DROP COLUMN IF EXISTS attributes;
+
+ALTER TABLE public.items DROP CONSTRAINT IF EXISTS items_category_id_fkey;
+ALTER TABLE public.items ADD CONSTRAINT items_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.category (id) ON DELETE CASCADE;
+
+DELETE FROM public.category WHERE name = 'Пуховики' AND parent_id IS NOT NULL;
+UPDATE public.category SET parent_id = NULL;
+DELETE FROM public.category WHERE name IN ('Одежда', 'Обувь');
+
+ALTER TABLE public.category
+    DROP CONSTRAINT IF EXISTS category_type_check,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.api_key (
+    id serial PRIMARY KEY,
+    name text NOT NULL,
+    prefix text NOT NULL,
+    key_hash text NOT NULL,
+    scopes text[] NOT NULL DEFAULT '{}',
+    rate_limit int NOT NULL,
+    daily_quota int NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    revoked_at timestamptz NULL,
+    CONSTRAINT api_key_hash_unique UNIQUE (key_hash),
+    CONSTRAINT api_key_scopes_check CHECK (scopes <@ ARRAY['catalog:read', 'items:write', 'images:write']),
+    CONSTRAINT api_key_limits_check CHECK (rate_limit > 0 AND daily_quota > 0)
+);
+
+CREATE TABLE IF NOT EXISTS public.api_key_usage (
+    api_key_id int NOT NULL REFERENCES public.api_key (id) ON DELETE CASCADE,
+    day date NOT NULL,
+    requests int NOT NULL DEFAULT 0,
+    rejected int NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day)
+);
+
+-- Column comments
+COMMENT ON COLUMN public.api_key.prefix IS 'Начало ключа для отображения, сам ключ не хранится';
+COMMENT ON COLUMN public.api_key.key_hash IS 'sha256 ключа';
+COMMENT ON COLUMN public.api_key.rate_limit IS 'Запросов в минуту';
+COMMENT ON COLUMN public.api_key.daily_quota IS 'Запросов в сутки (UTC)';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.api_key_usage;
+DROP TABLE IF EXISTS public.api_key;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE public.items ADD COLUMN IF NOT EXISTS deleted_at timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS items_deleted_at_idx ON public.items (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE public.audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE public.audit_log ADD CONSTRAINT audit_log_action_check CHECK (action IN ('create', 'update', 'delete', 'restore'));
+
+-- Column comments
+COMMENT ON COLUMN public.items.deleted_at IS 'Время удаления в корзину, NULL для активного товара. Удаляется навсегда после срока хранения';
+
+-- +goose Down
+DELETE FROM public.audit_log WHERE action = 'restore';
+
+ALTER TABLE public.audit_log DROP CONSTRAINT IF EXISTS audit_log_action_check;
+ALTER TABLE public.audit_log ADD CONSTRAINT audit_log_action_check CHECK (action IN ('create', 'update', 'delete'));
+
+DELETE FROM public.items WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS items_deleted_at_idx;
+ALTER TABLE public.items DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- existing items are already live
+ALTER TABLE public.items ADD COLUMN IF NOT EXISTS status int NOT NULL DEFAULT 3;
+ALTER TABLE public.items ALTER COLUMN status SET DEFAULT 1;
+ALTER TABLE public.items ADD COLUMN IF NOT EXISTS publish_at timestamptz NULL;
+ALTER TABLE public.items ADD COLUMN IF NOT EXISTS unpublish_at timestamptz NULL;
+
+UPDATE public.items SET publish_at = created_at WHERE status = 3 AND publish_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS items_status_idx ON public.items (status);
+CREATE INDEX IF NOT EXISTS items_publish_at_idx ON public.items (publish_at) WHERE status = 2;
+CREATE INDEX IF NOT EXISTS items_unpublish_at_idx ON public.items (unpublish_at) WHERE status = 3;
+
+-- Column comments
+COMMENT ON COLUMN public.items.status IS 'Статус публикации 1 - черновик, 2 - запланирован, 3 - опубликован, 4 - в архиве';
+COMMENT ON COLUMN public.items.publish_at IS 'Время публикации, для запланированного товара - когда он будет опубликован';
+COMMENT ON COLUMN public.items.unpublish_at IS 'Время снятия с публикации, товар переносится в архив';
+
+-- +goose Down
+DROP INDEX IF EXISTS items_unpublish_at_idx;
+DROP INDEX IF EXISTS items_publish_at_idx;
+DROP INDEX IF EXISTS items_status_idx;
+
+ALTER TABLE public.items DROP COLUMN IF EXISTS unpublish_at;
+ALTER TABLE public.items DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE public.items DROP COLUMN IF EXISTS status;
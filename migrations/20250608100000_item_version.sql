@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE public.items ADD COLUMN IF NOT EXISTS version int NOT NULL DEFAULT 1;
+
+-- Column comments
+COMMENT ON COLUMN public.items.version IS 'Версия товара, увеличивается при каждом изменении. Обновление с устаревшей версией отклоняется';
+
+-- +goose Down
+ALTER TABLE public.items DROP COLUMN IF EXISTS version;
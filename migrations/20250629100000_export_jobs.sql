@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.export_jobs (
+    id serial PRIMARY KEY,
+    format text NOT NULL,
+    filter jsonb NOT NULL DEFAULT '{}'::jsonb,
+    status text NOT NULL DEFAULT 'pending',
+    object_id text NULL,
+    items int NOT NULL DEFAULT 0,
+    size bigint NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    user_id int NULL REFERENCES public.users (id) ON DELETE SET NULL,
+    api_key_id int NULL REFERENCES public.api_key (id) ON DELETE SET NULL,
+    reserved_to timestamptz NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT export_jobs_format_check CHECK (format IN ('csv', 'ndjson', 'xlsx')),
+    CONSTRAINT export_jobs_status_check CHECK (status IN ('pending', 'running', 'done', 'failed'))
+);
+
+-- jobs waiting for background processing
+CREATE INDEX IF NOT EXISTS export_jobs_active_idx ON public.export_jobs (id) WHERE status IN ('pending', 'running');
+
+-- Column comments
+COMMENT ON COLUMN public.export_jobs.format IS 'Формат файла: csv, ndjson, xlsx';
+COMMENT ON COLUMN public.export_jobs.filter IS 'Фильтры товаров, как у GET /item/get';
+COMMENT ON COLUMN public.export_jobs.status IS 'Этап выгрузки: pending, running, done, failed';
+COMMENT ON COLUMN public.export_jobs.object_id IS 'Файл выгрузки в хранилище';
+COMMENT ON COLUMN public.export_jobs.items IS 'Выгружено товаров';
+COMMENT ON COLUMN public.export_jobs.size IS 'Размер файла в байтах';
+COMMENT ON COLUMN public.export_jobs.reserved_to IS 'Время, до которого задача обрабатывается экземпляром приложения';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.export_jobs;
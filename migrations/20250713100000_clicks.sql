@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.clicks (
+    id bigserial PRIMARY KEY,
+    item_id int NOT NULL REFERENCES public.items (id) ON DELETE CASCADE,
+    referrer text NOT NULL DEFAULT '',
+    ua_hash text NOT NULL,
+    utm_source text NULL,
+    utm_medium text NULL,
+    utm_campaign text NULL,
+    utm_content text NULL,
+    utm_term text NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS clicks_created_at_idx ON public.clicks (created_at);
+CREATE INDEX IF NOT EXISTS clicks_item_id_idx ON public.clicks (item_id, created_at);
+
+CREATE TABLE IF NOT EXISTS public.affiliate_templates (
+    domain text PRIMARY KEY,
+    params text NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Column comments
+COMMENT ON COLUMN public.clicks.referrer IS 'Страница, с которой перешли по ссылке';
+COMMENT ON COLUMN public.clicks.ua_hash IS 'sha256 User-Agent, сам User-Agent не хранится';
+COMMENT ON COLUMN public.affiliate_templates.domain IS 'Домен магазина, шаблон применяется и к поддоменам';
+COMMENT ON COLUMN public.affiliate_templates.params IS 'Query string с плейсхолдерами, добавляется к ссылке на магазин';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.affiliate_templates;
+DROP TABLE IF EXISTS public.clicks;
@@ -0,0 +1,73 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.price_history (
+    id bigserial PRIMARY KEY,
+    item_id int NOT NULL REFERENCES public.items (id) ON DELETE CASCADE,
+    price int NOT NULL,
+    discount int NOT NULL DEFAULT 0,
+    prev_price int NOT NULL,
+    prev_discount int NOT NULL DEFAULT 0,
+    source text NOT NULL,
+    user_id int NULL REFERENCES public.users (id) ON DELETE SET NULL,
+    api_key_id int NULL REFERENCES public.api_key (id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS price_history_item_id_idx ON public.price_history (item_id, created_at);
+
+CREATE TABLE IF NOT EXISTS public.scrape_rules (
+    domain text PRIMARY KEY,
+    price_selector text NOT NULL DEFAULT '',
+    old_price_selector text NOT NULL DEFAULT '',
+    availability_selector text NOT NULL DEFAULT '',
+    in_stock_text text NOT NULL DEFAULT '',
+    auto_apply boolean NOT NULL DEFAULT false,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.scrape_results (
+    item_id int PRIMARY KEY REFERENCES public.items (id) ON DELETE CASCADE,
+    url text NOT NULL,
+    price int NULL,
+    discount int NULL,
+    available boolean NULL,
+    extractor text NOT NULL DEFAULT '',
+    error text NOT NULL DEFAULT '',
+    scraped_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS scrape_results_scraped_at_idx ON public.scrape_results (scraped_at);
+
+CREATE TABLE IF NOT EXISTS public.price_proposals (
+    id bigserial PRIMARY KEY,
+    item_id int NOT NULL REFERENCES public.items (id) ON DELETE CASCADE,
+    url text NOT NULL,
+    price int NOT NULL,
+    discount int NOT NULL DEFAULT 0,
+    available boolean NULL,
+    version int NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    resolved_at timestamptz NULL
+);
+
+-- only the latest scraped price of item waits for review
+CREATE UNIQUE INDEX IF NOT EXISTS price_proposals_pending_idx ON public.price_proposals (item_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS price_proposals_status_idx ON public.price_proposals (status, created_at);
+
+-- Column comments
+COMMENT ON COLUMN public.price_history.source IS 'Откуда изменение цены: editor, scraper, rollback';
+COMMENT ON COLUMN public.price_history.prev_price IS 'Цена до изменения';
+COMMENT ON COLUMN public.scrape_rules.domain IS 'Домен магазина, правило применяется и к поддоменам';
+COMMENT ON COLUMN public.scrape_rules.price_selector IS 'CSS селектор цены, используется если на странице нет JSON-LD Product';
+COMMENT ON COLUMN public.scrape_rules.old_price_selector IS 'CSS селектор цены без скидки';
+COMMENT ON COLUMN public.scrape_rules.in_stock_text IS 'Текст элемента наличия, означающий что товар в наличии';
+COMMENT ON COLUMN public.scrape_rules.auto_apply IS 'Применять найденные цены без проверки редактором';
+COMMENT ON COLUMN public.scrape_results.extractor IS 'Чем найдена цена: jsonld, selectors или свой extractor домена';
+COMMENT ON COLUMN public.price_proposals.version IS 'Версия товара, для которой найдена цена';
+COMMENT ON COLUMN public.price_proposals.status IS 'pending, applied, rejected';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.price_proposals;
+DROP TABLE IF EXISTS public.scrape_results;
+DROP TABLE IF EXISTS public.scrape_rules;
+DROP TABLE IF EXISTS public.price_history;
@@ -0,0 +1,116 @@
+import { requireLogin, logout, authFetch } from './auth.js';
+
+const LIMIT = 20
+
+// Параметры периода из формы
+function statsParams() {
+    const params = new URLSearchParams();
+
+    params.append('days', document.getElementById('days-search').value);
+    params.append('limit', LIMIT);
+
+    return params
+}
+
+async function fetchStats(path, params) {
+    const url = `http://localhost:8081/analytics/${path}?${params.toString()}`;
+    const response = await authFetch(url)
+
+    if (!response.ok) {
+        throw new Error(`fetchStats Ошибка HTTP: ${response.status}`)
+    }
+
+    return response.json()
+}
+
+function escapeHTML(text) {
+    const div = document.createElement('div')
+    div.textContent = text
+
+    return div.innerHTML
+}
+
+// Получение и отрисовка всей статистики
+async function fetchAnalytics() {
+    try {
+        const searchParams = statsParams()
+        if (document.getElementById('zero-search').value) {
+            searchParams.append('zero_results', 'true');
+        }
+
+        const [popular, trending, searches] = await Promise.all([
+            fetchStats('items/popular', statsParams()),
+            fetchStats('items/trending', statsParams()),
+            fetchStats('searches', searchParams),
+        ])
+
+        renderPopular(popular)
+        renderTrending(trending)
+        renderSearches(searches)
+    } catch (error) {
+        console.error('fetchAnalytics Ошибка', error.message, error)
+    }
+}
+
+function renderPopular(items) {
+    const container = document.getElementById("popular-body")
+
+    container.innerHTML = ''
+
+    items.forEach((item) => {
+        const row = `
+        <tr>
+            <td><a href="/admin/update/${item.item_id}">${item.item_id}</a></td>
+            <td>${escapeHTML(item.name)}</td>
+            <td>${item.views}</td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+}
+
+function renderTrending(items) {
+    const container = document.getElementById("trending-body")
+
+    container.innerHTML = ''
+
+    items.forEach((item) => {
+        const row = `
+        <tr>
+            <td><a href="/admin/update/${item.item_id}">${item.item_id}</a></td>
+            <td>${escapeHTML(item.name)}</td>
+            <td>${item.views}</td>
+            <td>${item.prev_views}</td>
+            <td>+${item.velocity.toFixed(1)}</td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+}
+
+// Запросы без результатов выделены, в каталоге может не хватать товаров
+function renderSearches(queries) {
+    const container = document.getElementById("searches-body")
+
+    container.innerHTML = ''
+
+    queries.forEach((query) => {
+        const row = `
+        <tr class="${query.no_results ? 'no-results' : ''}">
+            <td>${escapeHTML(query.query)}</td>
+            <td>${query.searches}</td>
+            <td>${query.zero_results}</td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+}
+
+requireLogin()
+
+document.addEventListener('DOMContentLoaded', () => {
+    fetchAnalytics()
+
+    document.getElementById("search_btn").addEventListener('click', fetchAnalytics)
+    document.getElementById("logout_btn").addEventListener('click', logout)
+});
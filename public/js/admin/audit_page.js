@@ -0,0 +1,126 @@
+import { formatDate } from './date.js';
+import { requireLogin, logout, authFetch } from './auth.js';
+
+const LIMIT = 50
+
+let offset = 0
+let count = 0
+
+// Собирает фильтр журнала из формы
+function auditParams() {
+    const params = new URLSearchParams();
+
+    const fields = {
+        'entity_type': 'entity-type-search',
+        'entity_id': 'entity-id-search',
+        'action': 'action-search',
+        'user_id': 'user-search',
+    }
+    for (const [param, id] of Object.entries(fields)) {
+        if (document.getElementById(id).value) {
+            params.append(param, document.getElementById(id).value);
+        }
+    }
+
+    // datetime-local без часового пояса, переводим в RFC 3339
+    const periods = { 'from': 'from-search', 'to': 'to-search' }
+    for (const [param, id] of Object.entries(periods)) {
+        if (document.getElementById(id).value) {
+            params.append(param, new Date(document.getElementById(id).value).toISOString());
+        }
+    }
+
+    params.append('limit', LIMIT);
+    params.append('offset', offset);
+
+    return params
+}
+
+// Получение записей журнала
+async function fetchEntries() {
+    try {
+        const url = `http://localhost:8081/admin/audit?${auditParams().toString()}`;
+        const response = await authFetch(url)
+
+        if (!response.ok) {
+            throw new Error(`fetchEntries Ошибка HTTP: ${response.status}`)
+        }
+
+        const entries = await response.json()
+        count = entries.count
+        renderEntries(entries.entries)
+    } catch (error) {
+        console.error('fetchEntries Ошибка', error.message, error)
+    }
+}
+
+function escapeHTML(text) {
+    const div = document.createElement('div')
+    div.textContent = text
+
+    return div.innerHTML
+}
+
+function renderState(state) {
+    if (!state) {
+        return ''
+    }
+
+    return `<pre><code>${escapeHTML(JSON.stringify(state, null, 2))}</code></pre>`
+}
+
+// Отрисовывает записи журнала
+function renderEntries(entries) {
+    const container = document.getElementById("audit-body")
+
+    container.innerHTML = ''
+
+    entries.forEach((entry) => {
+        const row = `
+        <tr>
+            <td>${formatDate(entry.created_at)}</td>
+            <td>${escapeHTML(entry.actor)}</td>
+            <td>${entry.action}</td>
+            <td>${entry.entity_type} ${escapeHTML(entry.entity_id)}</td>
+            <td>${renderState(entry.before)}</td>
+            <td>${renderState(entry.after)}</td>
+            <td>${escapeHTML(entry.request_id)}</td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+}
+
+function search() {
+    offset = 0
+    fetchEntries()
+}
+
+function fetchNextEntries() {
+    if (count !== LIMIT) {
+        return
+    }
+
+    offset += LIMIT
+    fetchEntries()
+}
+
+function fetchPrevEntries() {
+    if (offset === 0) {
+        return
+    }
+
+    offset -= LIMIT
+    fetchEntries()
+}
+
+requireLogin()
+
+document.addEventListener('DOMContentLoaded', () => {
+    fetchEntries()
+
+    document.getElementById("search_btn").addEventListener('click', search)
+    document.getElementById("next_btn").addEventListener('click', fetchNextEntries)
+    document.getElementById("prev_btn").addEventListener('click', fetchPrevEntries)
+    document.getElementById("logout_btn").addEventListener('click', logout)
+});
@@ -0,0 +1,106 @@
+const API_URL = 'http://localhost:8081'
+const LOGIN_PAGE = '/admin/login'
+
+const ACCESS_TOKEN_KEY = 'access_token'
+const REFRESH_TOKEN_KEY = 'refresh_token'
+
+function saveTokens(tokens) {
+    localStorage.setItem(ACCESS_TOKEN_KEY, tokens.access_token)
+    localStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token)
+}
+
+function clearTokens() {
+    localStorage.removeItem(ACCESS_TOKEN_KEY)
+    localStorage.removeItem(REFRESH_TOKEN_KEY)
+}
+
+function redirectToLogin() {
+    clearTokens()
+    window.location.replace(LOGIN_PAGE)
+}
+
+// Вход по логину и паролю. Возвращает текст ошибки или null
+export async function login(login, password) {
+    const response = await fetch(`${API_URL}/auth/login`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify({ login, password })
+    })
+
+    if (!response.ok) {
+        const error = await response.json()
+        return error.error
+    }
+
+    saveTokens(await response.json())
+    return null
+}
+
+export async function logout() {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY)
+    if (refreshToken) {
+        await fetch(`${API_URL}/auth/logout`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ refresh_token: refreshToken })
+        }).catch((error) => console.error('logout Ошибка', error.message))
+    }
+
+    redirectToLogin()
+}
+
+// Обмен refresh токена на новую пару. Refresh токен одноразовый
+async function refresh() {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY)
+    if (!refreshToken) {
+        return false
+    }
+
+    const response = await fetch(`${API_URL}/auth/refresh`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify({ refresh_token: refreshToken })
+    })
+    if (!response.ok) {
+        return false
+    }
+
+    saveTokens(await response.json())
+    return true
+}
+
+function withToken(options) {
+    const headers = new Headers(options.headers)
+    headers.set('Authorization', `Bearer ${localStorage.getItem(ACCESS_TOKEN_KEY)}`)
+
+    return { ...options, headers }
+}
+
+// fetch с access токеном. При истекшем токене обновляет его и повторяет запрос,
+// если обновить не удалось - переход на страницу входа
+export async function authFetch(url, options = {}) {
+    let response = await fetch(url, withToken(options))
+    if (response.status !== 401) {
+        return response
+    }
+
+    if (!await refresh()) {
+        redirectToLogin()
+        return response
+    }
+
+    return fetch(url, withToken(options))
+}
+
+// Страницы админки доступны только после входа
+export function requireLogin() {
+    if (!localStorage.getItem(ACCESS_TOKEN_KEY)) {
+        redirectToLogin()
+    }
+}
@@ -1,6 +1,7 @@
 import { optionBrands } from './brand.js';
 import { optionCategory } from './category.js';
 import { getImage } from './image.js';
+import { authFetch, requireLogin } from './auth.js';
 
 const IMAGE_GALLERY_WIDHT = 323
 const IMAGE_GALLERY_HEIGHT = 430
@@ -14,12 +15,13 @@ let imagesIds = [];
  * @property {number} brand_id - Идентификатор бренда
  * @property {string} brandName - Название бренда
  * @property {string} category_id - Идентификатор категории товара
- * @property {number} sex - Пол (1 - муж, 2 - жен, 3 - уни)
+ * @property {string} sex - Пол (male, female, unisex)
  * @property {number} price - Цена товара
  * @property {number} discount - Процент скидки
  * @property {string} description - Описание товара
  * @property {string} outer_link - ссылка на товар
  * @property {array} temp_images - галлерия изображений
+ * @property {string} status - статус публикации, черновик не виден в каталоге
  */
 async function create() {
     /**
@@ -29,16 +31,17 @@ async function create() {
         brand_id: parseInt(document.getElementById('brand').value),
         name: document.getElementById('item-name').value,
         category_id: parseInt(document.getElementById('category').value),
-        sex: parseInt(document.getElementById('gender').value),
+        sex: document.getElementById('gender').value,
         price: parseInt(document.getElementById('price').value),
         discount: parseInt(document.getElementById('discount').value),
         description: document.getElementById('description').value,
         outer_link: document.getElementById('outer-link').value,
         temp_images: Object.keys(imagesIds),
+        status: document.getElementById('status').value,
     }
 
     try {
-        const response = await fetch(`http://localhost:8081/item/create`, {
+        const response = await authFetch(`http://localhost:8081/item/create`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -64,7 +67,7 @@ async function uploadImage(event) {
     formData.append('uuid', crypto.randomUUID())
 
     try {
-        const response = await fetch(`http://localhost:8081/image/temp`, {
+        const response = await authFetch(`http://localhost:8081/image/temp`, {
             method: 'POST',
             body: formData
         });
@@ -142,6 +145,8 @@ function createDeleteBtn(image) {
     container.appendChild(div);
 }
 
+requireLogin()
+
 document.addEventListener('DOMContentLoaded', () => {
     optionCategory(document.getElementById("category"))
     optionBrands(document.getElementById("brand"))
@@ -0,0 +1,177 @@
+import { formatDate } from './date.js';
+import { requireLogin, logout, authFetch } from './auth.js';
+
+const POLL_INTERVAL = 2000
+
+const STATUS_NAMES = {
+    validating: 'Проверка строк',
+    validated: 'Проверено, ожидает импорта',
+    importing: 'Создание товаров',
+    done: 'Завершен',
+    failed: 'Ошибка'
+}
+
+let pollTimer = null
+
+// Загрузка файла и создание задачи импорта
+async function uploadFile() {
+    const errorField = document.getElementById('upload-error')
+    errorField.textContent = ''
+
+    const file = document.getElementById('import-file').files[0]
+    if (!file) {
+        errorField.textContent = 'Выберите файл'
+        return
+    }
+
+    const formData = new FormData()
+    formData.append('file', file)
+    formData.append('dry_run', document.getElementById('dry-run').checked)
+    formData.append('create_brands', document.getElementById('create-brands').checked)
+
+    const mapping = document.getElementById('import-mapping').value.trim()
+    if (mapping !== '') {
+        formData.append('mapping', mapping)
+    }
+
+    try {
+        const response = await authFetch('http://localhost:8081/import/upload', {
+            method: 'POST',
+            body: formData
+        })
+
+        const result = await response.json()
+        if (!response.ok) {
+            const fields = (result.fields || []).map((field) => `${field.field}: ${field.message}`)
+            errorField.textContent = [result.error, ...fields].join('; ')
+            return
+        }
+
+        showJob(result.job_id)
+        fetchJobs()
+    } catch (error) {
+        console.error('uploadFile Ошибка', error.message, error)
+    }
+}
+
+// Получение задачи и повторный запрос, пока она обрабатывается
+async function showJob(id) {
+    clearTimeout(pollTimer)
+
+    try {
+        const response = await authFetch(`http://localhost:8081/import/jobs/${id}`)
+
+        if (!response.ok) {
+            throw new Error(`showJob Ошибка HTTP: ${response.status}`)
+        }
+
+        const job = await response.json()
+        renderJob(job)
+
+        if (job.status === 'validating' || job.status === 'importing') {
+            pollTimer = setTimeout(() => showJob(id), POLL_INTERVAL)
+        } else {
+            fetchJobs()
+        }
+    } catch (error) {
+        console.error('showJob Ошибка', error.message, error)
+    }
+}
+
+// Импорт проверенных строк
+async function commitJob(id) {
+    try {
+        const response = await authFetch(`http://localhost:8081/import/jobs/${id}/commit`, {
+            method: 'POST'
+        })
+
+        if (!response.ok) {
+            throw new Error(`commitJob Ошибка HTTP: ${response.status}`)
+        }
+
+        showJob(id)
+    } catch (error) {
+        console.error('commitJob Ошибка', error.message, error)
+    }
+}
+
+// Отрисовывает ход импорта и ошибки строк
+function renderJob(job) {
+    document.getElementById('job').hidden = false
+    document.getElementById('job-title').textContent = `Загрузка ${job.job_id}: ${job.file_name}`
+    document.getElementById('job-status').textContent = STATUS_NAMES[job.status] || job.status
+    document.getElementById('job-progress').textContent = `${job.processed} из ${job.total}`
+    document.getElementById('job-valid').textContent = job.valid
+    document.getElementById('job-created').textContent = job.created
+    document.getElementById('job-brands').textContent = job.new_brands.join(', ')
+    document.getElementById('job-error').textContent = job.error
+
+    const commitBtn = document.getElementById('commit_btn')
+    commitBtn.hidden = job.status !== 'validated'
+    commitBtn.onclick = () => commitJob(job.job_id)
+
+    const container = document.getElementById('errors-body')
+    container.innerHTML = ''
+
+    job.errors.forEach((rowError) => {
+        const row = `
+        <tr>
+            <td>${rowError.row}</td>
+            <td>${rowError.field}</td>
+            <td>${rowError.message}</td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+}
+
+// Получение последних загрузок
+async function fetchJobs() {
+    try {
+        const response = await authFetch('http://localhost:8081/import/jobs')
+
+        if (!response.ok) {
+            throw new Error(`fetchJobs Ошибка HTTP: ${response.status}`)
+        }
+
+        const result = await response.json()
+        renderJobs(result.jobs)
+    } catch (error) {
+        console.error('fetchJobs Ошибка', error.message, error)
+    }
+}
+
+// Отрисовывает последние загрузки
+function renderJobs(jobs) {
+    const container = document.getElementById('jobs-body')
+
+    container.innerHTML = ''
+
+    jobs.forEach((job) => {
+        const row = `
+        <tr>
+            <td>${job.job_id}</td>
+            <td>${job.file_name}</td>
+            <td>${STATUS_NAMES[job.status] || job.status}</td>
+            <td>${job.total}</td>
+            <td>${job.created}</td>
+            <td>${formatDate(job.created_at)}</td>
+            <td><button class="show-btn" data-id="${job.job_id}">Открыть</button></td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+
+    container.querySelectorAll('.show-btn').forEach((btn) => {
+        btn.addEventListener('click', () => showJob(btn.dataset.id))
+    });
+}
+
+requireLogin()
+
+document.addEventListener('DOMContentLoaded', () => {
+    fetchJobs()
+
+    document.getElementById('upload_btn').addEventListener('click', uploadFile)
+    document.getElementById('logout_btn').addEventListener('click', logout)
+});
@@ -0,0 +1,16 @@
+import { login } from './auth.js';
+
+document.getElementById('login-form').addEventListener('submit', async (event) => {
+    event.preventDefault()
+
+    const error = await login(
+        document.getElementById('login').value,
+        document.getElementById('password').value,
+    )
+    if (error) {
+        document.getElementById('login-error').textContent = error
+        return
+    }
+
+    window.location.replace('/admin/')
+})
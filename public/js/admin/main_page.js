@@ -2,6 +2,14 @@
 import { optionBrands } from './brand.js';
 import { optionCategory } from './category.js';
 import { formatDate } from './date.js';
+import { requireLogin, logout, authFetch } from './auth.js';
+
+const statusNames = {
+    draft: 'черновик',
+    scheduled: 'запланирован',
+    published: 'опубликован',
+    archived: 'в архиве',
+}
 
 async function performSearch() {
     const params = new URLSearchParams();
@@ -31,6 +39,9 @@ async function performSearch() {
     if (document.getElementById('discount-search').value) {
         params.append('discount', document.getElementById('discount-search').value);
     }
+    if (document.getElementById('status-search').value) {
+        params.append('status', document.getElementById('status-search').value);
+    }
 
     fetchItems(20, 0, '&'+params.toString())
 }
@@ -39,7 +50,8 @@ async function performSearch() {
 async function fetchItems(limit = 20, offset = 0, queryParams = "") {
     try {
         const url = `http://localhost:8081/item/get?limit=${limit}&offset=${offset}${queryParams}`;
-        const response = await fetch(url)
+        // с токеном редактор видит товары в любом статусе
+        const response = await authFetch(url)
 
         if (!response.ok) {
             throw new Error(`fetchItems Ошибка HTTP: ${response.status}`)
@@ -61,10 +73,10 @@ function renderItems(items) {
     items.items.forEach((product) => {
         let sex = ''
         switch (product.sex) {
-            case 1:
+            case 'male':
                 sex = 'муж';
                 break;
-            case 1:
+            case 'female':
                 sex = 'жен';
                 break;
             default:
@@ -81,6 +93,7 @@ function renderItems(items) {
             <td>${sex}</td>
             <td>${product.price} руб.</td>
             <td>${product.discount} %</td>
+            <td>${statusNames[product.status] ?? product.status}</td>
             <td><a href="${product.outer_link}" target="_blank">Товар в магазине</a></td>
             <td>${formatDate(product.created_at)}</td>
             <td>${formatDate(product.updated_at)}</td>
@@ -118,6 +131,8 @@ function fetchPrevItems() {
     currOffset.innerHTML = offset
 }
 
+requireLogin()
+
 document.addEventListener('DOMContentLoaded', () => {
     fetchItems()
     optionCategory(document.getElementById("category-search"))
@@ -131,4 +146,6 @@ document.addEventListener('DOMContentLoaded', () => {
 
     const prevBtn = document.getElementById("prev_btn")
     prevBtn.addEventListener('click', fetchPrevItems)
+
+    document.getElementById("logout_btn").addEventListener('click', logout)
 });
\ No newline at end of file
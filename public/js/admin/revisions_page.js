@@ -0,0 +1,126 @@
+import { formatDate } from './date.js';
+import { requireLogin, logout, authFetch } from './auth.js';
+
+const itemId = window.location.pathname.match(/\d+$/)[0]
+
+// Получение версий товара
+async function fetchRevisions() {
+    try {
+        const response = await authFetch(`http://localhost:8081/item/${itemId}/revisions?limit=100`)
+
+        if (!response.ok) {
+            throw new Error(`fetchRevisions Ошибка HTTP: ${response.status}`)
+        }
+
+        const revisions = await response.json()
+        renderRevisions(revisions.revisions)
+    } catch (error) {
+        console.error('fetchRevisions Ошибка', error.message, error)
+    }
+}
+
+// Откат товара к версии
+async function rollback(version) {
+    if (!confirm(`Откатить товар к версии ${version}?`)) {
+        return
+    }
+
+    try {
+        const response = await authFetch(`http://localhost:8081/item/${itemId}/revisions/${version}/rollback`, {
+            method: 'POST'
+        })
+
+        if (!response.ok) {
+            const error = await response.json()
+            alert(`Не удалось откатить товар: ${error.error}`)
+            throw new Error(`rollback Ошибка HTTP: ${response.status}`)
+        }
+
+        fetchRevisions()
+    } catch (error) {
+        console.error('rollback Ошибка', error.message, error)
+    }
+}
+
+// Сравнение двух версий
+async function fetchDiff() {
+    const from = document.getElementById('diff-from').value
+    const to = document.getElementById('diff-to').value
+
+    try {
+        const response = await authFetch(`http://localhost:8081/item/${itemId}/revisions/diff?from=${from}&to=${to}`)
+
+        if (!response.ok) {
+            throw new Error(`fetchDiff Ошибка HTTP: ${response.status}`)
+        }
+
+        const diff = await response.json()
+        renderDiff(diff.changes)
+    } catch (error) {
+        console.error('fetchDiff Ошибка', error.message, error)
+    }
+}
+
+function renderRevisions(revisions) {
+    const container = document.getElementById("revisions-body")
+    container.innerHTML = ''
+
+    let versionOptions = ''
+    revisions.forEach((revision) => {
+        let version = revision.version
+        if (revision.restored_from) {
+            version += ` (откат к ${revision.restored_from})`
+        }
+
+        const row = document.createElement('tr')
+        for (const value of [version, formatDate(revision.created_at), revision.actor, revision.snapshot.name, `${revision.snapshot.price} руб.`, revision.snapshot.images.length]) {
+            const cell = document.createElement('td')
+            cell.textContent = value
+            row.appendChild(cell)
+        }
+
+        const button = document.createElement('button')
+        button.textContent = 'Откатить'
+        button.addEventListener('click', () => rollback(revision.version))
+        const cell = document.createElement('td')
+        cell.appendChild(button)
+        row.appendChild(cell)
+
+        container.appendChild(row)
+
+        versionOptions += `<option value="${revision.version}">${revision.version}</option>`
+    });
+
+    document.getElementById('diff-from').innerHTML = versionOptions
+    document.getElementById('diff-to').innerHTML = versionOptions
+    // по умолчанию сравниваются две последние версии
+    if (revisions.length > 1) {
+        document.getElementById('diff-from').value = revisions[1].version
+    }
+}
+
+function renderDiff(changes) {
+    const container = document.getElementById("diff-body")
+    container.innerHTML = ''
+
+    changes.forEach((change) => {
+        const row = document.createElement('tr')
+        for (const value of [change.field, JSON.stringify(change.from), JSON.stringify(change.to)]) {
+            const cell = document.createElement('td')
+            cell.textContent = value
+            row.appendChild(cell)
+        }
+
+        container.appendChild(row)
+    });
+}
+
+requireLogin()
+
+document.addEventListener('DOMContentLoaded', () => {
+    fetchRevisions()
+
+    document.getElementById('item_link').href = `/admin/update/${itemId}`
+    document.getElementById("diff_btn").addEventListener('click', fetchDiff)
+    document.getElementById("logout_btn").addEventListener('click', logout)
+});
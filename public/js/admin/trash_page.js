@@ -0,0 +1,96 @@
+import { formatDate } from './date.js';
+import { requireLogin, logout, authFetch } from './auth.js';
+
+const LIMIT = 20
+
+let offset = 0
+let count = 0
+
+// Получение удаленных товаров
+async function fetchTrash() {
+    try {
+        const url = `http://localhost:8081/item/trash?limit=${LIMIT}&offset=${offset}`;
+        const response = await authFetch(url)
+
+        if (!response.ok) {
+            throw new Error(`fetchTrash Ошибка HTTP: ${response.status}`)
+        }
+
+        const trash = await response.json()
+        count = trash.count
+        renderTrash(trash.items)
+    } catch (error) {
+        console.error('fetchTrash Ошибка', error.message, error)
+    }
+}
+
+// Восстановление товара из корзины
+async function restoreItem(id) {
+    try {
+        const response = await authFetch(`http://localhost:8081/item/restore/${id}`, {
+            method: 'POST'
+        })
+
+        if (!response.ok) {
+            throw new Error(`restoreItem Ошибка HTTP: ${response.status}`)
+        }
+
+        fetchTrash()
+    } catch (error) {
+        console.error('restoreItem Ошибка', error.message, error)
+    }
+}
+
+// Отрисовывает удаленные товары
+function renderTrash(items) {
+    const container = document.getElementById("trash-body")
+
+    container.innerHTML = ''
+
+    items.forEach((product) => {
+        const row = `
+        <tr>
+            <td>${product.id}</td>
+            <td>${product.brand_name}</td>
+            <td>${product.name}</td>
+            <td>${product.category_name}</td>
+            <td>${product.price} руб.</td>
+            <td>${formatDate(product.deleted_at)}</td>
+            <td><button class="button-primary restore-btn" data-id="${product.id}">Восстановить</button></td>
+        </tr>`;
+
+        container.insertAdjacentHTML('beforeend', row);
+    });
+
+    container.querySelectorAll('.restore-btn').forEach((btn) => {
+        btn.addEventListener('click', () => restoreItem(btn.dataset.id))
+    });
+}
+
+function fetchNextTrash() {
+    if (count !== LIMIT) {
+        return
+    }
+
+    offset += LIMIT
+    fetchTrash()
+}
+
+function fetchPrevTrash() {
+    if (offset === 0) {
+        return
+    }
+
+    offset -= LIMIT
+    fetchTrash()
+}
+
+requireLogin()
+
+document.addEventListener('DOMContentLoaded', () => {
+    fetchTrash()
+
+    document.getElementById("next_btn").addEventListener('click', fetchNextTrash)
+    document.getElementById("prev_btn").addEventListener('click', fetchPrevTrash)
+    document.getElementById("logout_btn").addEventListener('click', logout)
+});
@@ -2,6 +2,7 @@ import { formatDate } from "./date.js";
 import { fetchBrands } from "./brand.js";
 import { fetchCategory } from "./category.js";
 import { getImage } from './image.js';
+import { authFetch, requireLogin } from './auth.js';
 
 const IMAGE_GALLERY_WIDHT = 323
 const IMAGE_GALLERY_HEIGHT = 430
@@ -12,7 +13,7 @@ async function fetchItem() {
 
     try {
         const url = `http://localhost:8081/item/get/${id}`;
-        const response = await fetch(url)
+        const response = await authFetch(url)
         const brands = await fetchBrands()
         const category = await fetchCategory()
 
@@ -43,10 +44,10 @@ function renderItem(item, brands, category) {
 
     let sex = ''
     switch (item.sex) {
-        case 1:
+        case 'male':
             sex = 'муж';
             break;
-        case 1:
+        case 'female':
             sex = 'жен';
             break;
         default:
@@ -54,13 +55,15 @@ function renderItem(item, brands, category) {
             break;
     }
     let genderOptions = `<option value="${item.sex}">${sex}</option>
-    <option value="1">Мужской</option>
-    <option value="2">Женский</option>
-    <option value="3">Унисекс</option>`
+    <option value="male">Мужской</option>
+    <option value="female">Женский</option>
+    <option value="unisex">Унисекс</option>`
 
     // заголовок с id
     document.getElementById('item-id-text').innerHTML = `ID: ${item.id} | Создан: ${formatDate(item.created_at)} | Обновлен: ${formatDate(item.updated_at)}`
     document.getElementById('item-id').value = item.id
+    document.getElementById('item-version').value = item.version
+    document.getElementById('revisions_link').href = `/admin/revisions/${item.id}`
 
     // опции для брендов
     document.getElementById('brand').innerHTML = brandOptions
@@ -83,6 +86,11 @@ function renderItem(item, brands, category) {
     // description
     document.getElementById('description').value = item.description
 
+    // статус публикации
+    document.getElementById('status').value = item.status
+    document.getElementById('publish-at').value = toInputDate(item.publish_at)
+    document.getElementById('unpublish-at').value = toInputDate(item.unpublish_at)
+
     // подставляет изображения вместо мокового, если такое есть
     let imageId = ''
     if (item?.image_id && Array.isArray(item.image_id) && item.image_id.length > 0) {
@@ -137,7 +145,7 @@ function createDeleteBtn(image) {
         const imageId = event.target.getAttribute('image_id');
 
         try {
-            const response = await fetch(`http://localhost:8081/image/delete?image_id=${imageId}`, {
+            const response = await authFetch(`http://localhost:8081/image/delete?image_id=${imageId}`, {
                 method: 'DELETE'
             });
 
@@ -151,6 +159,8 @@ function createDeleteBtn(image) {
             }
 
             event.target.remove();
+
+            refreshVersion()
         } catch (error) {
             console.error('Ошибка при удалении изображения:', error);
         }
@@ -164,6 +174,23 @@ function createDeleteBtn(image) {
     container.appendChild(div);
 }
 
+// Изменение изображений создает новую версию товара, иначе обновление полей вернет конфликт
+async function refreshVersion() {
+    const id = document.getElementById('item-id').value
+
+    try {
+        const response = await authFetch(`http://localhost:8081/item/get/${id}`)
+        if (!response.ok) {
+            throw new Error(`refreshVersion Ошибка HTTP: ${response.status}`)
+        }
+
+        const item = await response.json()
+        document.getElementById('item-version').value = item.version
+    } catch (error) {
+        console.error('refreshVersion Ошибка', error.message, error)
+    }
+}
+
 /**
  * @typedef {Object} updateData
  * @property {number} brand_id - Идентификатор бренда
@@ -184,16 +211,17 @@ async function update() {
         brand_id: parseInt(document.getElementById('brand').value),
         name: document.getElementById('item-name').value,
         category_id: parseInt(document.getElementById('category').value),
-        sex: parseInt(document.getElementById('gender').value),
+        sex: document.getElementById('gender').value,
         price: parseInt(document.getElementById('price').value),
         discount: parseInt(document.getElementById('discount').value),
-        description: document.getElementById('description').value
+        description: document.getElementById('description').value,
+        version: parseInt(document.getElementById('item-version').value)
     }
 
     console.log(updateData, id);
 
     try {
-        const response = await fetch(`http://localhost:8081/item/update/${id}`, {
+        const response = await authFetch(`http://localhost:8081/item/update/${id}`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -203,6 +231,9 @@ async function update() {
 
         if (response.ok) {
             window.location.replace('/admin/')
+        } else if (response.status === 409) {
+            const conflict = await response.json()
+            showConflict(conflict.current, updateData)
         } else {
             alert("Не удалось обновить данные.");
             throw new Error(`Ошибка HTTP: ${response.status}`);
@@ -212,6 +243,94 @@ async function update() {
     }
 }
 
+const conflictFields = {
+    brand_id: 'Бренд',
+    name: 'Название',
+    category_id: 'Категория',
+    sex: 'Пол',
+    price: 'Цена',
+    discount: 'Скидка',
+    description: 'Описание'
+}
+
+// Показывает поля, измененные другим пользователем. Введенные значения остаются в форме,
+// а версия берется из текущего товара, поэтому повторное обновление их сохранит
+function showConflict(current, updateData) {
+    const rows = document.getElementById('conflict-fields')
+    rows.innerHTML = ''
+
+    for (const [field, label] of Object.entries(conflictFields)) {
+        const currentValue = current[field] ?? 0
+        if (currentValue === updateData[field]) {
+            continue
+        }
+
+        const row = document.createElement('tr')
+        for (const value of [label, currentValue, updateData[field]]) {
+            const cell = document.createElement('td')
+            cell.textContent = value
+            row.appendChild(cell)
+        }
+        rows.appendChild(row)
+    }
+
+    document.getElementById('item-version').value = current.version
+    document.getElementById('conflict').style.display = 'block'
+}
+
+// Переводит дату из ISO в значение поля datetime-local
+function toInputDate(dt) {
+    if (!dt) {
+        return ''
+    }
+
+    const date = new Date(dt)
+    date.setMinutes(date.getMinutes() - date.getTimezoneOffset())
+
+    return date.toISOString().slice(0, 16)
+}
+
+// Переводит значение поля datetime-local в ISO или null, если поле пустое
+function fromInputDate(id) {
+    const value = document.getElementById(id).value
+    if (!value) {
+        return null
+    }
+
+    return new Date(value).toISOString()
+}
+
+// Смена статуса публикации. Запланированный товар публикуется автоматически
+async function changeStatus() {
+    const id = document.getElementById("item-id").value
+
+    const statusData = {
+        status: document.getElementById('status').value,
+        publish_at: fromInputDate('publish-at'),
+        unpublish_at: fromInputDate('unpublish-at')
+    }
+
+    try {
+        const response = await authFetch(`http://localhost:8081/item/status/${id}`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(statusData)
+        });
+
+        if (!response.ok) {
+            const error = await response.json()
+            alert(`Не удалось сменить статус: ${error.error}`);
+            throw new Error(`Ошибка HTTP: ${response.status}`);
+        }
+
+        fetchItem()
+    } catch (error) {
+        console.error('Ошибка при смене статуса: ', error.message)
+    }
+}
+
 async function uploadImage(event) {
     event.preventDefault();
 
@@ -219,7 +338,7 @@ async function uploadImage(event) {
     let id = document.getElementById('item-id').value
 
     try {
-        const response = await fetch(`http://localhost:8081/image/create?itemId=${id}`, {
+        const response = await authFetch(`http://localhost:8081/image/create?itemId=${id}`, {
             method: 'POST',
             body: formData
         });
@@ -243,17 +362,25 @@ async function uploadImage(event) {
 
                 createDeleteBtn(fileid.file_id)
             })
+
+        refreshVersion()
     } catch (error) {
         console.error('Ошибка при загрузке изображения:', error);
     }
 }
 
+requireLogin()
+
 document.addEventListener('DOMContentLoaded', () => {
     fetchItem()
 
     const updateBtn = document.getElementById("update_btn")
     updateBtn.addEventListener('click', update)
 
+    document.getElementById("status_btn").addEventListener('click', changeStatus)
+
+    document.getElementById("reload_btn").addEventListener('click', () => window.location.reload())
+
     // переключение форм с редактирование параметров и загрузкой изображения
     document.getElementById('update_image_btn').addEventListener('click', function() {
         document.getElementById('item').style.display = 'none'
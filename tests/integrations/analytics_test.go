@@ -0,0 +1,124 @@
+//go:build integration
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ItemViewStatsResponse struct {
+	ItemId    int     `json:"item_id"`
+	Name      string  `json:"name"`
+	Views     int     `json:"views"`
+	PrevViews int     `json:"prev_views"`
+	Velocity  float64 `json:"velocity"`
+}
+
+type SearchStatsResponse struct {
+	Query       string `json:"query"`
+	Searches    int    `json:"searches"`
+	ZeroResults int    `json:"zero_results"`
+	NoResults   bool   `json:"no_results"`
+}
+
+// Find stats of item in analytics list
+func (i *IntegrationSuite) itemViewStats(path string, itemId uint) (ItemViewStatsResponse, bool) {
+	var stats []ItemViewStatsResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+path, &stats))
+
+	for _, item := range stats {
+		if item.ItemId == int(itemId) {
+			return item, true
+		}
+	}
+
+	return ItemViewStatsResponse{}, false
+}
+
+func (i *IntegrationSuite) searchStats(query string) map[string]SearchStatsResponse {
+	var stats []SearchStatsResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/analytics/searches?"+query, &stats))
+
+	byQuery := make(map[string]SearchStatsResponse, len(stats))
+	for _, search := range stats {
+		byQuery[search.Query] = search
+	}
+
+	return byQuery
+}
+
+func (i *IntegrationSuite) TestAnalytics() {
+	id := i.createItem(testItem("Test Analytics Jacket"))
+	itemId := strconv.Itoa(int(id))
+	i.Require().Equal(http.StatusOK, i.changeItemStatus(itemId, `{"status": "published"}`))
+
+	// visitors views and searches are counted, queries are normalized
+	for range 3 {
+		i.Require().Equal(http.StatusOK, i.getPublicItemStatus(itemId))
+	}
+	i.Require().Equal(http.StatusOK, i.getPublicStatus("/item/get?name="+url.QueryEscape("Test Analytics Jacket")))
+	i.Require().Equal(http.StatusOK, i.getPublicStatus("/item/get?name=no-such-analytics-item"))
+
+	// editors views aren't counted
+	response, err := http.Get(host + "/item/get/" + itemId)
+	i.Require().NoError(err)
+	response.Body.Close()
+
+	// events are written and aggregated by background tasks
+	i.Require().Eventually(func() bool {
+		item, ok := i.itemViewStats("/analytics/items/popular", id)
+
+		return ok && item.Views == 3
+	}, 30*time.Second, 500*time.Millisecond)
+
+	item, ok := i.itemViewStats("/analytics/items/trending?days=7", id)
+	i.Require().True(ok)
+	i.Require().Equal(3, item.Views)
+	i.Require().Zero(item.PrevViews)
+	i.Require().InDelta(3.0/7, item.Velocity, 0.001)
+
+	searches := i.searchStats("")
+	i.Require().Equal(SearchStatsResponse{Query: "test analytics jacket", Searches: 1}, searches["test analytics jacket"])
+	i.Require().Equal(SearchStatsResponse{Query: "no-such-analytics-item", Searches: 1, ZeroResults: 1, NoResults: true},
+		searches["no-such-analytics-item"])
+
+	searches = i.searchStats("zero_results=true")
+	i.Require().NotContains(searches, "test analytics jacket")
+	i.Require().Contains(searches, "no-such-analytics-item")
+
+	// stats are only for editors
+	i.Require().Equal(http.StatusUnauthorized, i.getPublicStatus("/analytics/items/popular"))
+	i.Require().Equal(http.StatusUnauthorized, i.getPublicStatus("/analytics/searches"))
+}
+
+func (i *IntegrationSuite) TestAnalyticsLateEvents() {
+	id := i.createItem(testItem("Test Analytics Late Jacket"))
+
+	dayViews := func(day string) int {
+		var views int
+		err := i.db.QueryRow("SELECT COALESCE(sum(views), 0) FROM item_view_days WHERE item_id = $1 AND day = $2::date", id, day).Scan(&views)
+		i.Require().NoError(err)
+
+		return views
+	}
+
+	now := time.Now().UTC()
+	today := now.Format(time.DateOnly)
+	_, err := i.db.Exec("INSERT INTO item_views (item_id, created_at) VALUES ($1, $2)", id, now)
+	i.Require().NoError(err)
+	i.Require().Eventually(func() bool {
+		return dayViews(today) == 1
+	}, 30*time.Second, 500*time.Millisecond)
+
+	// event of previous day is written after today is aggregated, e.g. flushed after midnight
+	yesterday := now.AddDate(0, 0, -1)
+	_, err = i.db.Exec("INSERT INTO item_views (item_id, created_at) VALUES ($1, $2)", id, yesterday)
+	i.Require().NoError(err)
+	i.Require().Eventually(func() bool {
+		return dayViews(yesterday.Format(time.DateOnly)) == 1
+	}, 30*time.Second, 500*time.Millisecond)
+	i.Require().Equal(1, dayViews(today))
+}
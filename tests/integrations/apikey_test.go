@@ -0,0 +1,156 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type APIKeySecretResponse struct {
+	ID  int    `json:"api_key_id"`
+	Key string `json:"key"`
+}
+
+// Send request as admin and decode response
+func (i *IntegrationSuite) adminRequest(method, url, body string, out any) int {
+	admin := i.login("admin", testPassword)
+
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+admin.AccessToken)
+
+	response, err := i.anonymousClient().Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if out != nil && response.StatusCode == http.StatusOK {
+		err = json.NewDecoder(response.Body).Decode(out)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return response.StatusCode
+}
+
+func (i *IntegrationSuite) createAPIKey(scopes string, dailyQuota int) APIKeySecretResponse {
+	body := fmt.Sprintf(`{"name": "partner", "scopes": %s, "rate_limit": 1000, "daily_quota": %d}`, scopes, dailyQuota)
+
+	var key APIKeySecretResponse
+	status := i.adminRequest(http.MethodPost, host+"/apikey/create", body, &key)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().True(strings.HasPrefix(key.Key, "cma_"))
+
+	return key
+}
+
+func (i *IntegrationSuite) requestWithAPIKey(method, url, key string) *http.Response {
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("X-API-Key", key)
+
+	response, err := i.anonymousClient().Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return response
+}
+
+func (i *IntegrationSuite) TestAPIKeyScopes() {
+	key := i.createAPIKey(`["catalog:read"]`, 100)
+
+	response := i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", key.Key)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	// key without items:write can't delete items
+	response = i.requestWithAPIKey(http.MethodDelete, host+"/item/delete/1", key.Key)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	var errResponse ErrorResponse
+	err := json.NewDecoder(response.Body).Decode(&errResponse)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal("insufficient_scope", errResponse.Code)
+
+	// unknown key is rejected even for open routes
+	response = i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", "cma_unknown")
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestAPIKeyQuota() {
+	key := i.createAPIKey(`["catalog:read"]`, 2)
+
+	for range 2 {
+		response := i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", key.Key)
+		defer response.Body.Close()
+
+		i.Require().Equal(http.StatusOK, response.StatusCode)
+	}
+
+	response := i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", key.Key)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusTooManyRequests, response.StatusCode)
+	i.Require().NotEmpty(response.Header.Get("Retry-After"))
+
+	var usage []struct {
+		Requests int `json:"requests"`
+		Rejected int `json:"rejected"`
+	}
+	status := i.adminRequest(http.MethodGet, fmt.Sprintf("%s/apikey/%d/usage", host, key.ID), "", &usage)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Len(usage, 1)
+	i.Require().Equal(2, usage[0].Requests)
+	i.Require().Equal(1, usage[0].Rejected)
+}
+
+func (i *IntegrationSuite) TestAPIKeyRotateAndRevoke() {
+	key := i.createAPIKey(`["catalog:read", "items:write"]`, 100)
+
+	var rotated APIKeySecretResponse
+	status := i.adminRequest(http.MethodPost, fmt.Sprintf("%s/apikey/rotate/%d", host, key.ID), "", &rotated)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().NotEqual(key.Key, rotated.Key)
+
+	// previous key stops working after rotation
+	response := i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", key.Key)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+
+	response = i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", rotated.Key)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	status = i.adminRequest(http.MethodDelete, fmt.Sprintf("%s/apikey/revoke/%d", host, key.ID), "", nil)
+	i.Require().Equal(http.StatusOK, status)
+
+	response = i.requestWithAPIKey(http.MethodGet, host+"/item/get/1", rotated.Key)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+
+	// revoked key can't be rotated
+	status = i.adminRequest(http.MethodPost, fmt.Sprintf("%s/apikey/rotate/%d", host, key.ID), "", nil)
+	i.Require().Equal(http.StatusConflict, status)
+}
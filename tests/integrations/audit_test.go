@@ -0,0 +1,103 @@
+//go:build integration
+
+package integrations
+
+import (
+	"bytes"
+	domain "cloth-mini-app/internal/domain/item"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type AuditEntry struct {
+	UserId     *int           `json:"user_id"`
+	Actor      string         `json:"actor"`
+	Action     string         `json:"action"`
+	EntityType string         `json:"entity_type"`
+	EntityId   string         `json:"entity_id"`
+	Before     map[string]any `json:"before"`
+	After      map[string]any `json:"after"`
+	RequestId  string         `json:"request_id"`
+}
+
+type AuditResponse struct {
+	Count   int          `json:"count"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+func (i *IntegrationSuite) TestAuditItemUpdate() {
+	id := i.createItem(domain.ItemCreate{
+		BrandId:     1,
+		Name:        "test audit item",
+		Description: "some description...",
+		Sex:         1,
+		CategoryId:  1,
+		Price:       10000,
+		Discount:    10,
+		OuterLink:   "http://localhost:8080/",
+	})
+	itemId := strconv.Itoa(int(id))
+
+	body, err := json.Marshal(ItemUpdateField{Name: "updated", Price: 1234, Version: 1})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	response, err := http.Post(host+"/item/update/"+itemId, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var audit AuditResponse
+	status := i.adminRequest(http.MethodGet, host+"/admin/audit?entity_type=item&entity_id="+itemId, "", &audit)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal(1, audit.Count)
+
+	entry := audit.Entries[0]
+	i.Require().Equal("update", entry.Action)
+	i.Require().Equal("editor", entry.Actor)
+	i.Require().NotNil(entry.UserId)
+	i.Require().Equal(response.Header.Get("X-Request-Id"), entry.RequestId)
+	i.Require().Equal("test audit item", entry.Before["Name"])
+	i.Require().Equal("updated", entry.After["Name"])
+
+	// failed change isn't recorded
+	request, err := http.NewRequest(http.MethodDelete, host+"/image/delete?image_id=00000000-0000-0000-0000-000000000000", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusNotFound, response.StatusCode)
+
+	status = i.adminRequest(http.MethodGet, host+"/admin/audit?entity_type=image", "", &audit)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal(0, audit.Count)
+}
+
+func (i *IntegrationSuite) TestAuditFilterValidation() {
+	status := i.adminRequest(http.MethodGet, host+"/admin/audit?action=rename", "", nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	status = i.adminRequest(http.MethodGet, host+"/admin/audit?from=yesterday", "", nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	// audit is only for admin
+	response, err := http.Get(host + "/admin/audit")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,168 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func (i *IntegrationSuite) TestWriteRequiresAuth() {
+	body := `{"brand_id": 1, "name": "test", "description": "test", "sex": "male", "category_id": 3, "price": 100, "outer_link": "http://localhost"}`
+
+	response, err := i.anonymousClient().Post(host+"/item/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+
+	var errResponse ErrorResponse
+	err = json.NewDecoder(response.Body).Decode(&errResponse)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal("unauthenticated", errResponse.Code)
+
+	// viewer can't change content
+	viewer := i.login("viewer", testPassword)
+	request, err := http.NewRequest(http.MethodDelete, host+"/item/delete/1", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+viewer.AccessToken)
+
+	response, err = i.anonymousClient().Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	// reading is open
+	response, err = i.anonymousClient().Get(host + "/item/get/1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestLoginInvalidPassword() {
+	response, err := i.anonymousClient().Post(host+"/auth/login", "application/json", strings.NewReader(`{"login": "editor", "password": "wrong-password"}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestRefreshToken() {
+	tokens := i.login("editor", testPassword)
+
+	refresh := func(refreshToken string) *http.Response {
+		body := fmt.Sprintf(`{"refresh_token": %q}`, refreshToken)
+		response, err := i.anonymousClient().Post(host+"/auth/refresh", "application/json", strings.NewReader(body))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return response
+	}
+
+	response := refresh(tokens.RefreshToken)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var refreshed TokenResponse
+	err := json.NewDecoder(response.Body).Decode(&refreshed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().NotEmpty(refreshed.AccessToken)
+	i.Require().NotEqual(tokens.RefreshToken, refreshed.RefreshToken)
+
+	// refresh token is used once
+	response = refresh(tokens.RefreshToken)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+
+	// logged out token can't be used
+	body := fmt.Sprintf(`{"refresh_token": %q}`, refreshed.RefreshToken)
+	logout, err := i.anonymousClient().Post(host+"/auth/logout", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logout.Body.Close()
+
+	i.Require().Equal(http.StatusOK, logout.StatusCode)
+
+	response = refresh(refreshed.RefreshToken)
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestCreateUser() {
+	body := `{"login": "manager", "password": "manager-password", "role": "editor"}`
+
+	// editor token is used by default
+	response, err := http.Post(host+"/user/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	admin := i.login("admin", testPassword)
+	request, err := http.NewRequest(http.MethodPost, host+"/user/create", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+admin.AccessToken)
+
+	response, err = i.anonymousClient().Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	manager := i.login("manager", "manager-password")
+	request, err = http.NewRequest(http.MethodGet, host+"/auth/me", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+manager.AccessToken)
+
+	response, err = i.anonymousClient().Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var me struct {
+		Login string `json:"login"`
+		Role  string `json:"role"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&me)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal("manager", me.Login)
+	i.Require().Equal("editor", me.Role)
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type Brand struct {
@@ -42,3 +44,95 @@ func (i *IntegrationSuite) TestGetBrands() {
 
 	i.Require().Equal(3, len(brands))
 }
+
+type BrandById struct {
+	ID          int     `json:"brand_id"`
+	Name        string  `json:"brand_name"`
+	Slug        string  `json:"slug"`
+	Description string  `json:"description"`
+	Country     string  `json:"country"`
+	LogoId      *string `json:"logo_id"`
+	ItemsCount  int     `json:"items_count"`
+}
+
+func (i *IntegrationSuite) TestGetBrandById() {
+	brand, status := i.getBrand(3)
+
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal("Daze", brand.Name)
+	i.Require().Equal("daze", brand.Slug)
+	i.Require().Equal(1, brand.ItemsCount)
+
+	_, status = i.getBrand(1000)
+	i.Require().Equal(http.StatusNotFound, status)
+}
+
+func (i *IntegrationSuite) getBrand(brandId int) (BrandById, int) {
+	response, err := http.Get(host + "/brand/" + strconv.Itoa(brandId))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var brand BrandById
+	if response.StatusCode == http.StatusOK {
+		err = json.NewDecoder(response.Body).Decode(&brand)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return brand, response.StatusCode
+}
+
+func (i *IntegrationSuite) TestCreateBrand() {
+	body := `{"brand_name": "Test Brand", "description": "some brand", "country": "ru"}`
+
+	response, err := http.Post(host+"/brand/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var created struct {
+		ID int `json:"brand_id"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&created)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	brand, status := i.getBrand(created.ID)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal("test-brand", brand.Slug)
+	i.Require().Equal("RU", brand.Country)
+	i.Require().Equal(0, brand.ItemsCount)
+
+	// same slug
+	duplicate, err := http.Post(host+"/brand/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer duplicate.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, duplicate.StatusCode)
+}
+
+func (i *IntegrationSuite) TestDeleteBrandWithItems() {
+	request, err := http.NewRequest(http.MethodDelete, host+"/brand/delete/1", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	_, status := i.getBrand(1)
+	i.Require().Equal(http.StatusOK, status)
+}
@@ -4,14 +4,17 @@ package integrations
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type Category struct {
 	CategoryId int    `json:"category_id"`
-	Type       int    `json:"type"`
+	Type       string `json:"type"`
 	Name       string `json:"category_name"`
 }
 
@@ -43,3 +46,237 @@ func (i *IntegrationSuite) TestGetCategories() {
 
 	i.Require().Greater(len(categs), 0)
 }
+
+type CategoryNode struct {
+	Category
+	ParentId *int           `json:"parent_id"`
+	Children []CategoryNode `json:"children"`
+}
+
+func (i *IntegrationSuite) getCategoryTree() []CategoryNode {
+	response, err := http.Get(host + "/category/tree")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var tree []CategoryNode
+	err = json.NewDecoder(response.Body).Decode(&tree)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return tree
+}
+
+func (i *IntegrationSuite) TestCategoryTree() {
+	tree := i.getCategoryTree()
+
+	i.Require().Equal(2, len(tree))
+	for _, root := range tree {
+		i.Require().Nil(root.ParentId)
+		i.Require().Greater(len(root.Children), 0)
+		for _, child := range root.Children {
+			i.Require().Equal(root.Type, child.Type)
+		}
+	}
+}
+
+func (i *IntegrationSuite) TestItemsByParentCategory() {
+	// items of seed data are in "Свитеры и кардиганы" and "Толстовки" (clothes) and "Кроссовки" (shoes)
+	for _, root := range i.getCategoryTree() {
+		response, err := http.Get(host + "/item/get?category_id=" + strconv.Itoa(root.CategoryId))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer response.Body.Close()
+
+		i.Require().Equal(http.StatusOK, response.StatusCode)
+
+		var items ItemResponse
+		err = json.NewDecoder(response.Body).Decode(&items)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		expected := 1
+		if root.Type == "clothes" {
+			expected = 2
+		}
+		i.Require().Equal(expected, items.Count)
+	}
+}
+
+func (i *IntegrationSuite) TestCategoryAttributes() {
+	var shoes CategoryNode
+	for _, root := range i.getCategoryTree() {
+		if root.Type == "shoes" {
+			shoes = root
+		}
+	}
+
+	body := fmt.Sprintf(`{"category_name": "Слипоны", "parent_id": %d}`, shoes.CategoryId)
+	response, err := http.Post(host+"/category/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var created struct {
+		ID int `json:"category_id"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&created)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	body = `{"code": "closure", "name": "Застежка", "value_type": "enum", "options": ["none", "velcro"], "required": true}`
+	response, err = http.Post(host+"/category/"+strconv.Itoa(created.ID)+"/attribute", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	// inherited attribute can't be redefined
+	body = `{"code": "sole_type", "name": "Подошва", "value_type": "string"}`
+	response, err = http.Post(host+"/category/"+strconv.Itoa(created.ID)+"/attribute", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	item := `{"brand_id": 2, "name": "Слипоны", "description": "test", "sex": "unisex", "category_id": %d, "price": 1000, "outer_link": "https://example.com", "attributes": %s}`
+
+	cases := []struct {
+		attributes string
+		status     int
+	}{
+		{`{"sole_type": "eva"}`, http.StatusUnprocessableEntity},
+		{`{"closure": "zip"}`, http.StatusUnprocessableEntity},
+		{`{"closure": "none", "color": "red"}`, http.StatusUnprocessableEntity},
+		{`{"closure": "velcro", "sole_type": "rubber"}`, http.StatusOK},
+	}
+	for _, c := range cases {
+		body = fmt.Sprintf(item, created.ID, c.attributes)
+		response, err = http.Post(host+"/item/create", "application/json", strings.NewReader(body))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer response.Body.Close()
+
+		i.Require().Equal(c.status, response.StatusCode, c.attributes)
+	}
+
+	// category with items can't be deleted
+	request, err := http.NewRequest(http.MethodDelete, host+"/category/delete/"+strconv.Itoa(created.ID), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestCategoryMoveToSubtree() {
+	tree := i.getCategoryTree()
+	root := tree[0]
+	child := root.Children[0]
+
+	body := fmt.Sprintf(`{"parent_id": %d}`, child.CategoryId)
+	response, err := http.Post(host+"/category/update/"+strconv.Itoa(root.CategoryId), "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	// category can't be moved to tree of another type
+	body = fmt.Sprintf(`{"parent_id": %d}`, tree[1].CategoryId)
+	response, err = http.Post(host+"/category/update/"+strconv.Itoa(child.CategoryId), "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+}
+
+// Create subcategory and return its id
+func (i *IntegrationSuite) createCategory(name string, parentId int) int {
+	body := fmt.Sprintf(`{"category_name": %q, "parent_id": %d}`, name, parentId)
+	response, err := http.Post(host+"/category/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var created struct {
+		ID int `json:"category_id"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&created)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return created.ID
+}
+
+// Add string attribute to category and return response status
+func (i *IntegrationSuite) createAttribute(categoryId int, code string) int {
+	body := fmt.Sprintf(`{"code": %q, "name": "test", "value_type": "string"}`, code)
+	response, err := http.Post(host+"/category/"+strconv.Itoa(categoryId)+"/attribute", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+
+	return response.StatusCode
+}
+
+func (i *IntegrationSuite) TestCategoryAttributeCodesAcrossTree() {
+	var shoes CategoryNode
+	for _, root := range i.getCategoryTree() {
+		if root.Type == "shoes" {
+			shoes = root
+		}
+	}
+
+	parent := i.createCategory("Кеды", shoes.CategoryId)
+	child := i.createCategory("Высокие кеды", parent)
+	i.Require().Equal(http.StatusOK, i.createAttribute(child, "lining"))
+
+	// subcategory already has attribute with the code
+	i.Require().Equal(http.StatusConflict, i.createAttribute(parent, "lining"))
+
+	other := i.createCategory("Мокасины", shoes.CategoryId)
+	i.Require().Equal(http.StatusOK, i.createAttribute(other, "lining"))
+
+	// moved subcategory would inherit the same code
+	body := fmt.Sprintf(`{"parent_id": %d}`, other)
+	response, err := http.Post(host+"/category/update/"+strconv.Itoa(child), "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	var errResponse struct {
+		Code string `json:"code"`
+	}
+	i.Require().NoError(json.NewDecoder(response.Body).Decode(&errResponse))
+	i.Require().Equal("attribute_code_conflict", errResponse.Code)
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+package integrations
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ClickStatsResponse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Clicks   int    `json:"clicks"`
+	Visitors int    `json:"visitors"`
+}
+
+// Follow /go link without redirect and return status with location
+func (i *IntegrationSuite) goLink(path, userAgent string) (int, string) {
+	request, err := http.NewRequest(http.MethodGet, host+path, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Referer", "https://blog.example.org/review")
+
+	client := &http.Client{
+		Transport: i.transport.base,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode, response.Header.Get("Location")
+}
+
+func (i *IntegrationSuite) TestClickRedirect() {
+	item := testItem("test click")
+	item.OuterLink = "https://www.shop.example.com/p/1?color=red"
+	id := i.createItem(item)
+	itemId := strconv.Itoa(int(id))
+
+	// draft item isn't shown, so its link isn't followed
+	status, _ := i.goLink("/go/"+itemId, "agent-1")
+	i.Require().Equal(http.StatusNotFound, status)
+	i.Require().Equal(http.StatusOK, i.changeItemStatus(itemId, `{"status": "published"}`))
+
+	// without template link is returned as is
+	status, location := i.goLink("/go/"+itemId, "agent-1")
+	i.Require().Equal(http.StatusFound, status)
+	i.Require().Equal(item.OuterLink, location)
+
+	// template of parent domain is applied to subdomains
+	status = i.adminRequest(http.MethodPut, host+"/affiliate/templates/example.com",
+		`{"params": "utm_source=cloth&utm_campaign={campaign}&aff_sub={item_id}-{click_id}"}`, nil)
+	i.Require().Equal(http.StatusOK, status)
+	defer i.adminRequest(http.MethodDelete, host+"/affiliate/templates/example.com", "", nil)
+
+	status = i.adminRequest(http.MethodPut, host+"/affiliate/templates/example.com", `{"params": "aff={unknown}"}`, nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	status, location = i.goLink("/go/"+itemId+"?utm_campaign=summer", "agent-2")
+	i.Require().Equal(http.StatusFound, status)
+	link, err := url.Parse(location)
+	i.Require().NoError(err)
+	i.Require().Equal("www.shop.example.com", link.Host)
+	i.Require().Equal("red", link.Query().Get("color"))
+	i.Require().Equal("cloth", link.Query().Get("utm_source"))
+	i.Require().Equal("summer", link.Query().Get("utm_campaign"))
+	i.Require().Regexp("^"+itemId+"-[0-9]+$", link.Query().Get("aff_sub"))
+
+	var campaign string
+	err = i.db.QueryRow("SELECT utm_campaign FROM clicks WHERE item_id = $1 ORDER BY id DESC LIMIT 1", id).Scan(&campaign)
+	i.Require().NoError(err)
+	i.Require().Equal("summer", campaign)
+
+	// stats are only for editors
+	i.Require().Equal(http.StatusUnauthorized, i.getPublicStatus("/clicks/items"))
+
+	var items []ClickStatsResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/clicks/items?item_id="+itemId, &items))
+	i.Require().Len(items, 1)
+	i.Require().Equal(2, items[0].Clicks)
+	i.Require().Equal(2, items[0].Visitors)
+
+	var brands []ClickStatsResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/clicks/brands?brand_id=1", &brands))
+	i.Require().Len(brands, 1)
+	i.Require().GreaterOrEqual(brands[0].Clicks, 2)
+
+	var days []struct {
+		Day    string `json:"day"`
+		Clicks int    `json:"clicks"`
+	}
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/clicks/days?item_id="+itemId, &days))
+	i.Require().Len(days, 1)
+	i.Require().Equal(time.Now().UTC().Format(time.DateOnly), days[0].Day)
+	i.Require().Equal(2, days[0].Clicks)
+}
+
+// Status of request without access token
+func (i *IntegrationSuite) getPublicStatus(path string) int {
+	response, err := i.anonymousClient().Get(host + path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
@@ -0,0 +1,172 @@
+//go:build integration
+
+package integrations
+
+import (
+	"bufio"
+	"bytes"
+	"cloth-mini-app/internal/xlsx"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExportJobResponse struct {
+	ID          int    `json:"job_id"`
+	Format      string `json:"format"`
+	Status      string `json:"status"`
+	Items       int    `json:"items"`
+	Size        int64  `json:"size"`
+	Error       string `json:"error"`
+	DownloadURL string `json:"download_url"`
+}
+
+// Get body of response with status
+func (i *IntegrationSuite) download(client *http.Client, url string) (int, []byte) {
+	response, err := client.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return response.StatusCode, body
+}
+
+func (i *IntegrationSuite) countItems(query string, args ...any) int {
+	var count int
+	if err := i.db.QueryRow(query, args...).Scan(&count); err != nil {
+		log.Fatal(err)
+	}
+
+	return count
+}
+
+func (i *IntegrationSuite) TestExportItems() {
+	// text starting like formula isn't run by spreadsheet
+	formulaId := strconv.Itoa(int(i.createItem(testItem("=HYPERLINK(\"http://example.com\")"))))
+
+	total := i.countItems("SELECT count(*) FROM items WHERE deleted_at IS NULL")
+
+	status, body := i.download(http.DefaultClient, host+"/export/items?format=csv")
+	i.Require().Equal(http.StatusOK, status)
+
+	rows, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xEF\xBB\xBF")))).ReadAll()
+	i.Require().NoError(err)
+	i.Require().Len(rows, total+1)
+	i.Require().Equal("id", rows[0][0])
+
+	// items are exported by batches ordered by id
+	for idx := 2; idx < len(rows); idx++ {
+		prev, _ := strconv.Atoi(rows[idx-1][0])
+		current, _ := strconv.Atoi(rows[idx][0])
+		i.Require().Less(prev, current)
+	}
+
+	for _, row := range rows {
+		if row[0] == formulaId {
+			i.Require().Equal(`'=HYPERLINK("http://example.com")`, row[5])
+		}
+	}
+
+	// filters are the same as of GET /item/get
+	brandItems := i.countItems("SELECT count(*) FROM items WHERE deleted_at IS NULL AND brand_id = 2")
+	status, body = i.download(http.DefaultClient, host+"/export/items?format=ndjson&brand_id=2")
+	i.Require().Equal(http.StatusOK, status)
+
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var item struct {
+			BrandId    int            `json:"brand_id"`
+			Brand      string         `json:"brand"`
+			Attributes map[string]any `json:"attributes"`
+			Images     []string       `json:"images"`
+		}
+		i.Require().NoError(json.Unmarshal(scanner.Bytes(), &item))
+		i.Require().Equal(2, item.BrandId)
+		i.Require().Equal("Mizuno", item.Brand)
+		i.Require().NotNil(item.Images)
+		lines++
+	}
+	i.Require().Equal(brandItems, lines)
+
+	status, body = i.download(http.DefaultClient, host+"/export/items?format=xlsx")
+	i.Require().Equal(http.StatusOK, status)
+
+	sheet, err := xlsx.ReadRows(bytes.NewReader(body), int64(len(body)), total+1)
+	i.Require().NoError(err)
+	i.Require().Len(sheet, total+1)
+
+	status, _ = i.download(http.DefaultClient, host+"/export/items?format=pdf")
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+}
+
+func (i *IntegrationSuite) TestExportJob() {
+	response, err := http.Post(host+"/export/jobs", "application/json", strings.NewReader(`{"format": "csv", "brand_id": 2}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var created struct {
+		JobId int `json:"job_id"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&created)
+	response.Body.Close()
+	i.Require().NoError(err)
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	// file isn't ready until background task exports items
+	var job ExportJobResponse
+	i.Require().Eventually(func() bool {
+		i.Require().Equal(http.StatusOK, i.getJSON(host+"/export/jobs/"+strconv.Itoa(created.JobId), &job))
+
+		return job.Status == "done"
+	}, 10*time.Second, 500*time.Millisecond)
+
+	i.Require().Equal(i.countItems("SELECT count(*) FROM items WHERE deleted_at IS NULL AND brand_id = 2"), job.Items)
+	i.Require().NotEmpty(job.DownloadURL)
+
+	// signed link is used without access token
+	status, body := i.download(&http.Client{Transport: i.transport.base}, job.DownloadURL)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal(job.Size, int64(len(body)))
+
+	status, fallback := i.download(http.DefaultClient, host+"/export/jobs/"+strconv.Itoa(job.ID)+"/download")
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal(body, fallback)
+
+	var jobs struct {
+		Jobs []ExportJobResponse `json:"jobs"`
+	}
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/export/jobs", &jobs))
+	i.Require().NotEmpty(jobs.Jobs)
+	i.Require().Equal(job.ID, jobs.Jobs[0].ID)
+
+	// file is in the same storage as images, but image routes don't serve it
+	var objectId string
+	i.Require().NoError(i.db.QueryRow("SELECT object_id FROM export_jobs WHERE id = $1", job.ID).Scan(&objectId))
+	i.Require().Equal(http.StatusNotFound, i.getPublicStatus("/image/get/"+objectId))
+	i.Require().Equal(http.StatusNotFound, i.getPublicStatus("/image/archive?image_id="+objectId))
+
+	// file is deleted after retention period
+	_, err = i.db.Exec("UPDATE export_jobs SET updated_at = now() - interval '30 days' WHERE id = $1", job.ID)
+	i.Require().NoError(err)
+	i.Require().Eventually(func() bool {
+		i.Require().Equal(http.StatusOK, i.getJSON(host+"/export/jobs/"+strconv.Itoa(job.ID), &job))
+
+		return job.Status == "expired"
+	}, 10*time.Second, 500*time.Millisecond)
+	i.Require().Empty(job.DownloadURL)
+
+	status, _ = i.download(http.DefaultClient, host+"/export/jobs/"+strconv.Itoa(job.ID)+"/download")
+	i.Require().Equal(http.StatusNotFound, status)
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Get feed without access token, etag is sent in If-None-Match if it isn't empty
+func (i *IntegrationSuite) getFeed(name, etag string) (int, string, string) {
+	request, err := http.NewRequest(http.MethodGet, host+"/feed/"+name, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+
+	response, err := i.anonymousClient().Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return response.StatusCode, response.Header.Get("ETag"), string(body)
+}
+
+func (i *IntegrationSuite) TestProductFeeds() {
+	published := i.countItems("SELECT count(*) FROM items WHERE deleted_at IS NULL AND status = 'published'")
+
+	// feeds are generated by background task after start
+	var etag, body string
+	i.Require().Eventually(func() bool {
+		var status int
+		status, etag, body = i.getFeed("yandex.yml", "")
+
+		return status == http.StatusOK
+	}, 10*time.Second, 500*time.Millisecond)
+	i.Require().NotEmpty(etag)
+
+	var catalog struct {
+		Offers []struct {
+			ID      int      `xml:"id,attr"`
+			Price   int      `xml:"price"`
+			Picture []string `xml:"picture"`
+		} `xml:"shop>offers>offer"`
+	}
+	i.Require().NoError(xml.Unmarshal([]byte(body), &catalog))
+	i.Require().Len(catalog.Offers, published)
+
+	status, _, _ := i.getFeed("yandex.yml", etag)
+	i.Require().Equal(http.StatusNotModified, status)
+
+	status, _, body = i.getFeed("google.tsv", "")
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().True(strings.HasPrefix(body, "id\ttitle\t"))
+	i.Require().Len(strings.Split(strings.TrimSuffix(body, "\n"), "\n"), published+1)
+
+	status, _, _ = i.getFeed("bing.xml", "")
+	i.Require().Equal(http.StatusNotFound, status)
+
+	// published item appears in feeds after regeneration
+	id := i.createItem(testItem("test feed"))
+	i.Require().Equal(http.StatusOK, i.changeItemStatus(strconv.Itoa(int(id)), `{"status": "published"}`))
+
+	i.Require().Eventually(func() bool {
+		status, newETag, body := i.getFeed("google.xml", "")
+
+		return status == http.StatusOK && newETag != "" && strings.Contains(body, "<g:id>"+strconv.Itoa(int(id))+"</g:id>")
+	}, 10*time.Second, 500*time.Millisecond)
+
+	status, newETag, _ := i.getFeed("yandex.yml", etag)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().NotEqual(etag, newETag)
+
+	// draft item creates event, but content of feeds is the same, so etag isn't changed
+	i.createItem(testItem("test feed draft"))
+	lastEvent := i.countItems("SELECT max(id) FROM outbox")
+	i.Require().Eventually(func() bool {
+		return i.countItems("SELECT count(*) FROM feeds WHERE name = 'yandex.yml' AND event_id >= $1", lastEvent) == 1
+	}, 10*time.Second, 500*time.Millisecond)
+
+	status, _, _ = i.getFeed("yandex.yml", newETag)
+	i.Require().Equal(http.StatusNotModified, status)
+}
@@ -3,14 +3,19 @@
 package integrations
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	goimage "image"
+	"image/png"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/Masterminds/squirrel"
@@ -21,7 +26,7 @@ type ItemCreate struct {
 	BrandId     int      `json:"brand_id"`
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
-	Sex         int      `json:"sex"`
+	Sex         string   `json:"sex"`
 	CategoryId  int      `json:"category_id"`
 	Price       uint     `json:"price"`
 	Discount    uint     `json:"discount"`
@@ -302,5 +307,335 @@ func (i *IntegrationSuite) TestMaxImagePerItem() {
 		log.Fatal(err)
 	}
 
-	i.Require().Equal(http.StatusBadRequest, response.StatusCode)
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+}
+
+type UploadURLResponse struct {
+	FileId  string            `json:"file_id"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+}
+
+func (i *IntegrationSuite) TestPresignedUpload() {
+	image, err := os.ReadFile("fixtures/test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"content_type": "image/jpeg",
+		"size":         len(image),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := http.Client{}
+	response, err := client.Post(host+"/image/upload-url", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var upload UploadURLResponse
+	err = json.NewDecoder(response.Body).Decode(&upload)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	i.Require().False(i.isTempImageConfirmed(upload.FileId))
+
+	request, err := http.NewRequest(upload.Method, upload.URL, bytes.NewReader(image))
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Content-Type", upload.Headers["Content-Type"])
+
+	uploadResponse, err := client.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer uploadResponse.Body.Close()
+
+	i.Require().Equal(http.StatusOK, uploadResponse.StatusCode)
+
+	confirmResponse, err := client.Post(host+"/image/upload-url/"+upload.FileId+"/confirm", "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer confirmResponse.Body.Close()
+
+	i.Require().Equal(http.StatusOK, confirmResponse.StatusCode)
+	i.Require().True(i.isTempImageConfirmed(upload.FileId))
+}
+
+func (i *IntegrationSuite) TestConfirmNotUploadedImage() {
+	body, err := json.Marshal(map[string]any{
+		"content_type": "image/jpeg",
+		"size":         1024,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := http.Client{}
+	response, err := client.Post(host+"/image/upload-url", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var upload UploadURLResponse
+	err = json.NewDecoder(response.Body).Decode(&upload)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	confirmResponse, err := client.Post(host+"/image/upload-url/"+upload.FileId+"/confirm", "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer confirmResponse.Body.Close()
+
+	i.Require().Equal(http.StatusConflict, confirmResponse.StatusCode)
+	i.Require().False(i.isTempImageConfirmed(upload.FileId))
+}
+
+func (i *IntegrationSuite) isTempImageConfirmed(id string) bool {
+	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
+		Select("confirmed").
+		From("temp_images").
+		Where("object_id = ?", id).
+		ToSql()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var confirmed bool
+	err = i.db.QueryRow(sql, args...).Scan(&confirmed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return confirmed
+}
+
+type ArchiveResponse struct {
+	Total    int `json:"total"`
+	Attached int `json:"attached"`
+	Failed   int `json:"failed"`
+}
+
+func (i *IntegrationSuite) TestImageArchive() {
+	url := host + "/admin/image/archive"
+
+	image, err := os.ReadFile("fixtures/test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	for _, name := range []string{"1_1.jpg", "1_2.jpg", "unknown.jpg"} {
+		entry, err := zipWriter.Create(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err = entry.Write(image); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err = zipWriter.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	part, err := writer.CreateFormFile("archive", "images.zip")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = io.Copy(part, &archive)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	request, err := http.NewRequest("POST", url, &requestBody)
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.SetBasicAuth("admin", "admin")
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var report ArchiveResponse
+	err = json.NewDecoder(response.Body).Decode(&report)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	i.Require().Equal(3, report.Total)
+	i.Require().Equal(2, report.Attached)
+	i.Require().Equal(1, report.Failed)
+	i.Require().Len(i.getImages(mockItemID), 2)
+}
+
+type ItemImagesResponse struct {
+	Images []struct {
+		ImageId       string `json:"image_id"`
+		BlurHash      string `json:"blurhash"`
+		DominantColor string `json:"dominant_color"`
+		Width         int    `json:"width"`
+		Height        int    `json:"height"`
+	} `json:"images"`
+}
+
+func (i *IntegrationSuite) TestImageMeta() {
+	image, err := os.Open("fixtures/test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer image.Close()
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	part, err := writer.CreateFormFile("image", "test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = io.Copy(part, image)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := http.Client{}
+	response, err := client.Post(host+"/image/create?itemId=1", writer.FormDataContentType(), &requestBody)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	itemResponse, err := client.Get(fmt.Sprintf("%s/item/get/%d", host, mockItemID))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer itemResponse.Body.Close()
+
+	var item ItemImagesResponse
+	err = json.NewDecoder(itemResponse.Body).Decode(&item)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	i.Require().Len(item.Images, 1)
+	i.Require().NotEmpty(item.Images[0].BlurHash)
+	i.Require().Regexp("^#[0-9a-f]{6}$", item.Images[0].DominantColor)
+	i.Require().Greater(item.Images[0].Width, 0)
+	i.Require().Greater(item.Images[0].Height, 0)
+}
+
+func (i *IntegrationSuite) TestItemImagesArchive() {
+	image, err := os.ReadFile("fixtures/test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// png is stored with jpeg content type, extension is detected by content
+	var pngImage bytes.Buffer
+	if err = png.Encode(&pngImage, goimage.NewRGBA(goimage.Rect(0, 0, 2, 2))); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range [][]byte{image, pngImage.Bytes()} {
+		imageId := uuid.NewString()
+		i.putImageToMinio(imageId, file)
+		i.createImageDB(mockItemID, imageId)
+	}
+
+	client := http.Client{}
+	response, err := client.Get(fmt.Sprintf("%s/item/%d/images.zip", host, mockItemID))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+	i.Require().Equal("application/zip", response.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	i.Require().NoError(err)
+
+	names := make([]string, 0, len(archive.File))
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	i.Require().Equal([]string{"1_1.jpg", "1_2.png"}, names)
+}
+
+func (i *IntegrationSuite) TestUnpublishedImagesArchive() {
+	image, err := os.ReadFile("fixtures/test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// item created without status is draft
+	itemId := i.createItem(testItem("test unpublished archive"))
+	imageId := uuid.NewString()
+	i.putImageToMinio(imageId, image)
+	i.createImageDB(int(itemId), imageId)
+
+	itemArchive := fmt.Sprintf("/item/%d/images.zip", itemId)
+	imagesArchive := "/image/archive?image_id=" + imageId
+
+	// photos of unpublished drops aren't available to visitors
+	i.Require().Equal(http.StatusNotFound, i.getPublicStatus(itemArchive))
+	i.Require().Equal(http.StatusNotFound, i.getPublicStatus(imagesArchive))
+
+	status, _ := i.download(http.DefaultClient, host+itemArchive)
+	i.Require().Equal(http.StatusOK, status)
+	status, _ = i.download(http.DefaultClient, host+imagesArchive)
+	i.Require().Equal(http.StatusOK, status)
+
+	i.Require().Equal(http.StatusOK, i.changeItemStatus(strconv.Itoa(int(itemId)), `{"status": "published"}`))
+	i.Require().Equal(http.StatusOK, i.getPublicStatus(itemArchive))
+	i.Require().Equal(http.StatusOK, i.getPublicStatus(imagesArchive))
+
+	// items in trash are hidden again
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/item/delete/%d", host, itemId), nil)
+	i.Require().NoError(err)
+	response, err := http.DefaultClient.Do(request)
+	i.Require().NoError(err)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+	i.Require().Equal(http.StatusNotFound, i.getPublicStatus(itemArchive))
 }
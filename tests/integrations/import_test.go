@@ -0,0 +1,200 @@
+//go:build integration
+
+package integrations
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ImportJobResponse struct {
+	ID        int    `json:"job_id"`
+	Status    string `json:"status"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Valid     int    `json:"valid"`
+	Created   int    `json:"created"`
+	Errors    []struct {
+		Row     int    `json:"row"`
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	NewBrands []string `json:"new_brands"`
+}
+
+const importFile = `brand,category,name,description,sex,price,discount,outer_link
+Mizuno,Кроссовки,Imported sneakers,"Imported, with comma",male,9990,0,https://example.com/1
+New Brand,кеды,Imported keds,Imported description,female,5000,,https://example.com/2
+Daze,Unknown,Imported invalid,Imported description,alien,abc,,
+`
+
+// Upload file with form fields and return status and created job id
+func (i *IntegrationSuite) uploadImport(fileName, file string, fields map[string]string) (int, int) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err = part.Write([]byte(file)); err != nil {
+		log.Fatal(err)
+	}
+	for name, value := range fields {
+		if err = writer.WriteField(name, value); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	response, err := http.Post(host+"/import/upload", writer.FormDataContentType(), &body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var created struct {
+		JobId int `json:"job_id"`
+	}
+	if response.StatusCode == http.StatusOK {
+		if err = json.NewDecoder(response.Body).Decode(&created); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return response.StatusCode, created.JobId
+}
+
+// Wait until background task moves job to status
+func (i *IntegrationSuite) waitImportJob(jobId int, status string) ImportJobResponse {
+	var job ImportJobResponse
+	i.Require().Eventually(func() bool {
+		i.Require().Equal(http.StatusOK, i.getJSON(host+"/import/jobs/"+strconv.Itoa(jobId), &job))
+
+		return job.Status == status
+	}, 10*time.Second, 500*time.Millisecond)
+
+	return job
+}
+
+func (i *IntegrationSuite) commitImport(jobId int) (int, ErrorResponse) {
+	response, err := http.Post(host+"/import/jobs/"+strconv.Itoa(jobId)+"/commit", "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var errResponse ErrorResponse
+	if response.StatusCode != http.StatusOK {
+		if err = json.NewDecoder(response.Body).Decode(&errResponse); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return response.StatusCode, errResponse
+}
+
+func (i *IntegrationSuite) countImportedItems() int {
+	var count int
+	err := i.db.QueryRow("SELECT count(*) FROM items WHERE name LIKE 'Imported%'").Scan(&count)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return count
+}
+
+func (i *IntegrationSuite) TestImportItems() {
+	status, jobId := i.uploadImport("items.csv", importFile, map[string]string{"create_brands": "true"})
+	i.Require().Equal(http.StatusOK, status)
+
+	// dry run only checks rows
+	job := i.waitImportJob(jobId, "validated")
+	i.Require().Equal(3, job.Total)
+	i.Require().Equal(3, job.Processed)
+	i.Require().Equal(2, job.Valid)
+	i.Require().Equal([]string{"New Brand"}, job.NewBrands)
+
+	invalidFields := make([]string, 0, len(job.Errors))
+	for _, rowErr := range job.Errors {
+		i.Require().Equal(4, rowErr.Row)
+		invalidFields = append(invalidFields, rowErr.Field)
+	}
+	i.Require().ElementsMatch([]string{"outer_link", "sex", "price", "category"}, invalidFields)
+	i.Require().Equal(0, i.countImportedItems())
+
+	status, _ = i.commitImport(jobId)
+	i.Require().Equal(http.StatusOK, status)
+
+	job = i.waitImportJob(jobId, "done")
+	i.Require().Equal(2, job.Created)
+	i.Require().Equal(2, i.countImportedItems())
+
+	// committed job can't be committed again
+	status, response := i.commitImport(jobId)
+	i.Require().Equal(http.StatusConflict, status)
+	i.Require().Equal("import_job_state", response.Code)
+
+	var brandItems int
+	err := i.db.QueryRow("SELECT count(*) FROM items i JOIN brand b ON b.id = i.brand_id WHERE b.name = 'New Brand'").Scan(&brandItems)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(1, brandItems)
+
+	// every imported item has creation event
+	var events int
+	err = i.db.QueryRow("SELECT count(*) FROM outbox WHERE event_type = 'create_item' AND payload->>'item_name' LIKE 'Imported%'").Scan(&events)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(2, events)
+}
+
+func (i *IntegrationSuite) TestImportWithoutDryRun() {
+	file := `Бренд;Категория;Название;Описание;Пол;Цена;Ссылка;Статус
+Daze;Шорты;Imported shorts;Imported description;unisex;3000;https://example.com/3;published
+`
+	mapping := `{"brand": "Бренд", "category": "Категория", "name": "Название", "description": "Описание",
+		"sex": "Пол", "price": "Цена", "outer_link": "Ссылка", "status": "Статус"}`
+
+	status, jobId := i.uploadImport("items.csv", file, map[string]string{"mapping": mapping, "dry_run": "false"})
+	i.Require().Equal(http.StatusOK, status)
+
+	job := i.waitImportJob(jobId, "done")
+	i.Require().Equal(1, job.Valid)
+	i.Require().Equal(1, job.Created)
+	i.Require().Empty(job.Errors)
+
+	var itemStatus int
+	err := i.db.QueryRow("SELECT status FROM items WHERE name = 'Imported shorts'").Scan(&itemStatus)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(3, itemStatus)
+}
+
+func (i *IntegrationSuite) TestImportInvalidFile() {
+	status, _ := i.uploadImport("items.txt", importFile, nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	status, _ = i.uploadImport("items.xlsx", importFile, nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	// required column is missing
+	status, _ = i.uploadImport("items.csv", "brand,name\nDaze,Imported\n", nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	status, _ = i.uploadImport("items.csv", importFile, map[string]string{"mapping": `{"price": "Цена"}`})
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	status, _ = i.uploadImport("items.csv", "brand,category,name,description,sex,price,outer_link\n", nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+}
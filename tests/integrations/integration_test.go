@@ -7,9 +7,12 @@ import (
 	"cloth-mini-app/internal/config"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strings"
 	"testing"
 
 	_ "github.com/lib/pq"
@@ -17,11 +20,14 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/pressly/goose/v3"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	mockItemID = 1 //
 
+	testPassword = "password123"
+
 	// host = "http://app:8080"
 )
 
@@ -31,9 +37,26 @@ var (
 
 type IntegrationSuite struct {
 	suite.Suite
-	db     *sql.DB
-	minio  *minio.Client
-	config *config.Config
+	db        *sql.DB
+	minio     *minio.Client
+	config    *config.Config
+	transport *authTransport
+}
+
+// Adds access token to requests without Authorization header,
+// so tests of content API don't authenticate each request
+type authTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (a *authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if a.token != "" && r.Header.Get("Authorization") == "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	return a.base.RoundTrip(r)
 }
 
 func NewIntegrationSuite() *IntegrationSuite {
@@ -47,6 +70,10 @@ func (i *IntegrationSuite) SetupSuite() {
 
 	i.getDB()
 	i.getMinioClient()
+
+	// http.Post and clients without transport use default transport
+	i.transport = &authTransport{base: http.DefaultTransport}
+	http.DefaultTransport = i.transport
 }
 
 func (i *IntegrationSuite) SetupTest() {
@@ -56,12 +83,19 @@ func (i *IntegrationSuite) SetupTest() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	// users are removed with migrations, so they are created for each test
+	i.createUser("admin", "admin")
+	i.createUser("editor", "editor")
+	i.createUser("viewer", "viewer")
+
+	i.transport.token = i.login("editor", testPassword).AccessToken
 }
 
 func (i *IntegrationSuite) TearDownTest() {
 	log.Print("migration down")
 
-	err := goose.Down(i.db, "./migrations")
+	err := goose.Reset(i.db, "./migrations")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -71,6 +105,50 @@ func TestIntegrationSuite(t *testing.T) {
 	suite.Run(t, NewIntegrationSuite())
 }
 
+// Role is stored as int: 1 - admin, 2 - editor, 3 - viewer
+func (i *IntegrationSuite) createUser(login, role string) {
+	roles := map[string]int{"admin": 1, "editor": 2, "viewer": 3}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = i.db.Exec("INSERT INTO users (login, password_hash, role) VALUES ($1, $2, $3)", login, string(hash), roles[role])
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (i *IntegrationSuite) login(login, password string) TokenResponse {
+	body := fmt.Sprintf(`{"login": %q, "password": %q}`, login, password)
+	response, err := i.anonymousClient().Post(host+"/auth/login", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var tokens TokenResponse
+	err = json.NewDecoder(response.Body).Decode(&tokens)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return tokens
+}
+
+// Client sending requests without access token
+func (i *IntegrationSuite) anonymousClient() *http.Client {
+	return &http.Client{Transport: i.transport.base}
+}
+
 func (i *IntegrationSuite) getDB() {
 	psqlInfo := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
@@ -5,15 +5,19 @@ package integrations
 import (
 	"bytes"
 	domain "cloth-mini-app/internal/domain/item"
+	"context"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
 )
 
 type GetItem struct {
@@ -22,9 +26,9 @@ type GetItem struct {
 	BrandName    string     `json:"brand_name"`
 	Name         string     `json:"name"`
 	Description  string     `json:"description"`
-	Sex          int        `json:"sex"`
+	Sex          string     `json:"sex"`
 	CategoryId   int        `json:"category_id"`
-	CategoryType int        `json:"category_type"`
+	CategoryType string     `json:"category_type"`
 	CategoryName string     `json:"category_name"`
 	Price        int        `json:"price"`
 	Discount     *int       `json:"discount"`
@@ -74,9 +78,9 @@ type ItemByIdResponse struct {
 	BrandName    string     `json:"brand_name"`
 	Name         string     `json:"name"`
 	Description  string     `json:"description"`
-	Sex          int        `json:"sex"`
+	Sex          string     `json:"sex"`
 	CategoryId   int        `json:"category_id"`
-	CategoryType int        `json:"category_type"`
+	CategoryType string     `json:"category_type"`
 	CategoryName string     `json:"category_name"`
 	Price        int        `json:"price"`
 	Discount     *int       `json:"discount"`
@@ -95,7 +99,7 @@ func (i *IntegrationSuite) TestGetItemById() {
 		CategoryId:  1,
 		Price:       10000,
 		Discount:    10,
-		OuterLink:   "http:/localhost:8080/",
+		OuterLink:   "http://localhost:8080/",
 		Images:      nil,
 	}
 
@@ -152,8 +156,9 @@ func (i *IntegrationSuite) createItem(item domain.ItemCreate) uint {
 }
 
 type ItemUpdateField struct {
-	Name  string `json:"name"`
-	Price uint   `json:"price"`
+	Name    string `json:"name"`
+	Price   uint   `json:"price"`
+	Version int    `json:"version,omitempty"`
 }
 
 func (i *IntegrationSuite) TestUpdateItem() {
@@ -165,7 +170,7 @@ func (i *IntegrationSuite) TestUpdateItem() {
 		CategoryId:  1,
 		Price:       10000,
 		Discount:    10,
-		OuterLink:   "http:/localhost:8080/",
+		OuterLink:   "http://localhost:8080/",
 		Images:      nil,
 	}
 
@@ -188,6 +193,8 @@ func (i *IntegrationSuite) TestUpdateItem() {
 		log.Fatal(err)
 	}
 	request.Header.Set("Content-Type", "application/json")
+	// created item has first version
+	request.Header.Set("If-Match", `"1"`)
 
 	client := http.Client{}
 	response, err := client.Do(request)
@@ -204,6 +211,86 @@ func (i *IntegrationSuite) TestUpdateItem() {
 	i.Require().Equal(updateField.Price, dbItem.Price)
 }
 
+type VersionConflictResponse struct {
+	Code    string `json:"code"`
+	Current struct {
+		Name    string `json:"name"`
+		Version int    `json:"version"`
+	} `json:"current"`
+}
+
+// Update item with If-Match header if ifMatch isn't empty
+func (i *IntegrationSuite) updateItem(itemId, ifMatch, body string) *http.Response {
+	request, err := http.NewRequest(http.MethodPost, host+"/item/update/"+itemId, strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		request.Header.Set("If-Match", ifMatch)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return response
+}
+
+func (i *IntegrationSuite) TestUpdateItemVersionConflict() {
+	id := i.createItem(testItem("test version item"))
+	itemId := strconv.Itoa(int(id))
+
+	response, err := http.Get(host + "/item/get/" + itemId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+	etag := response.Header.Get("ETag")
+	i.Require().Equal(`"1"`, etag)
+
+	// version is required
+	response = i.updateItem(itemId, "", `{"name": "no version"}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusPreconditionRequired, response.StatusCode)
+
+	response = i.updateItem(itemId, etag, `{"name": "first editor"}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	// second editor fetched item before first update
+	response = i.updateItem(itemId, etag, `{"name": "second editor"}`)
+	defer response.Body.Close()
+	i.Require().Equal(http.StatusPreconditionFailed, response.StatusCode)
+	i.Require().Equal(`"2"`, response.Header.Get("ETag"))
+
+	var conflict VersionConflictResponse
+	err = json.NewDecoder(response.Body).Decode(&conflict)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal("version_conflict", conflict.Code)
+	i.Require().Equal("first editor", conflict.Current.Name)
+	i.Require().Equal(2, conflict.Current.Version)
+
+	response = i.updateItem(itemId, "", `{"name": "second editor", "version": 1}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	dbItem, err := i.getItem(id)
+	i.Require().NoError(err)
+	i.Require().Equal("first editor", dbItem.Name)
+
+	// change of status is a new version too
+	status := i.changeItemStatus(itemId, `{"status": "published"}`)
+	i.Require().Equal(http.StatusOK, status)
+
+	response = i.updateItem(itemId, "", `{"name": "second editor", "version": 2}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+}
+
 func (i *IntegrationSuite) getItem(itemId uint) (domain.ItemCreate, error) {
 	sql, args, err := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar).
 		Select(
@@ -243,11 +330,11 @@ func (i *IntegrationSuite) TestCreateItem() {
 		BrandId:     1,
 		Name:        "create test",
 		Description: "some description...",
-		Sex:         1,
+		Sex:         "male",
 		CategoryId:  1,
 		Price:       10000,
 		Discount:    10,
-		OuterLink:   "http:/localhost:8080/",
+		OuterLink:   "http://localhost:8080/",
 		Images: []string{
 			uuid.NewString(),
 			uuid.NewString(),
@@ -357,7 +444,7 @@ func (i *IntegrationSuite) TestDeleteItem() {
 		CategoryId:  1,
 		Price:       10000,
 		Discount:    10,
-		OuterLink:   "http:/localhost:8080/",
+		OuterLink:   "http://localhost:8080/",
 		Images:      nil,
 	}
 
@@ -377,6 +464,265 @@ func (i *IntegrationSuite) TestDeleteItem() {
 
 	i.Require().Equal(http.StatusOK, response.StatusCode)
 
+	// item is moved to trash with its images
+	i.Require().Equal(http.StatusNotFound, i.getItemStatus(itemId))
+
 	_, err = i.getItem(id)
+	i.Require().NoError(err)
+}
+
+// Status of getting item by api
+func (i *IntegrationSuite) getItemStatus(itemId string) int {
+	response, err := http.Get(host + "/item/get/" + itemId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+func testItem(name string) domain.ItemCreate {
+	return domain.ItemCreate{
+		BrandId:     1,
+		Name:        name,
+		Description: "some description...",
+		Sex:         1,
+		CategoryId:  1,
+		Price:       10000,
+		Discount:    10,
+		OuterLink:   "http://localhost:8080/",
+	}
+}
+
+type TrashResponse struct {
+	Count int `json:"count"`
+	Items []struct {
+		ID        uint       `json:"id"`
+		DeletedAt *time.Time `json:"deleted_at"`
+		Images    []struct {
+			ImageId string `json:"image_id"`
+		} `json:"images"`
+	} `json:"items"`
+}
+
+func (i *IntegrationSuite) TestRestoreItem() {
+	id := i.createItem(testItem("test restore"))
+	itemId := strconv.Itoa(int(id))
+	imageId := uuid.NewString()
+	i.createImageDB(int(id), imageId)
+
+	request, err := http.NewRequest(http.MethodDelete, host+"/item/delete/"+itemId, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = http.Get(host + "/item/trash")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var trash TrashResponse
+	err = json.NewDecoder(response.Body).Decode(&trash)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(1, trash.Count)
+	i.Require().Equal(id, trash.Items[0].ID)
+	i.Require().NotNil(trash.Items[0].DeletedAt)
+	i.Require().Len(trash.Items[0].Images, 1)
+	i.Require().Equal(imageId, trash.Items[0].Images[0].ImageId)
+
+	response, err = http.Post(host+"/item/restore/"+itemId, "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+	i.Require().Equal(http.StatusOK, i.getItemStatus(itemId))
+
+	// item isn't in trash anymore
+	response, err = http.Post(host+"/item/restore/"+itemId, "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusNotFound, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestPurgeDeletedItems() {
+	id := i.createItem(testItem("test purge"))
+	imageId := uuid.NewString()
+
+	image, err := os.ReadFile("fixtures/test_pic.jpg")
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.putImageToMinio(imageId, image)
+	i.createImageDB(int(id), imageId)
+
+	// TRASH_RETENTION is 1h, TRASH_PURGE_INTERVAL is 1s
+	_, err = i.db.Exec("UPDATE items SET deleted_at = now() - interval '2 hours' WHERE id = $1", id)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	i.Require().Eventually(func() bool {
+		_, err := i.getItem(id)
+		return err != nil
+	}, 10*time.Second, 500*time.Millisecond)
+
+	_, err = i.getImage(int(id))
+	i.Require().Error(err)
+
+	_, err = i.minio.StatObject(context.Background(), i.config.Minio.BucketName, imageId, minio.StatObjectOptions{})
 	i.Require().Error(err)
 }
+
+type ErrorResponse struct {
+	Err    string `json:"error"`
+	Code   string `json:"code"`
+	Fields []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"fields"`
+	RequestId string `json:"request_id"`
+	Retryable bool   `json:"retryable"`
+}
+
+func (i *IntegrationSuite) TestCreateItemValidation() {
+	url := host + "/item/create"
+
+	cases := []struct {
+		body   string
+		fields []string
+	}{
+		{
+			body:   `{"brand_id": 1, "name": "test", "description": "test", "sex": "robot", "category_id": 1, "price": 100, "outer_link": "http://localhost"}`,
+			fields: []string{"sex"},
+		},
+		{
+			body:   `{"brand_id": 1, "name": "test", "description": "test", "sex": 1, "category_id": 1, "price": 100, "outer_link": "http://localhost"}`,
+			fields: []string{"sex"},
+		},
+		{
+			body:   `{"brand_id": 1000, "name": "test", "description": "test", "sex": "female", "category_id": 1000, "price": 100, "outer_link": "http://localhost"}`,
+			fields: []string{"brand_id", "category_id"},
+		},
+		{
+			body:   `{"brand_id": 1, "name": "test", "description": "test", "sex": "male", "category_id": 1, "price": 100, "outer_link": "file:///etc/passwd"}`,
+			fields: []string{"outer_link"},
+		},
+		{
+			body:   `{"brand_id": 1, "name": "test", "description": "test", "sex": "male", "category_id": 1, "price": 100, "outer_link": "shop.example.com/item"}`,
+			fields: []string{"outer_link"},
+		},
+	}
+
+	for _, c := range cases {
+		response, err := http.Post(url, "application/json", bytes.NewBufferString(c.body))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer response.Body.Close()
+
+		i.Require().Equal(http.StatusUnprocessableEntity, response.StatusCode, c.body)
+
+		var validationErr ErrorResponse
+		err = json.NewDecoder(response.Body).Decode(&validationErr)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		i.Require().Equal("validation_failed", validationErr.Code, c.body)
+
+		fields := make([]string, 0, len(validationErr.Fields))
+		for _, field := range validationErr.Fields {
+			fields = append(fields, field.Field)
+		}
+		i.Require().ElementsMatch(c.fields, fields, c.body)
+	}
+}
+
+func (i *IntegrationSuite) TestGetItemsBySex() {
+	response, err := http.Get(host + "/item/get?sex=male")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var items ItemResponse
+	err = json.NewDecoder(response.Body).Decode(&items)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	i.Require().Equal(3, items.Count)
+	for _, item := range items.Items {
+		i.Require().Equal("male", item.Sex)
+	}
+
+	response, err = http.Get(host + "/item/get?sex=3")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusUnprocessableEntity, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestItemNotFound() {
+	response, err := http.Get(host + "/item/get/100000")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusNotFound, response.StatusCode)
+
+	var errResponse ErrorResponse
+	err = json.NewDecoder(response.Body).Decode(&errResponse)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	i.Require().Equal("item_not_found", errResponse.Code)
+	i.Require().False(errResponse.Retryable)
+	i.Require().NotEmpty(errResponse.RequestId)
+	i.Require().Equal(response.Header.Get("X-Request-Id"), errResponse.RequestId)
+
+	// missing item can't be updated or deleted
+	response, err = http.Post(host+"/item/update/100000", "application/json", bytes.NewBufferString(`{"name": "test", "version": 1}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusNotFound, response.StatusCode)
+
+	request, err := http.NewRequest(http.MethodDelete, host+"/item/delete/100000", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusNotFound, response.StatusCode)
+}
@@ -0,0 +1,47 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func (i *IntegrationSuite) TestBodyLimit() {
+	// BODY_LIMIT is 1M
+	body := fmt.Sprintf(`{"brand_name": "test", "description": %q}`, strings.Repeat("a", 2<<20))
+
+	response, err := http.Post(host+"/brand/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusRequestEntityTooLarge, response.StatusCode)
+
+	var errResponse ErrorResponse
+	err = json.NewDecoder(response.Body).Decode(&errResponse)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal("request_entity_too_large", errResponse.Code)
+
+	// body without length is checked while reading
+	request, err := http.NewRequest(http.MethodPost, host+"/brand/create", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.ContentLength = -1
+
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusRequestEntityTooLarge, response.StatusCode)
+}
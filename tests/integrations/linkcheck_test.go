@@ -0,0 +1,73 @@
+//go:build integration
+
+package integrations
+
+import (
+	"net/http"
+	"time"
+)
+
+type LinkCheckResponse struct {
+	ItemId      int    `json:"item_id"`
+	URL         string `json:"url"`
+	StatusCode  *int   `json:"status_code"`
+	RedirectURL string `json:"redirect_url"`
+	Error       string `json:"error"`
+	Failures    int    `json:"failures"`
+	Broken      bool   `json:"broken"`
+}
+
+type LinkReportResponse struct {
+	Summary struct {
+		Items   int `json:"items"`
+		Checked int `json:"checked"`
+		Broken  int `json:"broken"`
+	} `json:"summary"`
+	Count int                 `json:"count"`
+	Links []LinkCheckResponse `json:"links"`
+}
+
+// Find last check of item link in report
+func (i *IntegrationSuite) linkCheck(query string, itemId uint) (LinkCheckResponse, bool) {
+	var report LinkReportResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/links/report?limit=1000&"+query, &report))
+
+	for _, link := range report.Links {
+		if link.ItemId == int(itemId) {
+			return link, true
+		}
+	}
+
+	return LinkCheckResponse{}, false
+}
+
+func (i *IntegrationSuite) TestLinkCheck() {
+	// app itself is the shop: sitemap is only routed for GET, so checker falls back from HEAD
+	item := testItem("test link ok")
+	item.OuterLink = host + "/sitemap.xml"
+	okId := i.createItem(item)
+
+	item = testItem("test link broken")
+	item.OuterLink = host + "/no-such-page"
+	brokenId := i.createItem(item)
+
+	// link is broken after failed rechecks in a row
+	i.Require().Eventually(func() bool {
+		link, ok := i.linkCheck("broken=true", brokenId)
+
+		return ok && link.Failures >= 2
+	}, 30*time.Second, 500*time.Millisecond)
+
+	link, ok := i.linkCheck("broken=false", okId)
+	i.Require().True(ok)
+	i.Require().NotNil(link.StatusCode)
+	i.Require().Equal(http.StatusOK, *link.StatusCode)
+	i.Require().Zero(link.Failures)
+	i.Require().Empty(link.Error)
+
+	link, _ = i.linkCheck("broken=true", brokenId)
+	i.Require().Equal(http.StatusNotFound, *link.StatusCode)
+
+	// report is only for editors
+	i.Require().Equal(http.StatusUnauthorized, i.getPublicStatus("/links/report"))
+}
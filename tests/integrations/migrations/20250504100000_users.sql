@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.users (
+    id serial PRIMARY KEY,
+    login text NOT NULL,
+    password_hash text NOT NULL,
+    role smallint NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT users_login_unique UNIQUE (login),
+    CONSTRAINT users_role_check CHECK (role IN (1, 2, 3))
+);
+
+CREATE TABLE IF NOT EXISTS public.refresh_token (
+    token_hash text PRIMARY KEY,
+    user_id int NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS refresh_token_user_id_idx ON public.refresh_token (user_id);
+
+-- Column comments
+COMMENT ON COLUMN public.users.password_hash IS 'bcrypt хеш пароля';
+COMMENT ON COLUMN public.users.role IS '1 - admin, 2 - editor, 3 - viewer';
+COMMENT ON COLUMN public.refresh_token.token_hash IS 'sha256 refresh токена, сам токен не хранится';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.refresh_token;
+DROP TABLE IF EXISTS public.users;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.audit_log (
+    id bigserial PRIMARY KEY,
+    user_id int NULL REFERENCES public.users (id) ON DELETE SET NULL,
+    api_key_id int NULL REFERENCES public.api_key (id) ON DELETE SET NULL,
+    action text NOT NULL,
+    entity_type text NOT NULL,
+    entity_id text NOT NULL,
+    before jsonb NULL,
+    after jsonb NULL,
+    request_id text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT audit_log_action_check CHECK (action IN ('create', 'update', 'delete'))
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON public.audit_log (entity_type, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON public.audit_log (created_at DESC);
+
+-- Column comments
+COMMENT ON COLUMN public.audit_log.before IS 'Состояние сущности до изменения, NULL при создании';
+COMMENT ON COLUMN public.audit_log.after IS 'Состояние сущности после изменения, NULL при удалении';
+COMMENT ON COLUMN public.audit_log.request_id IS 'X-Request-ID запроса, в котором сделано изменение';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.audit_log;
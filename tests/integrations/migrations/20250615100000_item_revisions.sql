@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.item_revisions (
+    id bigserial PRIMARY KEY,
+    item_id int NOT NULL REFERENCES public.items (id) ON DELETE CASCADE,
+    version int NOT NULL,
+    snapshot jsonb NOT NULL,
+    restored_from int NULL,
+    user_id int NULL REFERENCES public.users (id) ON DELETE SET NULL,
+    api_key_id int NULL REFERENCES public.api_key (id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT item_revisions_version_unique UNIQUE (item_id, version)
+);
+
+-- existing items get revision of their current state
+INSERT INTO public.item_revisions (item_id, version, snapshot)
+SELECT i.id, i.version, jsonb_build_object(
+    'brand_id', i.brand_id,
+    'name', i.name,
+    'description', i.description,
+    'sex', i.sex,
+    'category_id', i.category_id,
+    'price', i.price,
+    'discount', COALESCE(i.discount, 0),
+    'outer_link', i.outer_link,
+    'attributes', i.attributes,
+    'images', COALESCE((SELECT jsonb_agg(im.object_id ORDER BY im.object_id) FROM public.images im WHERE im.item_id = i.id), '[]'::jsonb)
+)
+FROM public.items i
+ON CONFLICT DO NOTHING;
+
+-- Column comments
+COMMENT ON COLUMN public.item_revisions.version IS 'Версия товара, в которой он был сохранен';
+COMMENT ON COLUMN public.item_revisions.snapshot IS 'Поля товара и набор изображений на момент сохранения';
+COMMENT ON COLUMN public.item_revisions.restored_from IS 'Версия, к которой товар был откачен, NULL для обычного изменения';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.item_revisions;
@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.import_jobs (
+    id serial PRIMARY KEY,
+    file_name text NOT NULL,
+    status text NOT NULL DEFAULT 'validating',
+    dry_run boolean NOT NULL DEFAULT true,
+    create_brands boolean NOT NULL DEFAULT false,
+    columns jsonb NOT NULL,
+    rows jsonb NOT NULL,
+    total int NOT NULL,
+    processed int NOT NULL DEFAULT 0,
+    valid int NOT NULL DEFAULT 0,
+    created int NOT NULL DEFAULT 0,
+    errors jsonb NOT NULL DEFAULT '[]'::jsonb,
+    new_brands jsonb NOT NULL DEFAULT '[]'::jsonb,
+    error text NOT NULL DEFAULT '',
+    user_id int NULL REFERENCES public.users (id) ON DELETE SET NULL,
+    api_key_id int NULL REFERENCES public.api_key (id) ON DELETE SET NULL,
+    reserved_to timestamptz NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT import_jobs_status_check CHECK (status IN ('validating', 'validated', 'importing', 'done', 'failed'))
+);
+
+-- jobs waiting for background processing
+CREATE INDEX IF NOT EXISTS import_jobs_active_idx ON public.import_jobs (id) WHERE status IN ('validating', 'importing');
+
+-- Column comments
+COMMENT ON COLUMN public.import_jobs.status IS 'Этап импорта: validating, validated, importing, done, failed';
+COMMENT ON COLUMN public.import_jobs.dry_run IS 'Только проверка строк, товары создаются после подтверждения';
+COMMENT ON COLUMN public.import_jobs.columns IS 'Поле товара => номер колонки файла';
+COMMENT ON COLUMN public.import_jobs.rows IS 'Строки файла без заголовка';
+COMMENT ON COLUMN public.import_jobs.processed IS 'Обработано строк на текущем этапе';
+COMMENT ON COLUMN public.import_jobs.errors IS 'Ошибки строк: номер строки, поле, сообщение';
+COMMENT ON COLUMN public.import_jobs.new_brands IS 'Бренды из файла, которых нет в каталоге';
+COMMENT ON COLUMN public.import_jobs.reserved_to IS 'Время, до которого задача обрабатывается экземпляром приложения';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.import_jobs;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.feed_entries (
+    item_id int PRIMARY KEY REFERENCES public.items (id) ON DELETE CASCADE,
+    source text NOT NULL,
+    yml text NOT NULL,
+    google_xml text NOT NULL,
+    google_tsv text NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.feeds (
+    name text PRIMARY KEY,
+    object_id text NOT NULL,
+    etag text NOT NULL,
+    size bigint NOT NULL,
+    event_id int NOT NULL DEFAULT 0,
+    generated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Column comments
+COMMENT ON COLUMN public.feed_entries.source IS 'Хеш данных товара, из которых собран фрагмент фида';
+COMMENT ON COLUMN public.feed_entries.yml IS 'Предложение для фида Яндекс.Маркета';
+COMMENT ON COLUMN public.feed_entries.google_xml IS 'Товар для XML фида Google Merchant';
+COMMENT ON COLUMN public.feed_entries.google_tsv IS 'Строка TSV фида Google Merchant';
+COMMENT ON COLUMN public.feeds.name IS 'Имя файла фида: yandex.yml, google.xml, google.tsv';
+COMMENT ON COLUMN public.feeds.etag IS 'Хеш содержимого файла';
+COMMENT ON COLUMN public.feeds.event_id IS 'Последнее событие outbox на момент сборки';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.feeds;
+DROP TABLE IF EXISTS public.feed_entries;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.link_checks (
+    item_id int PRIMARY KEY REFERENCES public.items (id) ON DELETE CASCADE,
+    url text NOT NULL,
+    status_code int NULL,
+    redirect_url text NULL,
+    error text NOT NULL DEFAULT '',
+    failures int NOT NULL DEFAULT 0,
+    broken boolean NOT NULL DEFAULT false,
+    checked_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS link_checks_checked_at_idx ON public.link_checks (checked_at);
+
+-- Column comments
+COMMENT ON COLUMN public.link_checks.url IS 'Проверенная ссылка, при изменении outer_link проверка начинается заново';
+COMMENT ON COLUMN public.link_checks.status_code IS 'HTTP статус ответа, NULL если запрос не удался';
+COMMENT ON COLUMN public.link_checks.redirect_url IS 'Конечная ссылка после редиректов';
+COMMENT ON COLUMN public.link_checks.failures IS 'Неудачных проверок подряд';
+COMMENT ON COLUMN public.link_checks.broken IS 'Ссылка битая: много неудачных проверок подряд';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.link_checks;
@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.item_views (
+    id bigserial PRIMARY KEY,
+    item_id int NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS item_views_created_at_idx ON public.item_views (created_at);
+
+CREATE TABLE IF NOT EXISTS public.search_queries (
+    id bigserial PRIMARY KEY,
+    query text NOT NULL,
+    results int NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS search_queries_created_at_idx ON public.search_queries (created_at);
+
+CREATE TABLE IF NOT EXISTS public.item_view_days (
+    day date NOT NULL,
+    item_id int NOT NULL REFERENCES public.items (id) ON DELETE CASCADE,
+    views int NOT NULL,
+    PRIMARY KEY (day, item_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.search_query_days (
+    day date NOT NULL,
+    query text NOT NULL,
+    searches int NOT NULL,
+    zero_results int NOT NULL,
+    PRIMARY KEY (day, query)
+);
+
+-- Column comments
+COMMENT ON COLUMN public.item_views.item_id IS 'Товар, без внешнего ключа: журнал только дополняется';
+COMMENT ON COLUMN public.search_queries.query IS 'Запрос в нижнем регистре с одинарными пробелами';
+COMMENT ON COLUMN public.search_queries.results IS 'Количество найденных товаров';
+COMMENT ON COLUMN public.item_view_days.day IS 'День по UTC';
+COMMENT ON COLUMN public.search_query_days.zero_results IS 'Поиски, которые ничего не нашли';
+
+-- +goose Down
+DROP TABLE IF EXISTS public.search_query_days;
+DROP TABLE IF EXISTS public.item_view_days;
+DROP TABLE IF EXISTS public.search_queries;
+DROP TABLE IF EXISTS public.item_views;
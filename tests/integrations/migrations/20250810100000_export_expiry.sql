@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE public.export_jobs
+    DROP CONSTRAINT IF EXISTS export_jobs_status_check,
+    ADD CONSTRAINT export_jobs_status_check CHECK (status IN ('pending', 'running', 'done', 'failed', 'expired'));
+
+-- done jobs waiting for deletion of their files
+CREATE INDEX IF NOT EXISTS export_jobs_done_idx ON public.export_jobs (updated_at) WHERE status = 'done';
+
+-- Column comments
+COMMENT ON COLUMN public.export_jobs.status IS 'Этап выгрузки: pending, running, done, failed, expired (файл удален по сроку хранения)';
+
+-- +goose Down
+DROP INDEX IF EXISTS public.export_jobs_done_idx;
+
+UPDATE public.export_jobs SET status = 'failed', error = 'export file is expired' WHERE status = 'expired';
+
+ALTER TABLE public.export_jobs
+    DROP CONSTRAINT IF EXISTS export_jobs_status_check,
+    ADD CONSTRAINT export_jobs_status_check CHECK (status IN ('pending', 'running', 'done', 'failed'));
+
+COMMENT ON COLUMN public.export_jobs.status IS 'Этап выгрузки: pending, running, done, failed';
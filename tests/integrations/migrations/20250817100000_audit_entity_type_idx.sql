@@ -0,0 +1,6 @@
+-- +goose Up
+-- last change of brands and categories is checked by sitemap on every request
+CREATE INDEX IF NOT EXISTS audit_log_entity_type_id_idx ON public.audit_log (entity_type, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS public.audit_log_entity_type_id_idx;
@@ -0,0 +1,142 @@
+//go:build integration
+
+package integrations
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type PriceHistoryResponse struct {
+	Count   int `json:"count"`
+	Changes []struct {
+		Price        int    `json:"price"`
+		Discount     int    `json:"discount"`
+		PrevPrice    int    `json:"prev_price"`
+		PrevDiscount int    `json:"prev_discount"`
+		Source       string `json:"source"`
+		UserId       *int   `json:"user_id"`
+	} `json:"changes"`
+}
+
+type ProposalsResponse struct {
+	Count     int `json:"count"`
+	Proposals []struct {
+		ID        int64  `json:"id"`
+		ItemId    int    `json:"item_id"`
+		Price     uint   `json:"price"`
+		Discount  uint   `json:"discount"`
+		Available *bool  `json:"available"`
+		ItemPrice int    `json:"item_price"`
+		Status    string `json:"status"`
+	} `json:"proposals"`
+}
+
+// Insert proposal as scraper does and return its id
+func (i *IntegrationSuite) createProposal(itemId uint, price, discount int) int64 {
+	var id int64
+	err := i.db.QueryRow(`INSERT INTO price_proposals (item_id, url, price, discount, available, version)
+		SELECT id, outer_link, $2, $3, true, version FROM items WHERE id = $1 RETURNING id`, itemId, price, discount).Scan(&id)
+	i.Require().NoError(err)
+
+	return id
+}
+
+func (i *IntegrationSuite) TestPriceProposals() {
+	itemId := i.createItem(testItem("test price proposal"))
+	id := strconv.Itoa(int(itemId))
+
+	proposalId := i.createProposal(itemId, 8000, 0)
+	proposal := strconv.FormatInt(proposalId, 10)
+
+	var proposals ProposalsResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/prices/proposals?status=pending", &proposals))
+	i.Require().Equal(1, proposals.Count)
+	i.Require().Equal(uint(8000), proposals.Proposals[0].Price)
+	i.Require().Equal(10000, proposals.Proposals[0].ItemPrice)
+	i.Require().True(*proposals.Proposals[0].Available)
+
+	i.Require().Equal(http.StatusUnprocessableEntity, i.getJSON(host+"/prices/proposals?status=unknown", nil))
+
+	response, err := http.Post(host+"/prices/proposals/"+proposal+"/apply", "application/json", nil)
+	i.Require().NoError(err)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	item, err := i.getItem(itemId)
+	i.Require().NoError(err)
+	i.Require().Equal(uint(8000), item.Price)
+	i.Require().Equal(uint(0), item.Discount)
+
+	// proposal is applied only once
+	response, err = http.Post(host+"/prices/proposals/"+proposal+"/apply", "application/json", nil)
+	i.Require().NoError(err)
+	response.Body.Close()
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	// proposal for older version of item isn't applied and stays pending
+	outdated := strconv.FormatInt(i.createProposal(itemId, 7000, 0), 10)
+	response = i.updateItem(id, "", `{"name": "test price proposal changed", "version": 2}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = http.Post(host+"/prices/proposals/"+outdated+"/apply", "application/json", nil)
+	i.Require().NoError(err)
+	response.Body.Close()
+	i.Require().Equal(http.StatusConflict, response.StatusCode)
+
+	response, err = http.Post(host+"/prices/proposals/"+outdated+"/reject", "application/json", nil)
+	i.Require().NoError(err)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/prices/proposals?status=rejected", &proposals))
+	i.Require().Equal(1, proposals.Count)
+
+	// price set by editor is logged too
+	response = i.updateItem(id, "", `{"price": 9000, "discount": 5, "version": 3}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var history PriceHistoryResponse
+	i.Require().Equal(http.StatusOK, i.getJSON(host+"/item/"+id+"/prices", &history))
+	i.Require().Equal(2, history.Count)
+	i.Require().Equal("editor", history.Changes[0].Source)
+	i.Require().Equal(9000, history.Changes[0].Price)
+	i.Require().Equal(5, history.Changes[0].Discount)
+	i.Require().Equal(8000, history.Changes[0].PrevPrice)
+	i.Require().Equal("scraper", history.Changes[1].Source)
+	i.Require().Equal(10000, history.Changes[1].PrevPrice)
+	i.Require().Equal(10, history.Changes[1].PrevDiscount)
+	i.Require().NotNil(history.Changes[1].UserId)
+}
+
+func (i *IntegrationSuite) TestScrapeRules() {
+	status := i.adminRequest(http.MethodPut, host+"/prices/rules/www.Shop.example.com",
+		`{"price_selector": ".product-card .price--sale", "old_price_selector": ".price--old", "auto_apply": true}`, nil)
+	i.Require().Equal(http.StatusOK, status)
+
+	status = i.adminRequest(http.MethodPut, host+"/prices/rules/shop.example.com", `{"price_selector": "div:hover"}`, nil)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	var rules []struct {
+		Domain        string    `json:"domain"`
+		PriceSelector string    `json:"price_selector"`
+		AutoApply     bool      `json:"auto_apply"`
+		UpdatedAt     time.Time `json:"updated_at"`
+	}
+	i.Require().Equal(http.StatusOK, i.adminRequest(http.MethodGet, host+"/prices/rules", "", &rules))
+	i.Require().Len(rules, 1)
+	i.Require().Equal("shop.example.com", rules[0].Domain)
+	i.Require().True(rules[0].AutoApply)
+
+	// rules are only for admin
+	response, err := http.Get(host + "/prices/rules")
+	i.Require().NoError(err)
+	response.Body.Close()
+	i.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	i.Require().Equal(http.StatusOK, i.adminRequest(http.MethodDelete, host+"/prices/rules/shop.example.com", "", nil))
+	i.Require().Equal(http.StatusNotFound, i.adminRequest(http.MethodDelete, host+"/prices/rules/shop.example.com", "", nil))
+}
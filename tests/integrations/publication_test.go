@@ -0,0 +1,132 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (i *IntegrationSuite) changeItemStatus(itemId, body string) int {
+	response, err := http.Post(host+"/item/status/"+itemId, "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+// Status of getting item without access token
+func (i *IntegrationSuite) getPublicItemStatus(itemId string) int {
+	response, err := i.anonymousClient().Get(host + "/item/get/" + itemId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+func (i *IntegrationSuite) TestItemPublication() {
+	// item created without status is draft
+	id := i.createItem(testItem("test publication"))
+	itemId := strconv.Itoa(int(id))
+
+	i.Require().Equal(http.StatusNotFound, i.getPublicItemStatus(itemId))
+
+	response, err := http.Get(host + "/item/get/" + itemId)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var item struct {
+		Status string `json:"status"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&item)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal("draft", item.Status)
+
+	// scheduled item requires publish time in the future
+	status := i.changeItemStatus(itemId, `{"status": "scheduled"}`)
+	i.Require().Equal(http.StatusUnprocessableEntity, status)
+
+	publishAt := time.Now().Add(2 * time.Second).UTC().Format(time.RFC3339Nano)
+	status = i.changeItemStatus(itemId, fmt.Sprintf(`{"status": "scheduled", "publish_at": %q}`, publishAt))
+	i.Require().Equal(http.StatusOK, status)
+
+	i.Require().Equal(http.StatusNotFound, i.getPublicItemStatus(itemId))
+
+	// PUBLICATION_INTERVAL is 1s
+	i.Require().Eventually(func() bool {
+		return i.getPublicItemStatus(itemId) == http.StatusOK
+	}, 10*time.Second, 500*time.Millisecond)
+
+	var events int
+	err = i.db.QueryRow("SELECT count(*) FROM outbox WHERE event_type = 'publish_item' AND payload->>'item_id' = $1", itemId).Scan(&events)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(1, events)
+
+	status = i.changeItemStatus(itemId, `{"status": "archived"}`)
+	i.Require().Equal(http.StatusOK, status)
+
+	// every status change, including scheduled publish, is stored as revision
+	var versions, revisions int
+	err = i.db.QueryRow("SELECT version FROM items WHERE id = $1", id).Scan(&versions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = i.db.QueryRow("SELECT count(*) FROM item_revisions WHERE item_id = $1", id).Scan(&revisions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(4, versions)
+	i.Require().Equal(versions, revisions)
+
+	i.Require().Equal(http.StatusNotFound, i.getPublicItemStatus(itemId))
+
+	// archived item goes through draft before it's published again
+	status = i.changeItemStatus(itemId, `{"status": "published"}`)
+	i.Require().Equal(http.StatusConflict, status)
+}
+
+func (i *IntegrationSuite) TestPublicItemsOnlyPublished() {
+	i.createItem(testItem("test draft listing"))
+
+	var items ItemResponse
+	response, err := i.anonymousClient().Get(host + "/item/get?name=test%20draft%20listing")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	err = json.NewDecoder(response.Body).Decode(&items)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(0, items.Count)
+
+	response, err = http.Get(host + "/item/get?status=draft&name=test%20draft%20listing")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	err = json.NewDecoder(response.Body).Decode(&items)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(1, items.Count)
+}
@@ -0,0 +1,176 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type RevisionsResponse struct {
+	Count     int `json:"count"`
+	Revisions []struct {
+		Version      int  `json:"version"`
+		RestoredFrom *int `json:"restored_from"`
+		Snapshot     struct {
+			Name  string `json:"name"`
+			Price uint   `json:"price"`
+		} `json:"snapshot"`
+	} `json:"revisions"`
+}
+
+type RevisionDiffResponse struct {
+	Changes []struct {
+		Field string `json:"field"`
+		From  any    `json:"from"`
+		To    any    `json:"to"`
+	} `json:"changes"`
+}
+
+func (i *IntegrationSuite) getJSON(url string, v any) int {
+	response, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		err = json.NewDecoder(response.Body).Decode(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return response.StatusCode
+}
+
+func (i *IntegrationSuite) TestItemRevisions() {
+	body := `{"brand_id": 1, "name": "test revision item", "description": "some description...", "sex": "male", "category_id": 1, "price": 10000, "outer_link": "http://localhost:8080/"}`
+	response, err := http.Post(host+"/item/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var id int
+	err = i.db.QueryRow("SELECT id FROM items WHERE name = 'test revision item'").Scan(&id)
+	if err != nil {
+		log.Fatal(err)
+	}
+	itemId := strconv.Itoa(id)
+
+	response = i.updateItem(itemId, "", `{"name": "test revision renamed", "price": 2000, "version": 1}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var revisions RevisionsResponse
+	status := i.getJSON(host+"/item/"+itemId+"/revisions", &revisions)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal(2, revisions.Count)
+	i.Require().Equal(2, revisions.Revisions[0].Version)
+	i.Require().Equal("test revision renamed", revisions.Revisions[0].Snapshot.Name)
+	i.Require().Equal(1, revisions.Revisions[1].Version)
+
+	var diff RevisionDiffResponse
+	status = i.getJSON(host+"/item/"+itemId+"/revisions/diff?from=1&to=2", &diff)
+	i.Require().Equal(http.StatusOK, status)
+
+	fields := make([]string, 0, len(diff.Changes))
+	for _, change := range diff.Changes {
+		fields = append(fields, change.Field)
+	}
+	i.Require().ElementsMatch([]string{"name", "price"}, fields)
+
+	response, err = http.Post(host+"/item/"+itemId+"/revisions/1/rollback", "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	dbItem, err := i.getItem(uint(id))
+	i.Require().NoError(err)
+	i.Require().Equal("test revision item", dbItem.Name)
+	i.Require().Equal(uint(10000), dbItem.Price)
+
+	// rollback is a new revision
+	status = i.getJSON(host+"/item/"+itemId+"/revisions", &revisions)
+	i.Require().Equal(http.StatusOK, status)
+	i.Require().Equal(3, revisions.Count)
+	i.Require().Equal(3, revisions.Revisions[0].Version)
+	i.Require().NotNil(revisions.Revisions[0].RestoredFrom)
+	i.Require().Equal(1, *revisions.Revisions[0].RestoredFrom)
+
+	var events int
+	err = i.db.QueryRow("SELECT count(*) FROM outbox WHERE event_type = 'rollback_item' AND payload->>'item_id' = $1", itemId).Scan(&events)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.Require().Equal(1, events)
+
+	response, err = http.Post(host+"/item/"+itemId+"/revisions/100/rollback", "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+	i.Require().Equal(http.StatusNotFound, response.StatusCode)
+}
+
+func (i *IntegrationSuite) TestRollbackRestoresImageMeta() {
+	body := `{"brand_id": 1, "name": "test revision image meta", "description": "some description...", "sex": "male", "category_id": 1, "price": 10000, "outer_link": "http://localhost:8080/"}`
+	response, err := http.Post(host+"/item/create", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var id int
+	err = i.db.QueryRow("SELECT id FROM items WHERE name = 'test revision image meta'").Scan(&id)
+	if err != nil {
+		log.Fatal(err)
+	}
+	itemId := strconv.Itoa(id)
+
+	objectId := uuid.NewString()
+	_, err = i.db.Exec(`INSERT INTO images (item_id, object_id, uploaded_at, blurhash, dominant_color, width, height)
+		VALUES ($1, $2, now(), 'LEHV6nWB2yk8pyo0adR*.7kCMdnj', '#aabbcc', 640, 480)`, id, objectId)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// revision 2 has the image
+	response = i.updateItem(itemId, "", `{"name": "test revision image meta renamed", "version": 1}`)
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	_, err = i.db.Exec("DELETE FROM images WHERE object_id = $1", objectId)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	response, err = http.Post(host+"/item/"+itemId+"/revisions/2/rollback", "application/json", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var (
+		blurHash, dominantColor string
+		width, height           int
+	)
+	err = i.db.QueryRow("SELECT blurhash, dominant_color, width, height FROM images WHERE item_id = $1 AND object_id = $2", id, objectId).
+		Scan(&blurHash, &dominantColor, &width, &height)
+	i.Require().NoError(err)
+	i.Require().Equal("LEHV6nWB2yk8pyo0adR*.7kCMdnj", blurHash)
+	i.Require().Equal("#aabbcc", dominantColor)
+	i.Require().Equal(640, width)
+	i.Require().Equal(480, height)
+}
@@ -0,0 +1,119 @@
+//go:build integration
+
+package integrations
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type SitemapFile struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+type AtomFeed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+	} `xml:"entry"`
+}
+
+// Get public xml file by link from sitemap or feed, links have public url of app
+func (i *IntegrationSuite) getXML(link string, out any) int {
+	parsed, err := url.Parse(link)
+	i.Require().NoError(err)
+
+	status, body := i.download(i.anonymousClient(), host+parsed.Path)
+	if status == http.StatusOK {
+		i.Require().NoError(xml.Unmarshal(body, out))
+	}
+
+	return status
+}
+
+// Get urls of all sitemap pages and number of pages
+func (i *IntegrationSuite) sitemapURLs() ([]string, int) {
+	// sitemap is split into files of 5 urls in tests
+	var index SitemapFile
+	i.Require().Equal(http.StatusOK, i.getXML("/sitemap.xml", &index))
+	i.Require().NotEmpty(index.Sitemaps)
+	i.Require().Empty(index.URLs)
+
+	var urls []string
+	for _, link := range index.Sitemaps {
+		var page SitemapFile
+		i.Require().Equal(http.StatusOK, i.getXML(link, &page))
+		i.Require().LessOrEqual(len(page.URLs), 5)
+		urls = append(urls, page.URLs...)
+	}
+
+	return urls, len(index.Sitemaps)
+}
+
+func (i *IntegrationSuite) TestSitemap() {
+	urls, pages := i.sitemapURLs()
+
+	published := i.countItems("SELECT count(*) FROM items WHERE deleted_at IS NULL AND status = 'published'")
+	brands := i.countItems("SELECT count(*) FROM brand")
+	categories := i.countItems("SELECT count(*) FROM category")
+	i.Require().Len(urls, published+brands+categories)
+
+	var missing SitemapFile
+	i.Require().Equal(http.StatusNotFound, i.getXML("/sitemap-"+strconv.Itoa(pages+1)+".xml", &missing))
+}
+
+func (i *IntegrationSuite) TestSitemapSeesBrandChanges() {
+	// cache is filled before brand is created
+	i.sitemapURLs()
+
+	response, err := http.Post(host+"/brand/create", "application/json", strings.NewReader(`{"brand_name": "Sitemap Brand"}`))
+	i.Require().NoError(err)
+	defer response.Body.Close()
+	i.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var created struct {
+		ID int `json:"brand_id"`
+	}
+	i.Require().NoError(json.NewDecoder(response.Body).Decode(&created))
+
+	urls, _ := i.sitemapURLs()
+	i.Require().True(slices.ContainsFunc(urls, func(link string) bool {
+		return strings.HasSuffix(link, "/brand/"+strconv.Itoa(created.ID))
+	}), "sitemap doesn't have created brand")
+}
+
+func (i *IntegrationSuite) TestNewArrivalsFeed() {
+	var feed AtomFeed
+	i.Require().Equal(http.StatusOK, i.getXML("/feed/brand/1/atom", &feed))
+
+	// feed is cached until next item event, published item appears at the top
+	item := testItem("test new arrival")
+	id := i.createItem(item)
+	i.Require().Equal(http.StatusOK, i.changeItemStatus(strconv.Itoa(int(id)), `{"status": "published"}`))
+
+	i.Require().Equal(http.StatusOK, i.getXML("/feed/brand/1/atom", &feed))
+	i.Require().NotEmpty(feed.Entries)
+	i.Require().Equal(item.Name, feed.Entries[0].Title)
+	for idx := 1; idx < len(feed.Entries); idx++ {
+		i.Require().GreaterOrEqual(feed.Entries[idx-1].Published, feed.Entries[idx].Published)
+	}
+
+	var rss struct {
+		Items []struct {
+			Title string `xml:"title"`
+		} `xml:"channel>item"`
+	}
+	i.Require().Equal(http.StatusOK, i.getXML("/feed/category/1/rss", &rss))
+	i.Require().NotEmpty(rss.Items)
+	i.Require().Equal(item.Name, rss.Items[0].Title)
+
+	i.Require().Equal(http.StatusNotFound, i.getXML("/feed/brand/100000/rss", &rss))
+	i.Require().Equal(http.StatusUnprocessableEntity, i.getXML("/feed/brand/1/json", &rss))
+}